@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Chain links the records of a function instance by hash.
+type Chain struct {
+	id string
+
+	lock     sync.Mutex
+	sequence uint64
+	previous string
+}
+
+func NewChain() (*Chain, error) {
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &Chain{
+		id: hex.EncodeToString(id),
+	}, nil
+
+}
+
+// Link appends rec to the chain, setting its chain fields and hash, then
+// writes it. The chain only advances once the record is written, so a
+// failed write leaves no gap; the records are written one at a time.
+func (c *Chain) Link(rec *Record, write func(rec *Record) error) error {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	rec.ChainId = c.id
+	rec.Sequence = c.sequence
+	rec.PreviousHash = c.previous
+
+	hash, err := Hash(rec)
+	if err != nil {
+		return err
+	}
+
+	rec.Hash = hash
+
+	if err := write(rec); err != nil {
+		return err
+	}
+
+	c.sequence++
+	c.previous = hash
+
+	return nil
+
+}
+
+// Hash computes the hash of rec ignoring its current hash.
+func Hash(rec *Record) (string, error) {
+
+	unhashed := proto.Clone(rec).(*Record)
+	unhashed.Hash = ""
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(unhashed)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+
+	return hex.EncodeToString(sum[:]), nil
+
+}
+
+// Verify checks the records of a chain, in sequence order and starting at
+// the first record, were neither altered nor removed.
+func Verify(records []*Record) error {
+
+	previous := ""
+
+	for i, rec := range records {
+
+		if rec.Sequence != uint64(i) {
+			return fmt.Errorf("Record %d of chain %s is missing", i, rec.ChainId)
+		}
+
+		if rec.PreviousHash != previous {
+			return fmt.Errorf("Record %d of chain %s isn't linked to the previous one", i, rec.ChainId)
+		}
+
+		hash, err := Hash(rec)
+		if err != nil {
+			return err
+		}
+
+		if hash != rec.Hash {
+			return fmt.Errorf("Record %d of chain %s was altered", i, rec.ChainId)
+		}
+
+		previous = rec.Hash
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,202 @@
+// Package audit emits tamper-evident audit records of the calls, methods
+// are configured through the protomesh.audit.v1.audit method option.
+package audit
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/audit/v1/audit.proto
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"github.com/protomesh/protomesh-go/redact"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Sink persists audit records.
+type Sink interface {
+	Write(ctx context.Context, rec *Record) error
+}
+
+type Options struct {
+	// Required.
+	Sink  Sink
+	Chain *Chain
+	// Identifies the caller (e.g. from the claims of the token).
+	Principal func(ctx context.Context) string
+	// Audits methods without rule too.
+	AuditAll bool
+	// Redacts the sensitive resource fields, defaults to redact.Default.
+	Redactor *redact.Redactor
+}
+
+// validate rejects the options missing the sink or the chain, at the
+// creation of the interceptors rather than at the first call.
+func (o *Options) validate() error {
+
+	if o.Sink == nil || o.Chain == nil {
+		return errors.New("Sink and Chain of the audit options are required")
+	}
+
+	return nil
+
+}
+
+// Interceptor audits the calls of the methods with an enabled rule. It
+// panics when the options are invalid.
+func Interceptor(opts Options) grpc.UnaryServerInterceptor {
+
+	if err := opts.validate(); err != nil {
+		panic(err)
+	}
+
+	rules := &sync.Map{}
+
+	if opts.Redactor == nil {
+		opts.Redactor = redact.Default
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		rule := methodRule(rules, info.FullMethod)
+		if !rule.Enabled && !opts.AuditAll {
+			return handler(ctx, req)
+		}
+
+		out, err := handler(ctx, req)
+
+		if auditErr := audit(ctx, &opts, rule, info.FullMethod, req, err); auditErr != nil {
+			lambda.LoggerFromContext(ctx).Error("Failed to write audit record", "method", info.FullMethod, "error", auditErr)
+		}
+
+		return out, err
+
+	}
+
+}
+
+// StreamInterceptor is the Interceptor of the streaming methods, the
+// resources are read from the first received message. It panics when the
+// options are invalid.
+func StreamInterceptor(opts Options) grpc.StreamServerInterceptor {
+
+	if err := opts.validate(); err != nil {
+		panic(err)
+	}
+
+	rules := &sync.Map{}
+
+	if opts.Redactor == nil {
+		opts.Redactor = redact.Default
+	}
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		rule := methodRule(rules, info.FullMethod)
+		if !rule.Enabled && !opts.AuditAll {
+			return handler(srv, stream)
+		}
+
+		recording := &recordingStream{ServerStream: stream}
+
+		err := handler(srv, recording)
+
+		ctx := stream.Context()
+
+		if auditErr := audit(ctx, &opts, rule, info.FullMethod, recording.first, err); auditErr != nil {
+			lambda.LoggerFromContext(ctx).Error("Failed to write audit record", "method", info.FullMethod, "error", auditErr)
+		}
+
+		return err
+
+	}
+
+}
+
+// recordingStream keeps the first message received by the stream.
+type recordingStream struct {
+	grpc.ServerStream
+	first interface{}
+}
+
+func (s *recordingStream) RecvMsg(m interface{}) error {
+
+	err := s.ServerStream.RecvMsg(m)
+
+	if err == nil && s.first == nil {
+		s.first = m
+	}
+
+	return err
+
+}
+
+func audit(ctx context.Context, opts *Options, rule *AuditRule, method string, req interface{}, callErr error) error {
+
+	id := make([]byte, 16)
+	clock.IdGeneratorFromContext(ctx).Read(id)
+
+	st := status.Convert(callErr)
+
+	rec := &Record{
+		Id:        hex.EncodeToString(id),
+		Time:      timestamppb.New(clock.FromContext(ctx).Now()),
+		Method:    method,
+		Action:    rule.Action,
+		Resources: make(map[string]string),
+		Code:      int32(st.Code()),
+		Message:   st.Message(),
+	}
+
+	if len(rec.Action) == 0 {
+		rec.Action = method[strings.LastIndex(method, "/")+1:]
+	}
+
+	if opts.Principal != nil {
+		rec.Principal = opts.Principal(ctx)
+	}
+
+	if msg, ok := req.(proto.Message); ok && len(rule.ResourceFields) > 0 {
+
+		msg = opts.Redactor.Redacted(msg)
+
+		for _, path := range rule.ResourceFields {
+			if value, ok := lambda.FieldValue(msg.ProtoReflect(), path); ok {
+				rec.Resources[path] = value
+			}
+		}
+
+	}
+
+	return opts.Chain.Link(rec, func(rec *Record) error {
+		return opts.Sink.Write(ctx, rec)
+	})
+
+}
+
+// methodRule returns the audit rule of the method, an empty rule when it
+// has none.
+func methodRule(rules *sync.Map, fullMethod string) *AuditRule {
+
+	if rule, ok := rules.Load(fullMethod); ok {
+		return rule.(*AuditRule)
+	}
+
+	rule := &AuditRule{}
+
+	if method, ok := lambda.MethodDescriptor(fullMethod); ok && proto.HasExtension(method.Options(), E_Audit) {
+		rule = proto.GetExtension(method.Options(), E_Audit).(*AuditRule)
+	}
+
+	rules.Store(fullMethod, rule)
+
+	return rule
+
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// FirehoseSink puts records on a Kinesis Data Firehose delivery stream as
+// JSON lines, so they land in S3 ready to be queried.
+type FirehoseSink struct {
+	Client             *awsapi.Client
+	DeliveryStreamName string
+}
+
+func (f *FirehoseSink) Write(ctx context.Context, rec *Record) error {
+
+	body, err := protojson.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	in := map[string]interface{}{
+		"DeliveryStreamName": f.DeliveryStreamName,
+		"Record": map[string]interface{}{
+			"Data": append(body, '\n'),
+		},
+	}
+
+	return f.Client.CallJSON(ctx, "firehose", "1.1", "Firehose_20150804.PutRecord", in, nil)
+
+}
+
+// S3Sink writes each record to its own object keyed by chain and sequence,
+// the bucket should have object lock enabled.
+type S3Sink struct {
+	Client *awsapi.Client
+	Bucket string
+	Prefix string
+}
+
+func (s *S3Sink) Write(ctx context.Context, rec *Record) error {
+
+	body, err := protojson.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s/%s/%020d.json", s.Prefix, rec.ChainId, rec.Sequence)
+
+	req, err := http.NewRequest(http.MethodPut, s.Client.S3ObjectUrl(s.Bucket, key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.Client.Do(ctx, "s3", req, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(res.Body)
+		return &awsapi.Error{
+			StatusCode: res.StatusCode,
+			Code:       http.StatusText(res.StatusCode),
+			Message:    string(resBody),
+		}
+	}
+
+	return nil
+
+}
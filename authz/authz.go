@@ -0,0 +1,160 @@
+// Package authz enforces the permissions methods declare with the
+// protomesh.authz.v1.authz method option, decisions are delegated to an
+// Authorizer (roles, Cedar or OPA).
+package authz
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/authz/v1/authz.proto
+
+import (
+	"context"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const ErrorDomain = "authz.protomesh.io"
+
+type Principal struct {
+	Id         string
+	Roles      []string
+	Attributes map[string]string
+}
+
+type principalContextKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok
+}
+
+type PrincipalOptions struct {
+	// Defaults to "sub".
+	IdClaim string
+	// Defaults to "cognito:groups".
+	RolesClaim string
+	// Claims copied to the principal attributes.
+	AttributeClaims []string
+}
+
+// PrincipalMiddleware attaches the principal described by the claims of the
+// authorizer to the context, requests without identity have no principal.
+func PrincipalMiddleware(opts PrincipalOptions) lambda.Middleware {
+
+	if len(opts.IdClaim) == 0 {
+		opts.IdClaim = "sub"
+	}
+
+	if len(opts.RolesClaim) == 0 {
+		opts.RolesClaim = "cognito:groups"
+	}
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			id := req.Claim(opts.IdClaim)
+			if len(id) == 0 {
+				return next(ctx, req, res)
+			}
+
+			principal := &Principal{
+				Id:         id,
+				Roles:      splitClaim(req.Claim(opts.RolesClaim)),
+				Attributes: make(map[string]string),
+			}
+
+			for _, claim := range opts.AttributeClaims {
+				if value := req.Claim(claim); len(value) > 0 {
+					principal.Attributes[claim] = value
+				}
+			}
+
+			return next(ContextWithPrincipal(ctx, principal), req, res)
+
+		}
+
+	}
+
+}
+
+// splitClaim splits list claims, flattened by API Gateway as "a,b" or
+// "[a b]".
+func splitClaim(claim string) []string {
+
+	claim = strings.Trim(claim, "[]")
+
+	return strings.FieldsFunc(claim, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+}
+
+// Request is the authorization request of a call.
+type Request struct {
+	Principal   *Principal
+	Method      string
+	Permissions []string
+	// Values of the resource fields declared by the method.
+	Resource map[string]string
+	Message  proto.Message
+}
+
+// Authorizer decides whether a call is allowed, returning a PermissionDenied
+// error (see Denied) otherwise.
+type Authorizer interface {
+	Authorize(ctx context.Context, req *Request) error
+}
+
+// AuthorizerFunc adapts a function to Authorizer.
+type AuthorizerFunc func(ctx context.Context, req *Request) error
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *Request) error {
+	return f(ctx, req)
+}
+
+// AllOf allows calls allowed by every authorizer.
+func AllOf(authorizers ...Authorizer) Authorizer {
+
+	return AuthorizerFunc(func(ctx context.Context, req *Request) error {
+
+		for _, authorizer := range authorizers {
+			if err := authorizer.Authorize(ctx, req); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+
+}
+
+// Denied returns the PermissionDenied error of a call, detailed with the
+// method and the missing permission.
+func Denied(req *Request, permission, reason string) error {
+
+	st := status.Newf(codes.PermissionDenied, "Permission %s denied on %s: %s", permission, req.Method, reason)
+
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "PERMISSION_DENIED",
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			"method":     req.Method,
+			"permission": permission,
+		},
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+
+}
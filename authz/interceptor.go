@@ -0,0 +1,205 @@
+package authz
+
+import (
+	"context"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type Options struct {
+	Authorizer Authorizer
+	// Denies the methods without authz option or auth policy, they are
+	// allowed otherwise.
+	DenyUnannotated bool
+}
+
+// Interceptor authorizes the calls of the methods annotated with the authz
+// option, or registered with lambda.WithAuthPolicy, the principal is read
+// from the context (see PrincipalMiddleware).
+func Interceptor(opts Options) grpc.UnaryServerInterceptor {
+
+	rules := &sync.Map{}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		rule, err := callRule(ctx, rules, &opts, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		if rule != nil {
+			if err := authorize(ctx, &opts, rule, info.FullMethod, req); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+
+	}
+
+}
+
+// StreamInterceptor is the Interceptor of the streaming methods. Rules
+// with resource fields are checked against the first received message,
+// the stream can't send before it is authorized.
+func StreamInterceptor(opts Options) grpc.StreamServerInterceptor {
+
+	rules := &sync.Map{}
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		ctx := stream.Context()
+
+		rule, err := callRule(ctx, rules, &opts, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		if rule == nil {
+			return handler(srv, stream)
+		}
+
+		if len(rule.ResourceFields) == 0 {
+
+			if err := authorize(ctx, &opts, rule, info.FullMethod, nil); err != nil {
+				return err
+			}
+
+			return handler(srv, stream)
+
+		}
+
+		return handler(srv, &authorizingStream{
+			ServerStream: stream,
+			opts:         &opts,
+			rule:         rule,
+			method:       info.FullMethod,
+		})
+
+	}
+
+}
+
+// authorizingStream authorizes the call with its first received message.
+type authorizingStream struct {
+	grpc.ServerStream
+	opts       *Options
+	rule       *Authorization
+	method     string
+	authorized bool
+}
+
+func (s *authorizingStream) RecvMsg(m interface{}) error {
+
+	if err := s.ServerStream.RecvMsg(m); err != nil || s.authorized {
+		return err
+	}
+
+	if err := authorize(s.Context(), s.opts, s.rule, s.method, m); err != nil {
+		return err
+	}
+
+	s.authorized = true
+
+	return nil
+
+}
+
+func (s *authorizingStream) SendMsg(m interface{}) error {
+
+	if !s.authorized {
+		return status.Errorf(codes.PermissionDenied, "Method %s sent before its call was authorized", s.method)
+	}
+
+	return s.ServerStream.SendMsg(m)
+
+}
+
+// callRule returns the authorization of the call, nil when it is allowed
+// without authorization.
+func callRule(ctx context.Context, rules *sync.Map, opts *Options, fullMethod string) (*Authorization, error) {
+
+	rule := methodRule(rules, fullMethod)
+
+	if attrs, ok := lambda.MethodAttributesFromContext(ctx); ok && rule == nil && attrs.Auth != nil {
+		rule = &Authorization{
+			Permissions:    attrs.Auth.Permissions,
+			Public:         attrs.Auth.Public,
+			ResourceFields: attrs.Auth.ResourceFields,
+		}
+	}
+
+	switch {
+
+	case rule == nil && opts.DenyUnannotated:
+		return nil, status.Errorf(codes.PermissionDenied, "Method %s has no authorization rule", fullMethod)
+
+	case rule == nil, rule.Public:
+		return nil, nil
+
+	}
+
+	return rule, nil
+
+}
+
+// authorize asks the authorizer whether the principal of ctx may call the
+// method, the resource is read from req when it is a message.
+func authorize(ctx context.Context, opts *Options, rule *Authorization, fullMethod string, req interface{}) error {
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "Missing principal")
+	}
+
+	authzReq := &Request{
+		Principal:   principal,
+		Method:      fullMethod,
+		Permissions: rule.Permissions,
+		Resource:    make(map[string]string),
+	}
+
+	if msg, ok := req.(proto.Message); ok {
+
+		authzReq.Message = msg
+
+		for _, path := range rule.ResourceFields {
+			if value, ok := lambda.FieldValue(msg.ProtoReflect(), path); ok {
+				authzReq.Resource[path] = value
+			}
+		}
+
+	}
+
+	if err := opts.Authorizer.Authorize(ctx, authzReq); err != nil {
+		lambda.LoggerFromContext(ctx).Warn("Call denied", "method", fullMethod, "principal", principal.Id, "error", err)
+		return err
+	}
+
+	return nil
+
+}
+
+// methodRule returns the authorization of the method, nil when it has none.
+func methodRule(rules *sync.Map, fullMethod string) *Authorization {
+
+	if rule, ok := rules.Load(fullMethod); ok {
+		return rule.(*Authorization)
+	}
+
+	var rule *Authorization
+
+	if method, ok := lambda.MethodDescriptor(fullMethod); ok && proto.HasExtension(method.Options(), E_Authz) {
+		rule = proto.GetExtension(method.Options(), E_Authz).(*Authorization)
+	}
+
+	rules.Store(fullMethod, rule)
+
+	return rule
+
+}
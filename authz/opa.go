@@ -0,0 +1,226 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// OPAAuthorizer queries a decision of an OPA server (e.g. running as a
+// Lambda extension on localhost), the decision is either a boolean or an
+// object with "allow" and "reason" fields.
+type OPAAuthorizer struct {
+	// Defaults to http://localhost:8181.
+	Url string
+	// Path of the decision, e.g. "protomesh/authz/allow".
+	Decision   string
+	HttpClient *http.Client
+	// Keeps the policies of the server in sync with a bucket, optional.
+	Policies *OPAPolicies
+}
+
+type opaInput struct {
+	Principal   *Principal        `json:"principal"`
+	Method      string            `json:"method"`
+	Permissions []string          `json:"permissions"`
+	Resource    map[string]string `json:"resource"`
+	Request     json.RawMessage   `json:"request,omitempty"`
+}
+
+func (o *OPAAuthorizer) Authorize(ctx context.Context, req *Request) error {
+
+	if o.Policies != nil {
+		if err := o.Policies.sync(ctx, o); err != nil {
+			lambda.LoggerFromContext(ctx).Error("Failed to sync OPA policies", "error", err)
+		}
+	}
+
+	in := &opaInput{
+		Principal:   req.Principal,
+		Method:      req.Method,
+		Permissions: req.Permissions,
+		Resource:    req.Resource,
+	}
+
+	if req.Message != nil {
+		if body, err := protojson.Marshal(req.Message); err == nil {
+			in.Request = body
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"input": in})
+	if err != nil {
+		return err
+	}
+
+	resBody, err := o.call(ctx, http.MethodPost, "/v1/data/"+strings.Trim(o.Decision, "/"), body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "Failed to query OPA: %v", err)
+	}
+
+	out := struct {
+		Result json.RawMessage `json:"result"`
+	}{}
+
+	if err := json.Unmarshal(resBody, &out); err != nil {
+		return status.Errorf(codes.Internal, "Invalid OPA response: %v", err)
+	}
+
+	decision := struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}{}
+
+	if err := json.Unmarshal(out.Result, &decision.Allow); err != nil {
+		// Undefined decisions (no result) deny.
+		json.Unmarshal(out.Result, &decision)
+	}
+
+	if decision.Allow {
+		return nil
+	}
+
+	if len(decision.Reason) == 0 {
+		decision.Reason = "denied by policy " + o.Decision
+	}
+
+	return Denied(req, strings.Join(req.Permissions, ","), decision.Reason)
+
+}
+
+func (o *OPAAuthorizer) call(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+
+	baseUrl := o.Url
+	if len(baseUrl) == 0 {
+		baseUrl = "http://localhost:8181"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(baseUrl, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := o.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("OPA returned %d: %s", res.StatusCode, resBody)
+	}
+
+	return resBody, nil
+
+}
+
+// OPAPolicies uploads the .rego objects of a bucket prefix to the OPA
+// server, checked at most every Interval on authorization since Lambda
+// freezes background work between invocations.
+type OPAPolicies struct {
+	Client *awsapi.Client
+	Bucket string
+	Prefix string
+	// Defaults to a minute.
+	Interval time.Duration
+
+	lock     sync.Mutex
+	lastSync time.Time
+	etags    map[string]string
+}
+
+func (p *OPAPolicies) sync(ctx context.Context, opa *OPAAuthorizer) error {
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := clock.FromContext(ctx).Now()
+
+	if now.Sub(p.lastSync) < interval {
+		return nil
+	}
+
+	// Failed syncs are retried on the next interval too.
+	p.lastSync = now
+
+	objects, err := p.Client.ListS3Objects(ctx, p.Bucket, p.Prefix)
+	if err != nil {
+		return err
+	}
+
+	if p.etags == nil {
+		p.etags = make(map[string]string)
+	}
+
+	listed := make(map[string]bool)
+
+	for _, object := range objects {
+
+		if !strings.HasSuffix(object.Key, ".rego") {
+			continue
+		}
+
+		listed[object.Key] = true
+
+		if p.etags[object.Key] == object.ETag {
+			continue
+		}
+
+		policy, err := p.Client.GetS3Object(ctx, p.Bucket, object.Key)
+		if err != nil {
+			return err
+		}
+
+		if _, err := opa.call(ctx, http.MethodPut, "/v1/policies/"+object.Key, policy); err != nil {
+			return err
+		}
+
+		p.etags[object.Key] = object.ETag
+
+	}
+
+	for key := range p.etags {
+
+		if listed[key] {
+			continue
+		}
+
+		if _, err := opa.call(ctx, http.MethodDelete, "/v1/policies/"+key, nil); err != nil {
+			return err
+		}
+
+		delete(p.etags, key)
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,75 @@
+package authz
+
+import (
+	"context"
+	"strings"
+)
+
+// Condition restricts a granted permission with the attributes of the
+// principal and resource.
+type Condition func(req *Request, permission string) bool
+
+// RoleAuthorizer grants permissions through the roles of the principal.
+type RoleAuthorizer struct {
+	// Permissions of each role, "orders.*" grants every orders permission
+	// and "*" every permission.
+	Roles map[string][]string
+	// Conditions of the permissions, applied after the role check.
+	Conditions map[string]Condition
+}
+
+func (r *RoleAuthorizer) Authorize(ctx context.Context, req *Request) error {
+
+	for _, permission := range req.Permissions {
+
+		if !r.granted(req.Principal, permission) {
+			return Denied(req, permission, "not granted to the principal roles")
+		}
+
+		if condition, ok := r.Conditions[permission]; ok && !condition(req, permission) {
+			return Denied(req, permission, "condition not met")
+		}
+
+	}
+
+	return nil
+
+}
+
+func (r *RoleAuthorizer) granted(principal *Principal, permission string) bool {
+
+	for _, role := range principal.Roles {
+		for _, granted := range r.Roles[role] {
+			if matchPermission(granted, permission) {
+				return true
+			}
+		}
+	}
+
+	return false
+
+}
+
+func matchPermission(granted, permission string) bool {
+
+	if granted == "*" || granted == permission {
+		return true
+	}
+
+	return strings.HasSuffix(granted, ".*") && strings.HasPrefix(permission, strings.TrimSuffix(granted, "*"))
+
+}
+
+// AttributeEquals is a condition matching a principal attribute with a
+// resource field, e.g. the "custom:org_id" claim with "order.org_id".
+func AttributeEquals(attribute, resourceField string) Condition {
+
+	return func(req *Request, permission string) bool {
+
+		value, ok := req.Resource[resourceField]
+
+		return ok && len(value) > 0 && req.Principal.Attributes[attribute] == value
+
+	}
+
+}
@@ -0,0 +1,277 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	verifiedPermissionsTarget = "VerifiedPermissions.IsAuthorized"
+
+	defaultDecisionCacheSize = 1024
+)
+
+// entityIdEscaper escapes the separator of the resource field values joined
+// in an entity id, so that distinct values never share an id.
+var entityIdEscaper = strings.NewReplacer(`\`, `\\`, "/", `\/`)
+
+type cedarEntity struct {
+	EntityType string `json:"entityType"`
+	EntityId   string `json:"entityId"`
+}
+
+type cedarAction struct {
+	ActionType string `json:"actionType"`
+	ActionId   string `json:"actionId"`
+}
+
+type cedarValue struct {
+	String string `json:"string"`
+}
+
+type cedarEntityItem struct {
+	Identifier cedarEntity           `json:"identifier"`
+	Attributes map[string]cedarValue `json:"attributes,omitempty"`
+	Parents    []cedarEntity         `json:"parents,omitempty"`
+}
+
+type isAuthorizedInput struct {
+	PolicyStoreId string      `json:"policyStoreId"`
+	Principal     cedarEntity `json:"principal"`
+	Action        cedarAction `json:"action"`
+	Resource      cedarEntity `json:"resource"`
+	Context       struct {
+		ContextMap map[string]cedarValue `json:"contextMap"`
+	} `json:"context"`
+	Entities struct {
+		EntityList []cedarEntityItem `json:"entityList"`
+	} `json:"entities"`
+}
+
+type isAuthorizedOutput struct {
+	Decision            string `json:"decision"`
+	DeterminingPolicies []struct {
+		PolicyId string `json:"policyId"`
+	} `json:"determiningPolicies"`
+	Errors []struct {
+		ErrorDescription string `json:"errorDescription"`
+	} `json:"errors"`
+}
+
+type cachedDecision struct {
+	reason  string
+	expires time.Time
+}
+
+// VerifiedPermissionsAuthorizer evaluates the Cedar policies of an Amazon
+// Verified Permissions policy store, each permission being a Cedar action.
+//
+// The principal is a Namespace::User entity whose parents are its
+// Namespace::Role entities, the resource is a Namespace::ResourceType
+// entity identified by the values of the resource fields joined by "/"
+// (backslash-escaped within the values, the method when it has none) and
+// the context holds the method and resource fields. Resource fields or principal
+// attributes whose Cedar keys collide are rejected.
+type VerifiedPermissionsAuthorizer struct {
+	Client        *awsapi.Client
+	PolicyStoreId string
+	Namespace     string
+	// Defaults to "Resource".
+	ResourceType string
+	// Caches decisions, disabled when zero.
+	CacheTTL time.Duration
+
+	lock  sync.Mutex
+	cache map[string]*cachedDecision
+}
+
+func (v *VerifiedPermissionsAuthorizer) Authorize(ctx context.Context, req *Request) error {
+
+	for _, permission := range req.Permissions {
+
+		reason, err := v.decide(ctx, req, permission)
+		if err != nil {
+			return err
+		}
+
+		if len(reason) > 0 {
+			return Denied(req, permission, reason)
+		}
+
+	}
+
+	return nil
+
+}
+
+// decide returns the reason of the denial of permission, empty when allowed.
+func (v *VerifiedPermissionsAuthorizer) decide(ctx context.Context, req *Request, permission string) (string, error) {
+
+	in, err := v.input(req, permission)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+
+	if decision, ok := v.cached(ctx, string(key)); ok {
+		return decision, nil
+	}
+
+	out := &isAuthorizedOutput{}
+
+	if err := v.Client.CallJSON(ctx, "verifiedpermissions", "1.0", verifiedPermissionsTarget, in, out); err != nil {
+		return "", err
+	}
+
+	reason := ""
+
+	if out.Decision != "ALLOW" {
+
+		reasons := []string{}
+
+		for _, policy := range out.DeterminingPolicies {
+			reasons = append(reasons, "policy "+policy.PolicyId)
+		}
+
+		for _, evalErr := range out.Errors {
+			reasons = append(reasons, evalErr.ErrorDescription)
+		}
+
+		reason = "denied by Verified Permissions"
+		if len(reasons) > 0 {
+			reason += " (" + strings.Join(reasons, ", ") + ")"
+		}
+
+	}
+
+	v.store(ctx, string(key), reason)
+
+	return reason, nil
+
+}
+
+func (v *VerifiedPermissionsAuthorizer) input(req *Request, permission string) (*isAuthorizedInput, error) {
+
+	resourceType := v.ResourceType
+	if len(resourceType) == 0 {
+		resourceType = "Resource"
+	}
+
+	in := &isAuthorizedInput{
+		PolicyStoreId: v.PolicyStoreId,
+		Principal:     cedarEntity{EntityType: v.Namespace + "::User", EntityId: req.Principal.Id},
+		Action:        cedarAction{ActionType: v.Namespace + "::Action", ActionId: permission},
+		Resource:      cedarEntity{EntityType: v.Namespace + "::" + resourceType, EntityId: req.Method},
+	}
+
+	if len(req.Resource) > 0 {
+
+		paths := make([]string, 0, len(req.Resource))
+		for path := range req.Resource {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		ids := make([]string, len(paths))
+		for i, path := range paths {
+			ids[i] = entityIdEscaper.Replace(req.Resource[path])
+		}
+
+		in.Resource.EntityId = strings.Join(ids, "/")
+
+	}
+
+	in.Context.ContextMap = map[string]cedarValue{
+		"method": {String: req.Method},
+	}
+
+	for path, value := range req.Resource {
+
+		key := strings.ReplaceAll(path, ".", "_")
+
+		if _, ok := in.Context.ContextMap[key]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Resource field %s collides with context key %s", path, key)
+		}
+
+		in.Context.ContextMap[key] = cedarValue{String: value}
+
+	}
+
+	principal := cedarEntityItem{
+		Identifier: in.Principal,
+		Attributes: make(map[string]cedarValue),
+	}
+
+	for name, value := range req.Principal.Attributes {
+
+		key := strings.ReplaceAll(name, ":", "_")
+
+		if _, ok := principal.Attributes[key]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Principal attribute %s collides with attribute %s", name, key)
+		}
+
+		principal.Attributes[key] = cedarValue{String: value}
+
+	}
+
+	for _, role := range req.Principal.Roles {
+		principal.Parents = append(principal.Parents, cedarEntity{EntityType: v.Namespace + "::Role", EntityId: role})
+	}
+
+	in.Entities.EntityList = []cedarEntityItem{principal}
+
+	return in, nil
+
+}
+
+func (v *VerifiedPermissionsAuthorizer) cached(ctx context.Context, key string) (string, bool) {
+
+	if v.CacheTTL <= 0 {
+		return "", false
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	decision, ok := v.cache[key]
+	if !ok || clock.FromContext(ctx).Now().After(decision.expires) {
+		return "", false
+	}
+
+	return decision.reason, true
+
+}
+
+func (v *VerifiedPermissionsAuthorizer) store(ctx context.Context, key, reason string) {
+
+	if v.CacheTTL <= 0 {
+		return
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	// Decisions expire quickly, dropping the whole cache when full is enough.
+	if v.cache == nil || len(v.cache) >= defaultDecisionCacheSize {
+		v.cache = make(map[string]*cachedDecision)
+	}
+
+	v.cache[key] = &cachedDecision{
+		reason:  reason,
+		expires: clock.FromContext(ctx).Now().Add(v.CacheTTL),
+	}
+
+}
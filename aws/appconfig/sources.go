@@ -0,0 +1,250 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+const DefaultExtensionPort = 2772
+
+// ExtensionSource reads the configuration from the AWS AppConfig Lambda
+// extension, which already caches and polls AppConfig in the background.
+type ExtensionSource struct {
+	Application string
+	Environment string
+	Profile     string
+	Port        int
+	HttpClient  *http.Client
+}
+
+func (e *ExtensionSource) Fetch(ctx context.Context) ([]byte, error) {
+
+	port := e.Port
+	if port == 0 {
+		port = DefaultExtensionPort
+	}
+
+	endpoint := fmt.Sprintf(
+		"http://localhost:%d/applications/%s/environments/%s/configurations/%s",
+		port, url.PathEscape(e.Application), url.PathEscape(e.Environment), url.PathEscape(e.Profile),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := e.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AppConfig extension returned %d: %s", res.StatusCode, body)
+	}
+
+	return body, nil
+
+}
+
+// DataSource polls the AppConfig Data API directly, honoring the poll
+// tokens so unchanged configurations are not downloaded again.
+type DataSource struct {
+	Client      *awsapi.Client
+	Application string
+	Environment string
+	Profile     string
+
+	lock     sync.Mutex
+	token    string
+	nextPoll time.Time
+}
+
+func (d *DataSource) Fetch(ctx context.Context) ([]byte, error) {
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if clock.FromContext(ctx).Now().Before(d.nextPoll) {
+		return nil, nil
+	}
+
+	if len(d.token) == 0 {
+		if err := d.startSession(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, d.Client.Endpoint("appconfigdata")+"/configuration?configuration_token="+url.QueryEscape(d.token), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.Client.Do(ctx, "appconfig", req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		// Tokens expire after 24 hours, start a new session on the next fetch.
+		d.token = ""
+		return nil, fmt.Errorf("AppConfig returned %d: %s", res.StatusCode, body)
+	}
+
+	d.token = res.Header.Get("Next-Poll-Configuration-Token")
+
+	if interval, err := strconv.Atoi(res.Header.Get("Next-Poll-Interval-In-Seconds")); err == nil {
+		d.nextPoll = clock.FromContext(ctx).Now().Add(time.Duration(interval) * time.Second)
+	}
+
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	return body, nil
+
+}
+
+func (d *DataSource) startSession(ctx context.Context) error {
+
+	body, err := json.Marshal(map[string]string{
+		"ApplicationIdentifier":          d.Application,
+		"EnvironmentIdentifier":          d.Environment,
+		"ConfigurationProfileIdentifier": d.Profile,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.Client.Endpoint("appconfigdata")+"/configurationsessions", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := d.Client.Do(ctx, "appconfig", req, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("AppConfig returned %d: %s", res.StatusCode, resBody)
+	}
+
+	session := struct {
+		InitialConfigurationToken string
+	}{}
+
+	if err := json.Unmarshal(resBody, &session); err != nil {
+		return err
+	}
+
+	d.token = session.InitialConfigurationToken
+
+	return nil
+
+}
+
+// ParameterStoreSource reads every parameter under Path and nests them in
+// a document following the parameter hierarchy, /app/rate/limit becomes
+// {"rate": {"limit": ...}} for Path /app.
+type ParameterStoreSource struct {
+	Client *awsapi.Client
+	Path   string
+}
+
+func (p *ParameterStoreSource) Fetch(ctx context.Context) ([]byte, error) {
+
+	doc := make(map[string]interface{})
+	prefix := strings.TrimRight(p.Path, "/") + "/"
+
+	nextToken := ""
+
+	for {
+
+		in := map[string]interface{}{
+			"Path":           p.Path,
+			"Recursive":      true,
+			"WithDecryption": true,
+		}
+
+		if len(nextToken) > 0 {
+			in["NextToken"] = nextToken
+		}
+
+		out := struct {
+			Parameters []struct {
+				Name  string
+				Value string
+			}
+			NextToken string
+		}{}
+
+		if err := p.Client.CallJSON(ctx, "ssm", "1.1", "AmazonSSM.GetParametersByPath", in, &out); err != nil {
+			return nil, err
+		}
+
+		for _, param := range out.Parameters {
+
+			node := doc
+			parts := strings.Split(strings.TrimPrefix(param.Name, prefix), "/")
+
+			for _, part := range parts[:len(parts)-1] {
+				child, ok := node[part].(map[string]interface{})
+				if !ok {
+					child = make(map[string]interface{})
+					node[part] = child
+				}
+				node = child
+			}
+
+			node[parts[len(parts)-1]] = param.Value
+
+		}
+
+		if len(out.NextToken) == 0 {
+			break
+		}
+
+		nextToken = out.NextToken
+
+	}
+
+	return json.Marshal(doc)
+
+}
@@ -0,0 +1,231 @@
+package appconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+var (
+	_ app.ConfigSource = &Watcher{}
+)
+
+// Source fetches the configuration document as JSON, a nil document means
+// it didn't change since the previous fetch.
+type Source interface {
+	Fetch(ctx context.Context) ([]byte, error)
+}
+
+type ChangeHook func(ctx context.Context, w *Watcher)
+
+// Watcher keeps a configuration document fresh across warm invocations.
+// It implements app.ConfigSource so injector configs can be re-applied
+// whenever the document changes.
+//
+// Lambda freezes the container between invocations, so refreshes happen
+// lazily at the start of an invocation (see Middleware) instead of in a
+// background goroutine.
+type Watcher struct {
+	source   Source
+	interval time.Duration
+
+	fetchLock sync.Mutex
+	lock      sync.RWMutex
+	config    map[string]interface{}
+	digest    string
+	lastFetch time.Time
+	hooks     []ChangeHook
+	targets   []any
+}
+
+func NewWatcher(source Source, interval time.Duration) *Watcher {
+	return &Watcher{
+		source:   source,
+		interval: interval,
+		config:   make(map[string]interface{}),
+	}
+}
+
+// Bind re-applies the configuration to target, a struct pointer with
+// app.Config fields, every time the document changes.
+func (w *Watcher) Bind(target any) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.targets = append(w.targets, target)
+}
+
+func (w *Watcher) OnChange(hook ChangeHook) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.hooks = append(w.hooks, hook)
+}
+
+// Digest identifies the current configuration document.
+func (w *Watcher) Digest() string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.digest
+}
+
+func (w *Watcher) Load() error {
+	_, err := w.Refresh(context.Background(), true)
+	return err
+}
+
+// Refresh fetches the document if the interval elapsed (or force is set),
+// applying it to bound targets and running hooks when it changed. The
+// readers of the current configuration aren't blocked while fetching.
+func (w *Watcher) Refresh(ctx context.Context, force bool) (bool, error) {
+
+	// Serializes the fetches, the sources may keep state between them. The
+	// invocations don't wait for a fetch in progress, they keep the current
+	// configuration.
+	if force {
+		w.fetchLock.Lock()
+	} else if !w.fetchLock.TryLock() {
+		return false, nil
+	}
+	defer w.fetchLock.Unlock()
+
+	w.lock.RLock()
+	lastFetch, current := w.lastFetch, w.digest
+	w.lock.RUnlock()
+
+	now := clock.FromContext(ctx).Now()
+
+	if !force && now.Sub(lastFetch) < w.interval {
+		return false, nil
+	}
+
+	raw, err := w.source.Fetch(ctx)
+
+	w.lock.Lock()
+	w.lastFetch = clock.FromContext(ctx).Now()
+	w.lock.Unlock()
+
+	if err != nil || raw == nil {
+		return false, err
+	}
+
+	sum := sha256.Sum256(raw)
+	digest := hex.EncodeToString(sum[:])
+
+	if digest == current {
+		return false, nil
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return false, err
+	}
+
+	w.lock.Lock()
+
+	w.config = config
+	w.digest = digest
+
+	targets := append([]any{}, w.targets...)
+	hooks := append([]ChangeHook{}, w.hooks...)
+
+	w.lock.Unlock()
+
+	opts := &app.AppOptions{Source: w}
+	for _, target := range targets {
+		opts.ApplyConfigs(target)
+	}
+
+	for _, hook := range hooks {
+		hook(ctx, w)
+	}
+
+	return true, nil
+
+}
+
+func (w *Watcher) Get(k string) app.Config {
+
+	val, ok := w.lookup(k)
+	if !ok || val == nil {
+		return app.EmptyConfig()
+	}
+
+	switch typedVal := val.(type) {
+
+	case string:
+		return app.NewConfig(typedVal)
+
+	case float64:
+		return app.NewConfig(strconv.FormatFloat(typedVal, 'f', -1, 64))
+
+	case bool:
+		return app.NewConfig(strconv.FormatBool(typedVal))
+
+	}
+
+	raw, _ := json.Marshal(val)
+
+	return app.NewConfig(string(raw))
+
+}
+
+func (w *Watcher) Has(k string) bool {
+	val, ok := w.lookup(k)
+	return ok && val != nil
+}
+
+func (w *Watcher) lookup(k string) (interface{}, bool) {
+
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	var node interface{} = w.config
+
+	for _, part := range strings.Split(k, ".") {
+
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if node, ok = obj[part]; !ok {
+			return nil, false
+		}
+
+	}
+
+	return node, true
+
+}
+
+// Middleware refreshes the configuration before handling the invocation,
+// on failure the previous configuration is kept.
+func (w *Watcher) Middleware() lambda.Middleware {
+	return func(next lambda.Handler) lambda.Handler {
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			if _, err := w.Refresh(ctx, false); err != nil {
+				lambda.LoggerFromContext(ctx).Warn("Failed to refresh configuration, keeping previous one", "error", err)
+			}
+
+			return next(ctx, req, res)
+
+		}
+	}
+}
+
+// HandleDeploymentEvent forces a refresh, it is meant to be invoked by an
+// EventBridge rule matching AppConfig deployment completion events.
+func (w *Watcher) HandleDeploymentEvent(ctx context.Context, event events.CloudWatchEvent) error {
+	_, err := w.Refresh(ctx, true)
+	return err
+}
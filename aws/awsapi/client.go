@@ -0,0 +1,193 @@
+package awsapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Error is returned for non 2xx responses of AWS APIs.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// IsErrorCode reports whether err is an AWS API error with the given code.
+func IsErrorCode(err error, code string) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.Code == code
+}
+
+// Client is a minimal AWS API client covering the JSON protocol services
+// used by protomesh, it keeps Lambda binaries free of the full SDK.
+type Client struct {
+	Region      string
+	Credentials CredentialsProvider
+	HttpClient  *http.Client
+
+	// Base URL overrides per service (e.g. local emulators), the
+	// AWS_ENDPOINT_URL environment variable applies to all services.
+	Endpoints map[string]string
+}
+
+// NewClientFromEnv builds a client from the Lambda runtime environment.
+func NewClientFromEnv() *Client {
+
+	region := os.Getenv("AWS_REGION")
+	if len(region) == 0 {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	client := &Client{
+		Region:      region,
+		Credentials: EnvCredentials{},
+		HttpClient:  http.DefaultClient,
+		Endpoints:   make(map[string]string),
+	}
+
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); len(endpoint) > 0 {
+		client.Endpoints["*"] = endpoint
+	}
+
+	return client
+
+}
+
+func (c *Client) Endpoint(service string) string {
+
+	if endpoint, ok := c.Endpoints[service]; ok {
+		return strings.TrimRight(endpoint, "/")
+	}
+
+	if endpoint, ok := c.Endpoints["*"]; ok {
+		return strings.TrimRight(endpoint, "/")
+	}
+
+	return fmt.Sprintf("https://%s.%s.amazonaws.com", service, c.Region)
+
+}
+
+func (c *Client) Signer(service string) *Signer {
+	return &Signer{
+		Service: service,
+		Region:  c.Region,
+	}
+}
+
+// Do signs and sends the request, the body must be the request payload so
+// it can be hashed for the signature.
+func (c *Client) Do(ctx context.Context, service string, req *http.Request, body []byte) (*http.Response, error) {
+
+	creds, err := c.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+
+	c.Signer(service).Sign(req, HashPayload(body), creds, time.Now())
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return httpClient.Do(req)
+
+}
+
+// CallJSON invokes an operation of an AWS JSON protocol service, target is
+// the X-Amz-Target header (e.g. DynamoDB_20120810.PutItem).
+func (c *Client) CallJSON(ctx context.Context, service, jsonVersion, target string, in, out interface{}) error {
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint(service)+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-"+jsonVersion)
+	req.Header.Set("X-Amz-Target", target)
+
+	res, err := c.Do(ctx, service, req, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		return decodeJSONError(res, resBody)
+	}
+
+	if out == nil || len(resBody) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resBody, out)
+
+}
+
+func decodeJSONError(res *http.Response, body []byte) error {
+
+	apiErr := &Error{
+		StatusCode: res.StatusCode,
+		Code:       res.Header.Get("X-Amzn-ErrorType"),
+	}
+
+	payload := struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}{}
+
+	if err := json.Unmarshal(body, &payload); err == nil {
+
+		if len(payload.Type) > 0 {
+			apiErr.Code = payload.Type
+		}
+
+		apiErr.Message = payload.Message
+		if len(apiErr.Message) == 0 {
+			apiErr.Message = payload.MessageUpper
+		}
+
+	} else {
+		apiErr.Message = string(body)
+	}
+
+	if i := strings.LastIndex(apiErr.Code, "#"); i >= 0 {
+		apiErr.Code = apiErr.Code[i+1:]
+	}
+
+	if i := strings.Index(apiErr.Code, ":"); i >= 0 {
+		apiErr.Code = apiErr.Code[:i]
+	}
+
+	return apiErr
+
+}
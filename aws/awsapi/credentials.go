@@ -0,0 +1,56 @@
+package awsapi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+var (
+	MissingCredentialsError = errors.New("MissingCredentials")
+)
+
+type Credentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+func (c *Credentials) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && now.After(c.Expires)
+}
+
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (*Credentials, error)
+}
+
+// EnvCredentials reads the credentials the Lambda runtime exposes through
+// environment variables for the function execution role.
+type EnvCredentials struct{}
+
+func (EnvCredentials) Retrieve(ctx context.Context) (*Credentials, error) {
+
+	creds := &Credentials{
+		AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if len(creds.AccessKeyId) == 0 || len(creds.SecretAccessKey) == 0 {
+		return nil, MissingCredentialsError
+	}
+
+	return creds, nil
+
+}
+
+// StaticCredentials always returns the same credentials, mostly useful for
+// tests and local emulators.
+type StaticCredentials Credentials
+
+func (s StaticCredentials) Retrieve(ctx context.Context) (*Credentials, error) {
+	creds := Credentials(s)
+	return &creds, nil
+}
@@ -0,0 +1,10 @@
+package awsapi
+
+import "context"
+
+const dynamoDBTarget = "DynamoDB_20120810."
+
+// CallDynamoDB invokes an operation of DynamoDB (e.g. PutItem).
+func (c *Client) CallDynamoDB(ctx context.Context, operation string, in, out interface{}) error {
+	return c.CallJSON(ctx, "dynamodb", "1.0", dynamoDBTarget+operation, in, out)
+}
@@ -0,0 +1,141 @@
+package awsapi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3ObjectUrl returns the url of an object, path style when the endpoint
+// of s3 is overridden (emulators rarely support virtual hosts).
+func (c *Client) S3ObjectUrl(bucket, key string) *url.URL {
+
+	key = "/" + strings.TrimLeft(key, "/")
+
+	_, custom := c.Endpoints["s3"]
+	if _, all := c.Endpoints["*"]; custom || all {
+
+		u, err := url.Parse(c.Endpoint("s3"))
+		if err == nil {
+			u.Path = "/" + bucket + key
+			return u
+		}
+
+	}
+
+	return &url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, c.Region),
+		Path:   key,
+	}
+
+}
+
+// PresignS3 returns a presigned url to call method (GET, PUT...) on an
+// object without credentials until it expires.
+func (c *Client) PresignS3(ctx context.Context, method, bucket, key string, expires time.Duration) (string, error) {
+
+	creds, err := c.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Signer("s3").Presign(method, c.S3ObjectUrl(bucket, key), creds, time.Now(), expires).String(), nil
+
+}
+
+type S3Object struct {
+	Key  string
+	ETag string
+	Size int64
+}
+
+// ListS3Objects lists every object of bucket under prefix.
+func (c *Client) ListS3Objects(ctx context.Context, bucket, prefix string) ([]S3Object, error) {
+
+	objects := []S3Object{}
+	token := ""
+
+	for {
+
+		u := c.S3ObjectUrl(bucket, "")
+
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if len(token) > 0 {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = query.Encode()
+
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.Do(ctx, "s3", req, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode >= 300 {
+			return nil, decodeXMLError(res, body)
+		}
+
+		page := struct {
+			Contents              []S3Object
+			IsTruncated           bool
+			NextContinuationToken string
+		}{}
+
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, page.Contents...)
+
+		if !page.IsTruncated {
+			return objects, nil
+		}
+
+		token = page.NextContinuationToken
+
+	}
+
+}
+
+// GetS3Object reads an object.
+func (c *Client) GetS3Object(ctx context.Context, bucket, key string) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, c.S3ObjectUrl(bucket, key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Do(ctx, "s3", req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		return nil, decodeXMLError(res, body)
+	}
+
+	return body, nil
+
+}
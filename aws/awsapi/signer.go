@@ -0,0 +1,241 @@
+package awsapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+	amzShortFormat   = "20060102"
+
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// Signer implements AWS Signature Version 4 for a single service and region.
+type Signer struct {
+	Service string
+	Region  string
+}
+
+// Sign adds the authorization headers to req, payloadHash is the hex
+// encoded SHA-256 of the body (see HashPayload) or UnsignedPayload.
+func (s *Signer) Sign(req *http.Request, payloadHash string, creds *Credentials, now time.Time) {
+
+	now = now.UTC()
+
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+
+	if len(creds.SessionToken) > 0 {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+
+	signature := s.signature(creds, now, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, creds.AccessKeyId, scope, signedHeaders, signature,
+	))
+
+}
+
+// Presign returns a copy of u carrying the signature in the query string,
+// valid for the expires duration. Only the host header is signed.
+func (s *Signer) Presign(method string, u *url.URL, creds *Credentials, now time.Time, expires time.Duration) *url.URL {
+
+	now = now.UTC()
+	scope := s.scope(now)
+
+	signed := *u
+
+	query := signed.Query()
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyId+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	if len(creds.SessionToken) > 0 {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		s.canonicalPath(&signed),
+		canonicalQuery(query),
+		"host:" + signed.Host + "\n",
+		"host",
+		UnsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(creds, now, scope, canonicalRequest))
+
+	signed.RawQuery = canonicalQuery(query)
+
+	return &signed
+
+}
+
+func HashPayload(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Signer) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(amzShortFormat), s.Region, s.Service, "aws4_request"}, "/")
+}
+
+func (s *Signer) signature(creds *Credentials, now time.Time, scope, canonicalRequest string) string {
+
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		now.Format(amzDateFormat),
+		scope,
+		HashPayload([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+creds.SecretAccessKey), now.Format(amzShortFormat))
+	key = hmacSha256(key, s.Region)
+	key = hmacSha256(key, s.Service)
+	key = hmacSha256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSha256(key, stringToSign))
+
+}
+
+// canonicalPath escapes each path segment, twice for every service but S3
+// as required by the signature specification. Segments are split on the
+// escaped path so escaped slashes (e.g. IoT topics) stay in their segment.
+func (s *Signer) canonicalPath(u *url.URL) string {
+
+	if len(u.Path) == 0 {
+		return "/"
+	}
+
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segments[i] = escape(segment)
+		if s.Service != "s3" {
+			segments[i] = escape(segments[i])
+		}
+	}
+
+	return strings.Join(segments, "/")
+
+}
+
+func canonicalHeaders(req *http.Request) (string, string) {
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+
+	if len(req.Host) > 0 {
+		headers["host"] = req.Host
+	}
+
+	for key, values := range req.Header {
+
+		key = strings.ToLower(key)
+
+		switch {
+		case key == "content-type", strings.HasPrefix(key, "x-amz-"):
+			trimmed := make([]string, len(values))
+			for i, value := range values {
+				trimmed[i] = strings.Join(strings.Fields(value), " ")
+			}
+			headers[key] = strings.Join(trimmed, ",")
+		}
+
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	canonical := &strings.Builder{}
+	for _, key := range keys {
+		canonical.WriteString(key)
+		canonical.WriteString(":")
+		canonical.WriteString(headers[key])
+		canonical.WriteString("\n")
+	}
+
+	return canonical.String(), strings.Join(keys, ";")
+
+}
+
+func canonicalQuery(query url.Values) string {
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, escape(key)+"="+escape(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+
+}
+
+func escape(s string) string {
+
+	escaped := &strings.Builder{}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			escaped.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(escaped, "%%%02X", c)
+	}
+
+	return escaped.String()
+
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
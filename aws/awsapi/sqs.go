@@ -0,0 +1,9 @@
+package awsapi
+
+import "context"
+
+// CallSQS invokes an operation of SQS with its JSON protocol (e.g.
+// SendMessage).
+func (c *Client) CallSQS(ctx context.Context, operation string, in, out interface{}) error {
+	return c.CallJSON(ctx, "sqs", "1.0", "AmazonSQS."+operation, in, out)
+}
@@ -0,0 +1,158 @@
+package awsapi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Credentials are refreshed this long before they expire.
+const credentialsExpiryWindow = 5 * time.Minute
+
+// AssumeRoleCredentials assumes a role through STS with the credentials of
+// Client and caches the temporary credentials until they are about to
+// expire, typically to call resources in other accounts.
+type AssumeRoleCredentials struct {
+	Client      *Client
+	RoleArn     string
+	ExternalId  string
+	SessionName string
+	// Defaults to one hour.
+	Duration time.Duration
+
+	lock  sync.Mutex
+	creds *Credentials
+}
+
+func (a *AssumeRoleCredentials) Retrieve(ctx context.Context) (*Credentials, error) {
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.creds != nil && !a.creds.Expired(time.Now().Add(credentialsExpiryWindow)) {
+		return a.creds, nil
+	}
+
+	creds, err := a.assumeRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.creds = creds
+
+	return creds, nil
+
+}
+
+func (a *AssumeRoleCredentials) assumeRole(ctx context.Context) (*Credentials, error) {
+
+	duration := a.Duration
+	if duration <= 0 {
+		duration = time.Hour
+	}
+
+	sessionName := a.SessionName
+	if len(sessionName) == 0 {
+		sessionName = "protomesh"
+	}
+
+	form := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {a.RoleArn},
+		"RoleSessionName": {sessionName},
+		"DurationSeconds": {strconv.Itoa(int(duration / time.Second))},
+	}
+
+	if len(a.ExternalId) > 0 {
+		form.Set("ExternalId", a.ExternalId)
+	}
+
+	out := struct {
+		Credentials struct {
+			AccessKeyId     string
+			SecretAccessKey string
+			SessionToken    string
+			Expiration      time.Time
+		} `xml:"AssumeRoleResult>Credentials"`
+	}{}
+
+	if err := a.Client.CallQuery(ctx, "sts", form, &out); err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		AccessKeyId:     out.Credentials.AccessKeyId,
+		SecretAccessKey: out.Credentials.SecretAccessKey,
+		SessionToken:    out.Credentials.SessionToken,
+		Expires:         out.Credentials.Expiration,
+	}, nil
+
+}
+
+// CallQuery invokes an action of an AWS query protocol service (STS, SNS),
+// form holds the Action, Version and parameters of the call.
+func (c *Client) CallQuery(ctx context.Context, service string, form url.Values, out interface{}) error {
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint(service)+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.Do(ctx, service, req, []byte(form.Encode()))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		return decodeXMLError(res, body)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := xml.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("Invalid %s response: %w", form.Get("Action"), err)
+	}
+
+	return nil
+
+}
+
+func decodeXMLError(res *http.Response, body []byte) error {
+
+	payload := struct {
+		Code    string `xml:"Error>Code"`
+		Message string `xml:"Error>Message"`
+	}{}
+
+	if err := xml.Unmarshal(body, &payload); err != nil || len(payload.Code) == 0 {
+		return &Error{
+			StatusCode: res.StatusCode,
+			Code:       http.StatusText(res.StatusCode),
+			Message:    string(body),
+		}
+	}
+
+	return &Error{
+		StatusCode: res.StatusCode,
+		Code:       payload.Code,
+		Message:    payload.Message,
+	}
+
+}
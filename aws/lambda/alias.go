@@ -0,0 +1,135 @@
+package lambda
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// DeprecationHeader is set on the responses of aliased routes, so clients
+// can find out they are calling a legacy route.
+const DeprecationHeader = "Deprecation"
+
+// Alias serves a legacy route (e.g. a renamed method) with a current one.
+type Alias struct {
+	// Legacy key (e.g. "/acme.users.v1.Users/GetUser").
+	Key string
+	// Current key the requests are dispatched to, it may be registered
+	// after the alias.
+	Target string
+	// Rewrites the request before it's dispatched to Target, see
+	// TransformMessage to convert legacy request messages.
+	Transform func(ctx context.Context, req *Request) error
+}
+
+// RegisterAlias dispatches the requests of alias.Key to the handler of
+// alias.Target, conflicting registrations are handled according to the
+// ConflictPolicy.
+func (c *Controller[D]) RegisterAlias(alias Alias) error {
+
+	return c.registerRoute(Route{Key: alias.Key, Kind: RouteKindAlias, Target: alias.Target}, func(ctx context.Context, req *Request, res *Response) error {
+
+		handler, ok := c.handlers[alias.Target]
+		if !ok || alias.Target == alias.Key {
+			return convertResultError(res, status.Errorf(codes.Unimplemented, "Alias %s targets unknown route %s", alias.Key, alias.Target))
+		}
+
+		log := LoggerFromContext(ctx).With("alias", alias.Key, "handler_key", alias.Target)
+		log.Debug("Serving legacy route")
+
+		ctx = ContextWithLogger(ctx, log)
+
+		if alias.Transform != nil {
+			if err := alias.Transform(ctx, req); err != nil {
+				return convertResultError(res, err)
+			}
+		}
+
+		req.HandlerKey = alias.Target
+
+		err := handler(ctx, req, res)
+
+		if res.Headers == nil {
+			res.Headers = make(map[string]string)
+		}
+
+		res.Headers[DeprecationHeader] = "true"
+
+		return err
+
+	})
+
+}
+
+// TransformMessage returns an Alias.Transform decoding the request as the
+// legacy message L, with the codec of the request, and replacing it with the
+// message returned by fn, as raw protobuf.
+func TransformMessage[L proto.Message](fn func(ctx context.Context, legacy L) (proto.Message, error)) func(context.Context, *Request) error {
+
+	return func(ctx context.Context, req *Request) error {
+
+		var zero L
+		legacy := zero.ProtoReflect().New().Interface().(L)
+
+		if err := req.Unmarshal(legacy); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Failed to unmarshal legacy request: %s", status.Convert(err).Message())
+		}
+
+		current, err := fn(ctx, legacy)
+		if err != nil {
+			return err
+		}
+
+		body, err := marshalBase64(current)
+		if err != nil {
+			return status.Errorf(codes.Internal, "Failed to marshal transformed request: %v", err)
+		}
+
+		proxyReq := *req.APIGatewayProxyRequest
+		proxyReq.Body = body
+		proxyReq.IsBase64Encoded = len(body) > 0
+
+		// The transformed body is raw protobuf, whatever the codec and
+		// compression of the legacy request.
+		proxyReq.Headers = withoutHeader(withoutHeader(proxyReq.Headers, GrpcEncodingHeader), "Content-Type")
+		proxyReq.Headers["Content-Type"] = "application/x-protobuf"
+		proxyReq.MultiValueHeaders = withoutMultiValueHeader(withoutMultiValueHeader(proxyReq.MultiValueHeaders, GrpcEncodingHeader), "Content-Type")
+
+		req.APIGatewayProxyRequest = &proxyReq
+
+		return nil
+
+	}
+
+}
+
+func withoutHeader(headers map[string]string, key string) map[string]string {
+
+	copied := make(map[string]string, len(headers))
+
+	for k, v := range headers {
+		if !strings.EqualFold(k, key) {
+			copied[k] = v
+		}
+	}
+
+	return copied
+
+}
+
+func withoutMultiValueHeader(headers map[string][]string, key string) map[string][]string {
+
+	copied := make(map[string][]string, len(headers))
+
+	for k, v := range headers {
+		if !strings.EqualFold(k, key) {
+			copied[k] = v
+		}
+	}
+
+	return copied
+
+}
@@ -0,0 +1,220 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/status"
+)
+
+// InvocationTypeHeader is set to Event by API Gateway integrations invoking
+// the function asynchronously.
+const InvocationTypeHeader = "X-Amz-Invocation-Type"
+
+const (
+	DestinationConditionSuccess = "Success"
+	DestinationConditionFailure = "RetriesExhausted"
+)
+
+// IsAsyncInvocation reports whether the gateway asked for an Event
+// invocation, nobody waiting for the response.
+func IsAsyncInvocation(proxyReq *events.APIGatewayProxyRequest) bool {
+	req := &Request{APIGatewayProxyRequest: proxyReq}
+	return strings.EqualFold(req.Header(InvocationTypeHeader), "Event")
+}
+
+// DestinationRecord follows the payload shape of Lambda destinations, so
+// consumers can process records sent by the controller or by Lambda itself
+// the same way.
+type DestinationRecord struct {
+	Version         string                     `json:"version"`
+	Timestamp       string                     `json:"timestamp"`
+	RequestContext  DestinationRequestContext  `json:"requestContext"`
+	RequestPayload  interface{}                `json:"requestPayload"`
+	ResponseContext DestinationResponseContext `json:"responseContext"`
+	ResponsePayload interface{}                `json:"responsePayload"`
+}
+
+type DestinationRequestContext struct {
+	RequestId              string `json:"requestId"`
+	FunctionArn            string `json:"functionArn"`
+	Condition              string `json:"condition"`
+	ApproximateInvokeCount int    `json:"approximateInvokeCount"`
+}
+
+type DestinationResponseContext struct {
+	StatusCode      int    `json:"statusCode"`
+	ExecutedVersion string `json:"executedVersion"`
+	FunctionError   string `json:"functionError,omitempty"`
+}
+
+type destinationError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+// Destination receives the results of asynchronous invocations.
+type Destination interface {
+	Send(ctx context.Context, record *DestinationRecord) error
+}
+
+// AttemptCounter counts the attempts of the asynchronous invocations across
+// the execution environments, Lambda retrying them with the same request id
+// (e.g. an atomic counter in DynamoDB or Redis expiring after some hours).
+type AttemptCounter interface {
+	// Attempt records an attempt of the invocation and returns the number
+	// of attempts, this one included.
+	Attempt(ctx context.Context, requestId string) (int, error)
+}
+
+// AsyncDestinations routes the result of asynchronous invocations, a nil
+// destination discards the corresponding results.
+type AsyncDestinations struct {
+	OnSuccess Destination
+	OnFailure Destination
+	// Also treats the payloads without gateway request id as asynchronous
+	// invocations (Invoke with Event type, EventBridge, SNS...). The other
+	// sources of proxy payloads must then set one.
+	NoGatewayIsAsync bool
+	// Counts the attempts of the invocations, reported as their
+	// ApproximateInvokeCount. Without it, every invocation is reported as
+	// a first attempt and the failures Lambda retries aren't sent, see
+	// RegisterAsyncDestinations.
+	Attempts AttemptCounter
+	// Attempts of the invocations Lambda retries, its MaximumRetryAttempts
+	// plus one. Defaults to 3.
+	MaxAttempts int
+}
+
+// isAsync reports whether the result of proxyReq goes to the destinations.
+func (d *AsyncDestinations) isAsync(proxyReq *events.APIGatewayProxyRequest) bool {
+
+	if IsAsyncInvocation(proxyReq) {
+		return true
+	}
+
+	return d.NoGatewayIsAsync && len(proxyReq.RequestContext.RequestID) == 0
+
+}
+
+// RegisterAsyncDestinations enables result delivery for asynchronous
+// invocations. Failures are only delivered once the retries are exhausted:
+// when the ErrorPolicy swallows the error, since Lambda doesn't retry the
+// invocation, else on the last attempt counted by Attempts. Without
+// Attempts, the failures Lambda retries are left to the OnFailure
+// destination of the function itself.
+func (c *Controller[D]) RegisterAsyncDestinations(destinations AsyncDestinations) {
+
+	if destinations.MaxAttempts <= 0 {
+		destinations.MaxAttempts = 3
+	}
+
+	c.asyncDestinations = &destinations
+
+}
+
+// sendAsyncResult sends the result of the invocation to its destination,
+// retried reports whether Lambda retries the failed invocation.
+func (c *Controller[D]) sendAsyncResult(ctx context.Context, proxyReq *events.APIGatewayProxyRequest, res *Response, err error, retried bool) {
+
+	failed := err != nil || res.StatusCode >= http.StatusBadRequest
+
+	destination := c.asyncDestinations.OnSuccess
+	if failed {
+		destination = c.asyncDestinations.OnFailure
+	}
+
+	if destination == nil {
+		return
+	}
+
+	requestId := ""
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		requestId = lc.AwsRequestID
+	}
+
+	// Retried failures are only sent on their last known attempt.
+	attempt, known := 1, false
+
+	if counter := c.asyncDestinations.Attempts; counter != nil && len(requestId) > 0 {
+
+		count, countErr := counter.Attempt(ctx, requestId)
+		if countErr != nil {
+			LoggerFromContext(ctx).Error("Failed to count invocation attempt", "error", countErr)
+		} else {
+			attempt, known = count, true
+		}
+
+	}
+
+	if failed && retried && (!known || attempt < c.asyncDestinations.MaxAttempts) {
+		LoggerFromContext(ctx).Debug("Failure of async invocation left to its retry", "attempt", attempt)
+		return
+	}
+
+	record := &DestinationRecord{
+		Version:   "1.0",
+		Timestamp: clock.FromContext(ctx).Now().UTC().Format(time.RFC3339Nano),
+		RequestContext: DestinationRequestContext{
+			RequestId:              requestId,
+			Condition:              DestinationConditionSuccess,
+			ApproximateInvokeCount: attempt,
+		},
+		RequestPayload: proxyReq,
+		ResponseContext: DestinationResponseContext{
+			StatusCode:      res.StatusCode,
+			ExecutedVersion: lambdacontext.FunctionVersion,
+		},
+		ResponsePayload: res.APIGatewayProxyResponse,
+	}
+
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		record.RequestContext.FunctionArn = lc.InvokedFunctionArn
+	}
+
+	if failed {
+
+		record.RequestContext.Condition = DestinationConditionFailure
+		record.ResponseContext.FunctionError = "Unhandled"
+
+		payload := &destinationError{
+			ErrorMessage: res.Body,
+			ErrorType:    http.StatusText(res.StatusCode),
+		}
+
+		if err != nil {
+			payload.ErrorMessage = err.Error()
+			payload.ErrorType = fmt.Sprintf("%T", err)
+			if st, ok := status.FromError(err); ok {
+				payload.ErrorMessage = st.Message()
+				payload.ErrorType = st.Code().String()
+			}
+		}
+
+		record.ResponsePayload = payload
+
+	}
+
+	if err := destination.Send(ctx, record); err != nil {
+		LoggerFromContext(ctx).Error("Failed to send result to async destination", "error", err, "condition", record.RequestContext.Condition)
+	}
+
+}
+
+func marshalDestinationRecord(record *DestinationRecord) (string, error) {
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+
+}
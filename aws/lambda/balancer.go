@@ -0,0 +1,163 @@
+package lambda
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Balancer orders the targets of a call, the ClientConn tries them in order
+// while the Balancer allows failing over, and reports every attempt.
+type Balancer interface {
+	Order(method string, targets []*Target) []*Target
+	// Failover reports whether the call should be retried on the next target.
+	Failover(err error) bool
+	Started(target *Target)
+	Report(target *Target, err error, latency time.Duration)
+}
+
+// TargetKey identifies a target in balancer statistics.
+func TargetKey(target *Target) string {
+	return target.Region + "/" + target.FunctionName + ":" + target.Qualifier
+}
+
+// RegionFailoverBalancer prefers the targets of Region while they are
+// healthy and fails over to the other regions, the healthiest first.
+type RegionFailoverBalancer struct {
+	Region string
+	// Spreads the calls over the preferred targets, they are called in the
+	// resolved order when nil.
+	Picker Picker
+	// Codes failing over to the next target, defaults to Unavailable and
+	// DeadlineExceeded.
+	FailoverCodes []codes.Code
+	// Targets below this health score lose their preference, defaults to 0.5.
+	MinHealth float64
+	// Weight of the last result in the health score, defaults to 0.2.
+	Decay float64
+
+	lock   sync.Mutex
+	health map[string]float64
+}
+
+func (b *RegionFailoverBalancer) Order(method string, targets []*Target) []*Target {
+
+	ordered := make([]*Target, len(targets))
+	copy(ordered, targets)
+
+	if b.Picker != nil {
+		ordered = b.Picker.Order(ordered)
+	}
+
+	minHealth := b.MinHealth
+	if minHealth <= 0 {
+		minHealth = 0.5
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	preferred := func(target *Target) bool {
+		return (len(target.Region) == 0 || target.Region == b.Region) && b.healthLocked(target) >= minHealth
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+
+		pi, pj := preferred(ordered[i]), preferred(ordered[j])
+		if pi != pj {
+			return pi
+		}
+
+		// The picker order is kept between preferred targets.
+		if pi {
+			return false
+		}
+
+		return b.healthLocked(ordered[i]) > b.healthLocked(ordered[j])
+
+	})
+
+	return ordered
+
+}
+
+func (b *RegionFailoverBalancer) Failover(err error) bool {
+	return isFailoverCode(b.FailoverCodes, err)
+}
+
+func (b *RegionFailoverBalancer) Started(target *Target) {
+
+	if b.Picker != nil {
+		b.Picker.Started(target)
+	}
+
+}
+
+func (b *RegionFailoverBalancer) Report(target *Target, err error, latency time.Duration) {
+
+	if b.Picker != nil {
+		b.Picker.Report(target, err, latency)
+	}
+
+	decay := b.Decay
+	if decay <= 0 {
+		decay = 0.2
+	}
+
+	result := 1.0
+	if err != nil && b.Failover(err) {
+		result = 0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.health == nil {
+		b.health = make(map[string]float64)
+	}
+
+	key := TargetKey(target)
+	b.health[key] = (1-decay)*b.healthLocked(target) + decay*result
+
+}
+
+// Health returns the health score of target, from 0 to 1.
+func (b *RegionFailoverBalancer) Health(target *Target) float64 {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.healthLocked(target)
+
+}
+
+func (b *RegionFailoverBalancer) healthLocked(target *Target) float64 {
+
+	if score, ok := b.health[TargetKey(target)]; ok {
+		return score
+	}
+
+	return 1
+
+}
+
+func isFailoverCode(failoverCodes []codes.Code, err error) bool {
+
+	if len(failoverCodes) == 0 {
+		failoverCodes = []codes.Code{codes.Unavailable, codes.DeadlineExceeded}
+	}
+
+	code := status.Code(err)
+
+	for _, failoverCode := range failoverCodes {
+		if code == failoverCode {
+			return true
+		}
+	}
+
+	return false
+
+}
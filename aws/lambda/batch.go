@@ -0,0 +1,200 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/batch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const BatchHandlerKey = "/protomesh.batch/Execute"
+
+type BatchOptions struct {
+	// Maximum number of calls in a batch, defaults to 25.
+	MaxCalls int
+	// Maximum number of calls running at once when the controller has no
+	// Pool, defaults to 4.
+	Concurrency int
+}
+
+// RegisterBatchHandler exposes BatchHandlerKey, accepting a
+// batch.ExecuteRequest and dispatching each call to its handler (through
+// the middlewares) as if it was a standalone request.
+func (c *Controller[D]) RegisterBatchHandler(opts BatchOptions) error {
+
+	if opts.MaxCalls <= 0 {
+		opts.MaxCalls = 25
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+
+	return c.TryRegisterHandler(BatchHandlerKey, func(ctx context.Context, req *Request, res *Response) error {
+
+		in := &batch.ExecuteRequest{}
+
+		if err := req.UnmarshalProtobuf(in); err != nil {
+			return convertResultError(res, status.Errorf(codes.InvalidArgument, "Failed to unmarshal batch: %s", status.Convert(err).Message()))
+		}
+
+		if len(in.Calls) > opts.MaxCalls {
+			return convertResultError(res, status.Errorf(codes.InvalidArgument, "Batch has %d calls, the maximum is %d", len(in.Calls), opts.MaxCalls))
+		}
+
+		out := &batch.ExecuteResponse{
+			Results: make([]*batch.Result, len(in.Calls)),
+		}
+
+		pool := PoolFromContext(ctx)
+		if pool == nil {
+			pool = NewPool(opts.Concurrency)
+		}
+
+		group := pool.Group(ctx)
+
+		for i, call := range in.Calls {
+
+			i, call := i, call
+
+			group.Go(func(ctx context.Context) error {
+
+				result := c.executeBatchCall(ctx, req, call)
+
+				if len(result.Id) == 0 {
+					result.Id = strconv.Itoa(i)
+				}
+
+				out.Results[i] = result
+
+				return nil
+
+			})
+
+		}
+
+		if err := group.Wait(); err != nil {
+			return convertResultError(res, status.FromContextError(err).Err())
+		}
+
+		return res.MarshalProtobuf(out)
+
+	})
+
+}
+
+func (c *Controller[D]) executeBatchCall(ctx context.Context, batchReq *Request, call *batch.Call) *batch.Result {
+
+	result := &batch.Result{
+		Id: call.Id,
+	}
+
+	fail := func(err error) *batch.Result {
+		st := status.Convert(err)
+		result.Code = int32(st.Code())
+		result.Message = st.Message()
+		return result
+	}
+
+	proxyReq := *batchReq.APIGatewayProxyRequest
+
+	proxyReq.Headers, proxyReq.MultiValueHeaders = batchCallHeaders(batchReq.APIGatewayProxyRequest, call)
+
+	proxyReq.Body = base64.RawStdEncoding.EncodeToString(call.Body)
+	proxyReq.IsBase64Encoded = true
+
+	key, version, handler, ok := c.resolveHandler(&proxyReq, call.Method)
+	if !ok || key == BatchHandlerKey {
+		result.StatusCode = http.StatusNotFound
+		return fail(status.Errorf(codes.NotFound, "No handler registered for %s", call.Method))
+	}
+
+	req := &Request{
+		APIGatewayProxyRequest: &proxyReq,
+		HandlerKey:             key,
+		DefaultCodec:           c.DefaultCodecs[key],
+	}
+
+	res := &Response{
+		APIGatewayProxyResponse: &events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+		},
+	}
+
+	ctx = ContextWithLogger(ctx, LoggerFromContext(ctx).With("handler_key", key, "batch_call_id", call.Id))
+
+	// Same setup and statuses as a standalone request.
+	err := c.serve(ctx, req, res, handler, version)
+
+	result.StatusCode = int32(res.StatusCode)
+	result.Headers = res.Headers
+
+	if err != nil {
+		return fail(err)
+	}
+
+	if res.IsBase64Encoded {
+		body, err := DecodeBase64(res.Body)
+		if err != nil {
+			result.StatusCode = http.StatusInternalServerError
+			return fail(status.Errorf(codes.Internal, "Failed to decode response body: %v", err))
+		}
+		result.Body = body
+	} else {
+		result.Body = []byte(res.Body)
+	}
+
+	return result
+
+}
+
+// batchCallHeaders merges the headers of the call over the ones of the
+// batch request, without the batch's content headers since each call
+// carries its own codec and encoding.
+func batchCallHeaders(batchReq *events.APIGatewayProxyRequest, call *batch.Call) (map[string]string, map[string][]string) {
+
+	headers := make(map[string]string, len(batchReq.Headers)+len(call.Headers))
+	multiValueHeaders := make(map[string][]string, len(batchReq.MultiValueHeaders)+len(call.Headers))
+
+	for k, v := range batchReq.Headers {
+		if !isBatchContentHeader(k) {
+			headers[k] = v
+		}
+	}
+
+	for k, v := range batchReq.MultiValueHeaders {
+		if !isBatchContentHeader(k) {
+			multiValueHeaders[k] = v
+		}
+	}
+
+	for k, v := range call.Headers {
+
+		headers = withoutHeader(headers, k)
+		multiValueHeaders = withoutMultiValueHeader(multiValueHeaders, k)
+
+		headers[k] = v
+		multiValueHeaders[k] = []string{v}
+
+	}
+
+	return headers, multiValueHeaders
+
+}
+
+func isBatchContentHeader(key string) bool {
+
+	switch strings.ToLower(key) {
+	case "content-type", "content-length", "content-encoding", "accept", "accept-encoding", GrpcEncodingHeader, GrpcAcceptEncodingHeader:
+		return true
+	}
+
+	return false
+
+}
@@ -0,0 +1,85 @@
+package lambda
+
+import (
+	"encoding/base64"
+	"io"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Buffers above the Lambda payload limit are not kept in the pool.
+const maxPooledBuffer = 6 << 20
+
+var bodyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 32<<10)
+		return &buf
+	},
+}
+
+func getBodyBuffer(size int) *[]byte {
+
+	buf := bodyBuffers.Get().(*[]byte)
+
+	if cap(*buf) < size {
+		*buf = make([]byte, size)
+	}
+
+	*buf = (*buf)[:size]
+
+	return buf
+
+}
+
+func putBodyBuffer(buf *[]byte) {
+
+	if cap(*buf) > maxPooledBuffer {
+		return
+	}
+
+	bodyBuffers.Put(buf)
+
+}
+
+// withDecodedBase64 decodes s into a pooled buffer which is only valid
+// during fn. Both padded and raw standard encodings are accepted since
+// gateways differ on padding.
+func withDecodedBase64(s string, fn func([]byte) error) error {
+
+	s = strings.TrimRight(s, "=")
+
+	buf := getBodyBuffer(base64.RawStdEncoding.DecodedLen(len(s)))
+	defer putBodyBuffer(buf)
+
+	// Decoding from a reader avoids copying the body to a []byte first.
+	n, err := io.ReadFull(base64.NewDecoder(base64.RawStdEncoding, strings.NewReader(s)), *buf)
+	if err != nil {
+		return err
+	}
+
+	return fn((*buf)[:n])
+
+}
+
+// DecodeBase64 decodes a body in either padded or raw standard encoding.
+func DecodeBase64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func marshalBase64(m proto.Message) (string, error) {
+
+	buf := getBodyBuffer(0)
+	defer putBodyBuffer(buf)
+
+	body, err := proto.MarshalOptions{}.MarshalAppend(*buf, m)
+	if err != nil {
+		return "", err
+	}
+
+	*buf = body[:0]
+
+	return base64.RawStdEncoding.EncodeToString(body), nil
+
+}
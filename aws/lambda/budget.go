@@ -0,0 +1,163 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const GrpcTimeoutHeader = "grpc-timeout"
+
+// BudgetRemaining returns the time left before the deadline of ctx minus the
+// margin reserved to answer, false when ctx has no deadline.
+func BudgetRemaining(ctx context.Context, margin time.Duration) (time.Duration, bool) {
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	remaining := time.Until(deadline) - margin
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return remaining, true
+
+}
+
+// WithBudget derives a context expiring margin before ctx, leaving time to
+// answer once downstream calls time out. Parallel calls share it.
+func WithBudget(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+
+	remaining, ok := BudgetRemaining(ctx, margin)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, remaining)
+
+}
+
+// SplitBudget splits the budget of ctx among sequential calls in proportion
+// to their weights.
+func SplitBudget(ctx context.Context, margin time.Duration, weights ...float64) []time.Duration {
+
+	splits := make([]time.Duration, len(weights))
+
+	remaining, ok := BudgetRemaining(ctx, margin)
+	if !ok {
+		return splits
+	}
+
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	if total <= 0 {
+		return splits
+	}
+
+	for i, weight := range weights {
+		splits[i] = time.Duration(float64(remaining) * weight / total)
+	}
+
+	return splits
+
+}
+
+// BudgetClientInterceptor makes the outgoing calls expire margin before the
+// incoming call, grpc-go sends the resulting grpc-timeout.
+func BudgetClientInterceptor(margin time.Duration) grpc.UnaryClientInterceptor {
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		ctx, cancel := WithBudget(ctx, margin)
+		defer cancel()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+
+	}
+
+}
+
+// withIncomingTimeout applies the grpc-timeout header of the request.
+func withIncomingTimeout(ctx context.Context, req *Request) (context.Context, context.CancelFunc) {
+
+	timeout, err := decodeTimeout(req.Header(GrpcTimeoutHeader))
+	if err != nil {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+
+}
+
+// encodeTimeout encodes a grpc-timeout value, at most 8 digits.
+func encodeTimeout(timeout time.Duration) string {
+
+	if timeout <= 0 {
+		return "0n"
+	}
+
+	units := []struct {
+		unit time.Duration
+		code string
+	}{
+		{time.Nanosecond, "n"},
+		{time.Microsecond, "u"},
+		{time.Millisecond, "m"},
+		{time.Second, "S"},
+		{time.Minute, "M"},
+	}
+
+	for _, u := range units {
+		if value := timeout / u.unit; value < 1e8 {
+			return fmt.Sprintf("%d%s", value, u.code)
+		}
+	}
+
+	return fmt.Sprintf("%dH", timeout/time.Hour)
+
+}
+
+func decodeTimeout(value string) (time.Duration, error) {
+
+	if len(value) < 2 {
+		return 0, fmt.Errorf("Invalid timeout: %q", value)
+	}
+
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, fmt.Errorf("Invalid timeout unit: %q", value)
+	}
+
+	// gRPC timeouts have at most 8 digits.
+	digits := value[:len(value)-1]
+	if len(digits) > 8 || strings.Trim(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("Invalid timeout: %q", value)
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || amount > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("Invalid timeout: %q", value)
+	}
+
+	return time.Duration(amount) * unit, nil
+
+}
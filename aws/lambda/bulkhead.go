@@ -0,0 +1,158 @@
+package lambda
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bulkhead limits the requests in flight per group of methods, each group
+// having its own semaphore, so a slow dependency of a route can't exhaust
+// the memory or the connections of the routes sharing the function.
+//
+//	bulkhead := &lambda.Bulkhead{
+//		Groups: map[string]string{
+//			"/acme.reports.v1.Reports/Export": "reports",
+//			"/acme.reports.v1.Reports/Render": "reports",
+//		},
+//		Limits: map[string]int{"reports": 4},
+//	}
+//	controller.RegisterMiddleware(bulkhead.Middleware())
+type Bulkhead struct {
+	// Group by handler key, the keys without group are their own group.
+	Groups map[string]string
+	// Requests in flight by group.
+	Limits map[string]int
+	// Limit of the groups without one, unlimited when 0.
+	DefaultLimit int
+	// Wait for a slot before rejecting, requests are rejected right away
+	// when 0.
+	MaxWait time.Duration
+	// Retry-After of the rejected requests, defaults to a second.
+	RetryAfter time.Duration
+
+	lock       sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+// Middleware rejects with ResourceExhausted the requests of full groups.
+func (b *Bulkhead) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			group := b.group(req.HandlerKey)
+
+			semaphore := b.semaphore(group)
+			if semaphore == nil {
+				return next(ctx, req, res)
+			}
+
+			if err := b.acquire(ctx, semaphore); err != nil {
+
+				LoggerFromContext(ctx).Warn("Request rejected by bulkhead", "group", group, "limit", cap(semaphore))
+
+				return res.WriteError(err)
+
+			}
+			defer func() { <-semaphore }()
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
+
+// InFlight returns the requests in flight by group.
+func (b *Bulkhead) InFlight() map[string]int {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	inFlight := make(map[string]int, len(b.semaphores))
+
+	for group, semaphore := range b.semaphores {
+		inFlight[group] = len(semaphore)
+	}
+
+	return inFlight
+
+}
+
+func (b *Bulkhead) group(key string) string {
+
+	if group, ok := b.Groups[key]; ok {
+		return group
+	}
+
+	return key
+
+}
+
+// semaphore returns the semaphore of group, nil when it's unlimited.
+func (b *Bulkhead) semaphore(group string) chan struct{} {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if semaphore, ok := b.semaphores[group]; ok {
+		return semaphore
+	}
+
+	limit, ok := b.Limits[group]
+	if !ok {
+		limit = b.DefaultLimit
+	}
+
+	if limit <= 0 {
+		return nil
+	}
+
+	if b.semaphores == nil {
+		b.semaphores = make(map[string]chan struct{})
+	}
+
+	b.semaphores[group] = make(chan struct{}, limit)
+
+	return b.semaphores[group]
+
+}
+
+func (b *Bulkhead) acquire(ctx context.Context, semaphore chan struct{}) error {
+
+	select {
+	case semaphore <- struct{}{}:
+		return nil
+	default:
+	}
+
+	retryAfter := b.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+
+	full := WithRetryAfter(status.Error(codes.ResourceExhausted, "Too many requests in flight, retry later"), retryAfter)
+
+	if b.MaxWait <= 0 {
+		return full
+	}
+
+	timer := time.NewTimer(b.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case semaphore <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return full
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+}
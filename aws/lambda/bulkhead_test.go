@@ -0,0 +1,95 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestBulkheadMiddleware(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		bulkhead   *Bulkhead
+		key        string
+		inFlight   int
+		release    bool
+		status     int
+		retryAfter string
+	}{
+		{
+			name:     "under the limit",
+			bulkhead: &Bulkhead{Limits: map[string]int{"reports": 2}, Groups: map[string]string{"/reports/Export": "reports"}},
+			key:      "/reports/Export",
+			inFlight: 1,
+			status:   http.StatusOK,
+		},
+		{
+			name:       "rejected at the limit",
+			bulkhead:   &Bulkhead{Limits: map[string]int{"reports": 2}, Groups: map[string]string{"/reports/Export": "reports"}},
+			key:        "/reports/Export",
+			inFlight:   2,
+			status:     http.StatusTooManyRequests,
+			retryAfter: "1",
+		},
+		{
+			name:       "rejected with custom retry after",
+			bulkhead:   &Bulkhead{DefaultLimit: 1, RetryAfter: 3 * time.Second},
+			key:        "/orders/Get",
+			inFlight:   1,
+			status:     http.StatusTooManyRequests,
+			retryAfter: "3",
+		},
+		{
+			name:     "unlimited group",
+			bulkhead: &Bulkhead{Limits: map[string]int{"reports": 1}},
+			key:      "/orders/Get",
+			status:   http.StatusOK,
+		},
+		{
+			name:     "waits for a released slot",
+			bulkhead: &Bulkhead{DefaultLimit: 1, MaxWait: time.Second},
+			key:      "/orders/Get",
+			inFlight: 1,
+			release:  true,
+			status:   http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			semaphore := test.bulkhead.semaphore(test.bulkhead.group(test.key))
+			for i := 0; i < test.inFlight; i++ {
+				semaphore <- struct{}{}
+			}
+
+			if test.release {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					<-semaphore
+				}()
+			}
+
+			req := &Request{APIGatewayProxyRequest: &events.APIGatewayProxyRequest{}, HandlerKey: test.key}
+			res := &Response{APIGatewayProxyResponse: &events.APIGatewayProxyResponse{StatusCode: http.StatusOK}}
+
+			test.bulkhead.Middleware()(func(ctx context.Context, req *Request, res *Response) error {
+				return nil
+			})(context.Background(), req, res)
+
+			if res.StatusCode != test.status {
+				t.Fatalf("status = %d, want %d (%s)", res.StatusCode, test.status, res.Body)
+			}
+
+			if retryAfter := res.Header("Retry-After"); retryAfter != test.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, test.retryAfter)
+			}
+
+		})
+	}
+
+}
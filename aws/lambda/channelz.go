@@ -0,0 +1,148 @@
+package lambda
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CallStats counts the calls of a ClientConn or of one of its targets.
+type CallStats struct {
+	CallsStarted    int64
+	CallsSucceeded  int64
+	CallsFailed     int64
+	LastCallStarted time.Time
+	LastError       string
+	LastErrorTime   time.Time
+}
+
+// ResolverState is the outcome of the last target resolution.
+type ResolverState struct {
+	LastResolved time.Time
+	// Keys of the resolved targets, see TargetKey.
+	Targets   []string
+	LastError string
+}
+
+// ConnStats is a snapshot of the statistics of a ClientConn, calls count
+// once in Calls however many targets they tried.
+type ConnStats struct {
+	Calls    CallStats
+	Resolver ResolverState
+	// Attempts by target key.
+	Targets map[string]CallStats
+}
+
+type connStats struct {
+	lock     sync.Mutex
+	calls    CallStats
+	resolver ResolverState
+	targets  map[string]*CallStats
+}
+
+func (s *CallStats) started() {
+	s.CallsStarted++
+	s.LastCallStarted = time.Now()
+}
+
+func (s *CallStats) finished(err error) {
+
+	if err == nil {
+		s.CallsSucceeded++
+		return
+	}
+
+	s.CallsFailed++
+	s.LastError = err.Error()
+	s.LastErrorTime = time.Now()
+
+}
+
+func (c *connStats) callStarted() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls.started()
+}
+
+func (c *connStats) callFinished(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls.finished(err)
+}
+
+func (c *connStats) resolved(targets []*Target, err error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resolver.LastResolved = time.Now()
+	c.resolver.LastError = ""
+
+	if err != nil {
+		c.resolver.LastError = err.Error()
+		return
+	}
+
+	keys := make([]string, len(targets))
+	for i, target := range targets {
+		keys[i] = TargetKey(target)
+	}
+	sort.Strings(keys)
+
+	c.resolver.Targets = keys
+
+}
+
+func (c *connStats) attemptStarted(target *Target) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.targets == nil {
+		c.targets = make(map[string]*CallStats)
+	}
+
+	key := TargetKey(target)
+
+	stats, ok := c.targets[key]
+	if !ok {
+		stats = &CallStats{}
+		c.targets[key] = stats
+	}
+
+	stats.started()
+
+}
+
+func (c *connStats) attemptFinished(target *Target, err error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if stats, ok := c.targets[TargetKey(target)]; ok {
+		stats.finished(err)
+	}
+
+}
+
+// Stats returns a snapshot of the call statistics of the ClientConn.
+func (c *ClientConn) Stats() *ConnStats {
+
+	c.stats.lock.Lock()
+	defer c.stats.lock.Unlock()
+
+	snapshot := &ConnStats{
+		Calls:    c.stats.calls,
+		Resolver: c.stats.resolver,
+		Targets:  make(map[string]CallStats, len(c.stats.targets)),
+	}
+
+	snapshot.Resolver.Targets = append([]string{}, c.stats.resolver.Targets...)
+
+	for key, stats := range c.stats.targets {
+		snapshot.Targets[key] = *stats
+	}
+
+	return snapshot
+
+}
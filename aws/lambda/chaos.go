@@ -0,0 +1,137 @@
+package lambda
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ChaosHeader activates fault injection for a request when allowed, its
+// value may override the fault (e.g. "delay=250ms,code=UNAVAILABLE").
+const ChaosHeader = "x-protomesh-chaos"
+
+// Fault describes the failures injected in a method.
+type Fault struct {
+	Delay      time.Duration
+	DelayRatio float64
+	Code       codes.Code
+	AbortRatio float64
+}
+
+type ChaosOptions struct {
+	// Faults by full method name, the "*" key applies to the others.
+	Faults map[string]Fault
+	// Injects the faults in every request while it returns true (e.g. a
+	// flag of the configuration), nil means never.
+	Enabled func() bool
+	// Injects the faults in requests carrying ChaosHeader, every fault
+	// ratio is 1 for them.
+	HeaderActivation bool
+	// Longest delay requested by ChaosHeader, longer ones are rejected with
+	// InvalidArgument. Defaults to 5s.
+	MaxDelay time.Duration
+}
+
+// ChaosInterceptor injects latency and errors for resilience testing of
+// the consumers of the services.
+func ChaosInterceptor(opts ChaosOptions) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		fault, ok := opts.Faults[info.FullMethod]
+		if !ok {
+			fault, ok = opts.Faults["*"]
+		}
+
+		enabled := opts.Enabled != nil && opts.Enabled()
+
+		if opts.HeaderActivation {
+
+			inMeta, _ := metadata.FromIncomingContext(ctx)
+
+			if values := inMeta.Get(ChaosHeader); len(values) > 0 {
+
+				headerFault, err := parseFault(values[0], fault, opts.MaxDelay)
+				if err != nil {
+					return nil, err
+				}
+
+				fault, ok, enabled = headerFault, true, true
+
+			}
+
+		}
+
+		if !ok || !enabled {
+			return handler(ctx, req)
+		}
+
+		if fault.Delay > 0 && rand.Float64() < fault.DelayRatio {
+
+			select {
+			case <-time.After(fault.Delay):
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+
+		}
+
+		if fault.Code != codes.OK && rand.Float64() < fault.AbortRatio {
+			return nil, status.Errorf(fault.Code, "Fault injected in %s", info.FullMethod)
+		}
+
+		return handler(ctx, req)
+
+	}
+
+}
+
+// parseFault reads the ChaosHeader value over base, activating every
+// fault of the request.
+func parseFault(value string, base Fault, maxDelay time.Duration) (Fault, error) {
+
+	if maxDelay <= 0 {
+		maxDelay = 5 * time.Second
+	}
+
+	fault := base
+	fault.DelayRatio = 1
+	fault.AbortRatio = 1
+
+	for _, param := range strings.Split(value, ",") {
+
+		key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+
+		switch key {
+
+		case "delay":
+			delay, err := time.ParseDuration(val)
+			if err != nil {
+				return fault, status.Errorf(codes.InvalidArgument, "Invalid chaos delay: %s", val)
+			}
+			if delay > maxDelay {
+				return fault, status.Errorf(codes.InvalidArgument, "Chaos delay %s exceeds %s", delay, maxDelay)
+			}
+			fault.Delay = delay
+
+		case "code":
+			var code codes.Code
+			if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(val)))); err != nil {
+				return fault, status.Errorf(codes.InvalidArgument, "Invalid chaos code: %s", val)
+			}
+			fault.Code = code
+
+		}
+
+	}
+
+	return fault, nil
+
+}
@@ -0,0 +1,277 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/blob"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ClaimCheckHeader references an object holding the request body.
+	ClaimCheckHeader = "X-Protomesh-Claim-Check"
+
+	ClaimCheckHandlerKey = "/protomesh.claimcheck/Upload"
+)
+
+// ClaimCheck offloads payloads exceeding the gateway limits to a blob
+// store (usually S3): clients upload the body to a presigned url and send
+// its key in ClaimCheckHeader, the middleware resolves it before the
+// handler runs.
+type ClaimCheck struct {
+	Store blob.Store
+	// Keys are issued and accepted only under this prefix.
+	Prefix string
+	// Validity of the presigned urls, defaults to 15 minutes.
+	Expires time.Duration
+	// Maximum size of a resolved body, defaults to 64MiB.
+	MaxSize int64
+}
+
+// ClaimCheckTicket is the answer of the upload handler.
+type ClaimCheckTicket struct {
+	Key       string    `json:"key"`
+	Url       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (cc *ClaimCheck) expires() time.Duration {
+
+	if cc.Expires > 0 {
+		return cc.Expires
+	}
+
+	return 15 * time.Minute
+
+}
+
+// IssueUpload returns a presigned PUT url for a new object.
+func (cc *ClaimCheck) IssueUpload(ctx context.Context) (*ClaimCheckTicket, error) {
+
+	id := make([]byte, 16)
+	clock.IdGeneratorFromContext(ctx).Read(id)
+
+	key := strings.TrimRight(cc.Prefix, "/") + "/" + hex.EncodeToString(id)
+
+	return cc.issue(ctx, http.MethodPut, key)
+
+}
+
+// IssueDownload returns a presigned GET url for key, handlers use it to
+// answer with a reference when the response would be too large.
+func (cc *ClaimCheck) IssueDownload(ctx context.Context, key string) (*ClaimCheckTicket, error) {
+	return cc.issue(ctx, http.MethodGet, key)
+}
+
+func (cc *ClaimCheck) issue(ctx context.Context, method, key string) (*ClaimCheckTicket, error) {
+
+	url, err := cc.Store.Presign(ctx, method, key, cc.expires())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClaimCheckTicket{
+		Key:       key,
+		Url:       url,
+		ExpiresAt: clock.FromContext(ctx).Now().Add(cc.expires()),
+	}, nil
+
+}
+
+// UploadHandler issues upload tickets for ClaimCheckTransport, register it
+// at ClaimCheckHandlerKey.
+func (cc *ClaimCheck) UploadHandler() Handler {
+
+	return func(ctx context.Context, req *Request, res *Response) error {
+
+		ticket, err := cc.IssueUpload(ctx)
+		if err != nil {
+			return convertResultError(res, status.Errorf(codes.Internal, "Failed to issue upload: %v", err))
+		}
+
+		body, err := json.Marshal(ticket)
+		if err != nil {
+			return err
+		}
+
+		res.StatusCode = http.StatusOK
+		res.Headers = map[string]string{"Content-Type": "application/json"}
+		res.Body = string(body)
+		res.IsBase64Encoded = false
+
+		return nil
+
+	}
+
+}
+
+// Middleware replaces the body of requests carrying ClaimCheckHeader with
+// the referenced object.
+func (cc *ClaimCheck) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			key := req.Header(ClaimCheckHeader)
+			if len(key) == 0 {
+				return next(ctx, req, res)
+			}
+
+			if !strings.HasPrefix(key, strings.TrimRight(cc.Prefix, "/")+"/") || strings.Contains(key, "..") {
+				return convertResultError(res, status.Errorf(codes.InvalidArgument, "Invalid claim check: %s", key))
+			}
+
+			body, err := cc.fetch(ctx, key)
+			if err != nil {
+				return convertResultError(res, err)
+			}
+
+			// The request is shared with the caller, resolve into a copy.
+			proxyReq := *req.APIGatewayProxyRequest
+			proxyReq.Body = base64.RawStdEncoding.EncodeToString(body)
+			proxyReq.IsBase64Encoded = true
+
+			resolved := *req
+			resolved.APIGatewayProxyRequest = &proxyReq
+
+			return next(ctx, &resolved, res)
+
+		}
+
+	}
+
+}
+
+func (cc *ClaimCheck) fetch(ctx context.Context, key string) ([]byte, error) {
+
+	maxSize := cc.MaxSize
+	if maxSize <= 0 {
+		maxSize = 64 << 20
+	}
+
+	object, err := cc.Store.Stream(ctx, key)
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Errorf(codes.NotFound, "Claim check not found: %s", key)
+	} else if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to fetch claim check: %v", err)
+	}
+	defer object.Close()
+
+	body, err := io.ReadAll(io.LimitReader(object, maxSize+1))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to read claim check: %v", err)
+	}
+
+	if int64(len(body)) > maxSize {
+		return nil, status.Errorf(codes.ResourceExhausted, "Claim check exceeds %d bytes", maxSize)
+	}
+
+	return body, nil
+
+}
+
+// ClaimCheckTransport is the client side of the claim check flow: request
+// bodies above Threshold are uploaded with a ticket from UploadUrl and
+// replaced by ClaimCheckHeader.
+type ClaimCheckTransport struct {
+	Base      http.RoundTripper
+	UploadUrl string
+	// Defaults to 5MiB, below the API Gateway and Lambda payload limits
+	// once base64 encoded.
+	Threshold int64
+}
+
+func (t *ClaimCheckTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	threshold := t.Threshold
+	if threshold <= 0 {
+		threshold = 5 << 20
+	}
+
+	if req.Body == nil || (req.ContentLength >= 0 && req.ContentLength <= threshold) {
+		return base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) <= threshold {
+		buffered := req.Clone(req.Context())
+		buffered.Body = io.NopCloser(bytes.NewReader(body))
+		buffered.ContentLength = int64(len(body))
+		return base.RoundTrip(buffered)
+	}
+
+	ticket, err := t.upload(req.Context(), base, body)
+	if err != nil {
+		return nil, err
+	}
+
+	offloaded := req.Clone(req.Context())
+	offloaded.Body = http.NoBody
+	offloaded.ContentLength = 0
+	offloaded.Header.Set(ClaimCheckHeader, ticket.Key)
+
+	return base.RoundTrip(offloaded)
+
+}
+
+func (t *ClaimCheckTransport) upload(ctx context.Context, base http.RoundTripper, body []byte) (*ClaimCheckTicket, error) {
+
+	ticketReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.UploadUrl, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	ticketRes, err := base.RoundTrip(ticketReq)
+	if err != nil {
+		return nil, err
+	}
+	defer ticketRes.Body.Close()
+
+	if ticketRes.StatusCode != http.StatusOK {
+		return nil, status.Errorf(codes.Unavailable, "Claim check upload handler returned %d", ticketRes.StatusCode)
+	}
+
+	ticket := &ClaimCheckTicket{}
+	if err := json.NewDecoder(ticketRes.Body).Decode(ticket); err != nil {
+		return nil, err
+	}
+
+	putReq, err := http.NewRequestWithContext(ctx, http.MethodPut, ticket.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	putRes, err := base.RoundTrip(putReq)
+	if err != nil {
+		return nil, err
+	}
+	defer putRes.Body.Close()
+
+	if putRes.StatusCode >= 300 {
+		return nil, status.Errorf(codes.Unavailable, "Claim check upload returned %d", putRes.StatusCode)
+	}
+
+	return ticket, nil
+
+}
@@ -0,0 +1,436 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Target is a function serving gRPC services through HandleLambda.
+type Target struct {
+	// Name or ARN of the function.
+	FunctionName string
+	Qualifier    string
+	// Defaults to the region of the client.
+	Region string
+	// Role assumed to invoke functions of other accounts.
+	RoleArn    string
+	ExternalId string
+	// Compresses the requests before the function advertised the encoding
+	// of ClientConn.Compression.
+	Compress bool
+}
+
+// TargetResolver resolves the functions serving a method (e.g. from a
+// service registry), several targets are balanced by the Balancer.
+type TargetResolver func(ctx context.Context, fullMethod string) ([]*Target, error)
+
+// ClientConn calls gRPC methods of controllers by invoking their functions
+// directly, without going through API Gateway.
+type ClientConn struct {
+	// Orders the targets of each call, only the first target is called
+	// when nil.
+	Balancer Balancer
+	// Hedges the calls of idempotent methods when set.
+	Hedging *HedgingPolicy
+	// Accepts compressed responses when set, and compresses the large
+	// requests of the targets which advertised the encoding in their
+	// responses (or opted in with Target.Compress).
+	Compression *CompressionOptions
+	// Wraps every call (e.g. to propagate metadata), the ClientConn passed to
+	// the interceptor is nil.
+	Interceptor grpc.UnaryClientInterceptor
+	// Wrap the calls inside Interceptor, the first one being the outermost
+	// (e.g. telemetry, then credentials, then retries).
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+	// Serves in process the methods it serves (e.g. the controller of the
+	// function when it bundles several services), after the interceptors.
+	Local LocalInvoker
+
+	client   *awsapi.Client
+	resolver TargetResolver
+
+	lock    sync.Mutex
+	clients map[string]*awsapi.Client
+	// Functions which advertised the encoding of Compression.
+	compressing map[string]bool
+
+	stats connStats
+}
+
+var _ grpc.ClientConnInterface = &ClientConn{}
+
+func NewClientConn(client *awsapi.Client, resolver TargetResolver) *ClientConn {
+	return &ClientConn{
+		client:   client,
+		resolver: resolver,
+		clients:  make(map[string]*awsapi.Client),
+	}
+}
+
+func (c *ClientConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	invoker := chainUnaryClientInterceptors(append([]grpc.UnaryClientInterceptor{c.Interceptor}, c.UnaryInterceptors...), func(ctx context.Context, method string, args, reply interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return c.invoke(ctx, method, args, reply, opts...)
+	})
+
+	return invoker(ctx, method, args, reply, nil, opts...)
+
+}
+
+func (c *ClientConn) invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	if c.Local != nil && c.Local.Serves(method) {
+		return c.Local.InvokeLocal(ctx, method, args, reply, opts...)
+	}
+
+	c.stats.callStarted()
+
+	err := c.invokeResolved(ctx, method, args, reply, opts...)
+
+	c.stats.callFinished(err)
+
+	return err
+
+}
+
+func (c *ClientConn) invokeResolved(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	targets, err := c.resolver(ctx, method)
+
+	c.stats.resolved(targets, err)
+
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		return status.Errorf(codes.Unavailable, "No target for %s", method)
+	}
+
+	if c.Balancer != nil {
+		targets = c.Balancer.Order(method, targets)
+	}
+
+	if c.shouldHedge(method) {
+		return c.invokeHedged(ctx, targets, method, args, reply, opts...)
+	}
+
+	if c.Balancer == nil {
+		return c.invokeTarget(ctx, targets[0], method, args, reply, opts...)
+	}
+
+	for _, target := range targets {
+
+		c.Balancer.Started(target)
+		start := time.Now()
+
+		err = c.invokeTarget(ctx, target, method, args, reply, opts...)
+
+		c.Balancer.Report(target, err, time.Since(start))
+
+		if err == nil || !c.Balancer.Failover(err) || ctx.Err() != nil {
+			return err
+		}
+
+	}
+
+	return err
+
+}
+
+// NewStream fails with Unimplemented once through the stream interceptors,
+// which may serve the stream otherwise.
+func (c *ClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+	streamer := chainStreamClientInterceptors(c.StreamInterceptors, func(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Errorf(codes.Unimplemented, "Streams are not supported by function invocations: %s", method)
+	})
+
+	return streamer(ctx, desc, nil, method, opts...)
+
+}
+
+func (c *ClientConn) invokeTarget(ctx context.Context, target *Target, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	c.stats.attemptStarted(target)
+
+	err := c.callTarget(ctx, target, method, args, reply, opts...)
+
+	c.stats.attemptFinished(target, err)
+
+	return err
+
+}
+
+func (c *ClientConn) callTarget(ctx context.Context, target *Target, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	payload, err := newInvokePayload(ctx, method, args.(proto.Message), c.Compression, target.Compress || c.peerDecompresses(target))
+	if err != nil {
+		return err
+	}
+
+	client, err := c.targetClient(target)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/2015-03-31/functions/%s/invocations", client.Endpoint("lambda"), url.PathEscape(target.FunctionName))
+	if len(target.Qualifier) > 0 {
+		endpoint += "?Qualifier=" + url.QueryEscape(target.Qualifier)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(InvocationTypeHeader, "RequestResponse")
+
+	res, err := client.Do(ctx, "lambda", req, payload)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Errorf(codes.Unavailable, "Failed to invoke %s: %v", target.FunctionName, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "Failed to read response of %s: %v", target.FunctionName, err)
+	}
+
+	if res.StatusCode >= 300 {
+		return responseError(ctx, res.StatusCode, res.Header.Get("Retry-After"), fmt.Sprintf("Invoke of %s returned %d: %s", target.FunctionName, res.StatusCode, body))
+	}
+
+	if functionError := res.Header.Get("X-Amz-Function-Error"); len(functionError) > 0 {
+		return status.Errorf(codes.Internal, "Function %s failed (%s): %s", target.FunctionName, functionError, body)
+	}
+
+	proxyRes := &events.APIGatewayProxyResponse{}
+	if err := json.Unmarshal(body, proxyRes); err != nil {
+		return status.Errorf(codes.Internal, "Invalid response of %s: %v", target.FunctionName, err)
+	}
+
+	resMeta, resTrailer := splitTrailers(responseMetadata(proxyRes))
+
+	setCallMetadata(opts, resMeta, resTrailer)
+
+	c.learnEncoding(target, resMeta.Get(GrpcAcceptEncodingHeader))
+
+	if proxyRes.StatusCode >= 300 {
+		return responseError(ctx, proxyRes.StatusCode, (&Response{APIGatewayProxyResponse: proxyRes}).Header("Retry-After"), proxyRes.Body)
+	}
+
+	if encoding := resMeta.Get(GrpcEncodingHeader); len(encoding) > 0 && isCompressed(encoding[0]) {
+
+		msg, err := decompressBody(encoding[0], proxyRes.Body, proxyRes.IsBase64Encoded, c.Compression.maxDecompressedSize())
+		if err != nil {
+			return err
+		}
+
+		return proto.Unmarshal(msg, reply.(proto.Message))
+
+	}
+
+	if !proxyRes.IsBase64Encoded {
+		return proto.Unmarshal([]byte(proxyRes.Body), reply.(proto.Message))
+	}
+
+	return withDecodedBase64(proxyRes.Body, func(msg []byte) error {
+		return proto.Unmarshal(msg, reply.(proto.Message))
+	})
+
+}
+
+// peerDecompresses reports whether the function of target advertised the
+// encoding of the requests.
+func (c *ClientConn) peerDecompresses(target *Target) bool {
+
+	if c.Compression == nil {
+		return false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.compressing[targetKey(target)]
+
+}
+
+// learnEncoding records whether the function of target accepts the
+// encoding of the requests, per the grpc-accept-encoding of its response.
+func (c *ClientConn) learnEncoding(target *Target, accepted []string) {
+
+	if c.Compression == nil || len(accepted) == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.compressing == nil {
+		c.compressing = make(map[string]bool)
+	}
+
+	c.compressing[targetKey(target)] = acceptsEncoding(strings.Join(accepted, ","), c.Compression.encoding())
+
+}
+
+func targetKey(target *Target) string {
+	return strings.Join([]string{target.Region, target.FunctionName, target.Qualifier}, "|")
+}
+
+// targetClient returns the client invoking target, with assumed role
+// credentials for cross account targets. Clients are cached so the
+// credentials are only refreshed when they expire.
+func (c *ClientConn) targetClient(target *Target) (*awsapi.Client, error) {
+
+	if len(target.RoleArn) == 0 && (len(target.Region) == 0 || target.Region == c.client.Region) {
+		return c.client, nil
+	}
+
+	key := strings.Join([]string{target.Region, target.RoleArn, target.ExternalId}, "|")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if client, ok := c.clients[key]; ok {
+		return client, nil
+	}
+
+	client := *c.client
+
+	if len(target.Region) > 0 {
+		client.Region = target.Region
+	}
+
+	if len(target.RoleArn) > 0 {
+		client.Credentials = &awsapi.AssumeRoleCredentials{
+			Client:     c.client,
+			RoleArn:    target.RoleArn,
+			ExternalId: target.ExternalId,
+		}
+	}
+
+	c.clients[key] = &client
+
+	return &client, nil
+
+}
+
+// newInvokePayload builds the invocation of method, compressing large
+// requests when the peer decompresses them.
+func newInvokePayload(ctx context.Context, method string, args proto.Message, compression *CompressionOptions, peerDecompresses bool) ([]byte, error) {
+
+	body, err := proto.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"content-type": "application/grpc+proto"}
+
+	if compression != nil {
+
+		headers[GrpcAcceptEncodingHeader] = compression.encoding()
+
+		if peerDecompresses && compression.compresses(len(body)) {
+
+			if body, err = compress(compression.encoding(), body); err != nil {
+				return nil, err
+			}
+
+			headers[GrpcEncodingHeader] = compression.encoding()
+
+		}
+
+	}
+
+	requestId := make([]byte, 16)
+	clock.IdGeneratorFromContext(ctx).Read(requestId)
+
+	proxyReq := &events.APIGatewayProxyRequest{
+		HTTPMethod:        http.MethodPost,
+		Path:              method,
+		Headers:           headers,
+		MultiValueHeaders: map[string][]string{},
+		Body:              base64.RawStdEncoding.EncodeToString(body),
+		IsBase64Encoded:   true,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			// Marks the invocation as synchronous, see
+			// AsyncDestinations.NoGatewayIsAsync.
+			RequestID: hex.EncodeToString(requestId),
+		},
+	}
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for k, v := range md {
+			proxyReq.MultiValueHeaders[k] = v
+		}
+	}
+
+	if requestId := RequestIdFromContext(ctx); len(requestId) > 0 {
+		proxyReq.Headers[RequestIdHeader] = requestId
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		proxyReq.Headers[GrpcTimeoutHeader] = encodeTimeout(time.Until(deadline))
+	}
+
+	return json.Marshal(proxyReq)
+
+}
+
+// setCallMetadata sets the headers and trailers of a response to the
+// grpc.Header and grpc.Trailer call options.
+func setCallMetadata(opts []grpc.CallOption, header, trailer metadata.MD) {
+
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case grpc.HeaderCallOption:
+			*opt.HeaderAddr = header
+		case grpc.TrailerCallOption:
+			*opt.TrailerAddr = trailer
+		}
+	}
+
+}
+
+func responseMetadata(proxyRes *events.APIGatewayProxyResponse) metadata.MD {
+
+	md := make(metadata.MD, len(proxyRes.Headers)+len(proxyRes.MultiValueHeaders))
+
+	for k, v := range proxyRes.MultiValueHeaders {
+		md[strings.ToLower(k)] = v
+	}
+
+	for k, v := range proxyRes.Headers {
+		if _, ok := md[strings.ToLower(k)]; !ok {
+			md[strings.ToLower(k)] = []string{v}
+		}
+	}
+
+	return md
+
+}
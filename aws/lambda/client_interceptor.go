@@ -0,0 +1,54 @@
+package lambda
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// chainUnaryClientInterceptors wraps invoker in the interceptors, the first
+// one being the outermost like with grpc.WithChainUnaryInterceptor. The
+// ClientConn passed to the interceptors is nil.
+func chainUnaryClientInterceptors(interceptors []grpc.UnaryClientInterceptor, invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+
+	next := invoker
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+
+		if interceptors[i] == nil {
+			continue
+		}
+
+		interceptor, inner := interceptors[i], next
+		next = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return interceptor(ctx, method, req, reply, cc, inner, opts...)
+		}
+
+	}
+
+	return next
+
+}
+
+// chainStreamClientInterceptors wraps streamer in the interceptors, the
+// first one being the outermost like with grpc.WithChainStreamInterceptor.
+func chainStreamClientInterceptors(interceptors []grpc.StreamClientInterceptor, streamer grpc.Streamer) grpc.Streamer {
+
+	next := streamer
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+
+		if interceptors[i] == nil {
+			continue
+		}
+
+		interceptor, inner := interceptors[i], next
+		next = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return interceptor(ctx, desc, cc, method, inner, opts...)
+		}
+
+	}
+
+	return next
+
+}
@@ -0,0 +1,87 @@
+package lambda
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Decoder decodes a record payload into m, it strips the framing added by
+// schema registries serializers.
+type Decoder func(data []byte, m proto.Message) error
+
+// DecodeProtobuf decodes plain protobuf payloads.
+func DecodeProtobuf(data []byte, m proto.Message) error {
+	return proto.Unmarshal(data, m)
+}
+
+// DecodeConfluentProtobuf decodes payloads of the Confluent protobuf
+// serializer: magic byte, schema id and message indexes precede the message.
+func DecodeConfluentProtobuf(data []byte, m proto.Message) error {
+
+	if len(data) < 5 || data[0] != 0 {
+		return status.Errorf(codes.InvalidArgument, "Payload is not in the Confluent wire format")
+	}
+
+	data = data[5:]
+
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return status.Errorf(codes.InvalidArgument, "Invalid Confluent message indexes")
+	}
+
+	data = data[n:]
+
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(data); n <= 0 {
+			return status.Errorf(codes.InvalidArgument, "Invalid Confluent message indexes")
+		}
+		data = data[n:]
+	}
+
+	return proto.Unmarshal(data, m)
+
+}
+
+// DecodeGlueProtobuf decodes payloads of the AWS Glue Schema Registry
+// serializer: header version, compression and schema version id precede
+// the (possibly zlib compressed) message.
+func DecodeGlueProtobuf(data []byte, m proto.Message) error {
+
+	if len(data) < 18 || data[0] != 3 {
+		return status.Errorf(codes.InvalidArgument, "Payload is not in the Glue Schema Registry wire format")
+	}
+
+	compression := data[1]
+	data = data[18:]
+
+	switch compression {
+
+	case 0:
+
+	case 5:
+
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid compressed payload: %v", err)
+		}
+		defer reader.Close()
+
+		data, err = io.ReadAll(reader)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid compressed payload: %v", err)
+		}
+
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unknown Glue compression %d", compression)
+
+	}
+
+	return proto.Unmarshal(data, m)
+
+}
@@ -0,0 +1,138 @@
+package lambda
+
+import (
+	"mime"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Payloads of the Confluent protobuf serializer, see
+	// DecodeConfluentProtobuf.
+	CodecConfluentProtobuf Codec = "confluent-protobuf"
+	// Payloads of the AWS Glue Schema Registry serializer, see
+	// DecodeGlueProtobuf.
+	CodecGlueProtobuf Codec = "glue-protobuf"
+)
+
+// Codecs is the codec registry of the request bodies, the IoT messages and
+// the Kafka records.
+var Codecs = NewCodecRegistry()
+
+// CodecRegistry resolves the codec of a content type and the decoder of a
+// codec. Media types are matched without their parameters, then by their
+// +json or +proto suffix.
+//
+//	lambda.Codecs.RegisterContentType("application/vnd.acme.telemetry", lambda.CodecGlueProtobuf)
+type CodecRegistry struct {
+	lock         sync.RWMutex
+	contentTypes map[string]Codec
+	decoders     map[Codec]Decoder
+}
+
+// NewCodecRegistry returns a registry of the protobuf and JSON content
+// types, and of the decoders of the package.
+func NewCodecRegistry() *CodecRegistry {
+
+	r := &CodecRegistry{
+		contentTypes: make(map[string]Codec),
+		decoders:     make(map[Codec]Decoder),
+	}
+
+	for _, mediaType := range []string{"application/x-protobuf", "application/protobuf", "application/octet-stream", "application/grpc", "application/grpc+proto"} {
+		r.RegisterContentType(mediaType, CodecProtobuf)
+	}
+
+	r.RegisterContentType("application/json", CodecJSON)
+
+	r.RegisterDecoder(CodecProtobuf, DecodeProtobuf)
+	r.RegisterDecoder(CodecJSON, DecodeJSON)
+	r.RegisterDecoder(CodecConfluentProtobuf, DecodeConfluentProtobuf)
+	r.RegisterDecoder(CodecGlueProtobuf, DecodeGlueProtobuf)
+
+	return r
+
+}
+
+// RegisterContentType maps the media type (e.g. "application/vnd.acme+x")
+// to codec, replacing the previous mapping.
+func (r *CodecRegistry) RegisterContentType(mediaType string, codec Codec) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.contentTypes[strings.ToLower(mediaType)] = codec
+}
+
+// RegisterDecoder sets the decoder of codec, replacing the previous one.
+func (r *CodecRegistry) RegisterDecoder(codec Codec, decoder Decoder) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.decoders[codec] = decoder
+}
+
+// CodecFromContentType returns the codec of a Content-Type, false when it
+// is empty or unknown.
+func (r *CodecRegistry) CodecFromContentType(contentType string) (Codec, bool) {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if codec, ok := r.contentTypes[mediaType]; ok {
+		return codec, true
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return CodecJSON, true
+	case strings.HasSuffix(mediaType, "+proto"), strings.HasPrefix(mediaType, "application/grpc"):
+		return CodecProtobuf, true
+	}
+
+	return "", false
+
+}
+
+// Decoder returns the decoder of codec, false when none is registered.
+func (r *CodecRegistry) Decoder(codec Codec) (Decoder, bool) {
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	decoder, ok := r.decoders[codec]
+
+	return decoder, ok
+
+}
+
+// Decode decodes data into m with the decoder of codec, Unimplemented when
+// none is registered.
+func (r *CodecRegistry) Decode(codec Codec, data []byte, m proto.Message) error {
+
+	decoder, ok := r.Decoder(codec)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "No decoder for codec %s", codec)
+	}
+
+	return decoder(data, m)
+
+}
+
+// JSONDecoder decodes the JSON requests of the gRPC methods by full method
+// name, see Controller.JSONDecoder.
+type JSONDecoder interface {
+	DecodeJSON(method string, data []byte, m proto.Message) error
+}
+
+// DecodeJSON decodes protojson payloads, ignoring the unknown fields.
+func DecodeJSON(data []byte, m proto.Message) error {
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+}
@@ -0,0 +1,235 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ColdStartHeader reports the cold starts to the callers, when enabled.
+const ColdStartHeader = "x-protomesh-cold-start"
+
+// Approximates the start of the init phase, the package being initialized
+// early in the process.
+var processStart = time.Now()
+
+var initPhases struct {
+	lock   sync.Mutex
+	last   time.Time
+	phases []InitPhase
+}
+
+// InitPhase is a step of the initialization, e.g. loading the configuration
+// or connecting to a database.
+type InitPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// MarkInitPhase records the end of an init phase, it lasted since the
+// previous mark or the start of the process.
+func MarkInitPhase(name string) {
+
+	initPhases.lock.Lock()
+	defer initPhases.lock.Unlock()
+
+	last := initPhases.last
+	if last.IsZero() {
+		last = processStart
+	}
+
+	now := time.Now()
+
+	initPhases.phases = append(initPhases.phases, InitPhase{Name: name, Duration: now.Sub(last)})
+	initPhases.last = now
+
+}
+
+// ColdStart describes the first invocation of an execution environment.
+type ColdStart struct {
+	HandlerKey string
+	// on-demand, provisioned-concurrency or snap-start.
+	InitializationType string
+	// From the start of the process to the first invocation, zero when the
+	// environment was initialized ahead of it (provisioned concurrency,
+	// SnapStart), see InitMeasured.
+	InitDuration time.Duration
+	// Phases marked with MarkInitPhase.
+	Phases          []InitPhase
+	HandlerDuration time.Duration
+}
+
+// ColdStartHook receives the cold start of the execution environment.
+type ColdStartHook func(ctx context.Context, coldStart *ColdStart)
+
+type ColdStartOptions struct {
+	Hooks []ColdStartHook
+	// Sets ColdStartHeader on every response, for debugging only since it
+	// discloses the timings.
+	DebugHeader bool
+}
+
+type coldStartContextKey struct{}
+
+// IsColdStart reports whether the invocation is the first one of the
+// execution environment, within ColdStartMiddleware.
+func IsColdStart(ctx context.Context) bool {
+
+	cold, _ := ctx.Value(coldStartContextKey{}).(bool)
+
+	return cold
+
+}
+
+// ColdStartMiddleware flags the first invocation of the execution
+// environment and reports its init and handler durations.
+func ColdStartMiddleware(opts ColdStartOptions) Middleware {
+
+	var once sync.Once
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			cold := false
+			once.Do(func() {
+				cold = true
+			})
+
+			if !cold {
+
+				if opts.DebugHeader {
+					setHeader(res, ColdStartHeader, "false")
+				}
+
+				return next(ctx, req, res)
+
+			}
+
+			start := time.Now()
+
+			err := next(context.WithValue(ctx, coldStartContextKey{}, true), req, res)
+
+			initPhases.lock.Lock()
+			phases := append([]InitPhase{}, initPhases.phases...)
+			initPhases.lock.Unlock()
+
+			coldStart := &ColdStart{
+				HandlerKey:         req.HandlerKey,
+				InitializationType: os.Getenv("AWS_LAMBDA_INITIALIZATION_TYPE"),
+				Phases:             phases,
+				HandlerDuration:    time.Since(start),
+			}
+
+			// The environments initialized ahead of the invocation idle
+			// until it, the process age isn't their init duration.
+			if coldStart.InitMeasured() {
+				coldStart.InitDuration = start.Sub(processStart)
+			}
+
+			LoggerFromContext(ctx).Info("Cold start", "initialization_type", coldStart.InitializationType, "init_duration", coldStart.InitDuration.String(), "handler_duration", coldStart.HandlerDuration.String())
+
+			for _, hook := range opts.Hooks {
+				hook(ctx, coldStart)
+			}
+
+			if opts.DebugHeader {
+				setHeader(res, ColdStartHeader, coldStart.header())
+			}
+
+			return err
+
+		}
+
+	}
+
+}
+
+// InitMeasured reports whether InitDuration is measured, only for the
+// on-demand initializations (or outside Lambda).
+func (c *ColdStart) InitMeasured() bool {
+	return len(c.InitializationType) == 0 || c.InitializationType == "on-demand"
+}
+
+func (c *ColdStart) header() string {
+
+	parts := []string{"true"}
+
+	if c.InitMeasured() {
+		parts = append(parts, fmt.Sprintf("init=%dms", c.InitDuration.Milliseconds()))
+	}
+
+	parts = append(parts, fmt.Sprintf("handler=%dms", c.HandlerDuration.Milliseconds()))
+
+	for _, phase := range c.Phases {
+		parts = append(parts, fmt.Sprintf("%s=%dms", phase.Name, phase.Duration.Milliseconds()))
+	}
+
+	return strings.Join(parts, "; ")
+
+}
+
+func setHeader(res *Response, name, value string) {
+
+	if res.Headers == nil {
+		res.Headers = make(map[string]string)
+	}
+
+	res.Headers[name] = value
+
+}
+
+// ColdStartMetrics writes the cold starts in the CloudWatch embedded metric
+// format by function and version, so regressions show per deploy. Its
+// Report method is a ColdStartHook.
+type ColdStartMetrics struct {
+	// Defaults to "Protomesh/ColdStarts".
+	Namespace string
+	// Defaults to os.Stdout.
+	Writer io.Writer
+}
+
+func (m *ColdStartMetrics) Report(ctx context.Context, coldStart *ColdStart) {
+
+	namespace := m.Namespace
+	if len(namespace) == 0 {
+		namespace = "Protomesh/ColdStarts"
+	}
+
+	writer := m.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	metrics := []map[string]string{
+		{"Name": "ColdStart", "Unit": "Count"},
+		{"Name": "ColdHandlerDuration", "Unit": "Milliseconds"},
+	}
+
+	values := map[string]interface{}{
+		"FunctionName":        os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		"FunctionVersion":     os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+		"InitializationType":  coldStart.InitializationType,
+		"HandlerKey":          coldStart.HandlerKey,
+		"ColdStart":           1,
+		"ColdHandlerDuration": coldStart.HandlerDuration.Milliseconds(),
+	}
+
+	if coldStart.InitMeasured() {
+		metrics = append(metrics, map[string]string{"Name": "InitDuration", "Unit": "Milliseconds"})
+		values["InitDuration"] = coldStart.InitDuration.Milliseconds()
+	}
+
+	for _, phase := range coldStart.Phases {
+		name := "Init" + phase.Name
+		metrics = append(metrics, map[string]string{"Name": name, "Unit": "Milliseconds"})
+		values[name] = phase.Duration.Milliseconds()
+	}
+
+	writeEMF(ctx, writer, namespace, []string{"FunctionName", "FunctionVersion"}, metrics, values)
+
+}
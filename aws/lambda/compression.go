@@ -0,0 +1,197 @@
+package lambda
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+
+	// Registers the gzip compressor, other encodings (e.g. zstd) are
+	// registered by the application with encoding.RegisterCompressor.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	GrpcEncodingHeader       = "grpc-encoding"
+	GrpcAcceptEncodingHeader = "grpc-accept-encoding"
+)
+
+// CompressionOptions compresses the payloads above Threshold, the Lambda
+// invoke payloads being limited to 6 MB.
+type CompressionOptions struct {
+	// Name of a registered compressor, defaults to "gzip".
+	Encoding string
+	// Defaults to 32 KiB.
+	Threshold int
+	// Bounds the decompressed bodies, so that small payloads can't exhaust
+	// the memory of the function. Defaults to 6 MB.
+	MaxDecompressedSize int
+}
+
+func (o *CompressionOptions) encoding() string {
+
+	if len(o.Encoding) == 0 {
+		return "gzip"
+	}
+
+	return o.Encoding
+
+}
+
+// maxDecompressedSize also applies without options, the bodies being
+// decompressed whatever the encoding the controller compresses with.
+func (o *CompressionOptions) maxDecompressedSize() int {
+
+	if o == nil || o.MaxDecompressedSize <= 0 {
+		return 6 << 20
+	}
+
+	return o.MaxDecompressedSize
+
+}
+
+func (o *CompressionOptions) compresses(size int) bool {
+
+	threshold := o.Threshold
+	if threshold <= 0 {
+		threshold = 32 << 10
+	}
+
+	return size >= threshold
+
+}
+
+func compress(name string, body []byte) ([]byte, error) {
+
+	compressor := encoding.GetCompressor(name)
+	if compressor == nil {
+		return nil, status.Errorf(codes.Internal, "Compressor %s not registered", name)
+	}
+
+	buf := &bytes.Buffer{}
+
+	writer, err := compressor.Compress(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+
+}
+
+func decompress(name string, body []byte, maxSize int) ([]byte, error) {
+
+	compressor := encoding.GetCompressor(name)
+	if compressor == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Unsupported grpc-encoding %s", name)
+	}
+
+	reader, err := compressor.Decompress(bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid %s body: %v", name, err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid %s body: %v", name, err)
+	}
+
+	if len(data) > maxSize {
+		return nil, status.Errorf(codes.ResourceExhausted, "Decompressed body exceeds %d bytes", maxSize)
+	}
+
+	return data, nil
+
+}
+
+// isCompressed reports whether the grpc-encoding header names a compressor.
+func isCompressed(name string) bool {
+	return len(name) > 0 && name != "identity"
+}
+
+// acceptsEncoding reports whether name is listed in a grpc-accept-encoding
+// header.
+func acceptsEncoding(header, name string) bool {
+
+	for _, accepted := range strings.Split(header, ",") {
+		if strings.TrimSpace(accepted) == name {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// decompressBody decodes a (possibly base64 encoded) compressed body.
+func decompressBody(name, body string, isBase64 bool, maxSize int) ([]byte, error) {
+
+	data := []byte(body)
+
+	if isBase64 {
+
+		decoded, err := DecodeBase64(body)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid base64 body: %v", err)
+		}
+
+		data = decoded
+
+	}
+
+	return decompress(name, data, maxSize)
+
+}
+
+// compressResponse compresses the body of res with the encoding of opts
+// when the caller accepts it and the body is large enough. The encoding is
+// advertised to the callers, see ClientConn.Compression.
+func compressResponse(opts *CompressionOptions, req *Request, res *Response) error {
+
+	if opts == nil {
+		return nil
+	}
+
+	res.SetHeader(GrpcAcceptEncodingHeader, opts.encoding())
+
+	if !res.IsBase64Encoded || !acceptsEncoding(req.Header(GrpcAcceptEncodingHeader), opts.encoding()) {
+		return nil
+	}
+
+	if !opts.compresses(base64.RawStdEncoding.DecodedLen(len(res.Body))) {
+		return nil
+	}
+
+	body, err := DecodeBase64(res.Body)
+	if err != nil {
+		return err
+	}
+
+	compressed, err := compress(opts.encoding(), body)
+	if err != nil {
+		return err
+	}
+
+	res.Body = base64.RawStdEncoding.EncodeToString(compressed)
+
+	if res.Headers == nil {
+		res.Headers = make(map[string]string)
+	}
+
+	res.Headers[GrpcEncodingHeader] = opts.encoding()
+
+	return nil
+
+}
@@ -0,0 +1,168 @@
+package lambda
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec is the encoding of a request body.
+type Codec string
+
+const (
+	CodecProtobuf Codec = "protobuf"
+	CodecJSON     Codec = "json"
+)
+
+// CodecFromContentType returns the codec of a Content-Type in the Codecs
+// registry, false when it is empty or unknown.
+func CodecFromContentType(contentType string) (Codec, bool) {
+	return Codecs.CodecFromContentType(contentType)
+}
+
+// SniffCodec guesses the codec of a body: JSON objects and arrays, then
+// bodies parsing as protobuf wire format. False when the body is empty or
+// neither, e.g. "{}" is valid in both but detected as JSON.
+func SniffCodec(body []byte) (Codec, bool) {
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return CodecJSON, true
+	}
+
+	if len(body) > 0 && isProtobufWire(body) {
+		return CodecProtobuf, true
+	}
+
+	return "", false
+
+}
+
+// isProtobufWire reports whether body is a sequence of valid protobuf
+// fields, without knowing their message.
+func isProtobufWire(body []byte) bool {
+
+	for len(body) > 0 {
+
+		num, typ, n := protowire.ConsumeTag(body)
+		if n < 0 || num <= 0 {
+			return false
+		}
+		body = body[n:]
+
+		if n = protowire.ConsumeFieldValue(num, typ, body); n < 0 {
+			return false
+		}
+		body = body[n:]
+
+	}
+
+	return true
+
+}
+
+// Codec returns the codec of the body: the one of its Content-Type, else
+// the one sniffed from the body, else the DefaultCodec of the route, else
+// protobuf. Some gateways drop the Content-Type of the requests they
+// forward.
+func (r *Request) Codec() Codec {
+
+	if codec, ok := CodecFromContentType(r.Header("Content-Type")); ok {
+		return codec
+	}
+
+	// Compressed bodies are gRPC messages.
+	if !isCompressed(r.Header(GrpcEncodingHeader)) {
+
+		body, err := r.decodedBody()
+		if err == nil {
+			if codec, ok := SniffCodec(body); ok {
+				return codec
+			}
+		}
+
+	}
+
+	if len(r.DefaultCodec) > 0 {
+		return r.DefaultCodec
+	}
+
+	return CodecProtobuf
+
+}
+
+// Unmarshal decodes the body into m with the codec of the request,
+// malformed bodies fail with InvalidArgument. Unknown JSON fields are
+// ignored, as unknown protobuf fields are, unless the JSONDecoder of the
+// controller rejects them for the method. Protobuf bodies may be gRPC
+// framed or compressed, the other codecs are decoded by the Codecs
+// registry.
+func (r *Request) Unmarshal(m proto.Message) error {
+
+	codec := r.Codec()
+	if codec == CodecProtobuf {
+		return r.UnmarshalProtobuf(m)
+	}
+
+	body, err := r.decodedBody()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid base64 body: %v", err)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	decode := func(data []byte, m proto.Message) error {
+		return Codecs.Decode(codec, data, m)
+	}
+
+	if codec == CodecJSON && r.decodeJSON != nil {
+		decode = r.decodeJSON
+	}
+
+	if err := decode(body, m); err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return nil
+
+}
+
+func (r *Request) decodedBody() ([]byte, error) {
+
+	if r.IsBase64Encoded {
+		return DecodeBase64(r.Body)
+	}
+
+	return []byte(r.Body), nil
+
+}
+
+// marshalCodec encodes m into the response with codec, setting its
+// Content-Type.
+func (r *Response) marshalCodec(codec Codec, m proto.Message) error {
+
+	if codec != CodecJSON {
+		return r.MarshalProtobuf(m)
+	}
+
+	body, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	r.SetHeader("Content-Type", "application/json")
+	r.Body = string(body)
+	r.IsBase64Encoded = false
+
+	return nil
+
+}
@@ -0,0 +1,40 @@
+package lambda
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ContextErrorMiddleware answers with DeadlineExceeded (504) or Canceled
+// (499) the calls failing once the context of the invocation is done, the
+// handlers usually return the error of the call their context interrupted
+// (e.g. a wrapped I/O error) which would otherwise be a generic 500. Errors
+// with a specific status are kept.
+func ContextErrorMiddleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			err := next(ctx, req, res)
+			if err == nil || ctx.Err() == nil {
+				return err
+			}
+
+			switch status.Code(err) {
+			case codes.Unknown, codes.Internal, codes.Unavailable:
+			default:
+				return err
+			}
+
+			LoggerFromContext(ctx).Warn("Call failed after its context was done", "error", err, "cause", ctx.Err())
+
+			return res.WriteError(status.FromContextError(ctx.Err()).Err())
+
+		}
+
+	}
+
+}
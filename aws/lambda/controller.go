@@ -2,17 +2,18 @@ package lambda
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"net/http"
-	"reflect"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/clock"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -20,33 +21,107 @@ import (
 type Request struct {
 	*events.APIGatewayProxyRequest
 	HandlerKey string
+	// Codec of the bodies without Content-Type that can't be sniffed, see
+	// Codec.
+	DefaultCodec Codec
+
+	// Decoder of the JSON bodies of the gRPC method, see
+	// Controller.JSONDecoder.
+	decodeJSON func(data []byte, m proto.Message) error
+	// Bounds the compressed bodies, see Controller.Compression.
+	compression *CompressionOptions
 }
 
+// UnmarshalProtobuf decodes the body into m, malformed bodies fail with
+// InvalidArgument.
 func (r *Request) UnmarshalProtobuf(m proto.Message) error {
 
-	if r.IsBase64Encoded {
-		msg, err := base64.RawStdEncoding.DecodeString(r.Body)
+	if err := r.unmarshalProtobuf(m); err != nil {
+
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	}
+
+	return nil
+
+}
+
+func (r *Request) unmarshalProtobuf(m proto.Message) error {
+
+	if encoding := r.Header(GrpcEncodingHeader); isCompressed(encoding) {
+
+		body, err := decompressBody(encoding, r.Body, r.IsBase64Encoded, r.compression.maxDecompressedSize())
 		if err != nil {
 			return err
 		}
-		return proto.Unmarshal(msg, m)
+
+		return proto.Unmarshal(body, m)
+
+	}
+
+	if r.IsBase64Encoded {
+		return withDecodedBase64(r.Body, func(msg []byte) error {
+			return proto.Unmarshal(msg, m)
+		})
 	}
 
 	return proto.Unmarshal([]byte(r.Body), m)
 }
 
+// Header returns the first value of the header key, matching it case
+// insensitively since gateways don't normalize header names.
+func (r *Request) Header(key string) string {
+
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+
+	for k, v := range r.MultiValueHeaders {
+		if strings.EqualFold(k, key) && len(v) > 0 {
+			return v[0]
+		}
+	}
+
+	return ""
+}
+
+// Claim returns a claim of the caller set by the API Gateway authorizer,
+// either a Cognito/JWT claim or a Lambda authorizer context value.
+func (r *Request) Claim(name string) string {
+
+	authorizer := r.RequestContext.Authorizer
+
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		if value, ok := claims[name]; ok {
+			return fmt.Sprint(value)
+		}
+	}
+
+	if value, ok := authorizer[name]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+
+	return ""
+}
+
 type Response struct {
 	*events.APIGatewayProxyResponse
 }
 
 func (r *Response) MarshalProtobuf(m proto.Message) error {
-	body, err := proto.Marshal(m)
+	body, err := marshalBase64(m)
 	if err != nil {
 		return err
 	}
 
 	if len(body) > 0 {
-		r.Body = base64.RawStdEncoding.EncodeToString(body)
+		r.Body = body
 		r.IsBase64Encoded = true
 		return nil
 	}
@@ -69,118 +144,374 @@ type Controller[D ControllerDependency] struct {
 
 	Matcher Matcher[string]
 
-	handlers map[string]Handler
+	ConflictPolicy ConflictPolicy
+	ErrorPolicy    ErrorPolicy
+
+	// Bounds the goroutines spawned by fan-out handlers, see PoolFromContext.
+	Pool *Pool
+
+	// Reuses the input messages of gRPC methods across invocations, the
+	// services must not retain their requests after returning.
+	ReuseMessages bool
+
+	// Compresses the responses of unary methods to the callers accepting
+	// the encoding, see ClientConn.Compression.
+	Compression *CompressionOptions
+
+	// Dispatches the requests to the handler set of the requested version,
+	// see RegisterVersionedService.
+	Versioning *VersioningOptions
+
+	// Clock and id generator of the handlers, see clock.FromContext and
+	// clock.IdGeneratorFromContext. Default to the ones of the injected
+	// dependency (see clock.Provider), else clock.System and
+	// clock.RandomIds.
+	Clock clock.Clock
+	Ids   clock.IdGenerator
+
+	// Status of the successful calls by handler key (e.g. 201 Created or 204
+	// No Content, answered without body), overriding the success_status
+	// option of the methods. Defaults to 200.
+	SuccessStatus map[string]int
+
+	// Codec of the requests without Content-Type whose body can't be
+	// sniffed (e.g. empty or ambiguous), by handler key. Defaults to
+	// protobuf.
+	DefaultCodecs map[string]Codec
+
+	// Retry-After of the 429 and 503 responses whose error has no retry
+	// info, see WithRetryAfter. Unset by default.
+	RetryAfter time.Duration
+
+	// Headers not forwarded as incoming gRPC metadata (e.g. Cookie or large
+	// authorization headers), matched case insensitively.
+	ExcludedHeaders []string
+
+	// Decodes the JSON requests of the gRPC methods, e.g. a
+	// validate.Validator rejecting the unknown fields of its strict
+	// methods. Defaults to the JSON decoder of Codecs.
+	JSONDecoder JSONDecoder
+
+	routes             map[string]*Route
+	handlers           map[string]Handler
+	streams            map[string]*grpcStream
+	localMethods       map[string]*grpcMethod
+	healthChecks       map[string]HealthCheck
+	asyncDestinations  *AsyncDestinations
+	webSocket          *WebSocketOptions
+	iotRoutes          []*iotRoute
+	kafkaRoutes        map[string]*KafkaRoute
+	middlewares        []Middleware
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
 }
 
 func NewController[D ControllerDependency]() *Controller[D] {
 	return &Controller[D]{
-		handlers: make(map[string]Handler),
+		routes:       make(map[string]*Route),
+		handlers:     make(map[string]Handler),
+		streams:      make(map[string]*grpcStream),
+		localMethods: make(map[string]*grpcMethod),
+		healthChecks: make(map[string]HealthCheck),
+		kafkaRoutes:  make(map[string]*KafkaRoute),
 	}
 }
 
+// RegisterHandler registers handler for key, conflicting registrations are
+// handled according to the ConflictPolicy. It panics when the registration
+// fails, see TryRegisterHandler.
 func (c *Controller[D]) RegisterHandler(key string, handler Handler) {
-	c.handlers[key] = handler
+	if err := c.TryRegisterHandler(key, handler); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterHandler registers handler for key, returning the
+// DuplicateRouteError of the ConflictError policy.
+func (c *Controller[D]) TryRegisterHandler(key string, handler Handler) error {
+	return c.registerRoute(Route{Key: key, Kind: RouteKindHandler}, handler)
+}
+
+// RegisterMiddleware appends middlewares to the chain wrapping every
+// handler, the first one is the outermost.
+func (c *Controller[D]) RegisterMiddleware(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// RegisterUnaryInterceptor appends interceptors to the chain wrapping every
+// unary method of the registered gRPC services, the first one is the
+// outermost.
+func (c *Controller[D]) RegisterUnaryInterceptor(interceptors ...grpc.UnaryServerInterceptor) {
+	c.unaryInterceptors = append(c.unaryInterceptors, interceptors...)
+}
+
+// RegisterStreamInterceptor appends interceptors to the chain wrapping every
+// streaming method of the registered gRPC services, served over HTTP or
+// WebSocket, the first one is the outermost.
+func (c *Controller[D]) RegisterStreamInterceptor(interceptors ...grpc.StreamServerInterceptor) {
+	c.streamInterceptors = append(c.streamInterceptors, interceptors...)
+}
+
+// RegisterGRPCService registers the methods of svc, opts declare the
+// attributes of the methods (see ServiceOption). It panics when the
+// registration fails, see TryRegisterGRPCService.
+func (c *Controller[D]) RegisterGRPCService(desc grpc.ServiceDesc, svc interface{}, opts ...ServiceOption) {
+	if err := c.TryRegisterGRPCService(desc, svc, opts...); err != nil {
+		panic(err)
+	}
 }
 
-func (c *Controller[D]) RegisterGRPCService(desc grpc.ServiceDesc, svc interface{}) {
+// TryRegisterGRPCService registers the methods of svc, returning the errors
+// of invalid services or options and the DuplicateRouteError of the
+// ConflictError policy.
+func (c *Controller[D]) TryRegisterGRPCService(desc grpc.ServiceDesc, svc interface{}, opts ...ServiceOption) error {
+	return c.registerGRPCService("", desc, svc, opts)
+}
 
-	reflectSvc := reflect.ValueOf(svc)
+// registerGRPCService registers the methods of svc, their keys are prefixed
+// by the version (if any).
+func (c *Controller[D]) registerGRPCService(version string, desc grpc.ServiceDesc, svc interface{}, opts []ServiceOption) error {
 
+	names := make([]string, 0, len(desc.Methods)+len(desc.Streams))
 	for _, method := range desc.Methods {
+		names = append(names, method.MethodName)
+	}
+	for _, stream := range desc.Streams {
+		names = append(names, stream.StreamName)
+	}
 
-		key := strings.Join([]string{"/", desc.ServiceName, "/", method.MethodName}, "")
+	attrs, err := resolveAttributes(names, opts)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid options of %s: %v", desc.ServiceName, err)
+	}
 
-		methodCaller := reflectSvc.MethodByName(method.MethodName)
-		methodType := methodCaller.Type()
+	methods, err := newGrpcMethods(desc, svc)
+	if err != nil {
+		return err
+	}
 
-		methodInput := reflect.New(methodType.In(1).Elem()).Interface().(proto.Message)
+	for _, method := range methods {
 
-		c.RegisterHandler(key, func(ctx context.Context, req *Request, res *Response) error {
+		method.attrs = attrs[method.route.Method]
 
-			inMeta := metadata.Join(metadata.New(req.Headers), req.MultiValueHeaders)
+		if method.route.SuccessStatus, err = methodSuccessStatus(method.route.Key); err != nil {
+			return err
+		}
 
-			callCtx := metadata.NewOutgoingContext(metadata.NewIncomingContext(ctx, inMeta), metadata.New(map[string]string{}))
-			callInput := proto.Clone(methodInput)
+		method.route = versionedRoute(version, method.route)
 
-			if err := req.UnmarshalProtobuf(callInput); err != nil {
-				res.StatusCode = http.StatusBadRequest
-				res.Body = fmt.Sprintf("Failed to unmarshal request: %v", err)
-				return err
-			}
+		if err := c.registerRoute(method.route, c.unaryHandler(method)); err != nil {
+			return err
+		}
 
-			result := methodCaller.Call([]reflect.Value{
-				reflect.ValueOf(callCtx),
-				reflect.ValueOf(callInput),
-			})
+		if len(version) == 0 {
+			c.localMethods[method.info.FullMethod] = method
+		}
 
-			if outMeta, ok := metadata.FromOutgoingContext(ctx); ok {
-				res.MultiValueHeaders = outMeta
-			}
+	}
+
+	for _, stream := range newGrpcStreams(desc, svc) {
+
+		stream.attrs = attrs[stream.route.Method]
+		stream.route = versionedRoute(version, stream.route)
 
-			if len(result) != 2 {
-				res.StatusCode = http.StatusInternalServerError
-				res.Body = fmt.Sprintf("Invalid method output: %+v", result)
-				return nil
+		c.streams[stream.route.Key] = stream
+
+		if !stream.isServerStream() {
+			continue
+		}
+
+		if err := c.registerRoute(stream.route, c.streamHandler(stream)); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
+
+func (c *Controller[D]) unaryHandler(method *grpcMethod) Handler {
+
+	return func(ctx context.Context, req *Request, res *Response) error {
+
+		ctx, cancel := withIncomingTimeout(ctx, req)
+		defer cancel()
+
+		ctx, cancelAttrs := withMethodAttributes(ctx, method.attrs)
+		defer cancelAttrs()
+
+		if c.JSONDecoder != nil {
+			req.decodeJSON = func(data []byte, m proto.Message) error {
+				return c.JSONDecoder.DecodeJSON(method.info.FullMethod, data, m)
 			}
+		}
+
+		inMeta := incomingMetadata(req, c.ExcludedHeaders)
 
-			err := result[1].Interface()
-			if err != nil {
+		transport := transportStreamFromContext(ctx, method.info.FullMethod)
+
+		callCtx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(ctx, inMeta), transport)
+
+		callInput, out, release, err := method.invoke(callCtx, req, chainUnaryInterceptors(c.unaryInterceptors), c.ReuseMessages)
+		defer release()
+
+		// Like on gRPC servers, the headers and trailers are sent with the
+		// errors too.
+		transport.writeTo(res)
+
+		if err != nil {
+			return convertResultError(res, err)
+		}
+
+		if out == nil {
+			res.Body = ""
+			return nil
+		}
+
+		msg := out.(proto.Message)
+
+		// The handler may return a shared message (e.g. cached), the mask
+		// prunes a copy.
+		if paths := readFieldMask(req, callInput); len(paths) > 0 {
+
+			msg = proto.Clone(msg)
+
+			if err := applyFieldMask(msg, paths); err != nil {
 				return convertResultError(res, err)
 			}
 
-			out := result[0].Interface()
+		}
 
-			if out == nil {
-				res.Body = ""
-				return nil
-			} else if err := res.MarshalProtobuf(out.(proto.Message)); err != nil {
-				res.StatusCode = http.StatusInternalServerError
-				res.Body = fmt.Sprintf("Failed to marshal response: %v", err)
-				return err
-			}
+		// JSON callers get JSON back.
+		if err := res.marshalCodec(req.Codec(), msg); err != nil {
+			res.StatusCode = http.StatusInternalServerError
+			res.Body = fmt.Sprintf("Failed to marshal response: %v", err)
+			return err
+		}
 
-			return nil
+		setCacheControl(fullMethodName(method.route.Service, method.route.Method), method.attrs, res)
 
-		})
-	}
+		if err := compressResponse(c.Compression, req, res); err != nil {
+			LoggerFromContext(ctx).Warn("Failed to compress response", "error", err)
+		}
 
-	for _, stream := range desc.Streams {
+		return nil
 
-		key := strings.Join([]string{"/", desc.ServiceName, "/", stream.StreamName}, "")
+	}
 
-		if stream.ServerStreams && !stream.ClientStreams {
+}
 
-			c.RegisterHandler(key, func(ctx context.Context, req *Request, res *Response) error {
+func (c *Controller[D]) streamHandler(stream *grpcStream) Handler {
 
-				inMeta := metadata.Join(metadata.New(req.Headers), req.MultiValueHeaders)
+	return func(ctx context.Context, req *Request, res *Response) error {
 
-				callCtx := metadata.NewOutgoingContext(metadata.NewIncomingContext(ctx, inMeta), metadata.New(map[string]string{}))
+		ctx, cancel := withIncomingTimeout(ctx, req)
+		defer cancel()
 
-				serverStream := newGrpcServerStream(callCtx, req, res)
+		ctx, cancelAttrs := withMethodAttributes(ctx, stream.attrs)
+		defer cancelAttrs()
 
-				err := stream.Handler(svc, serverStream)
+		inMeta := incomingMetadata(req, c.ExcludedHeaders)
 
-				res.MultiValueHeaders, _ = metadata.FromOutgoingContext(serverStream.ctx)
+		transport := transportStreamFromContext(ctx, fullMethodName(stream.route.Service, stream.route.Method))
 
-				if err != nil {
-					return convertResultError(res, err)
-				}
+		callCtx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(ctx, inMeta), transport)
 
-				res.APIGatewayProxyResponse.StatusCode = http.StatusProcessing
+		serverStream := newGrpcServerStream(callCtx, req, res, transport)
 
-				return nil
+		var err error
+
+		if out, ok := ctx.Value(streamOutputContextKey{}).(*streamOutput); ok {
+			err = stream.invoke(&encodedServerStream{grpcServerStream: serverStream, out: out}, chainStreamInterceptors(c.streamInterceptors))
+		} else {
+			err = stream.invoke(serverStream, chainStreamInterceptors(c.streamInterceptors))
+		}
 
-			})
+		transport.writeTo(res)
 
+		if err != nil {
+			return convertResultError(res, err)
 		}
 
+		res.APIGatewayProxyResponse.StatusCode = http.StatusProcessing
+
+		return nil
+
 	}
 
 }
 
 func (c *Controller[D]) HandleLambda(ctx context.Context, proxyReq *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 
-	log := c.Log()
+	requestId := ensureRequestId(proxyReq, c.idGenerator())
+
+	ctx = ContextWithRequestId(ctx, requestId)
+	ctx = clock.ContextWithIdGenerator(clock.ContextWithClock(ctx, c.clock()), c.idGenerator())
+	ctx = ContextWithLogger(ctx, invocationLogger(ctx, c.Log(), proxyReq))
+
+	if c.Pool != nil {
+		ctx = ContextWithPool(ctx, c.Pool)
+	}
+
+	res, err := c.handle(ctx, proxyReq)
+
+	if res.Headers == nil {
+		res.Headers = make(map[string]string)
+	}
+
+	res.Headers[RequestIdHeader] = requestId
+
+	invocationErr := c.ErrorPolicy.invocationError(res, err)
+
+	if c.asyncDestinations != nil && c.asyncDestinations.isAsync(proxyReq) {
+		c.sendAsyncResult(ctx, proxyReq, res, err, invocationErr != nil)
+	}
+
+	return res.APIGatewayProxyResponse, invocationErr
+}
+
+func (c *Controller[D]) clock() clock.Clock {
+
+	if c.Clock != nil {
+		return c.Clock
+	}
+
+	if c.Injector != nil {
+		if provider, ok := any(c.Dependency()).(clock.Provider); ok {
+			if clk := provider.Clock(); clk != nil {
+				return clk
+			}
+		}
+	}
+
+	return clock.System{}
+
+}
+
+func (c *Controller[D]) idGenerator() clock.IdGenerator {
+
+	if c.Ids != nil {
+		return c.Ids
+	}
+
+	if c.Injector != nil {
+		if provider, ok := any(c.Dependency()).(clock.IdGeneratorProvider); ok {
+			if ids := provider.IdGenerator(); ids != nil {
+				return ids
+			}
+		}
+	}
+
+	return clock.RandomIds{}
+
+}
+
+// handle dispatches the request to the matched handler, the returned error
+// is the failure of the invocation (if any) to be handled by the policies.
+func (c *Controller[D]) handle(ctx context.Context, proxyReq *events.APIGatewayProxyRequest) (*Response, error) {
+
+	log := LoggerFromContext(ctx)
 
 	res := &Response{
 		APIGatewayProxyResponse: &events.APIGatewayProxyResponse{
@@ -193,36 +524,103 @@ func (c *Controller[D]) HandleLambda(ctx context.Context, proxyReq *events.APIGa
 
 		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
 			log.Error("Matcher returned not found", "error", err)
-			return res.APIGatewayProxyResponse, nil
+			return res, nil
 		}
 
 		log.Error("Failed to match request", "error", err)
 		res.StatusCode = http.StatusInternalServerError
-		return res.APIGatewayProxyResponse, nil
+		return res, err
 
 	}
 
-	handler, ok := c.handlers[key]
+	key, version, handler, ok := c.resolveHandler(proxyReq, key)
 	if !ok {
 		log.Error("No handler registered for key", "key", key, "handlers", fmt.Sprintf("%+v", c.handlers))
-		return res.APIGatewayProxyResponse, nil
+		return res, nil
 	}
 
+	ctx = ContextWithLogger(ctx, log.With("handler_key", key))
+
 	req := &Request{
 		APIGatewayProxyRequest: proxyReq,
 		HandlerKey:             key,
+		DefaultCodec:           c.DefaultCodecs[key],
 	}
 
 	res.APIGatewayProxyResponse.StatusCode = http.StatusOK
 
-	if err := handler(ctx, req, res); err != nil {
+	err = c.serve(ctx, req, res, handler, version)
+
+	return res, err
+}
+
+// resolveHandler returns the handler of key, resolved to the requested
+// version when versioned, false when there is none.
+func (c *Controller[D]) resolveHandler(proxyReq *events.APIGatewayProxyRequest, key string) (string, *APIVersion, Handler, bool) {
+
+	var version *APIVersion
+	if c.Versioning != nil {
+		key, version = c.Versioning.resolve(proxyReq, key, c.handlers)
+	}
+
+	handler, ok := c.handlers[key]
+
+	return key, version, handler, ok
+
+}
+
+// serve runs the handler of a request through the middlewares, with the
+// setup of its route (peer, gRPC transport stream, version) and the
+// statuses of its outcome. Batch calls are served the same way.
+func (c *Controller[D]) serve(ctx context.Context, req *Request, res *Response, handler Handler, version *APIVersion) error {
+
+	log := LoggerFromContext(ctx)
+	key := req.HandlerKey
+
+	req.compression = c.Compression
+
+	// Set for the middlewares and the gRPC handlers alike (e.g. rate limits
+	// by peer.FromContext).
+	ctx = peer.NewContext(ctx, requestPeer(req.RequestContext.Identity.SourceIP, strings.EqualFold(req.Header("X-Forwarded-Proto"), "https")))
+
+	// The middlewares of gRPC routes see the method in grpc.Method, their
+	// grpc.SetHeader calls reach the response too.
+	if route, ok := c.routes[key]; ok && route.Kind != RouteKindHandler && route.Kind != RouteKindAlias {
+		ctx = grpc.NewContextWithServerTransportStream(ctx, newServerTransportStream(fullMethodName(route.Service, route.Method)))
+	}
+
+	if version != nil {
+		log = log.With("api_version", version.Name)
+		ctx = ContextWithAPIVersion(ContextWithLogger(ctx, log), version.Name)
+	}
+
+	// The typed handlers decode and intercept as the gRPC methods, see Unary.
+	ctx = context.WithValue(ctx, typedCallContextKey{}, &typedCall{
+		interceptor: chainUnaryInterceptors(c.unaryInterceptors),
+		jsonDecoder: c.JSONDecoder,
+	})
+
+	err := chainMiddlewares(c.middlewares, handler)(ctx, req, res)
+
+	if version != nil {
+		version.setHeaders(res)
+	}
+
+	if err == nil {
+		c.setSuccessStatus(key, res)
+	}
+
+	c.setDefaultRetryAfter(res)
+
+	if err != nil {
 		log.Error("Failed to handle request", "error", err)
 		if res.StatusCode < 400 {
 			res.StatusCode = http.StatusInternalServerError
 		}
 	}
 
-	return res.APIGatewayProxyResponse, nil
+	return err
+
 }
 
 func MakeUrlPathMatcher(basePath string) Matcher[string] {
@@ -237,7 +635,8 @@ func MakeUrlPathMatcher(basePath string) Matcher[string] {
 			return urlPath, nil
 		}
 
-		if !strings.HasPrefix(urlPath, basePath) {
+		// The base path must match whole segments, /api doesn't match /apis.
+		if !strings.HasPrefix(urlPath, basePath) || (len(urlPath) > len(basePath) && urlPath[len(basePath)] != '/') {
 			return "", grpc.Errorf(codes.NotFound, "Not found (couldn't match prefix %s for url path %s)", basePath, urlPath)
 		}
 
@@ -0,0 +1,104 @@
+package lambda
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Cookies returns the cookies of the request, the Cookie headers of both
+// header maps are parsed.
+func (r *Request) Cookies() []*http.Cookie {
+	return r.httpRequest().Cookies()
+}
+
+// Cookie returns the named cookie of the request, http.ErrNoCookie when
+// absent.
+func (r *Request) Cookie(name string) (*http.Cookie, error) {
+	return r.httpRequest().Cookie(name)
+}
+
+func (r *Request) httpRequest() *http.Request {
+
+	header := http.Header{}
+
+	for k, values := range r.MultiValueHeaders {
+		if http.CanonicalHeaderKey(k) == "Cookie" {
+			header["Cookie"] = append(header["Cookie"], values...)
+		}
+	}
+
+	if len(header["Cookie"]) == 0 {
+		if cookie := r.Header("Cookie"); len(cookie) > 0 {
+			header.Set("Cookie", cookie)
+		}
+	}
+
+	return &http.Request{Header: header}
+
+}
+
+// SetCookie adds a Set-Cookie header to the response. A cookie previously
+// set with the same name, path and domain is replaced, the others are kept.
+// Invalid cookies (e.g. with a name containing separators) are dropped.
+func (r *Response) SetCookie(cookie *http.Cookie) {
+
+	value := cookie.String()
+	if len(value) == 0 {
+		return
+	}
+
+	cookies := []string{}
+
+	for k, values := range r.MultiValueHeaders {
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			cookies = append(cookies, values...)
+			delete(r.MultiValueHeaders, k)
+		}
+	}
+
+	for k, v := range r.Headers {
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			cookies = append(cookies, v)
+			delete(r.Headers, k)
+		}
+	}
+
+	kept := make([]string, 0, len(cookies)+1)
+
+	for _, previous := range cookies {
+		if !sameCookie(previous, cookie) {
+			kept = append(kept, previous)
+		}
+	}
+
+	if r.MultiValueHeaders == nil {
+		r.MultiValueHeaders = make(map[string][]string)
+	}
+
+	r.MultiValueHeaders["Set-Cookie"] = append(kept, value)
+
+}
+
+// DeleteCookie expires a cookie of the client, path and domain must be the
+// ones it was set with.
+func (r *Response) DeleteCookie(name, path, domain string) {
+	r.SetCookie(&http.Cookie{
+		Name:   name,
+		Path:   path,
+		Domain: domain,
+		MaxAge: -1,
+	})
+}
+
+func sameCookie(setCookie string, cookie *http.Cookie) bool {
+
+	parsed := (&http.Response{Header: http.Header{"Set-Cookie": {setCookie}}}).Cookies()
+	if len(parsed) == 0 {
+		return false
+	}
+
+	return parsed[0].Name == cookie.Name &&
+		parsed[0].Path == cookie.Path &&
+		strings.EqualFold(strings.TrimPrefix(parsed[0].Domain, "."), strings.TrimPrefix(cookie.Domain, "."))
+
+}
@@ -0,0 +1,247 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	DescriptorsHandlerKey = "/_protomesh/descriptors"
+	ReflectionHandlerKey  = "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+)
+
+// RegisterDescriptorHandler serves the FileDescriptorSet of the registered
+// gRPC services and their dependencies at DescriptorsHandlerKey, encoded
+// in protobuf or in JSON when the request accepts application/json.
+func (c *Controller[D]) RegisterDescriptorHandler() error {
+
+	return c.TryRegisterHandler(DescriptorsHandlerKey, func(ctx context.Context, req *Request, res *Response) error {
+
+		files, err := c.serviceFiles()
+		if err != nil {
+			return convertResultError(res, err)
+		}
+
+		set := &descriptorpb.FileDescriptorSet{}
+		for _, file := range files {
+			set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+		}
+
+		if strings.Contains(req.Header("Accept"), "application/json") {
+
+			body, err := protojson.Marshal(set)
+			if err != nil {
+				return err
+			}
+
+			res.Headers = map[string]string{"Content-Type": "application/json"}
+			res.Body = string(body)
+			res.IsBase64Encoded = false
+
+			return nil
+
+		}
+
+		res.Headers = map[string]string{"Content-Type": "application/x-protobuf"}
+
+		return res.MarshalProtobuf(set)
+
+	})
+
+}
+
+// RegisterReflectionHandler answers gRPC server reflection requests at
+// ReflectionHandlerKey. Invocations can't hold the bidirectional stream of
+// the reflection service, so each invocation answers the single
+// ServerReflectionRequest of its body.
+func (c *Controller[D]) RegisterReflectionHandler() error {
+
+	return c.TryRegisterHandler(ReflectionHandlerKey, func(ctx context.Context, req *Request, res *Response) error {
+
+		reflectionReq := &reflectionpb.ServerReflectionRequest{}
+		if err := req.UnmarshalProtobuf(reflectionReq); err != nil {
+			res.StatusCode = http.StatusBadRequest
+			return err
+		}
+
+		return res.MarshalProtobuf(c.reflect(reflectionReq))
+
+	})
+
+}
+
+func (c *Controller[D]) reflect(req *reflectionpb.ServerReflectionRequest) *reflectionpb.ServerReflectionResponse {
+
+	res := &reflectionpb.ServerReflectionResponse{
+		ValidHost:       req.Host,
+		OriginalRequest: req,
+	}
+
+	var file protoreflect.FileDescriptor
+	var err error
+
+	switch msgReq := req.MessageRequest.(type) {
+
+	case *reflectionpb.ServerReflectionRequest_ListServices:
+
+		services := &reflectionpb.ListServiceResponse{}
+		for _, name := range c.serviceNames() {
+			services.Service = append(services.Service, &reflectionpb.ServiceResponse{Name: name})
+		}
+
+		res.MessageResponse = &reflectionpb.ServerReflectionResponse_ListServicesResponse{ListServicesResponse: services}
+
+		return res
+
+	case *reflectionpb.ServerReflectionRequest_FileByFilename:
+		file, err = protoregistry.GlobalFiles.FindFileByPath(msgReq.FileByFilename)
+
+	case *reflectionpb.ServerReflectionRequest_FileContainingSymbol:
+
+		var desc protoreflect.Descriptor
+		if desc, err = protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(msgReq.FileContainingSymbol)); err == nil {
+			file = desc.ParentFile()
+		}
+
+	case *reflectionpb.ServerReflectionRequest_FileContainingExtension:
+
+		var ext protoreflect.ExtensionType
+		ext, err = protoregistry.GlobalTypes.FindExtensionByNumber(protoreflect.FullName(msgReq.FileContainingExtension.ContainingType), protoreflect.FieldNumber(msgReq.FileContainingExtension.ExtensionNumber))
+		if err == nil {
+			file = ext.TypeDescriptor().ParentFile()
+		}
+
+	case *reflectionpb.ServerReflectionRequest_AllExtensionNumbersOfType:
+
+		numbers := &reflectionpb.ExtensionNumberResponse{BaseTypeName: msgReq.AllExtensionNumbersOfType}
+
+		protoregistry.GlobalTypes.RangeExtensionsByMessage(protoreflect.FullName(msgReq.AllExtensionNumbersOfType), func(ext protoreflect.ExtensionType) bool {
+			numbers.ExtensionNumber = append(numbers.ExtensionNumber, int32(ext.TypeDescriptor().Number()))
+			return true
+		})
+
+		res.MessageResponse = &reflectionpb.ServerReflectionResponse_AllExtensionNumbersResponse{AllExtensionNumbersResponse: numbers}
+
+		return res
+
+	default:
+		res.MessageResponse = reflectionError(codes.InvalidArgument, "Unknown reflection request")
+		return res
+
+	}
+
+	if err != nil {
+		res.MessageResponse = reflectionError(codes.NotFound, err.Error())
+		return res
+	}
+
+	fileRes := &reflectionpb.FileDescriptorResponse{}
+
+	for _, dep := range withDependencies([]protoreflect.FileDescriptor{file}) {
+
+		body, err := proto.Marshal(protodesc.ToFileDescriptorProto(dep))
+		if err != nil {
+			res.MessageResponse = reflectionError(codes.Internal, err.Error())
+			return res
+		}
+
+		fileRes.FileDescriptorProto = append(fileRes.FileDescriptorProto, body)
+
+	}
+
+	res.MessageResponse = &reflectionpb.ServerReflectionResponse_FileDescriptorResponse{FileDescriptorResponse: fileRes}
+
+	return res
+
+}
+
+func reflectionError(code codes.Code, message string) *reflectionpb.ServerReflectionResponse_ErrorResponse {
+	return &reflectionpb.ServerReflectionResponse_ErrorResponse{
+		ErrorResponse: &reflectionpb.ErrorResponse{
+			ErrorCode:    int32(code),
+			ErrorMessage: message,
+		},
+	}
+}
+
+// serviceNames returns the full names of the registered gRPC services.
+func (c *Controller[D]) serviceNames() []string {
+
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, route := range c.routes {
+		if len(route.Service) > 0 && !seen[route.Service] {
+			seen[route.Service] = true
+			names = append(names, route.Service)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+
+}
+
+// serviceFiles returns the files of the registered services and their
+// dependencies, every file after its dependencies.
+func (c *Controller[D]) serviceFiles() ([]protoreflect.FileDescriptor, error) {
+
+	files := []protoreflect.FileDescriptor{}
+
+	for _, name := range c.serviceNames() {
+
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, desc.ParentFile())
+
+	}
+
+	return withDependencies(files), nil
+
+}
+
+func withDependencies(files []protoreflect.FileDescriptor) []protoreflect.FileDescriptor {
+
+	seen := map[string]bool{}
+	ordered := []protoreflect.FileDescriptor{}
+
+	var add func(file protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+
+		if seen[file.Path()] {
+			return
+		}
+
+		seen[file.Path()] = true
+
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		ordered = append(ordered, file)
+
+	}
+
+	for _, file := range files {
+		add(file)
+	}
+
+	return ordered
+
+}
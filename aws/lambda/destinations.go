@@ -0,0 +1,183 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+)
+
+// SQSDestination sends destination records as SQS messages.
+type SQSDestination struct {
+	Client   *awsapi.Client
+	QueueUrl string
+}
+
+func (s *SQSDestination) Send(ctx context.Context, record *DestinationRecord) error {
+
+	body, err := marshalDestinationRecord(record)
+	if err != nil {
+		return err
+	}
+
+	in := map[string]interface{}{
+		"QueueUrl":    s.QueueUrl,
+		"MessageBody": body,
+	}
+
+	return s.Client.CallJSON(ctx, "sqs", "1.0", "AmazonSQS.SendMessage", in, nil)
+
+}
+
+// SNSDestination publishes destination records to a SNS topic.
+type SNSDestination struct {
+	Client   *awsapi.Client
+	TopicArn string
+}
+
+func (s *SNSDestination) Send(ctx context.Context, record *DestinationRecord) error {
+
+	message, err := marshalDestinationRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// SNS only speaks the query protocol.
+	form := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {s.TopicArn},
+		"Message":  {message},
+	}
+
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, s.Client.Endpoint("sns")+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doSignedRequest(ctx, s.Client, "sns", req, body)
+
+}
+
+// EventBridgeDestination puts destination records on an event bus, the
+// detail type defaults to the Lambda destinations one.
+type EventBridgeDestination struct {
+	Client       *awsapi.Client
+	EventBusName string
+	Source       string
+	DetailType   string
+}
+
+func (e *EventBridgeDestination) Send(ctx context.Context, record *DestinationRecord) error {
+
+	detail, err := marshalDestinationRecord(record)
+	if err != nil {
+		return err
+	}
+
+	detailType := e.DetailType
+	if len(detailType) == 0 {
+		detailType = "Lambda Function Invocation Result - Failure"
+		if record.RequestContext.Condition == DestinationConditionSuccess {
+			detailType = "Lambda Function Invocation Result - Success"
+		}
+	}
+
+	source := e.Source
+	if len(source) == 0 {
+		source = "lambda"
+	}
+
+	in := map[string]interface{}{
+		"Entries": []map[string]string{
+			{
+				"EventBusName": e.EventBusName,
+				"Source":       source,
+				"DetailType":   detailType,
+				"Detail":       detail,
+			},
+		},
+	}
+
+	out := struct {
+		FailedEntryCount int
+		Entries          []struct {
+			ErrorCode    string
+			ErrorMessage string
+		}
+	}{}
+
+	if err := e.Client.CallJSON(ctx, "events", "1.1", "AWSEvents.PutEvents", in, &out); err != nil {
+		return err
+	}
+
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return &awsapi.Error{
+			StatusCode: http.StatusOK,
+			Code:       out.Entries[0].ErrorCode,
+			Message:    out.Entries[0].ErrorMessage,
+		}
+	}
+
+	return nil
+
+}
+
+// LambdaDestination invokes a function asynchronously with the record.
+type LambdaDestination struct {
+	Client       *awsapi.Client
+	FunctionName string
+}
+
+func (l *LambdaDestination) Send(ctx context.Context, record *DestinationRecord) error {
+
+	body, err := marshalDestinationRecord(record)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/2015-03-31/functions/%s/invocations", l.Client.Endpoint("lambda"), url.PathEscape(l.FunctionName))
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(InvocationTypeHeader, "Event")
+
+	return doSignedRequest(ctx, l.Client, "lambda", req, []byte(body))
+
+}
+
+func doSignedRequest(ctx context.Context, client *awsapi.Client, service string, req *http.Request, body []byte) error {
+
+	res, err := client.Do(ctx, service, req, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+
+		resBody, _ := io.ReadAll(res.Body)
+
+		return &awsapi.Error{
+			StatusCode: res.StatusCode,
+			Code:       http.StatusText(res.StatusCode),
+			Message:    strings.TrimSpace(string(resBody)),
+		}
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,160 @@
+package lambda
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Drain answers the new requests of its routes with 503 and Retry-After
+// while draining, the requests in flight finish. Deploy cutovers drain the
+// old version before shifting the traffic to the new one.
+//
+//	drain := &lambda.Drain{Enabled: func() bool { return deps.Draining.BoolVal() }}
+//	controller.RegisterMiddleware(drain.Middleware())
+type Drain struct {
+	// Drains while it returns true (e.g. a flag of the configuration), on
+	// top of Start and Stop.
+	Enabled func() bool
+	// Handler keys drained, keys ending with "*" match by prefix (e.g.
+	// "/acme.orders.v1.Orders/*"). Every route when empty.
+	Routes []string
+	// Defaults to 5 seconds.
+	RetryAfter time.Duration
+
+	lock     sync.Mutex
+	started  bool
+	inFlight int
+}
+
+// Start drains the routes until Stop.
+func (d *Drain) Start() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.started = true
+}
+
+func (d *Drain) Stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.started = false
+}
+
+// Draining reports whether the new requests of the routes are rejected.
+func (d *Drain) Draining() bool {
+
+	d.lock.Lock()
+	started := d.started
+	d.lock.Unlock()
+
+	return started || (d.Enabled != nil && d.Enabled())
+
+}
+
+// InFlight returns the requests of the routes being handled.
+func (d *Drain) InFlight() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.inFlight
+}
+
+// Wait returns once no request of the routes is in flight, or with the
+// error of ctx.
+func (d *Drain) Wait(ctx context.Context) error {
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for d.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+
+}
+
+// Middleware rejects the new requests of the routes with Unavailable while
+// draining, and counts the ones in flight.
+func (d *Drain) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			if !d.drains(req.HandlerKey) {
+				return next(ctx, req, res)
+			}
+
+			if !d.enter() {
+
+				retryAfter := d.RetryAfter
+				if retryAfter <= 0 {
+					retryAfter = 5 * time.Second
+				}
+
+				LoggerFromContext(ctx).Info("Request rejected while draining")
+
+				return res.WriteError(WithRetryAfter(status.Error(codes.Unavailable, "Service draining, retry later"), retryAfter))
+
+			}
+
+			defer func() {
+				d.lock.Lock()
+				d.inFlight--
+				d.lock.Unlock()
+			}()
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
+
+// enter counts a request in flight unless draining, in the same critical
+// section so that Wait can't miss a request admitted after Start.
+func (d *Drain) enter() bool {
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.started || (d.Enabled != nil && d.Enabled()) {
+		return false
+	}
+
+	d.inFlight++
+
+	return true
+
+}
+
+func (d *Drain) drains(key string) bool {
+
+	if len(d.Routes) == 0 {
+		return true
+	}
+
+	for _, route := range d.Routes {
+
+		if prefix, ok := strings.CutSuffix(route, "*"); ok && strings.HasPrefix(key, prefix) {
+			return true
+		}
+
+		if route == key {
+			return true
+		}
+
+	}
+
+	return false
+
+}
@@ -0,0 +1,137 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestDrainMiddleware(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		drain      *Drain
+		start      bool
+		key        string
+		status     int
+		retryAfter string
+	}{
+		{
+			name:   "not draining",
+			drain:  &Drain{},
+			key:    "/orders/Get",
+			status: http.StatusOK,
+		},
+		{
+			name:       "started",
+			drain:      &Drain{},
+			start:      true,
+			key:        "/orders/Get",
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "5",
+		},
+		{
+			name:       "enabled with custom retry after",
+			drain:      &Drain{Enabled: func() bool { return true }, RetryAfter: 2 * time.Second},
+			key:        "/orders/Get",
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "2",
+		},
+		{
+			name:       "matching route prefix",
+			drain:      &Drain{Routes: []string{"/orders/*"}},
+			start:      true,
+			key:        "/orders/Get",
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "5",
+		},
+		{
+			name:   "other route",
+			drain:  &Drain{Routes: []string{"/orders/*"}},
+			start:  true,
+			key:    "/reports/Export",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			if test.start {
+				test.drain.Start()
+			}
+
+			req := &Request{APIGatewayProxyRequest: &events.APIGatewayProxyRequest{}, HandlerKey: test.key}
+			res := &Response{APIGatewayProxyResponse: &events.APIGatewayProxyResponse{StatusCode: http.StatusOK}}
+
+			test.drain.Middleware()(func(ctx context.Context, req *Request, res *Response) error {
+				return nil
+			})(context.Background(), req, res)
+
+			if res.StatusCode != test.status {
+				t.Fatalf("status = %d, want %d (%s)", res.StatusCode, test.status, res.Body)
+			}
+
+			if retryAfter := res.Header("Retry-After"); retryAfter != test.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, test.retryAfter)
+			}
+
+			if inFlight := test.drain.InFlight(); inFlight != 0 {
+				t.Errorf("InFlight() = %d, want 0", inFlight)
+			}
+
+		})
+	}
+
+}
+
+func TestDrainWait(t *testing.T) {
+
+	d := &Drain{}
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+
+		defer close(done)
+
+		req := &Request{APIGatewayProxyRequest: &events.APIGatewayProxyRequest{}, HandlerKey: "/orders/Get"}
+		res := &Response{APIGatewayProxyResponse: &events.APIGatewayProxyResponse{StatusCode: http.StatusOK}}
+
+		d.Middleware()(func(ctx context.Context, req *Request, res *Response) error {
+			close(entered)
+			<-release
+			return nil
+		})(context.Background(), req, res)
+
+	}()
+
+	<-entered
+
+	d.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := d.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait() with a request in flight = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+
+	if err := d.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+
+	<-done
+
+	if inFlight := d.InFlight(); inFlight != 0 {
+		t.Errorf("InFlight() = %d, want 0", inFlight)
+	}
+
+}
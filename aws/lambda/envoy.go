@@ -0,0 +1,170 @@
+package lambda
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+// EnvoyRequest is the event of the Envoy AWS Lambda filter with payload
+// passthrough disabled. Envoy joins the values of repeated headers with
+// commas.
+type EnvoyRequest struct {
+	// Path of the request, with its query string.
+	RawPath               string            `json:"raw_path"`
+	Method                string            `json:"method"`
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"query_string_parameters"`
+	Body                  string            `json:"body"`
+	IsBase64Encoded       bool              `json:"is_base64_encoded"`
+}
+
+// EnvoyResponse is the response the Envoy AWS Lambda filter turns back
+// into an HTTP response.
+type EnvoyResponse struct {
+	StatusCode      int               `json:"status_code"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Cookies         []string          `json:"cookies,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"is_base64_encoded"`
+}
+
+// EnvoyHandler serves the invocations of the Envoy AWS Lambda filter with
+// handler (e.g. a Controller or a Mux), so the services can sit behind an
+// Envoy with Lambda egress instead of API Gateway.
+//
+//	awslambda.Start(lambda.EnvoyHandler(controller))
+func EnvoyHandler(handler InvocationHandler) func(ctx context.Context, envoyReq *EnvoyRequest) (*EnvoyResponse, error) {
+
+	return func(ctx context.Context, envoyReq *EnvoyRequest) (*EnvoyResponse, error) {
+
+		res, err := handler.HandleLambda(ctx, proxyRequestFromEnvoy(ctx, envoyReq))
+		if res == nil {
+			return nil, err
+		}
+
+		return envoyResponse(res), err
+
+	}
+
+}
+
+func proxyRequestFromEnvoy(ctx context.Context, envoyReq *EnvoyRequest) *events.APIGatewayProxyRequest {
+
+	path, rawQuery, _ := strings.Cut(envoyReq.RawPath, "?")
+
+	query := envoyReq.QueryStringParameters
+	multiQuery := map[string][]string{}
+
+	if values, err := url.ParseQuery(rawQuery); err == nil && len(values) > 0 {
+
+		if len(query) == 0 {
+			query = make(map[string]string, len(values))
+			for k, v := range values {
+				query[k] = v[len(v)-1]
+			}
+		}
+
+		multiQuery = values
+
+	}
+
+	headers := make(map[string]string, len(envoyReq.Headers))
+	for k, v := range envoyReq.Headers {
+		headers[k] = v
+	}
+
+	proxyReq := &events.APIGatewayProxyRequest{
+		Path:                            path,
+		HTTPMethod:                      envoyReq.Method,
+		Headers:                         headers,
+		QueryStringParameters:           query,
+		MultiValueQueryStringParameters: multiQuery,
+		Body:                            envoyReq.Body,
+		IsBase64Encoded:                 envoyReq.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: envoyReq.Method,
+			Path:       path,
+		},
+	}
+
+	req := &Request{APIGatewayProxyRequest: proxyReq}
+
+	// Envoy appends the address of the caller to X-Forwarded-For.
+	if forwarded := req.Header("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(forwarded, ",")
+		proxyReq.RequestContext.Identity.SourceIP = strings.TrimSpace(hops[len(hops)-1])
+	}
+
+	proxyReq.RequestContext.Identity.UserAgent = req.Header("User-Agent")
+	proxyReq.RequestContext.DomainName = req.Header("Host")
+	// Envoy calls are synchronous, the request id of the invocation stands
+	// for the gateway one.
+	if lc, ok := lambdacontext.FromContext(ctx); ok && len(lc.AwsRequestID) > 0 {
+		proxyReq.RequestContext.RequestID = lc.AwsRequestID
+	} else {
+		requestId := make([]byte, 16)
+		clock.IdGeneratorFromContext(ctx).Read(requestId)
+		proxyReq.RequestContext.RequestID = hex.EncodeToString(requestId)
+	}
+
+	return proxyReq
+
+}
+
+// envoyResponse re-encodes a response for Envoy, the Set-Cookie headers
+// move to the cookies and the other repeated headers are joined.
+func envoyResponse(res *events.APIGatewayProxyResponse) *EnvoyResponse {
+
+	envoyRes := &EnvoyResponse{
+		StatusCode:      res.StatusCode,
+		Headers:         make(map[string]string, len(res.Headers)+len(res.MultiValueHeaders)),
+		Body:            res.Body,
+		IsBase64Encoded: res.IsBase64Encoded,
+	}
+
+	singleCookie := ""
+
+	for k, v := range res.Headers {
+
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			singleCookie = v
+			continue
+		}
+
+		envoyRes.Headers[k] = v
+
+	}
+
+	// The multi value headers win, like with API Gateway.
+	for k, values := range res.MultiValueHeaders {
+
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			envoyRes.Cookies = append(envoyRes.Cookies, values...)
+			singleCookie = ""
+			continue
+		}
+
+		for existing := range envoyRes.Headers {
+			if strings.EqualFold(existing, k) {
+				delete(envoyRes.Headers, existing)
+			}
+		}
+
+		envoyRes.Headers[k] = strings.Join(values, ", ")
+
+	}
+
+	if len(singleCookie) > 0 && len(envoyRes.Cookies) == 0 {
+		envoyRes.Cookies = []string{singleCookie}
+	}
+
+	return envoyRes
+
+}
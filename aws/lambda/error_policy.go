@@ -0,0 +1,96 @@
+package lambda
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorPolicy defines which failures are returned to the Lambda runtime,
+// returned errors count as invocation errors and so trigger retries, DLQs
+// and error metrics for asynchronous invocations.
+type ErrorPolicy int
+
+const (
+	// Log the error and answer with the error status only, the default
+	// since API Gateway replies 502 to invocation errors.
+	ErrorPolicySwallow ErrorPolicy = iota
+	// Return the error when the response status is 5xx.
+	ErrorPolicyServerErrors
+	// Return every handler error, including 4xx ones.
+	ErrorPolicyAlways
+)
+
+var errorPolicyNames = map[ErrorPolicy]string{
+	ErrorPolicySwallow:      "swallow",
+	ErrorPolicyServerErrors: "5xx",
+	ErrorPolicyAlways:       "always",
+}
+
+func (p ErrorPolicy) String() string {
+
+	if name, ok := errorPolicyNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ErrorPolicy(%d)", int(p))
+
+}
+
+// ParseErrorPolicy parses the policy name, so it can be set per deployment
+// from configuration (swallow, 5xx or always).
+func ParseErrorPolicy(name string) (ErrorPolicy, error) {
+
+	for policy, policyName := range errorPolicyNames {
+		if strings.EqualFold(policyName, name) {
+			return policy, nil
+		}
+	}
+
+	return ErrorPolicySwallow, fmt.Errorf("Invalid error policy: %s", name)
+
+}
+
+func (p *ErrorPolicy) UnmarshalText(text []byte) error {
+
+	policy, err := ParseErrorPolicy(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = policy
+
+	return nil
+
+}
+
+func (p ErrorPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// invocationError decides what is returned to the Lambda runtime for an
+// invocation that answered with res and failed with err (which may be nil).
+func (p ErrorPolicy) invocationError(res *Response, err error) error {
+
+	switch p {
+
+	case ErrorPolicyAlways:
+		if err == nil && res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("Handler responded with status %d", res.StatusCode)
+		}
+		return err
+
+	case ErrorPolicyServerErrors:
+		if res.StatusCode < http.StatusInternalServerError {
+			return nil
+		}
+		if err == nil {
+			return fmt.Errorf("Handler responded with status %d", res.StatusCode)
+		}
+		return err
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,121 @@
+package lambda
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	ETagHeader        = "ETag"
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+type ETagOptions struct {
+	// Handler keys answering with ETags. Defaults to the methods declared
+	// with the NO_SIDE_EFFECTS or IDEMPOTENT idempotency level and the
+	// handlers called with GET.
+	Methods func(key string) bool
+	// Cache-Control of the tagged responses, e.g. "private, max-age=60" to
+	// let caches serve them without revalidation for a while. Defaults to
+	// "no-cache", caches revalidate them on every request.
+	CacheControl string
+}
+
+// ETagMiddleware tags the successful responses with the hash of their body
+// and answers 304 Not Modified without body when the caller already holds
+// it (If-None-Match). The handler still runs, only the egress is saved.
+func ETagMiddleware(opts ETagOptions) Middleware {
+
+	if opts.Methods == nil {
+		opts.Methods = idempotentMethod
+	}
+
+	if len(opts.CacheControl) == 0 {
+		opts.CacheControl = "no-cache"
+	}
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			err := next(ctx, req, res)
+			if err != nil || res.StatusCode != http.StatusOK {
+				return err
+			}
+
+			if req.HTTPMethod != http.MethodGet && !opts.Methods(req.HandlerKey) {
+				return nil
+			}
+
+			sum := sha256.Sum256([]byte(res.Body))
+			etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+			if res.Headers == nil {
+				res.Headers = make(map[string]string)
+			}
+
+			res.Headers[ETagHeader] = etag
+			res.Headers["Cache-Control"] = opts.CacheControl
+
+			if matchETag(req.Header(IfNoneMatchHeader), etag) {
+				res.StatusCode = http.StatusNotModified
+				res.Body = ""
+				res.IsBase64Encoded = false
+			}
+
+			return nil
+
+		}
+
+	}
+
+}
+
+func idempotentMethod(key string) bool {
+
+	method, ok := MethodDescriptor(key)
+
+	// Versioned keys are prefixed by their version.
+	if _, unversioned, cut := strings.Cut(strings.TrimPrefix(key, "/"), "/"); !ok && cut {
+		method, ok = MethodDescriptor("/" + unversioned)
+	}
+
+	if !ok {
+		return false
+	}
+
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok {
+		return false
+	}
+
+	switch opts.GetIdempotencyLevel() {
+	case descriptorpb.MethodOptions_NO_SIDE_EFFECTS, descriptorpb.MethodOptions_IDEMPOTENT:
+		return true
+	}
+
+	return false
+
+}
+
+// matchETag compares weakly, as required for If-None-Match.
+func matchETag(header, etag string) bool {
+
+	for _, candidate := range strings.Split(header, ",") {
+
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+
+	}
+
+	return false
+
+}
@@ -0,0 +1,321 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/protomesh/go-app"
+)
+
+const extensionAPIVersion = "2020-01-01"
+
+// Flusher flushes a buffer (e.g. of telemetry, outbox or audit records).
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// FlushFunc adapts a function to a Flusher.
+type FlushFunc func(ctx context.Context) error
+
+func (f FlushFunc) Flush(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Extension is an internal Lambda extension running the flushers once an
+// invocation is answered: Lambda returns the response to the caller but
+// keeps the execution environment awake until the extension polls its next
+// event, so the flushing adds no latency to the invocations.
+//
+// Register it during the init phase, then signal the end of every
+// invocation with Wrap or Done. Registering an extension also makes Lambda
+// send SIGTERM before shutting down the environment, see
+// lifecycle.Manager.StopOnSignal for the last flush.
+//
+//	ext := &lambda.Extension{Flushers: []lambda.Flusher{outbox, auditSink}}
+//	if err := ext.Register(context.Background()); err != nil { ... }
+//	awslambda.Start(ext.Wrap(controller).HandleLambda)
+type Extension struct {
+	// Defaults to "protomesh-flush".
+	Name string
+	// Defaults to RuntimeAPIEnv.
+	API string
+	// Defaults to a client without timeout, polling the next event blocks
+	// until there is one.
+	HttpClient *http.Client
+	Flushers   []Flusher
+	// Bounds the flush of an invocation, on top of its deadline. Defaults
+	// to the deadline.
+	FlushTimeout time.Duration
+	// Defaults to discarding the entries.
+	Log app.Logger
+
+	lock sync.Mutex
+	id   string
+	done chan string
+}
+
+type extensionEvent struct {
+	EventType  string `json:"eventType"`
+	DeadlineMs int64  `json:"deadlineMs"`
+	RequestId  string `json:"requestId"`
+}
+
+// Register registers the extension for the invocations and starts polling
+// its events. It must be called before the runtime polls its first
+// invocation, i.e. before awslambda.Start.
+func (e *Extension) Register(ctx context.Context) error {
+
+	name := e.Name
+	if len(name) == 0 {
+		name = "protomesh-flush"
+	}
+
+	// Internal extensions can't register for SHUTDOWN.
+	body, err := json.Marshal(map[string][]string{"events": {"INVOKE"}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url("/extension/register"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Lambda-Extension-Name", name)
+
+	res, err := e.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to register extension %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		payload, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("Extensions API returned %d registering %s: %s", res.StatusCode, name, payload)
+	}
+
+	e.lock.Lock()
+	e.id = res.Header.Get("Lambda-Extension-Identifier")
+	e.done = make(chan string, 1)
+	e.lock.Unlock()
+
+	go e.run()
+
+	return nil
+
+}
+
+// Done signals that the invocation of ctx is answered, its flush starts.
+// It is a no-op until the extension is registered.
+func (e *Extension) Done(ctx context.Context) {
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.done == nil {
+		return
+	}
+
+	requestId := ""
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		requestId = lc.AwsRequestID
+	}
+
+	// Only the last invocation matters, a signal left over by an invocation
+	// the extension didn't see is replaced.
+	select {
+	case <-e.done:
+	default:
+	}
+
+	e.done <- requestId
+
+}
+
+// Wrap returns handler signaling Done once each invocation is handled, the
+// response being returned while the flushers run.
+func (e *Extension) Wrap(handler InvocationHandler) InvocationHandler {
+	return &extensionHandler{extension: e, handler: handler}
+}
+
+type extensionHandler struct {
+	extension *Extension
+	handler   InvocationHandler
+}
+
+func (h *extensionHandler) HandleLambda(ctx context.Context, proxyReq *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	defer h.extension.Done(ctx)
+	return h.handler.HandleLambda(ctx, proxyReq)
+}
+
+// run flushes after each invocation until the Extensions API fails, the
+// failure being reported so Lambda resets the execution environment
+// instead of waiting for the extension.
+func (e *Extension) run() {
+
+	for {
+
+		event, err := e.next()
+		if err != nil {
+			e.logger().Error("Extension stopped", "error", err)
+			e.exitError(err)
+			return
+		}
+
+		if event.EventType != "INVOKE" {
+			continue
+		}
+
+		deadline := time.UnixMilli(event.DeadlineMs)
+
+		e.waitDone(event.RequestId, deadline)
+		e.flush(deadline)
+
+	}
+
+}
+
+// waitDone waits for the end of the invocation, or its deadline when the
+// handler never signals it.
+func (e *Extension) waitDone(requestId string, deadline time.Time) {
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		select {
+		case id := <-e.done:
+			if len(id) == 0 || id == requestId {
+				return
+			}
+		case <-timer.C:
+			e.logger().Warn("Invocation end not signaled before its deadline", "aws_request_id", requestId)
+			return
+		}
+	}
+
+}
+
+// flush runs the flushers concurrently.
+func (e *Extension) flush(deadline time.Time) {
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	if e.FlushTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, e.FlushTimeout)
+		defer cancel()
+	}
+
+	wg := &sync.WaitGroup{}
+
+	for _, flusher := range e.Flushers {
+
+		wg.Add(1)
+
+		go func(flusher Flusher) {
+
+			defer wg.Done()
+
+			if err := flusher.Flush(ctx); err != nil {
+				e.logger().Error("Failed to flush", "flusher", fmt.Sprintf("%T", flusher), "error", err)
+			}
+
+		}(flusher)
+
+	}
+
+	wg.Wait()
+
+}
+
+func (e *Extension) next() (*extensionEvent, error) {
+
+	req, err := http.NewRequest(http.MethodGet, e.url("/extension/event/next"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Lambda-Extension-Identifier", e.id)
+
+	res, err := e.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to poll next event: %w", err)
+	}
+	defer res.Body.Close()
+
+	payload, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read next event: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Extensions API returned %d polling next event: %s", res.StatusCode, payload)
+	}
+
+	event := &extensionEvent{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("Invalid extension event: %w", err)
+	}
+
+	return event, nil
+
+}
+
+func (e *Extension) exitError(exitErr error) {
+
+	_, payload := runtimeError(exitErr)
+
+	req, err := http.NewRequest(http.MethodPost, e.url("/extension/exit/error"), bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Lambda-Extension-Identifier", e.id)
+	req.Header.Set("Lambda-Extension-Function-Error-Type", "Extension.PollFailed")
+
+	if res, err := e.client().Do(req); err == nil {
+		res.Body.Close()
+	}
+
+}
+
+func (e *Extension) url(path string) string {
+
+	api := e.API
+	if len(api) == 0 {
+		api = os.Getenv(RuntimeAPIEnv)
+	}
+
+	return "http://" + api + "/" + extensionAPIVersion + path
+
+}
+
+func (e *Extension) client() *http.Client {
+
+	if e.HttpClient != nil {
+		return e.HttpClient
+	}
+
+	return http.DefaultClient
+
+}
+
+func (e *Extension) logger() app.Logger {
+
+	if e.Log != nil {
+		return e.Log
+	}
+
+	return nopLogger{}
+
+}
@@ -0,0 +1,201 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const FieldMaskHeader = "X-Fields"
+
+var fieldMaskNames = []protoreflect.Name{"read_mask", "field_mask"}
+
+type fieldMaskTree map[string]fieldMaskTree
+
+// readFieldMask returns the paths requested by the read_mask/field_mask
+// field of the request message or, when absent, by the X-Fields header.
+func readFieldMask(req *Request, in proto.Message) []string {
+
+	msg := in.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+
+	for _, name := range fieldMaskNames {
+
+		field := fields.ByName(name)
+		if field == nil || field.Message() == nil || field.Message().FullName() != "google.protobuf.FieldMask" {
+			continue
+		}
+
+		if !msg.Has(field) {
+			continue
+		}
+
+		mask, ok := msg.Get(field).Message().Interface().(*fieldmaskpb.FieldMask)
+		if ok && len(mask.Paths) > 0 {
+			return mask.Paths
+		}
+
+	}
+
+	header := req.Header(FieldMaskHeader)
+	if len(header) == 0 {
+		return nil
+	}
+
+	paths := []string{}
+
+	for _, path := range strings.Split(header, ",") {
+		if path = strings.TrimSpace(path); len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+
+}
+
+// applyFieldMask clears every field of out not covered by paths, paths
+// may use proto or JSON field names.
+func applyFieldMask(out proto.Message, paths []string) error {
+
+	tree := fieldMaskTree{}
+
+	for _, path := range paths {
+
+		if path == "*" {
+			return nil
+		}
+
+		node := tree
+		for _, part := range strings.Split(path, ".") {
+			if _, ok := node[part]; !ok {
+				node[part] = fieldMaskTree{}
+			}
+			node = node[part]
+		}
+
+	}
+
+	return pruneMessage(out.ProtoReflect(), tree)
+
+}
+
+func pruneMessage(msg protoreflect.Message, tree fieldMaskTree) error {
+
+	if len(tree) == 0 {
+		return nil
+	}
+
+	fields := msg.Descriptor().Fields()
+	keep := make(map[protoreflect.FieldNumber]fieldMaskTree, len(tree))
+
+	for name, subTree := range tree {
+
+		field := fields.ByName(protoreflect.Name(name))
+		if field == nil {
+			field = fields.ByJSONName(name)
+		}
+
+		if field == nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid field mask path %s for %s", name, msg.Descriptor().FullName())
+		}
+
+		if len(subTree) > 0 && !hasMessageValue(field) {
+			return status.Errorf(codes.InvalidArgument, "Invalid field mask path, %s is not a message", field.FullName())
+		}
+
+		keep[field.Number()] = subTree
+
+	}
+
+	var err error
+
+	msg.Range(func(field protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+
+		subTree, ok := keep[field.Number()]
+		if !ok {
+			msg.Clear(field)
+			return true
+		}
+
+		if len(subTree) == 0 {
+			return true
+		}
+
+		switch {
+
+		case field.IsMap():
+			val.Map().Range(func(_ protoreflect.MapKey, mapVal protoreflect.Value) bool {
+				err = pruneMessage(mapVal.Message(), subTree)
+				return err == nil
+			})
+
+		case field.IsList():
+			list := val.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				err = pruneMessage(list.Get(i).Message(), subTree)
+			}
+
+		default:
+			err = pruneMessage(val.Message(), subTree)
+
+		}
+
+		return err == nil
+
+	})
+
+	return err
+
+}
+
+// hasMessageValue reports whether the values of field, or of its elements
+// and map entries, are messages a sub-path can descend into.
+func hasMessageValue(field protoreflect.FieldDescriptor) bool {
+
+	if field.IsMap() {
+		return field.MapValue().Message() != nil
+	}
+
+	return field.Message() != nil
+
+}
+
+// FieldValue returns the value of a scalar field at a dotted path.
+func FieldValue(msg protoreflect.Message, path string) (string, bool) {
+
+	parts := strings.Split(path, ".")
+
+	for i, part := range parts {
+
+		field := msg.Descriptor().Fields().ByName(protoreflect.Name(part))
+		if field == nil || field.IsList() || field.IsMap() {
+			return "", false
+		}
+
+		if i == len(parts)-1 {
+
+			if field.Message() != nil {
+				return "", false
+			}
+
+			return fmt.Sprint(msg.Get(field).Interface()), true
+
+		}
+
+		if field.Message() == nil || !msg.Has(field) {
+			return "", false
+		}
+
+		msg = msg.Get(field).Message()
+
+	}
+
+	return "", false
+
+}
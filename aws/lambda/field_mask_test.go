@@ -0,0 +1,87 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestApplyFieldMask(t *testing.T) {
+
+	newEnvelope := func() *envelope.Envelope {
+		return &envelope.Envelope{
+			Id:         "1",
+			Source:     "orders",
+			Tenant:     "acme",
+			Attributes: map[string]string{"k": "v"},
+			Trace:      &envelope.TraceContext{Traceparent: "00-01", Tracestate: "a=b"},
+			Payload:    &anypb.Any{TypeUrl: "type.googleapis.com/orders.v1.Order"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		code   codes.Code
+		want   *envelope.Envelope
+	}{
+		{
+			name:   "scalar map with sub-path",
+			header: "attributes.k",
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "scalar with sub-path",
+			header: "id.value",
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "unknown field",
+			header: "id,missing",
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "top level fields",
+			header: "id, attributes",
+			want:   &envelope.Envelope{Id: "1", Attributes: map[string]string{"k": "v"}},
+		},
+		{
+			name:   "nested message with json names",
+			header: "idempotencyKey,trace.traceparent",
+			want:   &envelope.Envelope{Trace: &envelope.TraceContext{Traceparent: "00-01"}},
+		},
+		{
+			name:   "wildcard",
+			header: "*",
+			want:   newEnvelope(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			req := &Request{APIGatewayProxyRequest: &events.APIGatewayProxyRequest{
+				Headers: map[string]string{FieldMaskHeader: test.header},
+			}}
+
+			out := newEnvelope()
+
+			err := applyFieldMask(out, readFieldMask(req, &envelope.Envelope{}))
+
+			if code := status.Code(err); code != test.code {
+				t.Fatalf("applyFieldMask() code = %s, want %s (%v)", code, test.code, err)
+			}
+
+			if test.want != nil && !proto.Equal(out, test.want) {
+				t.Errorf("applyFieldMask() = %v, want %v", out, test.want)
+			}
+
+		})
+	}
+
+}
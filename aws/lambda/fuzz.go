@@ -0,0 +1,160 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// The Fuzz functions are entrypoints for native Go fuzz targets, they
+// return an error when an invariant breaks on malformed input. The
+// package runs its own targets in fuzz_test.go, services fuzz their
+// messages the same way:
+//
+//	func FuzzDecode(f *testing.F) {
+//		f.Add("CgNmb28=", true, "")
+//		f.Fuzz(func(t *testing.T, body string, isBase64 bool, encoding string) {
+//			if err := lambda.FuzzDecode(body, isBase64, encoding, &userspb.GetRequest{}); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+
+// NewFuzzRequest builds a gateway event from fuzzer inputs, headers being
+// "name: value" lines.
+func NewFuzzRequest(method, path, headers, body string, isBase64 bool) *events.APIGatewayProxyRequest {
+
+	req := &events.APIGatewayProxyRequest{
+		HTTPMethod:        method,
+		Path:              path,
+		Headers:           map[string]string{},
+		MultiValueHeaders: map[string][]string{},
+		Body:              body,
+		IsBase64Encoded:   isBase64,
+	}
+
+	for _, line := range strings.Split(headers, "\n") {
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		req.Headers[name] = value
+		req.MultiValueHeaders[name] = append(req.MultiValueHeaders[name], value)
+
+	}
+
+	return req
+
+}
+
+// FuzzDecode decodes a body into m, which must fail with InvalidArgument,
+// Unimplemented (unknown encoding) or ResourceExhausted when malformed.
+func FuzzDecode(body string, isBase64 bool, encoding string, m proto.Message) error {
+
+	req := &Request{APIGatewayProxyRequest: NewFuzzRequest(http.MethodPost, "/", "", body, isBase64)}
+
+	if len(encoding) > 0 {
+		req.Headers[GrpcEncodingHeader] = encoding
+	}
+
+	err := req.UnmarshalProtobuf(m)
+	if err == nil {
+		return nil
+	}
+
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unimplemented, codes.ResourceExhausted:
+		return nil
+	}
+
+	return fmt.Errorf("Decoding failed with %v", err)
+
+}
+
+// FuzzMetadata converts headers to incoming metadata, whose keys must be
+// lower case and values preserved.
+func FuzzMetadata(headers string) error {
+
+	req := &Request{APIGatewayProxyRequest: NewFuzzRequest(http.MethodPost, "/", headers, "", false)}
+
+	md := incomingMetadata(req, nil)
+
+	for k, values := range req.MultiValueHeaders {
+
+		got := md.Get(k)
+		if len(got) < len(values) {
+			return fmt.Errorf("Header %q lost values: %v != %v", k, got, values)
+		}
+
+	}
+
+	for k := range md {
+		if k != strings.ToLower(k) {
+			return fmt.Errorf("Metadata key %q is not lower case", k)
+		}
+	}
+
+	return nil
+
+}
+
+// FuzzMatcher matches path under basePath, a match must be a suffix of the
+// path starting with a slash and a mismatch must be NotFound.
+func FuzzMatcher(basePath, path string) error {
+
+	key, err := MakeUrlPathMatcher(basePath)(context.Background(), &events.APIGatewayProxyRequest{Path: path})
+	if err != nil {
+		if status.Code(err) != codes.NotFound {
+			return fmt.Errorf("Matcher failed with %v", err)
+		}
+		return nil
+	}
+
+	// Gateways always send absolute paths.
+	if strings.HasPrefix(path, "/") && len(key) > 0 && !strings.HasPrefix(key, "/") {
+		return fmt.Errorf("Key %q of %q doesn't start with a slash", key, path)
+	}
+
+	if !strings.HasSuffix(strings.TrimRight(path, "/"), key) {
+		return fmt.Errorf("Key %q is not a suffix of %q", key, path)
+	}
+
+	return nil
+
+}
+
+// FuzzEvent decodes data as a gateway event and dispatches it to handler
+// (e.g. Controller.HandleLambda), which must answer without a server error
+// since malformed requests are client errors. The handlers must not fail on
+// their own for the registered methods.
+func FuzzEvent(handler func(context.Context, *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error), data []byte) error {
+
+	req := &events.APIGatewayProxyRequest{}
+
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil
+	}
+
+	res, _ := handler(context.Background(), req)
+	if res == nil {
+		return fmt.Errorf("Handler returned no response")
+	}
+
+	if res.StatusCode >= 500 && res.StatusCode != http.StatusNotImplemented {
+		return fmt.Errorf("Handler answered %d: %s", res.StatusCode, res.Body)
+	}
+
+	return nil
+
+}
@@ -0,0 +1,62 @@
+package lambda_test
+
+import (
+	"testing"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func FuzzDecode(f *testing.F) {
+
+	f.Add("CgNmb28=", true, "")
+	f.Add("CgNmb28", true, "")
+	f.Add("\n\x03foo", false, "")
+	f.Add(`{"value":"foo"}`, false, "")
+	f.Add("H4sIAAAAAAAA/+IKTszPBwQAAP//kvLVLwUAAAA=", true, "gzip")
+	f.Add("AAAAAAUKA2Zvbw==", true, "identity")
+	f.Add("CgNmb28=", true, "br")
+	f.Add("", false, "")
+
+	f.Fuzz(func(t *testing.T, body string, isBase64 bool, encoding string) {
+		if err := lambda.FuzzDecode(body, isBase64, encoding, &wrapperspb.StringValue{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+}
+
+func FuzzMetadata(f *testing.F) {
+
+	f.Add("Content-Type: application/grpc+proto\nX-Request-Id: 42")
+	f.Add("X-Tenant-Id: acme\nx-tenant-id: other\nX-Tenant-Id: third")
+	f.Add("Grpc-Timeout: 5S\nAuthorization: Bearer token\nX-Bin-Bin: AAEC")
+	f.Add("Baggage: a=1,b=2\nbaggage: c=3")
+	f.Add("NoColon\n: empty name\nEmpty-Value:")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, headers string) {
+		if err := lambda.FuzzMetadata(headers); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+}
+
+func FuzzMatcher(f *testing.F) {
+
+	f.Add("", "/acme.users.v1.Users/Get")
+	f.Add("/users", "/users/acme.users.v1.Users/Get")
+	f.Add("/users/", "/users/acme.users.v1.Users/Get/")
+	f.Add("/users", "/usersx/acme.users.v1.Users/Get")
+	f.Add("/users", "/users")
+	f.Add("/v1/api", "/v1/api/orders/42")
+	f.Add("/", "//double//slashes")
+
+	f.Fuzz(func(t *testing.T, basePath, path string) {
+		if err := lambda.FuzzMatcher(basePath, path); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+}
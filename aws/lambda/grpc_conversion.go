@@ -1,6 +1,7 @@
 package lambda
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,66 +10,153 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// StatusClientClosedRequest answers the calls canceled by their caller,
+// following the nginx convention.
+const StatusClientClosedRequest = 499
+
 func convertResultError(res *Response, err any) error {
 
 	if err, ok := err.(error); ok {
 
+		// Context errors are plain errors, without them the calls timing out
+		// would fail with a generic 500.
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			if _, ok := status.FromError(err); !ok {
+				err = status.FromContextError(err).Err()
+			}
+		}
+
 		if err, ok := status.FromError(err); ok {
 
 			res.Body = err.Message()
 			res.IsBase64Encoded = false
 
-			switch err.Code() {
+			if statusCode, ok := HTTPStatusFromCode(err.Code()); ok {
+				res.StatusCode = statusCode
+			}
+
+			setRetryAfter(res, err)
+
+		}
+
+		return err
+	}
+
+	res.StatusCode = http.StatusInternalServerError
+	res.Body = fmt.Sprintf("Invalid error type: %T", err)
 
-			case codes.InvalidArgument:
-				res.StatusCode = http.StatusBadRequest
+	return errors.New(res.Body)
 
-			case codes.NotFound:
-				res.StatusCode = http.StatusNotFound
+}
 
-			case codes.AlreadyExists:
-				res.StatusCode = http.StatusConflict
+// HTTPStatusFromCode returns the HTTP status controllers answer with for a
+// gRPC code, false when the code has no specific status.
+func HTTPStatusFromCode(code codes.Code) (int, bool) {
 
-			case codes.PermissionDenied:
-				res.StatusCode = http.StatusForbidden
+	switch code {
 
-			case codes.Unauthenticated:
-				res.StatusCode = http.StatusUnauthorized
+	case codes.InvalidArgument:
+		return http.StatusBadRequest, true
 
-			case codes.ResourceExhausted:
-				res.StatusCode = http.StatusTooManyRequests
+	case codes.NotFound:
+		return http.StatusNotFound, true
 
-			case codes.FailedPrecondition:
-				res.StatusCode = http.StatusPreconditionFailed
+	case codes.AlreadyExists:
+		return http.StatusConflict, true
 
-			case codes.Aborted:
-				res.StatusCode = http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden, true
 
-			case codes.OutOfRange:
-				res.StatusCode = http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, true
 
-			case codes.Unimplemented:
-				res.StatusCode = http.StatusNotImplemented
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests, true
 
-			case codes.Internal:
-				res.StatusCode = http.StatusInternalServerError
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed, true
 
-			case codes.Unavailable:
-				res.StatusCode = http.StatusServiceUnavailable
+	case codes.Aborted:
+		return http.StatusConflict, true
 
-			case codes.DataLoss:
-				res.StatusCode = http.StatusInternalServerError
+	case codes.OutOfRange:
+		return http.StatusBadRequest, true
 
-			}
+	case codes.Unimplemented:
+		return http.StatusNotImplemented, true
 
-		}
+	case codes.Internal:
+		return http.StatusInternalServerError, true
+
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, true
+
+	case codes.DataLoss:
+		return http.StatusInternalServerError, true
+
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, true
+
+	case codes.Canceled:
+		return StatusClientClosedRequest, true
 
-		return err
 	}
 
-	res.StatusCode = http.StatusInternalServerError
-	res.Body = fmt.Sprintf("Invalid error type: %T", err)
+	return 0, false
 
-	return errors.New(res.Body)
+}
+
+// CodeFromHTTPStatus is the inverse of HTTPStatusFromCode, used by clients to
+// turn responses of controllers back into gRPC errors.
+func CodeFromHTTPStatus(statusCode int) codes.Code {
+
+	switch statusCode {
+
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+
+	case http.StatusNotFound:
+		return codes.NotFound
+
+	case http.StatusConflict:
+		return codes.Aborted
+
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+
+	case StatusClientClosedRequest:
+		return codes.Canceled
+
+	}
+
+	if statusCode >= 500 {
+		return codes.Internal
+	}
+
+	return codes.Unknown
+
+}
 
+// WriteError answers with the status of err, for middlewares and handlers
+// failing the call.
+func (r *Response) WriteError(err error) error {
+	return convertResultError(r, err)
 }
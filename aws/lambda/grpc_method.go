@@ -0,0 +1,160 @@
+package lambda
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcMethod is computed once per method on registration, so dispatching
+// goes through the generated method handler without any reflection.
+type grpcMethod struct {
+	route  Route
+	info   *grpc.UnaryServerInfo
+	desc   grpc.MethodDesc
+	inputs *messagePool
+	attrs  *MethodAttributes
+}
+
+type grpcStream struct {
+	route  Route
+	desc   grpc.StreamDesc
+	server interface{}
+	attrs  *MethodAttributes
+}
+
+func fullMethodName(serviceName, methodName string) string {
+	return strings.Join([]string{"/", serviceName, "/", methodName}, "")
+}
+
+func newGrpcMethods(desc grpc.ServiceDesc, svc interface{}) ([]*grpcMethod, error) {
+
+	reflectSvc := reflect.ValueOf(svc)
+
+	methods := make([]*grpcMethod, 0, len(desc.Methods))
+
+	for _, method := range desc.Methods {
+
+		key := fullMethodName(desc.ServiceName, method.MethodName)
+
+		methodCaller := reflectSvc.MethodByName(method.MethodName)
+		if !methodCaller.IsValid() || methodCaller.Type().NumIn() != 2 {
+			return nil, status.Errorf(codes.Unimplemented, "Service %T doesn't implement %s", svc, key)
+		}
+
+		input, ok := reflect.New(methodCaller.Type().In(1).Elem()).Interface().(proto.Message)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Input of %s is not a proto message", key)
+		}
+
+		methods = append(methods, &grpcMethod{
+			route: Route{
+				Key:     key,
+				Kind:    RouteKindUnary,
+				Service: desc.ServiceName,
+				Method:  method.MethodName,
+			},
+			info: &grpc.UnaryServerInfo{
+				Server:     svc,
+				FullMethod: key,
+			},
+			desc:   method,
+			inputs: newMessagePool(input),
+		})
+
+	}
+
+	return methods, nil
+
+}
+
+func newGrpcStreams(desc grpc.ServiceDesc, svc interface{}) []*grpcStream {
+
+	streams := make([]*grpcStream, 0, len(desc.Streams))
+
+	for _, stream := range desc.Streams {
+
+		kind := RouteKindServerStream
+		if stream.ClientStreams && stream.ServerStreams {
+			kind = RouteKindBidiStream
+		} else if stream.ClientStreams {
+			kind = RouteKindClientStream
+		}
+
+		streams = append(streams, &grpcStream{
+			route: Route{
+				Key:     fullMethodName(desc.ServiceName, stream.StreamName),
+				Kind:    kind,
+				Service: desc.ServiceName,
+				Method:  stream.StreamName,
+			},
+			desc:   stream,
+			server: svc,
+		})
+
+	}
+
+	return streams
+
+}
+
+// isServerStream reports whether the stream fits the request/response model
+// of Lambda, the others are only reachable through WebSocket connections.
+func (s *grpcStream) isServerStream() bool {
+	return s.route.Kind == RouteKindServerStream
+}
+
+// invoke calls the stream handler through interceptor.
+func (s *grpcStream) invoke(stream grpc.ServerStream, interceptor grpc.StreamServerInterceptor) error {
+
+	info := &grpc.StreamServerInfo{
+		FullMethod:     fullMethodName(s.route.Service, s.route.Method),
+		IsClientStream: s.desc.ClientStreams,
+		IsServerStream: s.desc.ServerStreams,
+	}
+
+	return interceptor(s.server, stream, info, s.desc.Handler)
+
+}
+
+// invoke decodes the request and calls the method through interceptor, the
+// returned release func must be called once the input is no longer used.
+func (m *grpcMethod) invoke(ctx context.Context, req *Request, interceptor grpc.UnaryServerInterceptor, reuse bool) (proto.Message, interface{}, func(), error) {
+
+	var input proto.Message
+
+	release := func() {
+		if reuse && input != nil {
+			m.inputs.put(input)
+		}
+	}
+
+	dec := func(in interface{}) error {
+
+		// The generated handler allocates its own input, it is replaced by a
+		// pooled one when messages are reused.
+		input = in.(proto.Message)
+		if reuse {
+			input = m.inputs.get()
+		}
+
+		if err := req.Unmarshal(input); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Failed to unmarshal request: %s", status.Convert(err).Message())
+		}
+
+		return nil
+
+	}
+
+	out, err := m.desc.Handler(m.info.Server, ctx, dec, func(ctx context.Context, _ interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return interceptor(ctx, input, m.info, handler)
+	})
+
+	return input, out, release, err
+
+}
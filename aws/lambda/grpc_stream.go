@@ -6,20 +6,23 @@ import (
 	"net/http"
 
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
 type grpcServerStream struct {
-	ctx context.Context
-	req *Request
-	res *Response
+	ctx       context.Context
+	req       *Request
+	res       *Response
+	transport *serverTransportStream
 }
 
-func newGrpcServerStream(ctx context.Context, req *Request, res *Response) *grpcServerStream {
+func newGrpcServerStream(ctx context.Context, req *Request, res *Response, transport *serverTransportStream) *grpcServerStream {
 	return &grpcServerStream{
-		ctx: ctx,
-		req: req,
-		res: res,
+		ctx:       ctx,
+		req:       req,
+		res:       res,
+		transport: transport,
 	}
 }
 
@@ -47,7 +50,7 @@ func (g *grpcServerStream) RecvMsg(m interface{}) error {
 
 	if err != nil {
 		g.res.StatusCode = http.StatusBadRequest
-		g.res.Body = fmt.Sprintf("Failed to unmarshal request: %v", err)
+		g.res.Body = fmt.Sprintf("Failed to unmarshal request: %s", status.Convert(err).Message())
 	}
 
 	return err
@@ -55,16 +58,13 @@ func (g *grpcServerStream) RecvMsg(m interface{}) error {
 }
 
 func (g *grpcServerStream) SendHeader(m metadata.MD) error {
-	g.SetTrailer(m)
-	return nil
+	return g.transport.SendHeader(m)
 }
 
 func (g *grpcServerStream) SetHeader(m metadata.MD) error {
-	g.SetTrailer(m)
-	return nil
+	return g.transport.SetHeader(m)
 }
 
 func (g *grpcServerStream) SetTrailer(m metadata.MD) {
-	outMeta, _ := metadata.FromOutgoingContext(g.ctx)
-	g.ctx = metadata.NewOutgoingContext(g.ctx, metadata.Join(outMeta, m))
+	g.transport.SetTrailer(m)
 }
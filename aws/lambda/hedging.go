@@ -0,0 +1,154 @@
+package lambda
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// HedgingPolicy sends additional attempts of idempotent calls when the
+// previous ones are slow, the first successful response wins and the other
+// attempts are canceled.
+type HedgingPolicy struct {
+	// Delay before each additional attempt.
+	Delay time.Duration
+	// Attempts of a call including the first one, defaults to 2.
+	MaxAttempts int
+	// Reports whether method is safe to call several times, calls of other
+	// methods are never hedged.
+	Idempotent func(method string) bool
+}
+
+type hedgedResult struct {
+	target *Target
+	reply  proto.Message
+	header metadata.MD
+	err    error
+}
+
+func (c *ClientConn) shouldHedge(method string) bool {
+	return c.Hedging != nil && c.Hedging.Idempotent != nil && c.Hedging.Idempotent(method)
+}
+
+// invokeHedged calls the targets in order (cycling when there are less
+// targets than attempts), the balancer decides which errors are worth
+// waiting for the other attempts.
+func (c *ClientConn) invokeHedged(ctx context.Context, targets []*Target, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	maxAttempts := c.Hedging.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 2
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *hedgedResult, maxAttempts)
+
+	attempt := func(target *Target) {
+
+		attemptReply := proto.Clone(reply.(proto.Message))
+		proto.Reset(attemptReply)
+
+		// Attempts run concurrently, only the winner sets the headers.
+		header := metadata.MD{}
+
+		if c.Balancer != nil {
+			c.Balancer.Started(target)
+		}
+
+		start := time.Now()
+
+		err := c.invokeTarget(ctx, target, method, args, attemptReply, grpc.Header(&header))
+
+		if c.Balancer != nil && ctx.Err() == nil {
+			c.Balancer.Report(target, err, time.Since(start))
+		}
+
+		results <- &hedgedResult{
+			target: target,
+			reply:  attemptReply,
+			header: header,
+			err:    err,
+		}
+
+	}
+
+	started, pending := 0, 0
+
+	launch := func() {
+		go attempt(targets[started%len(targets)])
+		started++
+		pending++
+	}
+
+	launch()
+
+	var lastErr error
+
+	for pending > 0 {
+
+		var hedge <-chan time.Time
+		if started < maxAttempts {
+			hedge = time.After(c.Hedging.Delay)
+		}
+
+		select {
+
+		case result := <-results:
+
+			pending--
+
+			if result.err == nil {
+
+				proto.Reset(reply.(proto.Message))
+				proto.Merge(reply.(proto.Message), result.reply)
+
+				for _, opt := range opts {
+					if headerOpt, ok := opt.(grpc.HeaderCallOption); ok {
+						*headerOpt.HeaderAddr = result.header
+					}
+				}
+
+				return nil
+
+			}
+
+			lastErr = result.err
+
+			if !c.failover(result.err) {
+				return result.err
+			}
+
+			// Fail fast to the next attempt instead of waiting for the delay.
+			if started < maxAttempts {
+				launch()
+			}
+
+		case <-hedge:
+			launch()
+
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+
+		}
+
+	}
+
+	return lastErr
+
+}
+
+func (c *ClientConn) failover(err error) bool {
+
+	if c.Balancer != nil {
+		return c.Balancer.Failover(err)
+	}
+
+	return isFailoverCode(nil, err)
+
+}
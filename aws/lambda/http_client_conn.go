@@ -0,0 +1,126 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// HTTPClientConn calls gRPC methods of a controller through its API Gateway
+// stage, the stage must list application/grpc+proto as binary media type.
+type HTTPClientConn struct {
+	// Base URL of the stage, including the base path of the controller.
+	Url        string
+	HttpClient *http.Client
+	// Signs the requests for IAM authorized stages when set.
+	Client *awsapi.Client
+	// Wrap the calls, the first one being the outermost. The ClientConn
+	// passed to the interceptors is nil.
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+}
+
+var _ grpc.ClientConnInterface = &HTTPClientConn{}
+
+func (c *HTTPClientConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	if len(c.UnaryInterceptors) == 0 {
+		return c.invoke(ctx, method, args, reply, opts...)
+	}
+
+	invoker := chainUnaryClientInterceptors(c.UnaryInterceptors, func(ctx context.Context, method string, args, reply interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return c.invoke(ctx, method, args, reply, opts...)
+	})
+
+	return invoker(ctx, method, args, reply, nil, opts...)
+
+}
+
+func (c *HTTPClientConn) invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	body, err := proto.Marshal(args.(proto.Message))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.Url, "/")+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("Accept", "application/grpc+proto")
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for k, v := range md {
+			for _, value := range v {
+				req.Header.Add(k, value)
+			}
+		}
+	}
+
+	if requestId := RequestIdFromContext(ctx); len(requestId) > 0 {
+		req.Header.Set(RequestIdHeader, requestId)
+	}
+
+	res, err := c.do(ctx, req, body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Errorf(codes.Unavailable, "Failed to call %s: %v", method, err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "Failed to read response of %s: %v", method, err)
+	}
+
+	resMeta, resTrailer := splitTrailers(metadata.MD(res.Header))
+
+	setCallMetadata(opts, resMeta, resTrailer)
+
+	if res.StatusCode >= 300 {
+		return responseError(ctx, res.StatusCode, res.Header.Get("Retry-After"), string(resBody))
+	}
+
+	return proto.Unmarshal(resBody, reply.(proto.Message))
+
+}
+
+// NewStream fails with Unimplemented once through the stream interceptors,
+// which may serve the stream otherwise.
+func (c *HTTPClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+	streamer := chainStreamClientInterceptors(c.StreamInterceptors, func(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Errorf(codes.Unimplemented, "Streams are not supported over HTTP: %s", method)
+	})
+
+	return streamer(ctx, desc, nil, method, opts...)
+
+}
+
+func (c *HTTPClientConn) do(ctx context.Context, req *http.Request, body []byte) (*http.Response, error) {
+
+	if c.Client != nil {
+		return c.Client.Do(ctx, "execute-api", req, body)
+	}
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return httpClient.Do(req)
+
+}
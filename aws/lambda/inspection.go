@@ -0,0 +1,173 @@
+package lambda
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	WAFTokenCookie  = "aws-waf-token"
+	WAFActionHeader = "x-amzn-waf-action"
+)
+
+// HeaderRule rejects requests whose header matches Pattern, or misses it
+// when Required.
+type HeaderRule struct {
+	Header   string
+	Pattern  *regexp.Regexp
+	Required bool
+}
+
+// InspectionRules of the requests, zero values disable the checks.
+type InspectionRules struct {
+	MaxBodySize   int
+	MaxHeaders    int
+	MaxHeaderSize int
+	// Media types accepted for requests with a body, e.g.
+	// "application/x-protobuf".
+	AllowedContentTypes []string
+	HeaderRules         []HeaderRule
+	// Requires the token AWS WAF sets once the client solved a challenge,
+	// clients without it are asked for a challenge.
+	RequireWAFToken bool
+}
+
+// InspectionMiddleware rejects obviously malicious requests before their
+// body is unmarshaled.
+func InspectionMiddleware(rules InspectionRules) Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			if err := rules.inspect(req); err != nil {
+
+				LoggerFromContext(ctx).Warn("Request rejected by inspection", "error", err)
+
+				if status.Code(err) == codes.Unauthenticated && rules.RequireWAFToken {
+					res.SetHeader(WAFActionHeader, "challenge")
+				}
+
+				return res.WriteError(err)
+
+			}
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
+
+func (rules *InspectionRules) inspect(req *Request) error {
+
+	bodySize := len(req.Body)
+	if req.IsBase64Encoded {
+		bodySize = bodySize * 3 / 4
+	}
+
+	if rules.MaxBodySize > 0 && bodySize > rules.MaxBodySize {
+		return status.Errorf(codes.ResourceExhausted, "Request body exceeds %d bytes", rules.MaxBodySize)
+	}
+
+	headers := len(req.Headers)
+	if len(req.MultiValueHeaders) > headers {
+		headers = len(req.MultiValueHeaders)
+	}
+
+	if rules.MaxHeaders > 0 && headers > rules.MaxHeaders {
+		return status.Errorf(codes.InvalidArgument, "Request has more than %d headers", rules.MaxHeaders)
+	}
+
+	if rules.MaxHeaderSize > 0 {
+
+		for key, value := range req.Headers {
+			if len(key)+len(value) > rules.MaxHeaderSize {
+				return status.Errorf(codes.InvalidArgument, "Header %s exceeds %d bytes", key, rules.MaxHeaderSize)
+			}
+		}
+
+		for key, values := range req.MultiValueHeaders {
+			for _, value := range values {
+				if len(key)+len(value) > rules.MaxHeaderSize {
+					return status.Errorf(codes.InvalidArgument, "Header %s exceeds %d bytes", key, rules.MaxHeaderSize)
+				}
+			}
+		}
+
+	}
+
+	if len(rules.AllowedContentTypes) > 0 && bodySize > 0 && !rules.allowedContentType(req.Header("Content-Type")) {
+		return status.Errorf(codes.InvalidArgument, "Content type %q not allowed", req.Header("Content-Type"))
+	}
+
+	for _, rule := range rules.HeaderRules {
+
+		value := req.Header(rule.Header)
+
+		if len(value) == 0 {
+			if rule.Required {
+				return status.Errorf(codes.InvalidArgument, "Missing header %s", rule.Header)
+			}
+			continue
+		}
+
+		if rule.Pattern != nil && rule.Pattern.MatchString(value) {
+			return status.Errorf(codes.InvalidArgument, "Header %s rejected", rule.Header)
+		}
+
+	}
+
+	if rules.RequireWAFToken && !hasCookie(req, WAFTokenCookie) {
+		return status.Error(codes.Unauthenticated, "Missing WAF token")
+	}
+
+	return nil
+
+}
+
+func (rules *InspectionRules) allowedContentType(contentType string) bool {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range rules.AllowedContentTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func hasCookie(req *Request, name string) bool {
+
+	cookies := []string{}
+
+	if cookie := req.Header("Cookie"); len(cookie) > 0 {
+		cookies = append(cookies, cookie)
+	}
+
+	for key, values := range req.MultiValueHeaders {
+		if strings.EqualFold(key, "Cookie") {
+			cookies = append(cookies, values...)
+		}
+	}
+
+	header := http.Header{"Cookie": cookies}
+
+	_, err := (&http.Request{Header: header}).Cookie(name)
+
+	return err == nil
+
+}
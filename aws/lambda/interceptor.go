@@ -0,0 +1,109 @@
+package lambda
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		next := handler
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+
+		return next(ctx, req)
+
+	}
+
+}
+
+func chainStreamInterceptors(interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		next := handler
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(srv interface{}, stream grpc.ServerStream) error {
+				return interceptor(srv, stream, info, inner)
+			}
+		}
+
+		return next(srv, stream)
+
+	}
+
+}
+
+// RecoveryInterceptor turns panics of the handlers into Internal errors, so
+// a faulty method doesn't crash the execution environment.
+func RecoveryInterceptor() grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (out interface{}, err error) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				LoggerFromContext(ctx).Error("Handler panicked", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+				out, err = nil, status.Errorf(codes.Internal, "Internal error")
+			}
+		}()
+
+		return handler(ctx, req)
+
+	}
+
+}
+
+// RecoveryMiddleware turns panics of the middlewares and handlers it wraps
+// into Internal errors, streaming methods and plain handlers included.
+// Register it first so that it wraps the whole chain.
+func RecoveryMiddleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) (err error) {
+
+			defer func() {
+				if r := recover(); r != nil {
+					LoggerFromContext(ctx).Error("Handler panicked", "handler", req.HandlerKey, "panic", r, "stack", string(debug.Stack()))
+					err = res.WriteError(status.Errorf(codes.Internal, "Internal error"))
+				}
+			}()
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
+
+// AccessLogInterceptor logs the method, code and duration of every call.
+func AccessLogInterceptor() grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		start := time.Now()
+
+		out, err := handler(ctx, req)
+
+		LoggerFromContext(ctx).Info("Call handled", "method", info.FullMethod, "code", status.Code(err).String(), "duration", time.Since(start).String())
+
+		return out, err
+
+	}
+
+}
@@ -0,0 +1,207 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// IoTEvent is the payload expected from IoT Core rules, binary payloads
+// must be base64 encoded by the rule, e.g.:
+//
+//	SELECT encode(*, 'base64') AS data, topic() AS topic, timestamp() AS timestamp,
+//	  get_mqtt_property('response_topic') AS response_topic,
+//	  get_mqtt_property('correlation_data') AS correlation_data,
+//	  get_mqtt_property('content_type') AS content_type FROM 'devices/#'
+//
+// JSON payloads may be selected as is in the payload field instead.
+type IoTEvent struct {
+	Topic           string          `json:"topic"`
+	Timestamp       int64           `json:"timestamp"`
+	Data            []byte          `json:"data"`
+	Payload         json.RawMessage `json:"payload"`
+	ResponseTopic   string          `json:"response_topic"`
+	CorrelationData string          `json:"correlation_data"`
+	// Content type of the data, its codec is resolved by the Codecs
+	// registry. Defaults to protobuf.
+	ContentType string `json:"content_type"`
+}
+
+// IoTMessage is the decoded message passed to topic handlers.
+type IoTMessage struct {
+	Topic           string
+	Pattern         string
+	Time            time.Time
+	Payload         proto.Message
+	ResponseTopic   string
+	CorrelationData string
+}
+
+type IoTHandler func(ctx context.Context, msg *IoTMessage) error
+
+type iotRoute struct {
+	pattern   string
+	prototype proto.Message
+	handler   IoTHandler
+}
+
+// RegisterIoTHandler routes messages published on topics matching pattern
+// (MQTT wildcards + and # supported) to handler, payloads are decoded into
+// messages of the prototype type. Routes are tried in registration order.
+func (c *Controller[D]) RegisterIoTHandler(pattern string, prototype proto.Message, handler IoTHandler) {
+	c.iotRoutes = append(c.iotRoutes, &iotRoute{
+		pattern:   pattern,
+		prototype: prototype,
+		handler:   handler,
+	})
+}
+
+// HandleIoT handles an invocation from an IoT Core rule through the unary
+// interceptors, errors are returned so the rule error action or the retries
+// of asynchronous invocations apply.
+func (c *Controller[D]) HandleIoT(ctx context.Context, event *IoTEvent) error {
+
+	log := c.Log().With("topic", event.Topic)
+	ctx = ContextWithLogger(ctx, log)
+
+	var route *iotRoute
+
+	for _, r := range c.iotRoutes {
+		if MatchTopic(r.pattern, event.Topic) {
+			route = r
+			break
+		}
+	}
+
+	if route == nil {
+		log.Error("No IoT handler registered for topic")
+		return status.Errorf(codes.NotFound, "No handler for topic %s", event.Topic)
+	}
+
+	payload := route.prototype.ProtoReflect().New().Interface()
+
+	if len(event.Data) > 0 {
+
+		codec, ok := Codecs.CodecFromContentType(event.ContentType)
+		if !ok {
+			codec = CodecProtobuf
+		}
+
+		if err := Codecs.Decode(codec, event.Data, payload); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Failed to unmarshal payload: %v", err)
+		}
+
+	} else if len(event.Payload) > 0 {
+		if err := Codecs.Decode(CodecJSON, event.Payload, payload); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Failed to unmarshal payload: %v", err)
+		}
+	}
+
+	msg := &IoTMessage{
+		Topic:           event.Topic,
+		Pattern:         route.pattern,
+		Time:            time.UnixMilli(event.Timestamp),
+		Payload:         payload,
+		ResponseTopic:   event.ResponseTopic,
+		CorrelationData: event.CorrelationData,
+	}
+
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "iot:" + route.pattern,
+	}
+
+	_, err := chainUnaryInterceptors(c.unaryInterceptors)(ctx, payload, info, func(ctx context.Context, in interface{}) (interface{}, error) {
+		return nil, route.handler(ctx, msg)
+	})
+
+	if err != nil {
+		log.Error("Failed to handle IoT message", "error", err)
+	}
+
+	return err
+
+}
+
+// MatchTopic reports whether topic matches the MQTT topic filter pattern.
+func MatchTopic(pattern, topic string) bool {
+
+	patternLevels := strings.Split(pattern, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range patternLevels {
+
+		if level == "#" {
+			return true
+		}
+
+		if i >= len(topicLevels) {
+			return false
+		}
+
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+
+	}
+
+	return len(patternLevels) == len(topicLevels)
+
+}
+
+// IoTPublisher publishes messages through the IoT Core data plane.
+type IoTPublisher struct {
+	Client *awsapi.Client
+	// Account specific data endpoint (https://xxx-ats.iot.region.amazonaws.com).
+	Endpoint string
+	Qos      int
+}
+
+func (p *IoTPublisher) Publish(ctx context.Context, topic string, msg proto.Message) error {
+	return p.publish(ctx, topic, "", msg)
+}
+
+// Reply publishes out to the response topic of msg, keeping its correlation
+// data so MQTT 5 clients can match it with their request.
+func (p *IoTPublisher) Reply(ctx context.Context, msg *IoTMessage, out proto.Message) error {
+
+	if len(msg.ResponseTopic) == 0 {
+		return status.Errorf(codes.FailedPrecondition, "Message published on %s has no response topic", msg.Topic)
+	}
+
+	return p.publish(ctx, msg.ResponseTopic, msg.CorrelationData, out)
+
+}
+
+func (p *IoTPublisher) publish(ctx context.Context, topic, correlationData string, msg proto.Message) error {
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/topics/%s?qos=%d", strings.TrimRight(p.Endpoint, "/"), url.PathEscape(topic), p.Qos)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if len(correlationData) > 0 {
+		req.Header.Set("X-Amz-Mqtt5-Correlation-Data", correlationData)
+	}
+
+	return doSignedRequest(ctx, p.Client, "iotdata", req, body)
+
+}
@@ -0,0 +1,238 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// KafkaAnyTopic registers a route matching every topic without a route.
+const KafkaAnyTopic = "*"
+
+type KafkaRoute struct {
+	// Topic name or KafkaAnyTopic.
+	Topic string
+	// Type of the record values.
+	Prototype proto.Message
+	// Codec of the record values, resolved by the Codecs registry. Defaults
+	// to the codec of the content-type header of the records, else
+	// protobuf.
+	Codec Codec
+	// Decodes the record values instead of the Codec.
+	Decoder Decoder
+	// Checks the prototype against the schema registry before the first
+	// record is handled (e.g. schemaregistry.Validator).
+	Validator envelope.Validator
+	Handler   KafkaHandler
+}
+
+// KafkaMessage is the decoded record passed to Kafka handlers.
+type KafkaMessage struct {
+	Topic     string
+	Partition int64
+	Offset    int64
+	Time      time.Time
+	Key       []byte
+	Value     proto.Message
+	Headers   map[string][]byte
+}
+
+type KafkaHandler func(ctx context.Context, msg *KafkaMessage) error
+
+// KafkaResponse reports the records to retry when the event source mapping
+// has ReportBatchItemFailures enabled.
+type KafkaResponse struct {
+	BatchItemFailures []KafkaBatchItemFailure `json:"batchItemFailures"`
+}
+
+// KafkaBatchItemFailure identifies a record by topic-partition and offset.
+type KafkaBatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+type KafkaOptions struct {
+	// Reports failures in the response instead of failing the invocation,
+	// the event source mapping must have ReportBatchItemFailures enabled or
+	// failed records would be lost.
+	ReportBatchItemFailures bool
+}
+
+// RegisterKafkaHandler routes the records of a MSK or self-managed Kafka
+// event source to the handler of their topic.
+func (c *Controller[D]) RegisterKafkaHandler(route KafkaRoute) {
+	c.kafkaRoutes[route.Topic] = &route
+}
+
+// HandleKafka handles the records through the unary interceptors. Partitions
+// are processed concurrently (on the Pool when set) and records of a
+// partition in order, a partition stops at its first failure so offsets are
+// never skipped.
+func (c *Controller[D]) HandleKafka(ctx context.Context, event *events.KafkaEvent, opts KafkaOptions) (*KafkaResponse, error) {
+
+	log := c.Log().With("event_source_arn", event.EventSourceARN)
+	ctx = ContextWithLogger(ctx, log)
+
+	partitions := make([]string, 0, len(event.Records))
+	for partition := range event.Records {
+		partitions = append(partitions, partition)
+	}
+	sort.Strings(partitions)
+
+	failures := make([]*KafkaBatchItemFailure, len(partitions))
+
+	pool := c.Pool
+	if pool == nil {
+		pool = NewPool(len(partitions))
+	}
+
+	group := pool.Group(ctx)
+
+	for i, partition := range partitions {
+
+		i, records := i, event.Records[partition]
+
+		group.Go(func(ctx context.Context) error {
+
+			for _, record := range records {
+
+				if err := c.handleKafkaRecord(ctx, &record); err != nil {
+
+					log.Error("Failed to handle Kafka record", "error", err, "topic", record.Topic, "partition", record.Partition, "offset", record.Offset)
+
+					failures[i] = &KafkaBatchItemFailure{
+						ItemIdentifier: fmt.Sprintf("%s-%d@%d", record.Topic, record.Partition, record.Offset),
+					}
+
+					return nil
+
+				}
+
+			}
+
+			return nil
+
+		})
+
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	res := &KafkaResponse{
+		BatchItemFailures: []KafkaBatchItemFailure{},
+	}
+
+	for _, failure := range failures {
+		if failure != nil {
+			res.BatchItemFailures = append(res.BatchItemFailures, *failure)
+		}
+	}
+
+	if len(res.BatchItemFailures) > 0 && !opts.ReportBatchItemFailures {
+		return nil, status.Errorf(codes.Aborted, "Failed to handle %d partitions", len(res.BatchItemFailures))
+	}
+
+	return res, nil
+
+}
+
+func (c *Controller[D]) handleKafkaRecord(ctx context.Context, record *events.KafkaRecord) error {
+
+	route, ok := c.kafkaRoutes[record.Topic]
+	if !ok {
+		route, ok = c.kafkaRoutes[KafkaAnyTopic]
+	}
+
+	if !ok {
+		return status.Errorf(codes.NotFound, "No handler for topic %s", record.Topic)
+	}
+
+	if route.Validator != nil {
+		if err := route.Validator.Validate(ctx, route.Prototype.ProtoReflect().Descriptor()); err != nil {
+			return err
+		}
+	}
+
+	msg := &KafkaMessage{
+		Topic:     record.Topic,
+		Partition: record.Partition,
+		Offset:    record.Offset,
+		Time:      record.Timestamp.Time,
+		Value:     route.Prototype.ProtoReflect().New().Interface(),
+		Headers:   make(map[string][]byte),
+	}
+
+	for _, headers := range record.Headers {
+		for k, v := range headers {
+			msg.Headers[k] = v
+		}
+	}
+
+	if len(record.Key) > 0 {
+		key, err := DecodeBase64(record.Key)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid record key: %v", err)
+		}
+		msg.Key = key
+	}
+
+	if len(record.Value) > 0 {
+
+		value, err := DecodeBase64(record.Value)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid record value: %v", err)
+		}
+
+		if err := route.decode(msg.Headers, value, msg.Value); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Failed to decode record value: %v", err)
+		}
+
+	}
+
+	info := &grpc.UnaryServerInfo{
+		FullMethod: "kafka:" + record.Topic,
+	}
+
+	_, err := chainUnaryInterceptors(c.unaryInterceptors)(ctx, msg.Value, info, func(ctx context.Context, in interface{}) (interface{}, error) {
+		return nil, route.Handler(ctx, msg)
+	})
+
+	return err
+
+}
+
+// decode decodes a record value with the decoder of the route, else its
+// codec or the one of the content-type header of the record.
+func (r *KafkaRoute) decode(headers map[string][]byte, value []byte, m proto.Message) error {
+
+	if r.Decoder != nil {
+		return r.Decoder(value, m)
+	}
+
+	codec := r.Codec
+
+	if len(codec) == 0 {
+		for k, v := range headers {
+			if strings.EqualFold(k, "content-type") {
+				codec, _ = Codecs.CodecFromContentType(string(v))
+			}
+		}
+	}
+
+	if len(codec) == 0 {
+		codec = CodecProtobuf
+	}
+
+	return Codecs.Decode(codec, value, m)
+
+}
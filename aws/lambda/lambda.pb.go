@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.23.4
+// source: protomesh/lambda/v1/lambda.proto
+
+package lambda
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Priority of the calls of a method, the load shedder drops the least
+// important calls first.
+type Priority int32
+
+const (
+	// Treated as normal.
+	Priority_PRIORITY_UNSPECIFIED Priority = 0
+	// Never shed.
+	Priority_PRIORITY_CRITICAL Priority = 1
+	Priority_PRIORITY_HIGH     Priority = 2
+	Priority_PRIORITY_NORMAL   Priority = 3
+	Priority_PRIORITY_LOW      Priority = 4
+	// Shed first (e.g. prefetching or analytics).
+	Priority_PRIORITY_SHEDDABLE Priority = 5
+)
+
+// Enum value maps for Priority.
+var (
+	Priority_name = map[int32]string{
+		0: "PRIORITY_UNSPECIFIED",
+		1: "PRIORITY_CRITICAL",
+		2: "PRIORITY_HIGH",
+		3: "PRIORITY_NORMAL",
+		4: "PRIORITY_LOW",
+		5: "PRIORITY_SHEDDABLE",
+	}
+	Priority_value = map[string]int32{
+		"PRIORITY_UNSPECIFIED": 0,
+		"PRIORITY_CRITICAL":    1,
+		"PRIORITY_HIGH":        2,
+		"PRIORITY_NORMAL":      3,
+		"PRIORITY_LOW":         4,
+		"PRIORITY_SHEDDABLE":   5,
+	}
+)
+
+func (x Priority) Enum() *Priority {
+	p := new(Priority)
+	*p = x
+	return p
+}
+
+func (x Priority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Priority) Descriptor() protoreflect.EnumDescriptor {
+	return file_protomesh_lambda_v1_lambda_proto_enumTypes[0].Descriptor()
+}
+
+func (Priority) Type() protoreflect.EnumType {
+	return &file_protomesh_lambda_v1_lambda_proto_enumTypes[0]
+}
+
+func (x Priority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Priority.Descriptor instead.
+func (Priority) EnumDescriptor() ([]byte, []int) {
+	return file_protomesh_lambda_v1_lambda_proto_rawDescGZIP(), []int{0}
+}
+
+var file_protomesh_lambda_v1_lambda_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*int32)(nil),
+		Field:         51006,
+		Name:          "protomesh.lambda.v1.success_status",
+		Tag:           "varint,51006,opt,name=success_status",
+		Filename:      "protomesh/lambda/v1/lambda.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*Priority)(nil),
+		Field:         51007,
+		Name:          "protomesh.lambda.v1.priority",
+		Tag:           "varint,51007,opt,name=priority,enum=protomesh.lambda.v1.Priority",
+		Filename:      "protomesh/lambda/v1/lambda.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// HTTP status of the successful calls (e.g. 201 Created, 202 Accepted or
+	// 204 No Content, answered without body), defaults to 200.
+	//
+	// optional int32 success_status = 51006;
+	E_SuccessStatus = &file_protomesh_lambda_v1_lambda_proto_extTypes[0]
+	// Priority of the calls, defaults to normal.
+	//
+	// optional protomesh.lambda.v1.Priority priority = 51007;
+	E_Priority = &file_protomesh_lambda_v1_lambda_proto_extTypes[1]
+)
+
+var File_protomesh_lambda_v1_lambda_proto protoreflect.FileDescriptor
+
+var file_protomesh_lambda_v1_lambda_proto_rawDesc = []byte{
+	0x0a, 0x20, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x6c, 0x61, 0x6d, 0x62,
+	0x64, 0x61, 0x2f, 0x76, 0x31, 0x2f, 0x6c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x6c, 0x61,
+	0x6d, 0x62, 0x64, 0x61, 0x2e, 0x76, 0x31, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2a, 0x8d, 0x01, 0x0a, 0x08, 0x50, 0x72,
+	0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49,
+	0x54, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x43, 0x52, 0x49,
+	0x54, 0x49, 0x43, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x50, 0x52, 0x49, 0x4f, 0x52,
+	0x49, 0x54, 0x59, 0x5f, 0x48, 0x49, 0x47, 0x48, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52,
+	0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x10, 0x03, 0x12,
+	0x10, 0x0a, 0x0c, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x4c, 0x4f, 0x57, 0x10,
+	0x04, 0x12, 0x16, 0x0a, 0x12, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x53, 0x48,
+	0x45, 0x44, 0x44, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x05, 0x3a, 0x47, 0x0a, 0x0e, 0x73, 0x75, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65,
+	0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xbe, 0x8e, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0d, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x3a, 0x5b, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x1e,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xbf,
+	0x8e, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65,
+	0x73, 0x68, 0x2e, 0x6c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x69,
+	0x6f, 0x72, 0x69, 0x74, 0x79, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x42,
+	0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73,
+	0x68, 0x2d, 0x67, 0x6f, 0x2f, 0x61, 0x77, 0x73, 0x2f, 0x6c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protomesh_lambda_v1_lambda_proto_rawDescOnce sync.Once
+	file_protomesh_lambda_v1_lambda_proto_rawDescData = file_protomesh_lambda_v1_lambda_proto_rawDesc
+)
+
+func file_protomesh_lambda_v1_lambda_proto_rawDescGZIP() []byte {
+	file_protomesh_lambda_v1_lambda_proto_rawDescOnce.Do(func() {
+		file_protomesh_lambda_v1_lambda_proto_rawDescData = protoimpl.X.CompressGZIP(file_protomesh_lambda_v1_lambda_proto_rawDescData)
+	})
+	return file_protomesh_lambda_v1_lambda_proto_rawDescData
+}
+
+var file_protomesh_lambda_v1_lambda_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_protomesh_lambda_v1_lambda_proto_goTypes = []interface{}{
+	(Priority)(0),                      // 0: protomesh.lambda.v1.Priority
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_protomesh_lambda_v1_lambda_proto_depIdxs = []int32{
+	1, // 0: protomesh.lambda.v1.success_status:extendee -> google.protobuf.MethodOptions
+	1, // 1: protomesh.lambda.v1.priority:extendee -> google.protobuf.MethodOptions
+	0, // 2: protomesh.lambda.v1.priority:type_name -> protomesh.lambda.v1.Priority
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	2, // [2:3] is the sub-list for extension type_name
+	0, // [0:2] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_protomesh_lambda_v1_lambda_proto_init() }
+func file_protomesh_lambda_v1_lambda_proto_init() {
+	if File_protomesh_lambda_v1_lambda_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protomesh_lambda_v1_lambda_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   0,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_protomesh_lambda_v1_lambda_proto_goTypes,
+		DependencyIndexes: file_protomesh_lambda_v1_lambda_proto_depIdxs,
+		EnumInfos:         file_protomesh_lambda_v1_lambda_proto_enumTypes,
+		ExtensionInfos:    file_protomesh_lambda_v1_lambda_proto_extTypes,
+	}.Build()
+	File_protomesh_lambda_v1_lambda_proto = out.File
+	file_protomesh_lambda_v1_lambda_proto_rawDesc = nil
+	file_protomesh_lambda_v1_lambda_proto_goTypes = nil
+	file_protomesh_lambda_v1_lambda_proto_depIdxs = nil
+}
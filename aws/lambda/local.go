@@ -0,0 +1,92 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// LocalInvoker serves in process the methods registered in the same
+// function, see ClientConn.Local.
+type LocalInvoker interface {
+	// Serves reports whether method is served in process.
+	Serves(method string) bool
+	InvokeLocal(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error
+}
+
+var _ LocalInvoker = &Controller[ControllerDependency]{}
+
+// localAddr is the address of the in process callers.
+type localAddr struct{}
+
+func (localAddr) Network() string { return "local" }
+func (localAddr) String() string  { return "local" }
+
+// Serves reports whether method is a unary method registered without
+// version on the controller.
+func (c *Controller[D]) Serves(method string) bool {
+	_, ok := c.localMethods[method]
+	return ok
+}
+
+// InvokeLocal calls a registered method without serializing the messages:
+// args is copied into the input of the method and its output into reply.
+// The call goes through the unary interceptors and the method attributes
+// like a remote one, the outgoing metadata of ctx becoming the incoming one
+// of the method, but not through the middlewares.
+func (c *Controller[D]) InvokeLocal(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+
+	m, ok := c.localMethods[method]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "Method %s not registered", method)
+	}
+
+	ctx, cancel := withMethodAttributes(ctx, m.attrs)
+	defer cancel()
+
+	inMeta, _ := metadata.FromOutgoingContext(ctx)
+
+	transport := newServerTransportStream(method)
+
+	// The method's own calls don't forward the metadata of its caller.
+	callCtx := metadata.NewOutgoingContext(metadata.NewIncomingContext(ctx, inMeta.Copy()), metadata.MD{})
+	callCtx = peer.NewContext(grpc.NewContextWithServerTransportStream(callCtx, transport), &peer.Peer{Addr: localAddr{}})
+
+	out, err := m.desc.Handler(m.info.Server, callCtx, func(in interface{}) error {
+		proto.Merge(in.(proto.Message), args.(proto.Message))
+		return nil
+	}, chainUnaryInterceptors(c.unaryInterceptors))
+
+	transport.lock.Lock()
+	header, trailer := transport.header, transport.trailer
+	transport.lock.Unlock()
+
+	setCallMetadata(opts, header, trailer)
+
+	if err != nil {
+
+		if _, ok := status.FromError(err); !ok && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+			return status.FromContextError(err).Err()
+		}
+
+		return status.Convert(err).Err()
+
+	}
+
+	output, ok := out.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "Output of %s is not a proto message", method)
+	}
+
+	proto.Reset(reply.(proto.Message))
+	proto.Merge(reply.(proto.Message), output)
+
+	return nil
+
+}
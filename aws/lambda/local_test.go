@@ -0,0 +1,74 @@
+package lambda
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestInvokeLocalMethodAttributes(t *testing.T) {
+
+	callerAttrs := &MethodAttributes{Auth: &AuthPolicy{Public: true}}
+
+	tests := []struct {
+		name string
+		opts []ServiceOption
+		want *AuthPolicy
+	}{
+		{
+			name: "callee without attributes",
+		},
+		{
+			name: "callee with attributes",
+			opts: []ServiceOption{WithAuthPolicy("Echo", AuthPolicy{Permissions: []string{"bench.echo"}})},
+			want: &AuthPolicy{Permissions: []string{"bench.echo"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			var (
+				seen    *AuthPolicy
+				hasAttr bool
+			)
+
+			c := NewController[ControllerDependency]()
+
+			c.RegisterUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				var attrs *MethodAttributes
+				if attrs, hasAttr = MethodAttributesFromContext(ctx); hasAttr {
+					seen = attrs.Auth
+				}
+				return handler(ctx, req)
+			})
+
+			if err := c.TryRegisterGRPCService(benchServiceDesc, benchService{}, test.opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			// The caller is a public method, its attributes must not reach
+			// the callee.
+			ctx := ContextWithMethodAttributes(context.Background(), callerAttrs)
+
+			if err := c.InvokeLocal(ctx, "/protomesh.bench.v1.Bench/Echo", &structpb.Struct{}, &structpb.Struct{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.want == nil {
+				if hasAttr {
+					t.Fatalf("callee saw attributes %+v, want none", seen)
+				}
+				return
+			}
+
+			if !hasAttr || seen == nil || seen.Public || len(seen.Permissions) != 1 || seen.Permissions[0] != test.want.Permissions[0] {
+				t.Fatalf("callee saw auth policy %+v, want %+v", seen, test.want)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,168 @@
+package lambda
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Catalog translates the error messages of the responses, false when it has
+// no translation for the locale.
+type Catalog interface {
+	Translate(locale string, code codes.Code, message string) (string, bool)
+}
+
+// MessageCatalog translates the messages found verbatim in Messages, the
+// others get the generic message of their code when Codes has one.
+type MessageCatalog struct {
+	// Translations by locale and message.
+	Messages map[string]map[string]string
+	// Generic messages by locale and code.
+	Codes map[string]map[codes.Code]string
+}
+
+func (m *MessageCatalog) Translate(locale string, code codes.Code, message string) (string, bool) {
+
+	if translated, ok := m.Messages[locale][message]; ok {
+		return translated, true
+	}
+
+	if translated, ok := m.Codes[locale][code]; ok {
+		return translated, true
+	}
+
+	return "", false
+
+}
+
+type LocaleOptions struct {
+	// Locales of the service (e.g. "en", "fr", "pt-BR"), in order of
+	// preference.
+	Supported []string
+	// Defaults to the first supported locale.
+	Default string
+	// Translates the error messages when set.
+	Catalog Catalog
+}
+
+type localeContextKey struct{}
+
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext returns the locale negotiated by LocaleMiddleware, empty
+// outside of it.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	return locale
+}
+
+// LocaleMiddleware negotiates the locale of the request from its
+// Accept-Language header, sets it in the context and in the
+// Content-Language header of the response, and translates the error
+// messages with the catalog.
+func LocaleMiddleware(opts LocaleOptions) Middleware {
+
+	if len(opts.Default) == 0 && len(opts.Supported) > 0 {
+		opts.Default = opts.Supported[0]
+	}
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			locale := NegotiateLocale(req.Header("Accept-Language"), opts.Supported, opts.Default)
+
+			err := next(ContextWithLocale(ctx, locale), req, res)
+
+			if len(locale) == 0 {
+				return err
+			}
+
+			if len(res.Header("Content-Language")) == 0 {
+				res.SetHeader("Content-Language", locale)
+			}
+
+			if opts.Catalog != nil && res.StatusCode >= 400 && !res.IsBase64Encoded {
+				if translated, ok := opts.Catalog.Translate(locale, CodeFromHTTPStatus(res.StatusCode), res.Body); ok {
+					res.Body = translated
+				}
+			}
+
+			return err
+
+		}
+
+	}
+
+}
+
+// NegotiateLocale returns the supported locale best matching an
+// Accept-Language header, by quality then order. Ranges match the locales
+// they prefix (e.g. "pt" matches "pt-BR") and locales match their base
+// language range (e.g. "fr-CA" matches "fr").
+func NegotiateLocale(acceptLanguage string, supported []string, fallback string) string {
+
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+
+	ranges := []languageRange{}
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if len(tag) == 0 {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, languageRange{tag: strings.ToLower(tag), quality: quality})
+		}
+
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, r := range ranges {
+
+		if r.tag == "*" {
+			return fallback
+		}
+
+		for _, locale := range supported {
+			if strings.ToLower(locale) == r.tag {
+				return locale
+			}
+		}
+
+		for _, locale := range supported {
+
+			lower := strings.ToLower(locale)
+
+			if strings.HasPrefix(lower, r.tag+"-") || strings.HasPrefix(r.tag, lower+"-") {
+				return locale
+			}
+
+		}
+
+	}
+
+	return fallback
+
+}
@@ -0,0 +1,70 @@
+package lambda
+
+import "testing"
+
+func TestNegotiateLocale(t *testing.T) {
+
+	supported := []string{"en", "fr", "pt-BR"}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{
+			name: "no header",
+			want: "en",
+		},
+		{
+			name:           "higher quality first",
+			acceptLanguage: "fr;q=0.5, pt-BR;q=0.9",
+			want:           "pt-BR",
+		},
+		{
+			name:           "equal quality in order",
+			acceptLanguage: "fr;q=0.8, pt-BR;q=0.8",
+			want:           "fr",
+		},
+		{
+			name:           "implicit quality of 1",
+			acceptLanguage: "fr;q=0.9, pt-BR",
+			want:           "pt-BR",
+		},
+		{
+			name:           "zero quality excluded",
+			acceptLanguage: "fr;q=0, de",
+			want:           "en",
+		},
+		{
+			name:           "malformed quality defaults to 1",
+			acceptLanguage: "fr;q=0.9, pt-BR;q=abc",
+			want:           "pt-BR",
+		},
+		{
+			name:           "range prefixing a locale",
+			acceptLanguage: "de, PT;q=0.7",
+			want:           "pt-BR",
+		},
+		{
+			name:           "locale matching its base range",
+			acceptLanguage: "fr-CA, en;q=0.5",
+			want:           "fr",
+		},
+		{
+			name:           "wildcard",
+			acceptLanguage: "de, *;q=0.5, fr;q=0.1",
+			want:           "en",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			if got := NegotiateLocale(test.acceptLanguage, supported, "en"); got != test.want {
+				t.Errorf("NegotiateLocale(%q) = %q, want %q", test.acceptLanguage, got, test.want)
+			}
+
+		})
+	}
+
+}
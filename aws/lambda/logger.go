@@ -0,0 +1,75 @@
+package lambda
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/protomesh/go-app"
+)
+
+const (
+	TraceIdHeader = "X-Amzn-Trace-Id"
+	TenantHeader  = "X-Tenant-Id"
+)
+
+type loggerContextKey struct{}
+
+func ContextWithLogger(ctx context.Context, log app.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, log)
+}
+
+// LoggerFromContext returns the invocation logger set by HandleLambda, a
+// logger discarding every entry is returned outside of an invocation.
+func LoggerFromContext(ctx context.Context) app.Logger {
+
+	if log, ok := ctx.Value(loggerContextKey{}).(app.Logger); ok {
+		return log
+	}
+
+	return nopLogger{}
+
+}
+
+// invocationLogger derives a child logger with the correlation fields of
+// the invocation.
+func invocationLogger(ctx context.Context, log app.Logger, proxyReq *events.APIGatewayProxyRequest) app.Logger {
+
+	kv := []interface{}{}
+
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		kv = append(kv, "aws_request_id", lc.AwsRequestID)
+	}
+
+	if requestId := RequestIdFromContext(ctx); len(requestId) > 0 {
+		kv = append(kv, "request_id", requestId)
+	}
+
+	if gatewayId := proxyReq.RequestContext.RequestID; len(gatewayId) > 0 && gatewayId != RequestIdFromContext(ctx) {
+		kv = append(kv, "gateway_request_id", gatewayId)
+	}
+
+	req := &Request{APIGatewayProxyRequest: proxyReq}
+
+	if traceId, ok := ctx.Value("x-amzn-trace-id").(string); ok && len(traceId) > 0 {
+		kv = append(kv, "trace_id", traceId)
+	} else if traceId := req.Header(TraceIdHeader); len(traceId) > 0 {
+		kv = append(kv, "trace_id", traceId)
+	}
+
+	if tenant := req.Header(TenantHeader); len(tenant) > 0 {
+		kv = append(kv, "tenant", tenant)
+	}
+
+	return log.With(kv...)
+
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(message string, kv ...interface{}) {}
+func (nopLogger) Info(message string, kv ...interface{})  {}
+func (nopLogger) Warn(message string, kv ...interface{})  {}
+func (nopLogger) Error(message string, kv ...interface{}) {}
+func (nopLogger) Panic(message string, kv ...interface{}) { panic(message) }
+func (n nopLogger) With(kv ...interface{}) app.Logger     { return n }
@@ -0,0 +1,40 @@
+package lambda
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// messagePool reuses the input messages of a method when the controller
+// allows it.
+type messagePool struct {
+	typ  protoreflect.MessageType
+	pool sync.Pool
+}
+
+func newMessagePool(prototype proto.Message) *messagePool {
+
+	p := &messagePool{
+		typ: prototype.ProtoReflect().Type(),
+	}
+
+	p.pool.New = func() interface{} {
+		return p.typ.New().Interface()
+	}
+
+	return p
+
+}
+
+func (p *messagePool) get() proto.Message {
+	return p.pool.Get().(proto.Message)
+}
+
+func (p *messagePool) put(m proto.Message) {
+
+	proto.Reset(m)
+	p.pool.Put(m)
+
+}
@@ -0,0 +1,107 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type benchService struct{}
+
+func (benchService) Echo(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	return in, nil
+}
+
+var benchServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protomesh.bench.v1.Bench",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Echo",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+
+				in := new(structpb.Struct)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/protomesh.bench.v1.Bench/Echo"}
+
+				return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(benchService).Echo(ctx, req.(*structpb.Struct))
+				})
+
+			},
+		},
+	},
+}
+
+// BenchmarkDispatch compares the pooled inputs of ReuseMessages with the
+// allocation of an input per request.
+func BenchmarkDispatch(b *testing.B) {
+
+	methods, err := newGrpcMethods(benchServiceDesc, benchService{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	input, err := structpb.NewStruct(map[string]interface{}{
+		"tenant":   "acme",
+		"order_id": "4b1d6f3e",
+		"items":    []interface{}{"sku-1", "sku-2", "sku-3"},
+		"total":    129.9,
+		"express":  true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	body, err := proto.Marshal(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := &Request{APIGatewayProxyRequest: &events.APIGatewayProxyRequest{
+		HTTPMethod:      http.MethodPost,
+		Path:            methods[0].route.Key,
+		Headers:         map[string]string{"content-type": "application/grpc+proto"},
+		Body:            base64.StdEncoding.EncodeToString(body),
+		IsBase64Encoded: true,
+	}}
+
+	interceptor := chainUnaryInterceptors(nil)
+
+	for _, bc := range []struct {
+		name  string
+		reuse bool
+	}{
+		{"Pooled", true},
+		{"PerRequest", false},
+	} {
+
+		b.Run(bc.name, func(b *testing.B) {
+
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+
+				_, _, release, err := methods[0].invoke(context.Background(), req, interceptor, bc.reuse)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				release()
+
+			}
+
+		})
+
+	}
+
+}
@@ -0,0 +1,55 @@
+package lambda
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// incomingMetadata converts the request headers to gRPC metadata in a
+// single pre-sized map. API Gateway fills both header maps with the same
+// headers, the single value ones are only used for keys missing from the
+// multi value map so values aren't duplicated.
+func incomingMetadata(req *Request, excluded []string) metadata.MD {
+
+	md := make(metadata.MD, len(req.MultiValueHeaders)+len(req.Headers))
+
+	for k, v := range req.MultiValueHeaders {
+
+		k = strings.ToLower(k)
+
+		if isExcludedHeader(k, excluded) {
+			continue
+		}
+
+		md[k] = append(md[k], v...)
+
+	}
+
+	for k, v := range req.Headers {
+
+		k = strings.ToLower(k)
+
+		if _, ok := md[k]; ok || isExcludedHeader(k, excluded) {
+			continue
+		}
+
+		md[k] = []string{v}
+
+	}
+
+	return md
+
+}
+
+func isExcludedHeader(key string, excluded []string) bool {
+
+	for _, header := range excluded {
+		if strings.EqualFold(header, key) {
+			return true
+		}
+	}
+
+	return false
+
+}
@@ -0,0 +1,15 @@
+package lambda
+
+// Middleware wraps the handler matched for an invocation, it runs for plain
+// handlers and gRPC methods alike.
+type Middleware func(next Handler) Handler
+
+func chainMiddlewares(middlewares []Middleware, handler Handler) Handler {
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+
+}
@@ -0,0 +1,208 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MultipartOptions struct {
+	// Maximum number of parts, defaults to 16.
+	MaxParts int
+	// Maximum size of a single part, defaults to 5MiB.
+	MaxPartSize int64
+}
+
+// Part is a decoded part of a multipart/form-data request.
+type Part struct {
+	Name        string
+	FileName    string
+	ContentType string
+	Header      textproto.MIMEHeader
+	Data        []byte
+}
+
+// MultipartReader returns a reader over the parts of a multipart/form-data
+// request, decoding base64 bodies on the fly.
+func (r *Request) MultipartReader() (*multipart.Reader, error) {
+
+	mediaType, params, err := mime.ParseMediaType(r.Header("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, status.Errorf(codes.InvalidArgument, "Request is not multipart: %s", r.Header("Content-Type"))
+	}
+
+	boundary, ok := params["boundary"]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "Multipart request without boundary")
+	}
+
+	var body io.Reader = strings.NewReader(r.Body)
+	if r.IsBase64Encoded {
+		body = base64.NewDecoder(base64.RawStdEncoding, strings.NewReader(strings.TrimRight(r.Body, "=")))
+	}
+
+	return multipart.NewReader(body, boundary), nil
+
+}
+
+// ReadMultipart reads every part of a multipart/form-data request within
+// the limits of opts.
+func (r *Request) ReadMultipart(opts MultipartOptions) ([]*Part, error) {
+
+	if opts.MaxParts <= 0 {
+		opts.MaxParts = 16
+	}
+
+	if opts.MaxPartSize <= 0 {
+		opts.MaxPartSize = 5 << 20
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []*Part{}
+
+	for {
+
+		mpart, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid multipart body: %v", err)
+		}
+
+		if len(parts) == opts.MaxParts {
+			return nil, status.Errorf(codes.ResourceExhausted, "Multipart request has more than %d parts", opts.MaxParts)
+		}
+
+		data, err := readPart(mpart, opts.MaxPartSize)
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, &Part{
+			Name:        mpart.FormName(),
+			FileName:    mpart.FileName(),
+			ContentType: mpart.Header.Get("Content-Type"),
+			Header:      mpart.Header,
+			Data:        data,
+		})
+
+	}
+
+}
+
+func readPart(mpart *multipart.Part, maxSize int64) ([]byte, error) {
+
+	data, err := io.ReadAll(io.LimitReader(mpart, maxSize+1))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to read part %s: %v", mpart.FormName(), err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, status.Errorf(codes.ResourceExhausted, "Part %s exceeds %d bytes", mpart.FormName(), maxSize)
+	}
+
+	return data, nil
+
+}
+
+// UploadPart puts a part to a presigned S3 url (see awsapi.Client.PresignS3)
+// as it is read from the request, so handlers don't have to keep uploads.
+// The part is streamed with its Content-Length when it declares one, chunked
+// otherwise, and fails with ResourceExhausted beyond maxSize.
+func UploadPart(ctx context.Context, httpClient *http.Client, presignedUrl string, mpart *multipart.Part, maxSize int64) error {
+
+	body := &partReader{part: mpart, max: maxSize}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, presignedUrl, body)
+	if err != nil {
+		return err
+	}
+
+	if length, err := strconv.ParseInt(mpart.Header.Get("Content-Length"), 10, 64); err == nil && length >= 0 {
+
+		if length > maxSize {
+			return status.Errorf(codes.ResourceExhausted, "Part %s exceeds %d bytes", mpart.FormName(), maxSize)
+		}
+
+		req.Body = io.NopCloser(io.LimitReader(body, length))
+		req.ContentLength = length
+
+	} else {
+		// Unknown length, sent chunked.
+		req.ContentLength = -1
+	}
+
+	if contentType := mpart.Header.Get("Content-Type"); len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if body.exceeded {
+		if err == nil {
+			res.Body.Close()
+		}
+		return status.Errorf(codes.ResourceExhausted, "Part %s exceeds %d bytes", mpart.FormName(), maxSize)
+	}
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "Failed to upload part %s: %v", mpart.FormName(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		body, _ := io.ReadAll(res.Body)
+		return status.Errorf(codes.Internal, "Upload of part %s returned %d: %s", mpart.FormName(), res.StatusCode, body)
+	}
+
+	return nil
+
+}
+
+// partReader reads a part, failing once it exceeds max bytes so a streamed
+// upload never sends more.
+type partReader struct {
+	part     *multipart.Part
+	max      int64
+	read     int64
+	exceeded bool
+}
+
+func (r *partReader) Read(p []byte) (int, error) {
+
+	if r.exceeded {
+		return 0, status.Errorf(codes.ResourceExhausted, "Part %s exceeds %d bytes", r.part.FormName(), r.max)
+	}
+
+	// Reads a byte past max to detect the larger parts.
+	if remaining := r.max + 1 - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.part.Read(p)
+	r.read += int64(n)
+
+	if r.read > r.max {
+		r.exceeded = true
+		return 0, status.Errorf(codes.ResourceExhausted, "Part %s exceeds %d bytes", r.part.FormName(), r.max)
+	}
+
+	return n, err
+
+}
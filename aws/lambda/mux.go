@@ -0,0 +1,162 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ForwardedPrefixHeader carries the base path a Mux stripped from the path
+// of a request.
+const ForwardedPrefixHeader = "X-Forwarded-Prefix"
+
+// InvocationHandler serves API Gateway proxy invocations, e.g. a
+// Controller whatever its dependencies.
+type InvocationHandler interface {
+	HandleLambda(ctx context.Context, proxyReq *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
+}
+
+// Mux bundles several controllers in one function, each mounted under its
+// own base path with its own middlewares, interceptors and dependencies.
+// The mounted controllers see the paths without their base path, so their
+// matcher has no base path.
+//
+//	mux := lambda.NewMux()
+//	mux.Mount("/orders", ordersController)
+//	mux.Mount("/billing", billingController)
+//	awslambda.Start(mux.HandleLambda)
+type Mux struct {
+	mounts []*mount
+}
+
+type mount struct {
+	basePath string
+	handler  InvocationHandler
+}
+
+var _ LocalInvoker = &Mux{}
+
+func NewMux() *Mux {
+	return &Mux{}
+}
+
+// Mount serves the requests under basePath (e.g. "/orders") with handler,
+// the longest base path matching a request wins. An empty or "/" base path
+// serves the requests no other mount matches.
+func (m *Mux) Mount(basePath string, handler InvocationHandler) error {
+
+	basePath = "/" + strings.Trim(basePath, "/")
+	if basePath == "/" {
+		basePath = ""
+	}
+
+	for _, existing := range m.mounts {
+		if existing.basePath == basePath {
+			return status.Errorf(codes.AlreadyExists, "Base path %q already mounted", basePath)
+		}
+	}
+
+	m.mounts = append(m.mounts, &mount{basePath: basePath, handler: handler})
+
+	sort.SliceStable(m.mounts, func(i, j int) bool {
+		return len(m.mounts[i].basePath) > len(m.mounts[j].basePath)
+	})
+
+	return nil
+
+}
+
+// HandleLambda forwards the request to the mount matching its path, with
+// the base path stripped into ForwardedPrefixHeader. Requests matching no
+// mount are answered with 404.
+func (m *Mux) HandleLambda(ctx context.Context, proxyReq *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+	for _, mnt := range m.mounts {
+
+		rest, ok := stripBasePath(proxyReq.Path, mnt.basePath)
+		if !ok {
+			continue
+		}
+
+		forwarded := *proxyReq
+		forwarded.Path = rest
+
+		// The prefix sent by the client never reaches the controllers, in
+		// any case, even at the root mount.
+		forwarded.Headers = withoutHeader(proxyReq.Headers, ForwardedPrefixHeader)
+		forwarded.MultiValueHeaders = withoutMultiValueHeader(proxyReq.MultiValueHeaders, ForwardedPrefixHeader)
+
+		if len(mnt.basePath) > 0 {
+			forwarded.Headers[ForwardedPrefixHeader] = mnt.basePath
+			forwarded.MultiValueHeaders[ForwardedPrefixHeader] = []string{mnt.basePath}
+		}
+
+		return mnt.handler.HandleLambda(ctx, &forwarded)
+
+	}
+
+	LoggerFromContext(ctx).Warn("No mount for path", "path", proxyReq.Path)
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusNotFound,
+		Body:       "No service mounted for " + proxyReq.Path,
+	}, nil
+
+}
+
+// Serves reports whether a mounted controller serves method in process.
+func (m *Mux) Serves(method string) bool {
+	return m.local(method) != nil
+}
+
+// InvokeLocal calls method on the mounted controller serving it, see
+// Controller.InvokeLocal.
+func (m *Mux) InvokeLocal(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+
+	local := m.local(method)
+	if local == nil {
+		return status.Errorf(codes.Unimplemented, "Method %s not registered", method)
+	}
+
+	return local.InvokeLocal(ctx, method, args, reply, opts...)
+
+}
+
+func (m *Mux) local(method string) LocalInvoker {
+
+	for _, mnt := range m.mounts {
+		if local, ok := mnt.handler.(LocalInvoker); ok && local.Serves(method) {
+			return local
+		}
+	}
+
+	return nil
+
+}
+
+// stripBasePath returns the path under basePath, false when path is not
+// under it.
+func stripBasePath(path, basePath string) (string, bool) {
+
+	if len(basePath) == 0 {
+		return path, true
+	}
+
+	rest, ok := strings.CutPrefix(path, basePath)
+	if !ok || (len(rest) > 0 && rest[0] != '/') {
+		return "", false
+	}
+
+	if len(rest) == 0 {
+		rest = "/"
+	}
+
+	return rest, true
+
+}
@@ -0,0 +1,30 @@
+package lambda
+
+import (
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// requestPeer returns the caller of a request as a gRPC server would see
+// it: the source IP reported by API Gateway, and TLS authentication info
+// when the request reached API Gateway over TLS. The port of the caller is
+// unknown.
+func requestPeer(sourceIP string, secure bool) *peer.Peer {
+
+	p := &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP(sourceIP)},
+	}
+
+	// API Gateway terminates TLS, the client certificates of mutual TLS
+	// aren't part of the proxy events.
+	if secure {
+		p.AuthInfo = credentials.TLSInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		}
+	}
+
+	return p
+
+}
@@ -0,0 +1,176 @@
+package lambda
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Picker spreads calls over equivalent targets, balancers use it to order
+// the targets they consider alike (e.g. healthy targets of a region).
+type Picker interface {
+	Order(targets []*Target) []*Target
+	Started(target *Target)
+	Report(target *Target, err error, latency time.Duration)
+}
+
+// PickerBalancer balances calls with a Picker, failing over to the next
+// target on FailoverCodes (Unavailable and DeadlineExceeded by default).
+type PickerBalancer struct {
+	Picker        Picker
+	FailoverCodes []codes.Code
+}
+
+func (b *PickerBalancer) Order(method string, targets []*Target) []*Target {
+	return b.Picker.Order(targets)
+}
+
+func (b *PickerBalancer) Failover(err error) bool {
+	return isFailoverCode(b.FailoverCodes, err)
+}
+
+func (b *PickerBalancer) Started(target *Target) {
+	b.Picker.Started(target)
+}
+
+func (b *PickerBalancer) Report(target *Target, err error, latency time.Duration) {
+	b.Picker.Report(target, err, latency)
+}
+
+// RoundRobinPicker rotates the targets on every call.
+type RoundRobinPicker struct {
+	next uint64
+}
+
+func (p *RoundRobinPicker) Order(targets []*Target) []*Target {
+
+	ordered := make([]*Target, 0, len(targets))
+
+	if len(targets) == 0 {
+		return ordered
+	}
+
+	start := int((atomic.AddUint64(&p.next, 1) - 1) % uint64(len(targets)))
+
+	ordered = append(ordered, targets[start:]...)
+	ordered = append(ordered, targets[:start]...)
+
+	return ordered
+
+}
+
+func (p *RoundRobinPicker) Started(target *Target) {}
+
+func (p *RoundRobinPicker) Report(target *Target, err error, latency time.Duration) {}
+
+// LeastPendingPicker prefers the targets with the fewest calls in flight.
+type LeastPendingPicker struct {
+	lock    sync.Mutex
+	pending map[string]int
+}
+
+func (p *LeastPendingPicker) Order(targets []*Target) []*Target {
+
+	ordered := make([]*Target, len(targets))
+	copy(ordered, targets)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return p.pending[TargetKey(ordered[i])] < p.pending[TargetKey(ordered[j])]
+	})
+
+	return ordered
+
+}
+
+func (p *LeastPendingPicker) Started(target *Target) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.pending == nil {
+		p.pending = make(map[string]int)
+	}
+
+	p.pending[TargetKey(target)]++
+
+}
+
+func (p *LeastPendingPicker) Report(target *Target, err error, latency time.Duration) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if key := TargetKey(target); p.pending[key] > 0 {
+		p.pending[key]--
+	}
+
+}
+
+// EWMALatencyPicker prefers the targets with the lowest moving average of
+// latency, targets without calls yet are tried first.
+type EWMALatencyPicker struct {
+	// Weight of the last call in the average, defaults to 0.3.
+	Decay float64
+	// Latency accounted for failed calls, defaults to 5 seconds.
+	ErrorPenalty time.Duration
+
+	lock    sync.Mutex
+	latency map[string]float64
+}
+
+func (p *EWMALatencyPicker) Order(targets []*Target) []*Target {
+
+	ordered := make([]*Target, len(targets))
+	copy(ordered, targets)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return p.latency[TargetKey(ordered[i])] < p.latency[TargetKey(ordered[j])]
+	})
+
+	return ordered
+
+}
+
+func (p *EWMALatencyPicker) Started(target *Target) {}
+
+func (p *EWMALatencyPicker) Report(target *Target, err error, latency time.Duration) {
+
+	decay := p.Decay
+	if decay <= 0 {
+		decay = 0.3
+	}
+
+	penalty := p.ErrorPenalty
+	if penalty <= 0 {
+		penalty = 5 * time.Second
+	}
+
+	if err != nil && latency < penalty {
+		latency = penalty
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.latency == nil {
+		p.latency = make(map[string]float64)
+	}
+
+	key := TargetKey(target)
+
+	if prev, ok := p.latency[key]; ok {
+		p.latency[key] = (1-decay)*prev + decay*float64(latency)
+	} else {
+		p.latency[key] = float64(latency)
+	}
+
+}
@@ -0,0 +1,182 @@
+package lambda
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pool bounds the goroutines spawned while handling invocations, it is
+// shared by every fan-out of the controller (batch calls, handlers using
+// PoolFromContext) so small Lambda sizes don't run out of memory.
+type Pool struct {
+	sem chan struct{}
+
+	running    int64
+	queued     int64
+	peakQueued int64
+	completed  int64
+	waitNanos  int64
+}
+
+// PoolStats is a snapshot of the pool usage.
+type PoolStats struct {
+	Size       int           `json:"size"`
+	Running    int64         `json:"running"`
+	Queued     int64         `json:"queued"`
+	PeakQueued int64         `json:"peak_queued"`
+	Completed  int64         `json:"completed"`
+	TotalWait  time.Duration `json:"total_wait"`
+}
+
+type poolContextKey struct{}
+
+type poolWorkerContextKey struct{}
+
+func NewPool(size int) *Pool {
+
+	if size <= 0 {
+		size = 1
+	}
+
+	return &Pool{
+		sem: make(chan struct{}, size),
+	}
+
+}
+
+func ContextWithPool(ctx context.Context, pool *Pool) context.Context {
+	return context.WithValue(ctx, poolContextKey{}, pool)
+}
+
+// PoolFromContext returns the pool of the controller, nil if it has none.
+func PoolFromContext(ctx context.Context) *Pool {
+
+	pool, _ := ctx.Value(poolContextKey{}).(*Pool)
+
+	return pool
+
+}
+
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Size:       cap(p.sem),
+		Running:    atomic.LoadInt64(&p.running),
+		Queued:     atomic.LoadInt64(&p.queued),
+		PeakQueued: atomic.LoadInt64(&p.peakQueued),
+		Completed:  atomic.LoadInt64(&p.completed),
+		TotalWait:  time.Duration(atomic.LoadInt64(&p.waitNanos)),
+	}
+}
+
+// acquire takes a slot, waiting for one unless the caller already runs in
+// the pool: nested fan-outs then run inline instead of deadlocking on the
+// slots held by their parents.
+func (p *Pool) acquire(ctx context.Context) (bool, error) {
+
+	select {
+	case p.sem <- struct{}{}:
+		return true, nil
+	default:
+	}
+
+	if ctx.Value(poolWorkerContextKey{}) == p {
+		return false, nil
+	}
+
+	queued := atomic.AddInt64(&p.queued, 1)
+	defer atomic.AddInt64(&p.queued, -1)
+
+	for {
+		peak := atomic.LoadInt64(&p.peakQueued)
+		if queued <= peak || atomic.CompareAndSwapInt64(&p.peakQueued, peak, queued) {
+			break
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		atomic.AddInt64(&p.waitNanos, int64(time.Since(start)))
+	}()
+
+	select {
+	case p.sem <- struct{}{}:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+}
+
+// Group runs functions on the pool and waits for them.
+type Group struct {
+	pool *Pool
+	ctx  context.Context
+	wg   sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// Group starts a group of functions bound to ctx.
+func (p *Pool) Group(ctx context.Context) *Group {
+	return &Group{
+		pool: p,
+		ctx:  ctx,
+	}
+}
+
+// Go runs fn once a slot is available, blocking the caller meanwhile.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+
+	acquired, err := g.pool.acquire(g.ctx)
+	if err != nil {
+		g.setErr(err)
+		return
+	}
+
+	if !acquired {
+		g.setErr(fn(g.ctx))
+		atomic.AddInt64(&g.pool.completed, 1)
+		return
+	}
+
+	atomic.AddInt64(&g.pool.running, 1)
+	g.wg.Add(1)
+
+	go func() {
+
+		defer func() {
+			atomic.AddInt64(&g.pool.running, -1)
+			atomic.AddInt64(&g.pool.completed, 1)
+			<-g.pool.sem
+			g.wg.Done()
+		}()
+
+		g.setErr(fn(context.WithValue(g.ctx, poolWorkerContextKey{}, g.pool)))
+
+	}()
+
+}
+
+// Wait blocks until every function returned, the first error is returned.
+func (g *Group) Wait() error {
+
+	g.wg.Wait()
+
+	return g.err
+
+}
+
+func (g *Group) setErr(err error) {
+
+	if err == nil {
+		return
+	}
+
+	g.errOnce.Do(func() {
+		g.err = err
+	})
+
+}
@@ -0,0 +1,136 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PrivateProxyRequest is the event of a private REST API, its request
+// context identifies the VPC endpoint the request came through, which
+// events.APIGatewayProxyRequest drops.
+type PrivateProxyRequest struct {
+	events.APIGatewayProxyRequest
+	VPC VPCIdentity
+}
+
+// VPCIdentity is the origin of a request to a private REST API.
+type VPCIdentity struct {
+	// Id of the source VPC (e.g. "vpc-0a1b2c3d").
+	VpcId string
+	// Id of the interface VPC endpoint (e.g. "vpce-0a1b2c3d").
+	EndpointId string
+}
+
+func (r *PrivateProxyRequest) UnmarshalJSON(data []byte) error {
+
+	if err := json.Unmarshal(data, &r.APIGatewayProxyRequest); err != nil {
+		return err
+	}
+
+	var event struct {
+		RequestContext struct {
+			Identity struct {
+				VpcId  string `json:"vpcId"`
+				VpceId string `json:"vpceId"`
+			} `json:"identity"`
+		} `json:"requestContext"`
+	}
+
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+
+	r.VPC = VPCIdentity{
+		VpcId:      event.RequestContext.Identity.VpcId,
+		EndpointId: event.RequestContext.Identity.VpceId,
+	}
+
+	return nil
+
+}
+
+type vpcIdentityContextKey struct{}
+
+func ContextWithVPCIdentity(ctx context.Context, vpc VPCIdentity) context.Context {
+	return context.WithValue(ctx, vpcIdentityContextKey{}, vpc)
+}
+
+// VPCIdentityFromContext returns the origin of a request handled by
+// HandlePrivateLambda, false for the other requests.
+func VPCIdentityFromContext(ctx context.Context) (VPCIdentity, bool) {
+	vpc, ok := ctx.Value(vpcIdentityContextKey{}).(VPCIdentity)
+	return vpc, ok
+}
+
+// HandlePrivateLambda serves the invocations of private REST APIs like
+// HandleLambda, with the VPC identity of the requests in their context.
+//
+//	awslambda.Start(controller.HandlePrivateLambda)
+func (c *Controller[D]) HandlePrivateLambda(ctx context.Context, req *PrivateProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+	if len(req.VPC.VpcId) > 0 || len(req.VPC.EndpointId) > 0 {
+		ctx = ContextWithVPCIdentity(ctx, req.VPC)
+	}
+
+	return c.HandleLambda(ctx, &req.APIGatewayProxyRequest)
+
+}
+
+// VPCPolicy restricts the callers to VPC endpoints or source VPCs, a
+// request is allowed when either list has its origin.
+type VPCPolicy struct {
+	// Interface VPC endpoint ids.
+	Endpoints []string
+	// Source VPC ids.
+	Vpcs []string
+}
+
+// Allows reports whether the policy allows requests from vpc.
+func (p *VPCPolicy) Allows(vpc VPCIdentity) bool {
+
+	for _, id := range p.Endpoints {
+		if len(vpc.EndpointId) > 0 && id == vpc.EndpointId {
+			return true
+		}
+	}
+
+	for _, id := range p.Vpcs {
+		if len(vpc.VpcId) > 0 && id == vpc.VpcId {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// VPCPolicyMiddleware rejects with PermissionDenied the requests whose
+// origin the policy doesn't allow, and the requests without VPC identity
+// (e.g. not served by HandlePrivateLambda). The x-amzn-vpce-id header is
+// ignored, callers can set it.
+func VPCPolicyMiddleware(policy VPCPolicy) Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			vpc, ok := VPCIdentityFromContext(ctx)
+			if !ok || !policy.Allows(vpc) {
+
+				LoggerFromContext(ctx).Warn("Request rejected by VPC policy", "vpc_id", vpc.VpcId, "vpce_id", vpc.EndpointId)
+
+				return res.WriteError(status.Error(codes.PermissionDenied, "Caller VPC not allowed"))
+
+			}
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
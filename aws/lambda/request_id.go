@@ -0,0 +1,55 @@
+package lambda
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+// RequestIdHeader carries the mesh-wide id of a request, generated by the
+// first controller and propagated by the clients and envelopes.
+const RequestIdHeader = "X-Request-Id"
+
+type requestIdContextKey struct{}
+
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, requestId)
+}
+
+// RequestIdFromContext returns the request id set by HandleLambda, empty
+// outside of an invocation.
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey{}).(string)
+	return requestId
+}
+
+// ensureRequestId returns the request id of proxyReq, generating it when
+// absent. Generated ids are set as header so they reach the incoming
+// metadata too.
+func ensureRequestId(proxyReq *events.APIGatewayProxyRequest, ids clock.IdGenerator) string {
+
+	req := &Request{APIGatewayProxyRequest: proxyReq}
+
+	if requestId := req.Header(RequestIdHeader); len(requestId) > 0 {
+		return requestId
+	}
+
+	requestId := proxyReq.RequestContext.RequestID
+
+	if len(requestId) == 0 {
+		id := make([]byte, 16)
+		ids.Read(id)
+		requestId = hex.EncodeToString(id)
+	}
+
+	if proxyReq.Headers == nil {
+		proxyReq.Headers = make(map[string]string)
+	}
+
+	proxyReq.Headers[RequestIdHeader] = requestId
+
+	return requestId
+
+}
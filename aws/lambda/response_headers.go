@@ -0,0 +1,114 @@
+package lambda
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Header returns the first value of a response header, matched case
+// insensitively.
+func (r *Response) Header(name string) string {
+
+	for k, v := range r.MultiValueHeaders {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0]
+		}
+	}
+
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return ""
+
+}
+
+// SetHeader replaces every value of a response header. API Gateway merges
+// both header maps, the multi value one winning, so the header is removed
+// from both whatever its case.
+func (r *Response) SetHeader(name, value string) {
+
+	r.DelHeader(name)
+
+	if r.Headers == nil {
+		r.Headers = make(map[string]string)
+	}
+
+	r.Headers[http.CanonicalHeaderKey(name)] = value
+
+}
+
+// AddHeader appends a value to a response header, the values already set
+// are kept.
+func (r *Response) AddHeader(name, value string) {
+
+	values := []string{}
+
+	for k, v := range r.MultiValueHeaders {
+		if strings.EqualFold(k, name) {
+			values = append(values, v...)
+			delete(r.MultiValueHeaders, k)
+		}
+	}
+
+	// The single value is shadowed by the multi value header, it moves
+	// there.
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, name) {
+			values = append([]string{v}, values...)
+			delete(r.Headers, k)
+		}
+	}
+
+	if r.MultiValueHeaders == nil {
+		r.MultiValueHeaders = make(map[string][]string)
+	}
+
+	r.MultiValueHeaders[http.CanonicalHeaderKey(name)] = append(values, value)
+
+}
+
+// DelHeader removes a response header from both header maps.
+func (r *Response) DelHeader(name string) {
+
+	for k := range r.Headers {
+		if strings.EqualFold(k, name) {
+			delete(r.Headers, k)
+		}
+	}
+
+	for k := range r.MultiValueHeaders {
+		if strings.EqualFold(k, name) {
+			delete(r.MultiValueHeaders, k)
+		}
+	}
+
+}
+
+// Redirect answers with a redirection to url, code must be one of 301,
+// 302, 303, 307 or 308 (303 See Other for the redirections following a
+// POST).
+//
+//	return res.Redirect("/orders/"+id, http.StatusSeeOther)
+func (r *Response) Redirect(url string, code int) error {
+
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return status.Errorf(codes.Internal, "Invalid redirect status %d", code)
+	}
+
+	r.SetHeader("Location", url)
+
+	r.StatusCode = code
+	r.Body = ""
+	r.IsBase64Encoded = false
+
+	return nil
+
+}
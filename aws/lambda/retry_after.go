@@ -0,0 +1,216 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// WithRetryAfter attaches the delay after which a call failing with err is
+// worth retrying, as a RetryInfo detail. The controllers answer the
+// ResourceExhausted and Unavailable errors carrying it with Retry-After.
+//
+//	return lambda.WithRetryAfter(status.Error(codes.ResourceExhausted, "Rate limit exceeded"), wait)
+func WithRetryAfter(err error, delay time.Duration) error {
+
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.New(codes.Unknown, err.Error())
+	}
+
+	detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if detailsErr != nil {
+		return err
+	}
+
+	return detailed.Err()
+
+}
+
+// RetryAfterFromError returns the RetryInfo delay of err, false when it
+// has none.
+func RetryAfterFromError(err error) (time.Duration, bool) {
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, false
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+
+	return 0, false
+
+}
+
+// isRetryableCode reports whether the responses of code carry Retry-After.
+func isRetryableCode(code codes.Code) bool {
+	return code == codes.ResourceExhausted || code == codes.Unavailable
+}
+
+// formatRetryAfter returns the Retry-After of delay, in whole seconds
+// rounded up.
+func formatRetryAfter(delay time.Duration) string {
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return strconv.Itoa(int((delay + time.Second - 1) / time.Second))
+
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, in seconds or
+// as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+
+		return 0, true
+
+	}
+
+	return 0, false
+
+}
+
+// setRetryAfter sets the Retry-After of a ResourceExhausted or Unavailable
+// response from the RetryInfo of err, unless the handler set the header.
+func setRetryAfter(res *Response, st *status.Status) {
+
+	if !isRetryableCode(st.Code()) || len(res.Header("Retry-After")) > 0 {
+		return
+	}
+
+	if delay, ok := RetryAfterFromError(st.Err()); ok {
+		res.SetHeader("Retry-After", formatRetryAfter(delay))
+	}
+
+}
+
+// setDefaultRetryAfter sets the Retry-After hint of the controller on the
+// 429 and 503 responses without one.
+func (c *Controller[D]) setDefaultRetryAfter(res *Response) {
+
+	if c.RetryAfter <= 0 || len(res.Header("Retry-After")) > 0 {
+		return
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+		res.SetHeader("Retry-After", formatRetryAfter(c.RetryAfter))
+	}
+
+}
+
+// responseError returns the error of an HTTP response, with the delay of
+// its Retry-After as RetryInfo.
+func responseError(ctx context.Context, statusCode int, retryAfter string, message string) error {
+
+	code := CodeFromHTTPStatus(statusCode)
+	err := status.Error(code, message)
+
+	if !isRetryableCode(code) {
+		return err
+	}
+
+	if delay, ok := parseRetryAfter(retryAfter, clock.FromContext(ctx).Now()); ok {
+		return WithRetryAfter(err, delay)
+	}
+
+	return err
+
+}
+
+// RetryPolicy retries the calls failing with ResourceExhausted or
+// Unavailable, after the delay the server asked for or an exponential
+// backoff.
+type RetryPolicy struct {
+	// Attempts of a call including the first one, defaults to 3.
+	MaxAttempts int
+	// Delay before the first retry of the calls without retry info, doubled
+	// on each retry. Defaults to 100 milliseconds.
+	Backoff time.Duration
+	// Longest delay waited, the calls asking for longer fail right away.
+	// Defaults to 10 seconds.
+	MaxDelay time.Duration
+}
+
+// RetryInterceptor retries the calls as the policy states, unless the
+// context expires before the delay.
+//
+//	conn.UnaryInterceptors = append(conn.UnaryInterceptors, lambda.RetryInterceptor(lambda.RetryPolicy{MaxAttempts: 4}))
+func RetryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	backoff := policy.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	maxDelay := policy.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		for attempt := 1; ; attempt++ {
+
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= maxAttempts || !isRetryableCode(status.Code(err)) {
+				return err
+			}
+
+			delay, ok := RetryAfterFromError(err)
+			if !ok {
+				delay = backoff << (attempt - 1)
+			}
+
+			if delay > maxDelay {
+				return err
+			}
+
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return err
+			}
+
+			LoggerFromContext(ctx).Debug("Retrying call", "method", method, "attempt", attempt, "delay", delay, "error", err)
+
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-time.After(delay):
+			}
+
+		}
+
+	}
+
+}
@@ -0,0 +1,121 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+var (
+	DuplicateRouteError = errors.New("DuplicateRoute")
+)
+
+// ConflictPolicy defines what happens when a key is registered twice.
+type ConflictPolicy int
+
+const (
+	// Replace the previous handler silently, the default.
+	ConflictOverride ConflictPolicy = iota
+	// Panic on registration, failing fast when the routes are registered
+	// on init.
+	ConflictPanic
+	// Return DuplicateRouteError from the Try registrations and keep the
+	// first handler.
+	ConflictError
+)
+
+type RouteKind string
+
+const (
+	RouteKindHandler      RouteKind = "handler"
+	RouteKindUnary        RouteKind = "unary"
+	RouteKindServerStream RouteKind = "server_stream"
+	RouteKindClientStream RouteKind = "client_stream"
+	RouteKindBidiStream   RouteKind = "bidi_stream"
+	RouteKindAlias        RouteKind = "alias"
+)
+
+// Route describes a registered handler.
+type Route struct {
+	Key     string    `json:"key"`
+	Kind    RouteKind `json:"kind"`
+	Service string    `json:"service,omitempty"`
+	Method  string    `json:"method,omitempty"`
+	// Handler set of the route, see RegisterVersionedService.
+	Version string `json:"version,omitempty"`
+	// Key serving the requests of an alias.
+	Target string `json:"target,omitempty"`
+	// Status of the successful calls declared by the success_status option
+	// of the method, 200 when unset.
+	SuccessStatus int `json:"success_status,omitempty"`
+	// Location of the registration call, useful to track down conflicts.
+	Source string `json:"source"`
+}
+
+// Routes returns the registered routes sorted by key.
+func (c *Controller[D]) Routes() []Route {
+
+	routes := make([]Route, 0, len(c.routes))
+
+	for _, route := range c.routes {
+		routes = append(routes, *route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Key < routes[j].Key
+	})
+
+	return routes
+
+}
+
+func (c *Controller[D]) registerRoute(route Route, handler Handler) error {
+
+	route.Source = registrationSource()
+
+	if prev, ok := c.routes[route.Key]; ok {
+
+		switch c.ConflictPolicy {
+
+		case ConflictPanic:
+			panic(fmt.Sprintf("%s: %s registered at %s and %s", DuplicateRouteError, route.Key, prev.Source, route.Source))
+
+		case ConflictError:
+			return fmt.Errorf("%w: %s already registered at %s", DuplicateRouteError, route.Key, prev.Source)
+
+		}
+
+	}
+
+	c.routes[route.Key] = &route
+	c.handlers[route.Key] = handler
+
+	return nil
+
+}
+
+// registrationSource returns the first caller outside of this package.
+func registrationSource() string {
+
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+
+		frame, more := frames.Next()
+
+		if !strings.HasPrefix(frame.Function, "github.com/protomesh/protomesh-go/aws/lambda.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+
+		if !more {
+			return "unknown"
+		}
+
+	}
+
+}
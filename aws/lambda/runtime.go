@@ -0,0 +1,422 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Host of the Lambda Runtime API, set by Lambda.
+	RuntimeAPIEnv = "AWS_LAMBDA_RUNTIME_API"
+
+	runtimeAPIVersion = "2018-06-01"
+
+	// Content type of the streamed Function URL responses: a JSON prelude
+	// with the status and headers, 8 null bytes, then the body.
+	httpIntegrationContentType = "application/vnd.awslambda.http-integration-response"
+)
+
+// RuntimeResult is the response of an invocation.
+type RuntimeResult struct {
+	Body io.Reader
+	// Defaults to application/json.
+	ContentType string
+	// Streams the body as it's read instead of buffering it, for the
+	// functions invoked in RESPONSE_STREAM mode.
+	Streaming bool
+}
+
+// RuntimeHandler handles the raw payload of an invocation.
+type RuntimeHandler func(ctx context.Context, payload []byte) (*RuntimeResult, error)
+
+// Runtime serves the invocations by long polling the Lambda Runtime API
+// instead of aws-lambda-go's Start: the package controls the marshaling,
+// streams the responses of any handler, and runs AfterResponse once the
+// invocation is answered but before the next event is polled, which would
+// freeze the execution environment.
+//
+//	(&lambda.Runtime{}).Start(lambda.ProxyRuntimeHandler(controller))
+type Runtime struct {
+	// Defaults to RuntimeAPIEnv.
+	API string
+	// Defaults to a client without timeout, polling the next event blocks
+	// until there is one.
+	HttpClient *http.Client
+	// Run after each response is sent (e.g. to flush telemetry).
+	AfterResponse []func(ctx context.Context)
+}
+
+type runtimeInvocation struct {
+	id      string
+	headers http.Header
+	payload []byte
+}
+
+// Start serves the invocations, it never returns.
+func (r *Runtime) Start(handler RuntimeHandler) {
+
+	err := r.Serve(context.Background(), handler)
+
+	fmt.Fprintln(os.Stderr, "Runtime stopped:", err)
+	os.Exit(1)
+
+}
+
+// Serve serves the invocations until ctx is done or the Runtime API
+// fails.
+func (r *Runtime) Serve(ctx context.Context, handler RuntimeHandler) error {
+
+	for {
+
+		invocation, err := r.next(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := r.handle(ctx, invocation, handler); err != nil {
+			return err
+		}
+
+	}
+
+}
+
+func (r *Runtime) url(path string) string {
+
+	api := r.API
+	if len(api) == 0 {
+		api = os.Getenv(RuntimeAPIEnv)
+	}
+
+	return "http://" + api + "/" + runtimeAPIVersion + path
+
+}
+
+func (r *Runtime) client() *http.Client {
+
+	if r.HttpClient != nil {
+		return r.HttpClient
+	}
+
+	return http.DefaultClient
+
+}
+
+func (r *Runtime) next(ctx context.Context) (*runtimeInvocation, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url("/runtime/invocation/next"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to poll next invocation: %w", err)
+	}
+	defer res.Body.Close()
+
+	payload, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read next invocation: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Runtime API returned %d polling next invocation: %s", res.StatusCode, payload)
+	}
+
+	return &runtimeInvocation{
+		id:      res.Header.Get("Lambda-Runtime-Aws-Request-Id"),
+		headers: res.Header,
+		payload: payload,
+	}, nil
+
+}
+
+// handle calls the handler with the context of the invocation and posts
+// its result, only the failures of the Runtime API are returned.
+func (r *Runtime) handle(ctx context.Context, invocation *runtimeInvocation, handler RuntimeHandler) error {
+
+	ctx, cancel, err := invocationContext(ctx, invocation)
+	if err != nil {
+		return r.fail(invocation, err)
+	}
+	defer cancel()
+
+	result, err := callRuntimeHandler(ctx, invocation.payload, handler)
+	if err != nil {
+		return r.fail(invocation, err)
+	}
+
+	if closer, ok := result.Body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if err := r.respond(invocation, result); err != nil {
+		return err
+	}
+
+	for _, hook := range r.AfterResponse {
+		hook(ctx)
+	}
+
+	return nil
+
+}
+
+// invocationContext sets the deadline, Lambda context and trace id of the
+// invocation like aws-lambda-go does.
+func invocationContext(ctx context.Context, invocation *runtimeInvocation) (context.Context, context.CancelFunc, error) {
+
+	deadlineMs, err := strconv.ParseInt(invocation.headers.Get("Lambda-Runtime-Deadline-Ms"), 10, 64)
+	if err != nil {
+		return ctx, func() {}, fmt.Errorf("Invalid deadline: %w", err)
+	}
+
+	lc := &lambdacontext.LambdaContext{
+		AwsRequestID:       invocation.id,
+		InvokedFunctionArn: invocation.headers.Get("Lambda-Runtime-Invoked-Function-Arn"),
+	}
+
+	if clientContext := invocation.headers.Get("Lambda-Runtime-Client-Context"); len(clientContext) > 0 {
+		if err := json.Unmarshal([]byte(clientContext), &lc.ClientContext); err != nil {
+			return ctx, func() {}, fmt.Errorf("Invalid client context: %w", err)
+		}
+	}
+
+	if identity := invocation.headers.Get("Lambda-Runtime-Cognito-Identity"); len(identity) > 0 {
+		if err := json.Unmarshal([]byte(identity), &lc.Identity); err != nil {
+			return ctx, func() {}, fmt.Errorf("Invalid cognito identity: %w", err)
+		}
+	}
+
+	traceId := invocation.headers.Get("Lambda-Runtime-Trace-Id")
+	os.Setenv("_X_AMZN_TRACE_ID", traceId)
+
+	ctx, cancel := context.WithDeadline(ctx, time.UnixMilli(deadlineMs))
+
+	//nolint:staticcheck // Key read by the code written for aws-lambda-go.
+	ctx = context.WithValue(lambdacontext.NewContext(ctx, lc), "x-amzn-trace-id", traceId)
+
+	return ctx, cancel, nil
+
+}
+
+func callRuntimeHandler(ctx context.Context, payload []byte, handler RuntimeHandler) (result *RuntimeResult, err error) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Handler panicked: %v", recovered)
+		}
+	}()
+
+	result, err = handler(ctx, payload)
+
+	if err == nil && result == nil {
+		result = &RuntimeResult{Body: bytes.NewReader([]byte("null"))}
+	}
+
+	return result, err
+
+}
+
+func (r *Runtime) respond(invocation *runtimeInvocation, result *RuntimeResult) error {
+
+	contentType := result.ContentType
+	if len(contentType) == 0 {
+		contentType = "application/json"
+	}
+
+	body := result.Body
+	if body == nil {
+		body = http.NoBody
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.url("/runtime/invocation/"+invocation.id+"/response"), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	if !result.Streaming {
+
+		buffered, err := io.ReadAll(body)
+		if err != nil {
+			return r.fail(invocation, err)
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(buffered))
+		req.ContentLength = int64(len(buffered))
+
+		return r.post(req, "response")
+
+	}
+
+	// The errors occurring once the stream started are reported in the
+	// trailers.
+	req.Header.Set("Lambda-Runtime-Function-Response-Mode", "streaming")
+	req.Trailer = http.Header{
+		"Lambda-Runtime-Function-Error-Type": nil,
+		"Lambda-Runtime-Function-Error-Body": nil,
+	}
+	req.ContentLength = -1
+
+	pipeReader, pipeWriter := io.Pipe()
+	req.Body = pipeReader
+
+	go func() {
+
+		if _, err := io.Copy(pipeWriter, body); err != nil {
+
+			errorType, payload := runtimeError(err)
+
+			req.Trailer.Set("Lambda-Runtime-Function-Error-Type", errorType)
+			req.Trailer.Set("Lambda-Runtime-Function-Error-Body", base64.StdEncoding.EncodeToString(payload))
+
+		}
+
+		pipeWriter.Close()
+
+	}()
+
+	err = r.post(req, "streamed response")
+
+	// Unblocks the copy when the Runtime API stopped reading.
+	pipeReader.Close()
+
+	return err
+
+}
+
+// fail reports the error of an invocation. The responses are posted
+// without the context of the invocation, which may be past its deadline.
+func (r *Runtime) fail(invocation *runtimeInvocation, invokeErr error) error {
+
+	errorType, payload := runtimeError(invokeErr)
+
+	req, err := http.NewRequest(http.MethodPost, r.url("/runtime/invocation/"+invocation.id+"/error"), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Lambda-Runtime-Function-Error-Type", errorType)
+
+	return r.post(req, "error")
+
+}
+
+func (r *Runtime) post(req *http.Request, what string) error {
+
+	res, err := r.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to post %s: %w", what, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("Runtime API returned %d posting %s: %s", res.StatusCode, what, body)
+	}
+
+	return nil
+
+}
+
+// runtimeError returns the type and JSON payload of an invocation error,
+// the type of the status errors being their code.
+func runtimeError(err error) (string, []byte) {
+
+	errorType := reflect.TypeOf(err).String()
+	if st, ok := status.FromError(err); ok {
+		errorType = st.Code().String()
+	}
+
+	payload, _ := json.Marshal(map[string]string{
+		"errorMessage": err.Error(),
+		"errorType":    errorType,
+	})
+
+	return errorType, payload
+
+}
+
+// ProxyRuntimeHandler decodes the API Gateway proxy events for handler
+// (e.g. a Controller or a Mux).
+func ProxyRuntimeHandler(handler InvocationHandler) RuntimeHandler {
+
+	return func(ctx context.Context, payload []byte) (*RuntimeResult, error) {
+
+		proxyReq := &events.APIGatewayProxyRequest{}
+		if err := json.Unmarshal(payload, proxyReq); err != nil {
+			return nil, fmt.Errorf("Invalid proxy event: %w", err)
+		}
+
+		res, err := handler.HandleLambda(ctx, proxyReq)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := json.Marshal(res)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RuntimeResult{Body: bytes.NewReader(body)}, nil
+
+	}
+
+}
+
+// StreamingRuntimeHandler decodes the Function URL events for fn (e.g.
+// Controller.HandleStreaming) and streams its responses.
+func StreamingRuntimeHandler(fn func(ctx context.Context, urlReq *events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error)) RuntimeHandler {
+
+	return func(ctx context.Context, payload []byte) (*RuntimeResult, error) {
+
+		urlReq := &events.LambdaFunctionURLRequest{}
+		if err := json.Unmarshal(payload, urlReq); err != nil {
+			return nil, fmt.Errorf("Invalid Function URL event: %w", err)
+		}
+
+		res, err := fn(ctx, urlReq)
+		if err != nil {
+			return nil, err
+		}
+
+		prelude, err := json.Marshal(struct {
+			StatusCode int               `json:"statusCode"`
+			Headers    map[string]string `json:"headers,omitempty"`
+			Cookies    []string          `json:"cookies,omitempty"`
+		}{res.StatusCode, res.Headers, res.Cookies})
+		if err != nil {
+			return nil, err
+		}
+
+		body := res.Body
+		if body == nil {
+			body = http.NoBody
+		}
+
+		return &RuntimeResult{
+			Body:        io.MultiReader(bytes.NewReader(prelude), bytes.NewReader(make([]byte, 8)), body),
+			ContentType: httpIntegrationContentType,
+			Streaming:   true,
+		}, nil
+
+	}
+
+}
@@ -0,0 +1,213 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// authzOptionName is the method option of the authz package, which imports
+// this one.
+const authzOptionName = "protomesh.authz.v1.authz"
+
+// AllMethods keys the options applying to every method of a service, the
+// options of a method override them.
+const AllMethods = "*"
+
+// MethodAttributes tune the calls of a method, they're declared at
+// registration for the services without (or overriding) custom options.
+type MethodAttributes struct {
+	// Deadline of the calls, the shortest of it and the grpc-timeout of the
+	// caller applies.
+	Timeout time.Duration
+	// Authorization of the calls, applied by the authz interceptor to the
+	// methods without authz option.
+	Auth *AuthPolicy
+	// Max age of the successful responses in Cache-Control, private unless
+	// the method is public, unless the handler sets the header.
+	CacheTTL time.Duration
+}
+
+// AuthPolicy mirrors the authz method option.
+type AuthPolicy struct {
+	// Callable without principal.
+	Public bool
+	// Every permission is required (e.g. "orders.read").
+	Permissions []string
+	// Field paths of the request identifying the resource.
+	ResourceFields []string
+}
+
+// ServiceOption declares attributes of the methods of a service, keyed by
+// method name (e.g. "GetOrder") or AllMethods.
+//
+//	controller.RegisterGRPCService(ordersv1.Orders_ServiceDesc, svc,
+//		lambda.WithMethodTimeout(lambda.AllMethods, 5*time.Second),
+//		lambda.WithCacheTTL("GetOrder", time.Minute),
+//		lambda.WithAuthPolicy("DeleteOrder", lambda.AuthPolicy{Permissions: []string{"orders.delete"}}),
+//	)
+type ServiceOption func(attrs map[string]*MethodAttributes)
+
+func WithMethodTimeout(method string, timeout time.Duration) ServiceOption {
+	return func(attrs map[string]*MethodAttributes) {
+		methodAttributes(attrs, method).Timeout = timeout
+	}
+}
+
+func WithAuthPolicy(method string, policy AuthPolicy) ServiceOption {
+	return func(attrs map[string]*MethodAttributes) {
+		methodAttributes(attrs, method).Auth = &policy
+	}
+}
+
+func WithCacheTTL(method string, ttl time.Duration) ServiceOption {
+	return func(attrs map[string]*MethodAttributes) {
+		methodAttributes(attrs, method).CacheTTL = ttl
+	}
+}
+
+func methodAttributes(attrs map[string]*MethodAttributes, method string) *MethodAttributes {
+
+	if _, ok := attrs[method]; !ok {
+		attrs[method] = &MethodAttributes{}
+	}
+
+	return attrs[method]
+
+}
+
+// resolveAttributes returns the attributes of every method of the options,
+// the ones of AllMethods being merged into the method ones.
+func resolveAttributes(methods []string, opts []ServiceOption) (map[string]*MethodAttributes, error) {
+
+	declared := make(map[string]*MethodAttributes)
+
+	for _, opt := range opts {
+		opt(declared)
+	}
+
+	known := map[string]bool{AllMethods: true}
+	for _, method := range methods {
+		known[method] = true
+	}
+
+	for method := range declared {
+		if !known[method] {
+			return nil, fmt.Errorf("Options declared for unknown method %s", method)
+		}
+	}
+
+	resolved := make(map[string]*MethodAttributes, len(methods))
+
+	for _, method := range methods {
+
+		all, specific := declared[AllMethods], declared[method]
+		if all == nil && specific == nil {
+			continue
+		}
+
+		attrs := &MethodAttributes{}
+
+		for _, source := range []*MethodAttributes{all, specific} {
+
+			if source == nil {
+				continue
+			}
+
+			if source.Timeout > 0 {
+				attrs.Timeout = source.Timeout
+			}
+
+			if source.Auth != nil {
+				attrs.Auth = source.Auth
+			}
+
+			if source.CacheTTL > 0 {
+				attrs.CacheTTL = source.CacheTTL
+			}
+
+		}
+
+		resolved[method] = attrs
+
+	}
+
+	return resolved, nil
+
+}
+
+type methodAttributesContextKey struct{}
+
+func ContextWithMethodAttributes(ctx context.Context, attrs *MethodAttributes) context.Context {
+	return context.WithValue(ctx, methodAttributesContextKey{}, attrs)
+}
+
+// MethodAttributesFromContext returns the attributes declared at the
+// registration of the called method, false when it has none.
+func MethodAttributesFromContext(ctx context.Context) (*MethodAttributes, bool) {
+	attrs, ok := ctx.Value(methodAttributesContextKey{}).(*MethodAttributes)
+	return attrs, ok && attrs != nil
+}
+
+// withMethodAttributes sets attrs in ctx and applies their timeout. The
+// attributes of ctx are replaced even when attrs is nil, so a method never
+// sees the ones of its caller (e.g. in process calls).
+func withMethodAttributes(ctx context.Context, attrs *MethodAttributes) (context.Context, context.CancelFunc) {
+
+	ctx = ContextWithMethodAttributes(ctx, attrs)
+
+	if attrs != nil && attrs.Timeout > 0 {
+		return context.WithTimeout(ctx, attrs.Timeout)
+	}
+
+	return ctx, func() {}
+
+}
+
+// setCacheControl sets the Cache-Control of a successful response of a
+// method with CacheTTL. Only the responses of public methods are cacheable
+// by shared caches, the others are private to the caller.
+func setCacheControl(fullMethod string, attrs *MethodAttributes, res *Response) {
+
+	if attrs == nil || attrs.CacheTTL <= 0 || len(res.Header("Cache-Control")) > 0 {
+		return
+	}
+
+	maxAge := int(attrs.CacheTTL / time.Second)
+
+	if !publicMethod(fullMethod, attrs) {
+		res.SetHeader("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+		return
+	}
+
+	res.SetHeader("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+
+}
+
+// publicMethod reports whether the method is callable without principal,
+// per its authz option (protomesh.authz.v1.authz) or else its AuthPolicy,
+// as the authz interceptor does.
+func publicMethod(fullMethod string, attrs *MethodAttributes) bool {
+
+	if method, ok := MethodDescriptor(fullMethod); ok {
+
+		xt, err := protoregistry.GlobalTypes.FindExtensionByName(authzOptionName)
+
+		if err == nil && proto.HasExtension(method.Options(), xt) {
+
+			rule := proto.GetExtension(method.Options(), xt).(proto.Message).ProtoReflect()
+
+			public := rule.Descriptor().Fields().ByName("public")
+
+			return public != nil && rule.Get(public).Bool()
+
+		}
+
+	}
+
+	return attrs != nil && attrs.Auth != nil && attrs.Auth.Public
+
+}
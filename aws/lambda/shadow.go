@@ -0,0 +1,127 @@
+package lambda
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ShadowHeader marks mirrored requests, they are never mirrored again.
+const ShadowHeader = "x-protomesh-shadow"
+
+type ShadowOptions struct {
+	// Backend receiving the mirrored requests (e.g. a ClientConn targeting
+	// the new version of the function).
+	Conn grpc.ClientConnInterface
+	// Share of the requests mirrored, from 0 to 1.
+	Ratio float64
+	// Restricts mirroring to some methods, every method when nil.
+	Methods func(method string) bool
+	// How long the invocation waits for the shadow call once the primary
+	// one is done, the call is canceled afterwards. Lambda freezes the
+	// environment between invocations so the call can't outlive it.
+	// Defaults to 100ms.
+	MaxWait time.Duration
+}
+
+// ShadowInterceptor mirrors a share of the requests to a shadow backend
+// concurrently with the primary call, shadow responses and errors are
+// discarded so consumers are unaffected.
+func ShadowInterceptor(opts ShadowOptions) grpc.UnaryServerInterceptor {
+
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = 100 * time.Millisecond
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		inMeta, _ := metadata.FromIncomingContext(ctx)
+
+		if len(inMeta.Get(ShadowHeader)) > 0 || rand.Float64() >= opts.Ratio {
+			return handler(ctx, req)
+		}
+
+		if opts.Methods != nil && !opts.Methods(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		reply := NewMethodOutput(info.FullMethod)
+		if reply == nil {
+			return handler(ctx, req)
+		}
+
+		shadowCtx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), inMeta.Copy()))
+		defer cancel()
+
+		shadowCtx = metadata.AppendToOutgoingContext(shadowCtx, ShadowHeader, "1")
+
+		// The input may be reused once the handler returns.
+		shadowReq := proto.Clone(req.(proto.Message))
+
+		done := make(chan struct{})
+
+		go func() {
+
+			defer close(done)
+
+			if err := opts.Conn.Invoke(shadowCtx, info.FullMethod, shadowReq, reply); err != nil {
+				LoggerFromContext(ctx).Debug("Shadow call failed", "method", info.FullMethod, "error", err)
+			}
+
+		}()
+
+		out, err := handler(ctx, req)
+
+		select {
+		case <-done:
+		case <-time.After(opts.MaxWait):
+			LoggerFromContext(ctx).Debug("Shadow call canceled", "method", info.FullMethod)
+		}
+
+		return out, err
+
+	}
+
+}
+
+// MethodDescriptor looks up a method in the registered descriptors.
+func MethodDescriptor(fullMethod string) (protoreflect.MethodDescriptor, bool) {
+
+	name := strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1)
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, false
+	}
+
+	method, ok := desc.(protoreflect.MethodDescriptor)
+
+	return method, ok
+
+}
+
+// NewMethodOutput allocates the output of a method from the registered
+// descriptors, nil when the method is unknown.
+func NewMethodOutput(fullMethod string) protoreflect.ProtoMessage {
+
+	method, ok := MethodDescriptor(fullMethod)
+	if !ok {
+		return nil
+	}
+
+	msgType, err := protoregistry.GlobalTypes.FindMessageByName(method.Output().FullName())
+	if err != nil {
+		return dynamicpb.NewMessage(method.Output())
+	}
+
+	return msgType.New().Interface()
+
+}
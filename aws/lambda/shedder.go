@@ -0,0 +1,284 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// Lowers the priority of a call (e.g. "low" or "sheddable"), it can't
+	// raise it above the priority option of the method.
+	PriorityHeader = "x-protomesh-priority"
+
+	// Domain of the ErrorInfo of the shed calls, their reason is
+	// LoadShedReason.
+	ShedErrorDomain = "lambda.protomesh.io"
+	LoadShedReason  = "LOAD_SHED"
+)
+
+// Shedder drops the calls by priority under pressure, the pressure being
+// the highest of its signals: concurrency used over its limit, in flight
+// requests over MaxInFlight and average latency over TargetLatency. A call
+// is shed when the pressure reaches the threshold of its priority.
+//
+//	shedder := &lambda.Shedder{TargetLatency: 300 * time.Millisecond, MaxInFlight: 64}
+//	controller.RegisterMiddleware(shedder.Middleware())
+type Shedder struct {
+	// Reports the concurrency of the function, unused when nil.
+	Source ConcurrencySource
+	// Age of the concurrency readings, defaults to 10 seconds. The latency
+	// of the calls is forgotten after it too, so shedding every call
+	// doesn't keep the pressure up.
+	Refresh time.Duration
+	// Requests in flight of the instance at full pressure, unused when 0.
+	MaxInFlight int
+	// Average latency of the calls at full pressure, unused when 0.
+	TargetLatency time.Duration
+	// Pressure from which the calls of a priority are shed. Defaults to 0.6
+	// for sheddable, 0.75 for low, 0.9 for normal and 1 for high calls,
+	// critical calls are never shed.
+	Thresholds map[Priority]float64
+	// Defaults to a second.
+	RetryAfter time.Duration
+
+	lock       sync.Mutex
+	throttle   *Throttle
+	inFlight   int
+	latency    time.Duration
+	measuredAt time.Time
+	priorities sync.Map
+}
+
+var defaultShedThresholds = map[Priority]float64{
+	Priority_PRIORITY_SHEDDABLE: 0.6,
+	Priority_PRIORITY_LOW:       0.75,
+	Priority_PRIORITY_NORMAL:    0.9,
+	Priority_PRIORITY_HIGH:      1,
+}
+
+type priorityContextKey struct{}
+
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, priority)
+}
+
+// PriorityFromContext returns the priority of the call set by the shedder,
+// normal outside of it.
+func PriorityFromContext(ctx context.Context) Priority {
+
+	if priority, ok := ctx.Value(priorityContextKey{}).(Priority); ok {
+		return priority
+	}
+
+	return Priority_PRIORITY_NORMAL
+
+}
+
+// Middleware rejects the shed calls with ResourceExhausted, an ErrorInfo
+// detailing the priority and pressure and a RetryInfo.
+func (s *Shedder) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			priority := s.priority(ctx, req)
+			ctx = ContextWithPriority(ctx, priority)
+
+			if pressure, signal := s.pressure(ctx); s.shed(priority, pressure) {
+
+				LoggerFromContext(ctx).Warn("Request shed", "priority", priority, "pressure", fmt.Sprintf("%.2f", pressure), "signal", signal)
+
+				return res.WriteError(s.shedError(priority, pressure, signal))
+
+			}
+
+			s.lock.Lock()
+			s.inFlight++
+			s.lock.Unlock()
+
+			clk := clock.FromContext(ctx)
+
+			start := clk.Now()
+
+			err := next(ctx, req, res)
+
+			end := clk.Now()
+			s.done(end, end.Sub(start))
+
+			return err
+
+		}
+
+	}
+
+}
+
+// priority returns the priority option of the called method, lowered by
+// the PriorityHeader of the request.
+func (s *Shedder) priority(ctx context.Context, req *Request) Priority {
+
+	priority := Priority_PRIORITY_NORMAL
+
+	if method, ok := grpc.Method(ctx); ok {
+		priority = s.methodPriority(method)
+	}
+
+	name := "PRIORITY_" + strings.ToUpper(strings.TrimSpace(req.Header(PriorityHeader)))
+
+	if value, ok := Priority_value[name]; ok && Priority(value) > priority {
+		priority = Priority(value)
+	}
+
+	return priority
+
+}
+
+func (s *Shedder) methodPriority(fullMethod string) Priority {
+
+	if priority, ok := s.priorities.Load(fullMethod); ok {
+		return priority.(Priority)
+	}
+
+	priority := Priority_PRIORITY_NORMAL
+
+	if method, ok := MethodDescriptor(fullMethod); ok && proto.HasExtension(method.Options(), E_Priority) {
+		if declared := proto.GetExtension(method.Options(), E_Priority).(Priority); declared != Priority_PRIORITY_UNSPECIFIED {
+			priority = declared
+		}
+	}
+
+	s.priorities.Store(fullMethod, priority)
+
+	return priority
+
+}
+
+// pressure returns the highest pressure of the signals and its name.
+func (s *Shedder) pressure(ctx context.Context) (float64, string) {
+
+	pressure, signal := 0.0, ""
+
+	if s.Source != nil {
+
+		s.lock.Lock()
+		if s.throttle == nil {
+			s.throttle = &Throttle{Source: s.Source, Refresh: s.Refresh}
+		}
+		throttle := s.throttle
+		s.lock.Unlock()
+
+		if used := 1 - throttle.currentHeadroom(ctx); used > pressure {
+			pressure, signal = used, "concurrency"
+		}
+
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.MaxInFlight > 0 {
+		if used := float64(s.inFlight) / float64(s.MaxInFlight); used > pressure {
+			pressure, signal = used, "in_flight"
+		}
+	}
+
+	if s.TargetLatency > 0 && clock.FromContext(ctx).Now().Sub(s.measuredAt) < s.refresh() {
+		if used := float64(s.latency) / float64(s.TargetLatency); used > pressure {
+			pressure, signal = used, "latency"
+		}
+	}
+
+	return pressure, signal
+
+}
+
+func (s *Shedder) shed(priority Priority, pressure float64) bool {
+
+	if priority == Priority_PRIORITY_CRITICAL {
+		return false
+	}
+
+	threshold, ok := s.Thresholds[priority]
+	if !ok {
+		threshold = defaultShedThresholds[priority]
+	}
+
+	return threshold > 0 && pressure >= threshold
+
+}
+
+// done releases the in flight slot of a call and folds its latency into
+// the moving average.
+func (s *Shedder) done(now time.Time, latency time.Duration) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.inFlight--
+
+	if now.Sub(s.measuredAt) >= s.refresh() {
+		s.latency = 0
+	}
+
+	s.measuredAt = now
+
+	if s.latency == 0 {
+		s.latency = latency
+		return
+	}
+
+	// Exponentially weighted, the last 10 calls or so weigh the most.
+	s.latency += (latency - s.latency) / 10
+
+}
+
+func (s *Shedder) refresh() time.Duration {
+
+	if s.Refresh > 0 {
+		return s.Refresh
+	}
+
+	return 10 * time.Second
+
+}
+
+func (s *Shedder) shedError(priority Priority, pressure float64, signal string) error {
+
+	retryAfter := s.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+
+	st := status.New(codes.ResourceExhausted, "Service overloaded, retry later")
+
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: LoadShedReason,
+			Domain: ShedErrorDomain,
+			Metadata: map[string]string{
+				"priority": strings.ToLower(strings.TrimPrefix(priority.String(), "PRIORITY_")),
+				"pressure": fmt.Sprintf("%.2f", pressure),
+				"signal":   signal,
+			},
+		},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)},
+	)
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+
+}
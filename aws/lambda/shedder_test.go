@@ -0,0 +1,112 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestShedderMiddleware(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		thresholds map[Priority]float64
+		retryAfter time.Duration
+		inFlight   int
+		priority   string
+		shed       bool
+		want       string
+	}{
+		{
+			name:     "normal under its threshold",
+			inFlight: 85,
+		},
+		{
+			name:     "normal at its threshold",
+			inFlight: 90,
+			shed:     true,
+			want:     "1",
+		},
+		{
+			name:     "low at its threshold",
+			inFlight: 75,
+			priority: "low",
+			shed:     true,
+			want:     "1",
+		},
+		{
+			name:     "sheddable under its threshold",
+			inFlight: 59,
+			priority: "sheddable",
+		},
+		{
+			name:     "sheddable at its threshold",
+			inFlight: 60,
+			priority: "sheddable",
+			shed:     true,
+			want:     "1",
+		},
+		{
+			name:     "header can't raise the priority",
+			inFlight: 95,
+			priority: "critical",
+			shed:     true,
+			want:     "1",
+		},
+		{
+			name:       "custom threshold",
+			thresholds: map[Priority]float64{Priority_PRIORITY_NORMAL: 0.5},
+			inFlight:   50,
+			shed:       true,
+			want:       "1",
+		},
+		{
+			name:       "custom retry after",
+			retryAfter: 5 * time.Second,
+			inFlight:   95,
+			shed:       true,
+			want:       "5",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			s := &Shedder{MaxInFlight: 100, Thresholds: test.thresholds, RetryAfter: test.retryAfter}
+			s.inFlight = test.inFlight
+
+			req := &Request{APIGatewayProxyRequest: &events.APIGatewayProxyRequest{
+				Headers: map[string]string{PriorityHeader: test.priority},
+			}}
+			res := &Response{APIGatewayProxyResponse: &events.APIGatewayProxyResponse{StatusCode: http.StatusOK}}
+
+			called := false
+
+			s.Middleware()(func(ctx context.Context, req *Request, res *Response) error {
+				called = true
+				return nil
+			})(context.Background(), req, res)
+
+			if called == test.shed {
+				t.Fatalf("handler called = %t, want %t", called, !test.shed)
+			}
+
+			if !test.shed {
+				return
+			}
+
+			if res.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("status = %d, want %d", res.StatusCode, http.StatusTooManyRequests)
+			}
+
+			if retryAfter := res.Header("Retry-After"); retryAfter != test.want {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, test.want)
+			}
+
+		})
+	}
+
+}
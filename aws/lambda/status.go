@@ -0,0 +1,190 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const StatusHandlerKey = "/_protomesh/status"
+
+// HealthCheck reports the health of a dependency, a nil error means healthy.
+type HealthCheck func(ctx context.Context) error
+
+type StatusOptions struct {
+	// Identifies the configuration in use (e.g. appconfig.Watcher.Digest).
+	ConfigDigest func() string
+}
+
+type dependencyStatus struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+type controllerStatus struct {
+	Function           map[string]interface{}      `json:"function"`
+	Build              map[string]string           `json:"build"`
+	ConfigDigest       string                      `json:"config_digest,omitempty"`
+	Routes             []Route                     `json:"routes"`
+	Middlewares        []string                    `json:"middlewares"`
+	UnaryInterceptors  []string                    `json:"unary_interceptors"`
+	StreamInterceptors []string                    `json:"stream_interceptors"`
+	Dependencies       map[string]dependencyStatus `json:"dependencies"`
+	Pool               *PoolStats                  `json:"pool,omitempty"`
+}
+
+// RegisterHealthCheck adds a dependency to the status report.
+func (c *Controller[D]) RegisterHealthCheck(name string, check HealthCheck) {
+	c.healthChecks[name] = check
+}
+
+// RegisterStatusHandler exposes a JSON report of the controller at
+// StatusHandlerKey. It is opt-in since it discloses the registered routes,
+// deployments should protect it with an authorizer. It panics when the
+// registration fails, see TryRegisterStatusHandler.
+func (c *Controller[D]) RegisterStatusHandler(opts StatusOptions) {
+	if err := c.TryRegisterStatusHandler(opts); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterStatusHandler registers the status handler, returning the
+// DuplicateRouteError of the ConflictError policy.
+func (c *Controller[D]) TryRegisterStatusHandler(opts StatusOptions) error {
+
+	return c.TryRegisterHandler(StatusHandlerKey, func(ctx context.Context, req *Request, res *Response) error {
+
+		st := &controllerStatus{
+			Function: map[string]interface{}{
+				"name":      lambdacontext.FunctionName,
+				"version":   lambdacontext.FunctionVersion,
+				"memory_mb": lambdacontext.MemoryLimitInMB,
+			},
+			Build:              buildInfo(),
+			Routes:             c.Routes(),
+			Middlewares:        make([]string, 0, len(c.middlewares)),
+			UnaryInterceptors:  make([]string, 0, len(c.unaryInterceptors)),
+			StreamInterceptors: make([]string, 0, len(c.streamInterceptors)),
+			Dependencies:       c.checkHealth(ctx),
+		}
+
+		if opts.ConfigDigest != nil {
+			st.ConfigDigest = opts.ConfigDigest()
+		}
+
+		if c.Pool != nil {
+			stats := c.Pool.Stats()
+			st.Pool = &stats
+		}
+
+		for _, middleware := range c.middlewares {
+			st.Middlewares = append(st.Middlewares, funcName(middleware))
+		}
+
+		for _, interceptor := range c.unaryInterceptors {
+			st.UnaryInterceptors = append(st.UnaryInterceptors, funcName(interceptor))
+		}
+
+		for _, interceptor := range c.streamInterceptors {
+			st.StreamInterceptors = append(st.StreamInterceptors, funcName(interceptor))
+		}
+
+		body, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+
+		res.StatusCode = http.StatusOK
+
+		for _, dep := range st.Dependencies {
+			if !dep.Healthy {
+				res.StatusCode = http.StatusServiceUnavailable
+			}
+		}
+
+		res.Headers = map[string]string{"Content-Type": "application/json"}
+		res.Body = string(body)
+		res.IsBase64Encoded = false
+
+		return nil
+
+	})
+
+}
+
+func (c *Controller[D]) checkHealth(ctx context.Context) map[string]dependencyStatus {
+
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	deps := make(map[string]dependencyStatus, len(c.healthChecks))
+
+	for name, check := range c.healthChecks {
+
+		wg.Add(1)
+
+		go func(name string, check HealthCheck) {
+
+			defer wg.Done()
+
+			dep := dependencyStatus{Healthy: true}
+
+			if err := check(ctx); err != nil {
+				dep.Healthy = false
+				dep.Error = err.Error()
+			}
+
+			lock.Lock()
+			deps[name] = dep
+			lock.Unlock()
+
+		}(name, check)
+
+	}
+
+	wg.Wait()
+
+	return deps
+
+}
+
+func buildInfo() map[string]string {
+
+	build := map[string]string{
+		"go_version": runtime.Version(),
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+
+	build["module"] = info.Main.Path
+	build["version"] = info.Main.Version
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			build[setting.Key] = setting.Value
+		}
+	}
+
+	return build
+
+}
+
+func funcName(f interface{}) string {
+
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name()
+	}
+
+	return "unknown"
+
+}
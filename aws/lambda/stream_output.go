@@ -0,0 +1,272 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	EventStreamContentType = "text/event-stream"
+	NDJSONContentType      = "application/x-ndjson"
+)
+
+type streamOutputContextKey struct{}
+
+// streamOutput writes the messages of a server stream as they are sent,
+// either as server-sent events or as newline delimited JSON.
+type streamOutput struct {
+	contentType string
+	w           io.Writer
+}
+
+func (o *streamOutput) writeMessage(id int, data []byte) error {
+
+	if o.contentType == NDJSONContentType {
+		_, err := fmt.Fprintf(o.w, "%s\n", data)
+		return err
+	}
+
+	_, err := fmt.Fprintf(o.w, "id: %d\nevent: message\ndata: %s\n\n", id, data)
+
+	return err
+
+}
+
+func (o *streamOutput) writeError(code codes.Code, message string) {
+
+	failure := map[string]interface{}{
+		"code":    code.String(),
+		"message": message,
+	}
+
+	if o.contentType == NDJSONContentType {
+		data, _ := json.Marshal(map[string]interface{}{"error": failure})
+		fmt.Fprintf(o.w, "%s\n", data)
+		return
+	}
+
+	data, _ := json.Marshal(failure)
+	fmt.Fprintf(o.w, "event: error\ndata: %s\n\n", data)
+
+}
+
+// encodedServerStream sends the messages JSON encoded to the stream output.
+type encodedServerStream struct {
+	*grpcServerStream
+	out *streamOutput
+	id  int
+}
+
+func (s *encodedServerStream) SendMsg(m interface{}) error {
+
+	data, err := protojson.Marshal(m.(proto.Message))
+	if err != nil {
+		return err
+	}
+
+	s.id++
+
+	return s.out.writeMessage(s.id, data)
+
+}
+
+// HandleStreaming serves the Function URL invocations in RESPONSE_STREAM
+// invoke mode:
+//
+//	awslambda.Start(controller.HandleStreaming)
+//
+// Server-streaming methods send each message while the handler runs, JSON
+// encoded, when called with either:
+//   - Accept: text/event-stream, as "message" events and the failures as an
+//     "error" event.
+//   - Accept: application/x-ndjson, one message per line and the failures as
+//     a last {"error": {"code", "message"}} line.
+//
+// The other requests are handled by HandleLambda and their buffered response
+// is streamed once complete.
+func (c *Controller[D]) HandleStreaming(ctx context.Context, urlReq *events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+
+	proxyReq := proxyRequestFromURL(urlReq)
+
+	contentType := c.streamContentType(ctx, proxyReq)
+
+	if len(contentType) == 0 {
+
+		res, err := c.HandleLambda(ctx, proxyReq)
+		if res == nil {
+			return nil, err
+		}
+
+		return streamingResponse(res), err
+
+	}
+
+	requestId := ensureRequestId(proxyReq, c.idGenerator())
+
+	reader, writer := io.Pipe()
+	out := &streamOutput{contentType: contentType, w: writer}
+
+	go func() {
+
+		// The stream outlives the invocation handler, a panic would crash
+		// the execution environment.
+		defer func() {
+			if r := recover(); r != nil {
+				LoggerFromContext(ctx).Error("Streaming handler panicked", "path", proxyReq.Path, "panic", r, "stack", string(debug.Stack()))
+				out.writeError(codes.Internal, "Internal error")
+				writer.CloseWithError(fmt.Errorf("Streaming handler panicked: %v", r))
+			}
+		}()
+
+		res, err := c.HandleLambda(context.WithValue(ctx, streamOutputContextKey{}, out), proxyReq)
+
+		switch {
+		case res == nil && err != nil:
+			out.writeError(status.Code(err), status.Convert(err).Message())
+		// The error policy may swallow the failures.
+		case err != nil || res.StatusCode >= 400:
+			out.writeError(CodeFromHTTPStatus(res.StatusCode), res.Body)
+		}
+
+		writer.Close()
+
+	}()
+
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":  contentType,
+			"Cache-Control": "no-cache",
+			RequestIdHeader: requestId,
+		},
+		Body: reader,
+	}, nil
+
+}
+
+// streamContentType returns the streamed content type accepted by the
+// request of a server-streaming method, empty otherwise.
+func (c *Controller[D]) streamContentType(ctx context.Context, proxyReq *events.APIGatewayProxyRequest) string {
+
+	req := &Request{APIGatewayProxyRequest: proxyReq}
+
+	accept := req.Header("Accept")
+
+	contentType := ""
+	switch {
+	case strings.Contains(accept, EventStreamContentType):
+		contentType = EventStreamContentType
+	case strings.Contains(accept, NDJSONContentType):
+		contentType = NDJSONContentType
+	default:
+		return ""
+	}
+
+	key, err := c.Matcher(ctx, proxyReq)
+	if err != nil {
+		return ""
+	}
+
+	if c.Versioning != nil {
+		key, _ = c.Versioning.resolve(proxyReq, key, c.handlers)
+	}
+
+	if route, ok := c.routes[key]; !ok || route.Kind != RouteKindServerStream {
+		return ""
+	}
+
+	return contentType
+
+}
+
+func proxyRequestFromURL(urlReq *events.LambdaFunctionURLRequest) *events.APIGatewayProxyRequest {
+
+	headers := urlReq.Headers
+
+	// Function URLs move the cookies out of the headers.
+	if len(urlReq.Cookies) > 0 {
+
+		headers = make(map[string]string, len(urlReq.Headers)+1)
+		for k, v := range urlReq.Headers {
+			headers[k] = v
+		}
+
+		headers["cookie"] = strings.Join(urlReq.Cookies, "; ")
+
+	}
+
+	return &events.APIGatewayProxyRequest{
+		Path:                  urlReq.RawPath,
+		HTTPMethod:            urlReq.RequestContext.HTTP.Method,
+		Headers:               headers,
+		QueryStringParameters: urlReq.QueryStringParameters,
+		Body:                  urlReq.Body,
+		IsBase64Encoded:       urlReq.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:  urlReq.RequestContext.AccountID,
+			RequestID:  urlReq.RequestContext.RequestID,
+			DomainName: urlReq.RequestContext.DomainName,
+			HTTPMethod: urlReq.RequestContext.HTTP.Method,
+			Path:       urlReq.RequestContext.HTTP.Path,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  urlReq.RequestContext.HTTP.SourceIP,
+				UserAgent: urlReq.RequestContext.HTTP.UserAgent,
+			},
+		},
+	}
+
+}
+
+func streamingResponse(res *events.APIGatewayProxyResponse) *events.LambdaFunctionURLStreamingResponse {
+
+	headers := make(map[string]string, len(res.Headers)+len(res.MultiValueHeaders))
+	cookies := []string{}
+
+	for k, values := range res.MultiValueHeaders {
+
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			cookies = append(cookies, values...)
+			continue
+		}
+
+		headers[k] = strings.Join(values, ", ")
+
+	}
+
+	for k, v := range res.Headers {
+
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			cookies = append(cookies, v)
+			continue
+		}
+
+		headers[k] = v
+
+	}
+
+	var body io.Reader = strings.NewReader(res.Body)
+	if res.IsBase64Encoded {
+		if decoded, err := DecodeBase64(res.Body); err == nil {
+			body = strings.NewReader(string(decoded))
+		}
+	}
+
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: res.StatusCode,
+		Headers:    headers,
+		Body:       body,
+		Cookies:    cookies,
+	}
+
+}
@@ -0,0 +1,58 @@
+package lambda
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/lambda/v1/lambda.proto
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// methodSuccessStatus returns the success_status option of a method, 0
+// when unset.
+func methodSuccessStatus(fullMethod string) (int, error) {
+
+	method, ok := MethodDescriptor(fullMethod)
+	if !ok || !proto.HasExtension(method.Options(), E_SuccessStatus) {
+		return 0, nil
+	}
+
+	code := int(proto.GetExtension(method.Options(), E_SuccessStatus).(int32))
+
+	if code < 200 || code > 299 {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid success_status %d of %s, must be 2xx", code, fullMethod)
+	}
+
+	return code, nil
+
+}
+
+// setSuccessStatus replaces the 200 of a successful call by the status of
+// the handler key, if any.
+func (c *Controller[D]) setSuccessStatus(key string, res *Response) {
+
+	if res.StatusCode != http.StatusOK {
+		return
+	}
+
+	code, ok := c.SuccessStatus[key]
+	if !ok {
+		if route, found := c.routes[key]; found {
+			code = route.SuccessStatus
+		}
+	}
+
+	if code == 0 {
+		return
+	}
+
+	res.StatusCode = code
+
+	if code == http.StatusNoContent {
+		res.Body = ""
+		res.IsBase64Encoded = false
+	}
+
+}
@@ -0,0 +1,288 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Caps the concurrency the throttle considers available, e.g. the
+// connection limit of the database behind the function.
+const ConcurrencyLimitEnv = "PROTOMESH_CONCURRENCY_LIMIT"
+
+// ConcurrencySource reports the concurrent executions of the function and
+// the limit they are bounded by.
+type ConcurrencySource interface {
+	Concurrency(ctx context.Context) (used, limit float64, err error)
+}
+
+// CloudWatchConcurrency reads the ConcurrentExecutions metric of the
+// function, the limit is the ConcurrencyLimitEnv hint, the reserved
+// concurrency of the function or the account limit, in that order.
+type CloudWatchConcurrency struct {
+	Client *awsapi.Client
+	// Defaults to AWS_LAMBDA_FUNCTION_NAME.
+	FunctionName string
+
+	limit float64
+}
+
+func (cw *CloudWatchConcurrency) Concurrency(ctx context.Context) (float64, float64, error) {
+
+	functionName := cw.FunctionName
+	if len(functionName) == 0 {
+		functionName = os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
+	}
+
+	if cw.limit == 0 {
+
+		limit, err := cw.concurrencyLimit(ctx, functionName)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		cw.limit = limit
+
+	}
+
+	now := clock.FromContext(ctx).Now()
+
+	in := map[string]interface{}{
+		"StartTime": now.Add(-3 * time.Minute).Unix(),
+		"EndTime":   now.Unix(),
+		"MetricDataQueries": []interface{}{
+			map[string]interface{}{
+				"Id": "concurrency",
+				"MetricStat": map[string]interface{}{
+					"Metric": map[string]interface{}{
+						"Namespace":  "AWS/Lambda",
+						"MetricName": "ConcurrentExecutions",
+						"Dimensions": []interface{}{
+							map[string]string{"Name": "FunctionName", "Value": functionName},
+						},
+					},
+					"Period": 60,
+					"Stat":   "Maximum",
+				},
+			},
+		},
+	}
+
+	out := struct {
+		MetricDataResults []struct {
+			Values []float64
+		}
+	}{}
+
+	if err := cw.Client.CallJSON(ctx, "monitoring", "1.0", "GraniteServiceVersion20100801.GetMetricData", in, &out); err != nil {
+		return 0, 0, err
+	}
+
+	used := 0.0
+
+	// Values are sorted from the most recent.
+	if len(out.MetricDataResults) > 0 && len(out.MetricDataResults[0].Values) > 0 {
+		used = out.MetricDataResults[0].Values[0]
+	}
+
+	return used, cw.limit, nil
+
+}
+
+func (cw *CloudWatchConcurrency) concurrencyLimit(ctx context.Context, functionName string) (float64, error) {
+
+	if hint, err := strconv.ParseFloat(os.Getenv(ConcurrencyLimitEnv), 64); err == nil && hint > 0 {
+		return hint, nil
+	}
+
+	reserved := struct {
+		ReservedConcurrentExecutions float64
+	}{}
+
+	if err := cw.getLambda(ctx, "/2019-09-30/functions/"+url.PathEscape(functionName)+"/concurrency", &reserved); err != nil {
+		return 0, err
+	}
+
+	if reserved.ReservedConcurrentExecutions > 0 {
+		return reserved.ReservedConcurrentExecutions, nil
+	}
+
+	account := struct {
+		AccountLimit struct {
+			UnreservedConcurrentExecutions float64
+		}
+	}{}
+
+	if err := cw.getLambda(ctx, "/2016-08-19/account-settings/", &account); err != nil {
+		return 0, err
+	}
+
+	return account.AccountLimit.UnreservedConcurrentExecutions, nil
+
+}
+
+func (cw *CloudWatchConcurrency) getLambda(ctx context.Context, path string, out interface{}) error {
+
+	req, err := http.NewRequest(http.MethodGet, cw.Client.Endpoint("lambda")+path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := cw.Client.Do(ctx, "lambda", req, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		return &awsapi.Error{
+			StatusCode: res.StatusCode,
+			Code:       http.StatusText(res.StatusCode),
+			Message:    string(body),
+		}
+	}
+
+	return json.Unmarshal(body, out)
+
+}
+
+// Throttle sheds load when the concurrency headroom of the function drops
+// below MinHeadroom, the share of requests shed grows as headroom shrinks.
+type Throttle struct {
+	Source ConcurrencySource
+	// Share of the limit kept free, defaults to 0.1.
+	MinHeadroom float64
+	// Defaults to a second.
+	RetryAfter time.Duration
+	// Age of the concurrency readings, defaults to 10 seconds.
+	Refresh time.Duration
+
+	lock       sync.Mutex
+	headroom   float64
+	measured   bool
+	refreshed  time.Time
+	refreshing bool
+}
+
+// Middleware rejects shed requests with ResourceExhausted and Retry-After.
+func (t *Throttle) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			if !t.shed(ctx) {
+				return next(ctx, req, res)
+			}
+
+			retryAfter := t.RetryAfter
+			if retryAfter <= 0 {
+				retryAfter = time.Second
+			}
+
+			return res.WriteError(WithRetryAfter(status.Error(codes.ResourceExhausted, "Concurrency headroom exhausted, retry later"), retryAfter))
+
+		}
+
+	}
+
+}
+
+func (t *Throttle) shed(ctx context.Context) bool {
+
+	minHeadroom := t.MinHeadroom
+	if minHeadroom <= 0 {
+		minHeadroom = 0.1
+	}
+
+	headroom := t.currentHeadroom(ctx)
+
+	if headroom >= minHeadroom {
+		return false
+	}
+
+	return rand.Float64() < (minHeadroom-headroom)/minHeadroom
+
+}
+
+// currentHeadroom refreshes the readings when stale, failed readings keep
+// the last headroom. A single call refreshes them at a time, without holding
+// the lock, the others use the last headroom meanwhile.
+func (t *Throttle) currentHeadroom(ctx context.Context) float64 {
+
+	refresh := t.Refresh
+	if refresh <= 0 {
+		refresh = 10 * time.Second
+	}
+
+	now := clock.FromContext(ctx).Now()
+
+	t.lock.Lock()
+
+	if t.refreshing || (!t.refreshed.IsZero() && now.Sub(t.refreshed) < refresh) {
+		headroom := t.lastHeadroom()
+		t.lock.Unlock()
+		return headroom
+	}
+
+	t.refreshing = true
+	t.refreshed = now
+
+	t.lock.Unlock()
+
+	used, limit, err := t.Source.Concurrency(ctx)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.refreshing = false
+
+	if err != nil {
+		LoggerFromContext(ctx).Warn("Failed to read concurrency", "error", err)
+		return t.lastHeadroom()
+	}
+
+	t.measured = true
+
+	if limit <= 0 {
+		t.headroom = 1
+		return t.headroom
+	}
+
+	t.headroom = 1 - used/limit
+
+	LoggerFromContext(ctx).Debug("Concurrency headroom", "used", used, "limit", limit, "headroom", fmt.Sprintf("%.2f", t.headroom))
+
+	return t.headroom
+
+}
+
+// lastHeadroom returns the last measured headroom, full before the first
+// reading. Called with the lock held.
+func (t *Throttle) lastHeadroom() float64 {
+
+	if !t.measured {
+		return 1
+	}
+
+	return t.headroom
+
+}
@@ -0,0 +1,142 @@
+package lambda
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TrailerHeaderPrefix prefixes the trailers of the calls in the response
+// headers, the Lambda responses having no trailers. The clients of the
+// package strip it back (see grpc.Trailer).
+const TrailerHeaderPrefix = "grpc-trailer-"
+
+// serverTransportStream collects the headers and trailers set by the
+// handlers through grpc.SetHeader, grpc.SendHeader and grpc.SetTrailer,
+// they're written to the response once the call returns.
+type serverTransportStream struct {
+	method string
+
+	lock       sync.Mutex
+	header     metadata.MD
+	headerSent bool
+	trailer    metadata.MD
+}
+
+var _ grpc.ServerTransportStream = &serverTransportStream{}
+
+func newServerTransportStream(method string) *serverTransportStream {
+	return &serverTransportStream{method: method}
+}
+
+// transportStreamFromContext returns the stream set by the controller for
+// the middlewares, a new one when ctx has none.
+func transportStreamFromContext(ctx context.Context, method string) *serverTransportStream {
+
+	if s, ok := grpc.ServerTransportStreamFromContext(ctx).(*serverTransportStream); ok && s.method == method {
+		return s
+	}
+
+	return newServerTransportStream(method)
+
+}
+
+func (s *serverTransportStream) Method() string {
+	return s.method
+}
+
+func (s *serverTransportStream) SetHeader(md metadata.MD) error {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.headerSent {
+		return status.Errorf(codes.Internal, "Headers already sent")
+	}
+
+	s.header = metadata.Join(s.header, md)
+
+	return nil
+
+}
+
+// SendHeader freezes the headers, the responses of Lambda being buffered
+// they're only sent with the response.
+func (s *serverTransportStream) SendHeader(md metadata.MD) error {
+
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.headerSent = true
+
+	return nil
+
+}
+
+func (s *serverTransportStream) SetTrailer(md metadata.MD) error {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.trailer = metadata.Join(s.trailer, md)
+
+	return nil
+
+}
+
+// writeTo adds the headers and the prefixed trailers to the response
+// headers.
+func (s *serverTransportStream) writeTo(res *Response) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.header) == 0 && len(s.trailer) == 0 {
+		return
+	}
+
+	if res.MultiValueHeaders == nil {
+		res.MultiValueHeaders = make(map[string][]string)
+	}
+
+	for k, v := range s.header {
+		res.MultiValueHeaders[k] = append(res.MultiValueHeaders[k], v...)
+	}
+
+	for k, v := range s.trailer {
+		name := TrailerHeaderPrefix + k
+		res.MultiValueHeaders[name] = append(res.MultiValueHeaders[name], v...)
+	}
+
+}
+
+// splitTrailers separates the prefixed trailers from the headers of a
+// response metadata.
+func splitTrailers(md metadata.MD) (metadata.MD, metadata.MD) {
+
+	header, trailer := metadata.MD{}, metadata.MD{}
+
+	for k, v := range md {
+
+		k = strings.ToLower(k)
+
+		if name, ok := strings.CutPrefix(k, TrailerHeaderPrefix); ok {
+			trailer[name] = append(trailer[name], v...)
+		} else {
+			header[k] = append(header[k], v...)
+		}
+
+	}
+
+	return header, trailer
+
+}
@@ -0,0 +1,144 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type typedCallContextKey struct{}
+
+// typedCall is what the typed handlers need from their controller, set in
+// the context of every request.
+type typedCall struct {
+	interceptor grpc.UnaryServerInterceptor
+	jsonDecoder JSONDecoder
+}
+
+// Unary adapts fn into a Handler for the endpoints without ServiceDesc:
+// the body is decoded into Req with the codec of the request (see
+// Request.Codec) and the JSONDecoder of the controller, the call goes
+// through its unary interceptors (e.g. validate.Interceptor and
+// authz.Interceptor, with the handler key as full method) and the response
+// is encoded the same way. Errors are written as by the gRPC methods.
+//
+//	controller.RegisterHandler("/hooks/ping", lambda.Unary(func(ctx context.Context, in *pingv1.Ping) (*pingv1.Pong, error) {
+//		return &pingv1.Pong{Id: in.Id}, nil
+//	}))
+func Unary[Req, Res proto.Message](fn func(ctx context.Context, req Req) (Res, error)) Handler {
+
+	return func(ctx context.Context, req *Request, res *Response) error {
+
+		out, err := invokeTyped(ctx, req, func(ctx context.Context, in Req) (proto.Message, error) {
+			return fn(ctx, in)
+		})
+		if err != nil {
+			return res.WriteError(err)
+		}
+
+		if err := encodeTyped(req, res, out); err != nil {
+			return res.WriteError(status.Errorf(codes.Internal, "Failed to marshal response: %v", err))
+		}
+
+		return nil
+
+	}
+
+}
+
+// Consume adapts fn into a Handler answering 204 No Content, for the
+// endpoints without response (e.g. notifications), see Unary.
+func Consume[Req proto.Message](fn func(ctx context.Context, req Req) error) Handler {
+
+	return func(ctx context.Context, req *Request, res *Response) error {
+
+		_, err := invokeTyped(ctx, req, func(ctx context.Context, in Req) (proto.Message, error) {
+			return nil, fn(ctx, in)
+		})
+		if err != nil {
+			return res.WriteError(err)
+		}
+
+		res.StatusCode = http.StatusNoContent
+		res.Body = ""
+
+		return nil
+
+	}
+
+}
+
+// invokeTyped decodes the request and calls fn through the unary
+// interceptors of the controller.
+func invokeTyped[Req proto.Message](ctx context.Context, req *Request, fn func(ctx context.Context, in Req) (proto.Message, error)) (proto.Message, error) {
+
+	call, _ := ctx.Value(typedCallContextKey{}).(*typedCall)
+
+	if call != nil && call.jsonDecoder != nil {
+		req.decodeJSON = func(data []byte, m proto.Message) error {
+			return call.jsonDecoder.DecodeJSON(req.HandlerKey, data, m)
+		}
+	}
+
+	var zero Req
+	in := zero.ProtoReflect().New().Interface().(Req)
+
+	if err := req.Unmarshal(in); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to unmarshal request: %s", status.Convert(err).Message())
+	}
+
+	handler := func(ctx context.Context, in interface{}) (interface{}, error) {
+		return fn(ctx, in.(Req))
+	}
+
+	if call == nil || call.interceptor == nil {
+		out, err := handler(ctx, in)
+		return typedOutput(out), err
+	}
+
+	out, err := call.interceptor(ctx, in, &grpc.UnaryServerInfo{FullMethod: req.HandlerKey}, handler)
+
+	return typedOutput(out), err
+
+}
+
+func typedOutput(out interface{}) proto.Message {
+
+	msg, _ := out.(proto.Message)
+
+	return msg
+
+}
+
+func encodeTyped(req *Request, res *Response, out proto.Message) error {
+
+	if res.Headers == nil {
+		res.Headers = make(map[string]string)
+	}
+
+	if req.Codec() == CodecJSON || strings.Contains(req.Header("Accept"), "application/json") {
+
+		body, err := protojson.Marshal(out)
+		if err != nil {
+			return err
+		}
+
+		res.Headers["Content-Type"] = "application/json"
+		res.Body = string(body)
+		res.IsBase64Encoded = false
+
+		return nil
+
+	}
+
+	res.Headers["Content-Type"] = "application/x-protobuf"
+
+	return res.MarshalProtobuf(out)
+
+}
@@ -0,0 +1,134 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+// Usage is the resource usage of an invocation.
+type Usage struct {
+	HandlerKey string
+	Duration   time.Duration
+	// User and system CPU time spent by the process during the invocation.
+	CPUTime time.Duration
+	// Peak resident memory during the invocation, or since the start of the
+	// process when the peak can't be reset. Zero when unavailable.
+	MaxRSS int64
+	// Memory configured for the function, from the environment.
+	MemoryLimit int64
+}
+
+// UsageHook receives the usage of every invocation.
+type UsageHook func(ctx context.Context, usage *Usage)
+
+// UsageMiddleware measures the CPU time and peak memory of the invocations
+// by handler key, to right-size the memory of the functions. Lambda runs a
+// single invocation at a time per execution environment so the process
+// usage is the one of the invocation.
+func UsageMiddleware(hooks ...UsageHook) Middleware {
+
+	memoryLimit, _ := strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64)
+
+	return func(next Handler) Handler {
+
+		return func(ctx context.Context, req *Request, res *Response) error {
+
+			resetPeakRSS()
+
+			start := time.Now()
+			cpuStart := processCPUTime()
+
+			err := next(ctx, req, res)
+
+			usage := &Usage{
+				HandlerKey:  req.HandlerKey,
+				Duration:    time.Since(start),
+				CPUTime:     processCPUTime() - cpuStart,
+				MaxRSS:      peakRSS(),
+				MemoryLimit: memoryLimit << 20,
+			}
+
+			for _, hook := range hooks {
+				hook(ctx, usage)
+			}
+
+			return err
+
+		}
+
+	}
+
+}
+
+// UsageMetrics writes the usage in the CloudWatch embedded metric format by
+// function and handler key, its Report method is a UsageHook.
+type UsageMetrics struct {
+	// Defaults to "Protomesh/Invocations".
+	Namespace string
+	// Defaults to os.Stdout.
+	Writer io.Writer
+}
+
+func (m *UsageMetrics) Report(ctx context.Context, usage *Usage) {
+
+	namespace := m.Namespace
+	if len(namespace) == 0 {
+		namespace = "Protomesh/Invocations"
+	}
+
+	writer := m.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	metrics := []map[string]string{
+		{"Name": "Duration", "Unit": "Milliseconds"},
+		{"Name": "CPUTime", "Unit": "Milliseconds"},
+		{"Name": "MaxRSS", "Unit": "Megabytes"},
+	}
+
+	values := map[string]interface{}{
+		"FunctionName": os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		"HandlerKey":   usage.HandlerKey,
+		"Duration":     float64(usage.Duration.Microseconds()) / 1000,
+		"CPUTime":      float64(usage.CPUTime.Microseconds()) / 1000,
+		"MaxRSS":       float64(usage.MaxRSS) / (1 << 20),
+	}
+
+	if usage.MemoryLimit > 0 {
+		metrics = append(metrics, map[string]string{"Name": "MemoryUtilization", "Unit": "Percent"})
+		values["MemoryUtilization"] = float64(usage.MaxRSS) * 100 / float64(usage.MemoryLimit)
+	}
+
+	writeEMF(ctx, writer, namespace, []string{"FunctionName", "HandlerKey"}, metrics, values)
+
+}
+
+// writeEMF writes values as a line of the CloudWatch embedded metric format,
+// Lambda ships it to CloudWatch Logs which extracts the metrics by
+// dimensions.
+func writeEMF(ctx context.Context, writer io.Writer, namespace string, dimensions []string, metrics []map[string]string, values map[string]interface{}) {
+
+	values["_aws"] = map[string]interface{}{
+		"Timestamp": clock.FromContext(ctx).Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{{
+			"Namespace":  namespace,
+			"Dimensions": [][]string{dimensions},
+			"Metrics":    metrics,
+		}},
+	}
+
+	line, err := json.Marshal(values)
+	if err != nil {
+		return
+	}
+
+	writer.Write(append(line, '\n'))
+
+}
@@ -0,0 +1,58 @@
+package lambda
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func processCPUTime() time.Duration {
+
+	usage := &syscall.Rusage{}
+
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, usage); err != nil {
+		return 0
+	}
+
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+
+}
+
+// resetPeakRSS resets the VmHWM of the process, as documented in proc(5).
+func resetPeakRSS() {
+	os.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+}
+
+// peakRSS returns VmHWM of the process in bytes.
+func peakRSS() int64 {
+
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+
+		value, ok := strings.CutPrefix(scanner.Text(), "VmHWM:")
+		if !ok {
+			continue
+		}
+
+		kb, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "kB")), 10, 64)
+		if err != nil {
+			return 0
+		}
+
+		return kb << 10
+
+	}
+
+	return 0
+
+}
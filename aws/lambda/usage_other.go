@@ -0,0 +1,19 @@
+//go:build !linux
+
+package lambda
+
+import (
+	"time"
+)
+
+// The resource usage is only measured on Linux, where Lambda runs.
+
+func processCPUTime() time.Duration {
+	return 0
+}
+
+func resetPeakRSS() {}
+
+func peakRSS() int64 {
+	return 0
+}
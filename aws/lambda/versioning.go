@@ -0,0 +1,167 @@
+package lambda
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc"
+)
+
+const (
+	// APIVersionHeader selects the version of the versioned routes called
+	// without a version prefix.
+	APIVersionHeader = "x-api-version"
+	SunsetHeader     = "Sunset"
+	LinkHeader       = "Link"
+)
+
+// APIVersion is a handler set of the versioned services.
+type APIVersion struct {
+	// Prefix of the route keys (e.g. "v1" serves "/v1/acme.users.Users/Get").
+	Name string
+	// Sets the Deprecation header on the responses of the version.
+	Deprecated bool
+	// Sets the Sunset header, when the version is removed.
+	Sunset time.Time
+	// Migration guide set as deprecation link.
+	Link string
+}
+
+type VersioningOptions struct {
+	Versions []APIVersion
+	// Header selecting the version. Defaults to APIVersionHeader.
+	Header string
+	// Version of the requests without version. Defaults to the last of
+	// Versions.
+	Default string
+}
+
+type apiVersionContextKey struct{}
+
+// ContextWithAPIVersion sets the version being served in ctx.
+func ContextWithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionContextKey{}, version)
+}
+
+// APIVersionFromContext returns the version being served, empty for the
+// routes without version.
+func APIVersionFromContext(ctx context.Context) string {
+
+	version, _ := ctx.Value(apiVersionContextKey{}).(string)
+
+	return version
+
+}
+
+// RegisterVersionedService registers the handler set of version for the
+// service, the version must be listed in Versioning. Its methods are served
+// under the version prefix or, without it, to the callers selecting the
+// version through the header. The interceptors and middlewares are shared
+// by every version.
+func (c *Controller[D]) RegisterVersionedService(version string, desc grpc.ServiceDesc, svc interface{}, opts ...ServiceOption) error {
+	return c.registerGRPCService(version, desc, svc, opts)
+}
+
+func versionedRoute(version string, route Route) Route {
+
+	if len(version) == 0 {
+		return route
+	}
+
+	route.Key = "/" + version + route.Key
+	route.Version = version
+
+	return route
+
+}
+
+// resolve returns the key of the handler serving the request and its
+// version, the key is unchanged when it isn't versioned.
+func (o *VersioningOptions) resolve(req *events.APIGatewayProxyRequest, key string, handlers map[string]Handler) (string, *APIVersion) {
+
+	if name, _, ok := strings.Cut(strings.TrimPrefix(key, "/"), "/"); ok {
+		if version := o.version(name); version != nil {
+			return key, version
+		}
+	}
+
+	name := (&Request{APIGatewayProxyRequest: req}).Header(o.header())
+	if len(name) == 0 {
+		name = o.defaultVersion()
+	}
+
+	version := o.version(name)
+	if version == nil {
+		return key, nil
+	}
+
+	if _, ok := handlers["/"+version.Name+key]; !ok {
+		return key, nil
+	}
+
+	return "/" + version.Name + key, version
+
+}
+
+func (o *VersioningOptions) version(name string) *APIVersion {
+
+	for i := range o.Versions {
+		if o.Versions[i].Name == name {
+			return &o.Versions[i]
+		}
+	}
+
+	return nil
+
+}
+
+func (o *VersioningOptions) header() string {
+
+	if len(o.Header) > 0 {
+		return o.Header
+	}
+
+	return APIVersionHeader
+
+}
+
+func (o *VersioningOptions) defaultVersion() string {
+
+	if len(o.Default) > 0 {
+		return o.Default
+	}
+
+	if len(o.Versions) > 0 {
+		return o.Versions[len(o.Versions)-1].Name
+	}
+
+	return ""
+
+}
+
+func (v *APIVersion) setHeaders(res *Response) {
+
+	if !v.Deprecated && v.Sunset.IsZero() {
+		return
+	}
+
+	if res.Headers == nil {
+		res.Headers = make(map[string]string)
+	}
+
+	if v.Deprecated {
+		res.Headers[DeprecationHeader] = "true"
+	}
+
+	if !v.Sunset.IsZero() {
+		res.Headers[SunsetHeader] = v.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	if len(v.Link) > 0 {
+		res.Headers[LinkHeader] = "<" + v.Link + `>; rel="deprecation"`
+	}
+
+}
@@ -0,0 +1,381 @@
+package lambda
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type WebSocketOptions struct {
+	// Buffers the client frames until the stream reads them.
+	Store websocket.FrameStore
+	// Signs the calls to the management API.
+	Client *awsapi.Client
+	// Management API url, defaults to the domain and stage of the request.
+	Endpoint string
+	// Delay between reads of the store while waiting for a frame, defaults
+	// to 50ms.
+	PollInterval time.Duration
+}
+
+// RegisterWebSocket enables HandleWebSocket, which serves every stream of
+// the registered gRPC services (client and bidirectional streaming
+// included) over API Gateway WebSocket connections. The stream runs in the
+// invocation receiving its OPEN frame, the following frames reach it through
+// the store since they may be delivered to other instances.
+func (c *Controller[D]) RegisterWebSocket(opts WebSocketOptions) {
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 50 * time.Millisecond
+	}
+
+	c.webSocket = &opts
+
+}
+
+func (c *Controller[D]) HandleWebSocket(ctx context.Context, wsReq *events.APIGatewayWebsocketProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+	res := &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+	}
+
+	log := c.Log().With("connection_id", wsReq.RequestContext.ConnectionID, "request_id", wsReq.RequestContext.RequestID)
+	ctx = ContextWithLogger(ctx, log)
+
+	if c.webSocket == nil {
+		log.Error("WebSocket not registered in controller")
+		res.StatusCode = http.StatusNotImplemented
+		return res, nil
+	}
+
+	switch wsReq.RequestContext.RouteKey {
+	case "$connect", "$disconnect":
+		return res, nil
+	}
+
+	body := wsReq.Body
+	if wsReq.IsBase64Encoded {
+		decoded, err := DecodeBase64(body)
+		if err != nil {
+			log.Error("Failed to decode frame", "error", err)
+			res.StatusCode = http.StatusBadRequest
+			return res, nil
+		}
+		body = string(decoded)
+	}
+
+	frame := &websocket.Frame{}
+
+	if err := protojson.Unmarshal([]byte(body), frame); err != nil {
+		log.Error("Failed to unmarshal frame", "error", err)
+		res.StatusCode = http.StatusBadRequest
+		return res, nil
+	}
+
+	log = log.With("stream_id", frame.StreamId)
+	ctx = ContextWithLogger(ctx, log)
+
+	if frame.Kind != websocket.Frame_OPEN {
+
+		if err := c.webSocket.Store.Put(ctx, wsReq.RequestContext.ConnectionID, frame); err != nil {
+			log.Error("Failed to store frame", "error", err, "sequence", frame.Sequence)
+			res.StatusCode = http.StatusInternalServerError
+			return res, c.ErrorPolicy.invocationError(&Response{res}, err)
+		}
+
+		return res, nil
+
+	}
+
+	grpcStream, ok := c.streams[frame.Method]
+
+	var attrs *MethodAttributes
+	if ok {
+		attrs = grpcStream.attrs
+	}
+
+	stream := c.newWebSocketStream(ctx, wsReq, frame, attrs)
+	defer stream.cancel()
+
+	err := status.Errorf(codes.Unimplemented, "Unknown method %s", frame.Method)
+
+	if ok {
+		err = grpcStream.invoke(stream, chainStreamInterceptors(c.streamInterceptors))
+	}
+
+	if err != nil {
+		log.Error("Failed to handle stream", "error", err, "method", frame.Method)
+	}
+
+	if err := stream.close(err); err != nil {
+		log.Error("Failed to send trailers", "error", err)
+	}
+
+	return res, nil
+
+}
+
+func (c *Controller[D]) newWebSocketStream(ctx context.Context, wsReq *events.APIGatewayWebsocketProxyRequest, open *websocket.Frame, attrs *MethodAttributes) *webSocketStream {
+
+	endpoint := c.webSocket.Endpoint
+	if len(endpoint) == 0 {
+		endpoint = "https://" + wsReq.RequestContext.DomainName + "/" + wsReq.RequestContext.Stage
+	}
+
+	inMeta := make(metadata.MD, len(open.Metadata))
+	for k, v := range open.Metadata {
+		inMeta[strings.ToLower(k)] = []string{v}
+	}
+
+	callCtx := peer.NewContext(metadata.NewIncomingContext(ctx, inMeta), requestPeer(wsReq.RequestContext.Identity.SourceIP, true))
+
+	// The interceptors read the auth policy and the like of the stream.
+	callCtx = ContextWithMethodAttributes(callCtx, attrs)
+
+	streamCtx, cancel := context.WithCancel(callCtx)
+
+	stream := &webSocketStream{
+		ctx:          streamCtx,
+		cancel:       cancel,
+		sendCtx:      ctx,
+		connectionId: wsReq.RequestContext.ConnectionID,
+		streamId:     open.StreamId,
+		store:        c.webSocket.Store,
+		pollInterval: c.webSocket.PollInterval,
+		conns: &websocket.Connections{
+			Client:   c.webSocket.Client,
+			Endpoint: strings.TrimRight(endpoint, "/"),
+		},
+		recvSeq: 1,
+		header:  metadata.MD{},
+		trailer: metadata.MD{},
+	}
+
+	stream.ctx = grpc.NewContextWithServerTransportStream(streamCtx, webSocketTransport{webSocketStream: stream, method: open.Method})
+
+	return stream
+
+}
+
+// webSocketTransport exposes a stream to grpc.Method, grpc.SetHeader and
+// the like.
+type webSocketTransport struct {
+	*webSocketStream
+	method string
+}
+
+func (t webSocketTransport) Method() string {
+	return t.method
+}
+
+func (t webSocketTransport) SetTrailer(md metadata.MD) error {
+	t.webSocketStream.SetTrailer(md)
+	return nil
+}
+
+// webSocketStream implements grpc.ServerStream over frames.
+type webSocketStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// The stream context may be canceled by the client, trailers must still
+	// be sent with the invocation one.
+	sendCtx context.Context
+
+	connectionId string
+	streamId     string
+	store        websocket.FrameStore
+	conns        *websocket.Connections
+	pollInterval time.Duration
+
+	lock       sync.Mutex
+	recvSeq    uint64
+	sendSeq    uint64
+	header     metadata.MD
+	headerSent bool
+	trailer    metadata.MD
+}
+
+func (w *webSocketStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *webSocketStream) SetHeader(md metadata.MD) error {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.headerSent {
+		return status.Errorf(codes.Internal, "Headers already sent")
+	}
+
+	w.header = metadata.Join(w.header, md)
+
+	return nil
+
+}
+
+func (w *webSocketStream) SendHeader(md metadata.MD) error {
+
+	if err := w.SetHeader(md); err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.sendHeaderLocked()
+
+}
+
+func (w *webSocketStream) SetTrailer(md metadata.MD) {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.trailer = metadata.Join(w.trailer, md)
+
+}
+
+func (w *webSocketStream) SendMsg(m interface{}) error {
+
+	payload, err := proto.Marshal(m.(proto.Message))
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to marshal message: %v", err)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if err := w.sendHeaderLocked(); err != nil {
+		return err
+	}
+
+	return w.sendLocked(&websocket.Frame{
+		Kind:    websocket.Frame_MESSAGE,
+		Payload: payload,
+	})
+
+}
+
+func (w *webSocketStream) RecvMsg(m interface{}) error {
+
+	for {
+
+		frame, err := w.store.Get(w.ctx, w.connectionId, w.streamId, w.recvSeq)
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "Failed to read frame: %v", err)
+		}
+
+		if frame == nil {
+
+			select {
+			case <-w.ctx.Done():
+				return status.FromContextError(w.ctx.Err()).Err()
+			case <-time.After(w.pollInterval):
+			}
+
+			continue
+
+		}
+
+		switch frame.Kind {
+
+		case websocket.Frame_MESSAGE:
+			w.recvSeq++
+			if err := proto.Unmarshal(frame.Payload, m.(proto.Message)); err != nil {
+				return status.Errorf(codes.InvalidArgument, "Failed to unmarshal message: %v", err)
+			}
+			return nil
+
+		case websocket.Frame_HALF_CLOSE:
+			return io.EOF
+
+		case websocket.Frame_CANCEL:
+			w.cancel()
+			return status.Errorf(codes.Canceled, "Stream canceled by the client")
+
+		default:
+			return status.Errorf(codes.InvalidArgument, "Unexpected frame %s", frame.Kind)
+
+		}
+
+	}
+
+}
+
+// close ends the stream with the status of err.
+func (w *webSocketStream) close(err error) error {
+
+	st := status.Convert(err)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if err := w.sendHeaderLocked(); err != nil {
+		return err
+	}
+
+	return w.sendLocked(&websocket.Frame{
+		Kind:     websocket.Frame_TRAILERS,
+		Code:     int32(st.Code()),
+		Message:  st.Message(),
+		Metadata: flattenMetadata(w.trailer),
+	})
+
+}
+
+func (w *webSocketStream) sendHeaderLocked() error {
+
+	if w.headerSent {
+		return nil
+	}
+
+	w.headerSent = true
+
+	return w.sendLocked(&websocket.Frame{
+		Kind:     websocket.Frame_HEADERS,
+		Metadata: flattenMetadata(w.header),
+	})
+
+}
+
+func (w *webSocketStream) sendLocked(frame *websocket.Frame) error {
+
+	frame.StreamId = w.streamId
+	frame.Sequence = w.sendSeq
+
+	if err := w.conns.Send(w.sendCtx, w.connectionId, frame); err != nil {
+		return err
+	}
+
+	w.sendSeq++
+
+	return nil
+
+}
+
+func flattenMetadata(md metadata.MD) map[string]string {
+
+	flat := make(map[string]string, len(md))
+
+	for k, v := range md {
+		flat[k] = strings.Join(v, ",")
+	}
+
+	return flat
+
+}
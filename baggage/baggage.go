@@ -0,0 +1,273 @@
+// Package baggage propagates key/values (tenant, feature flags, experiment
+// ids) along a call chain, through the W3C baggage header of gRPC calls and
+// the attributes of event envelopes.
+package baggage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Header of the W3C baggage and attribute of the envelopes.
+	Header = "baggage"
+
+	DefaultMaxMembers = 64
+	DefaultMaxBytes   = 8192
+)
+
+// Baggage is an immutable set of key/values, see Set.
+type Baggage map[string]string
+
+type baggageContextKey struct{}
+
+// FromContext returns the baggage of ctx, empty when there is none.
+func FromContext(ctx context.Context) Baggage {
+
+	if b, ok := ctx.Value(baggageContextKey{}).(Baggage); ok {
+		return b
+	}
+
+	return Baggage{}
+
+}
+
+func ContextWithBaggage(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageContextKey{}, b)
+}
+
+func Get(ctx context.Context, key string) string {
+	return FromContext(ctx)[key]
+}
+
+// Set returns a context whose baggage has key set to value, the baggage of
+// ctx is left untouched.
+func Set(ctx context.Context, key, value string) context.Context {
+
+	parent := FromContext(ctx)
+
+	b := make(Baggage, len(parent)+1)
+	for k, v := range parent {
+		b[k] = v
+	}
+
+	b[key] = value
+
+	return ContextWithBaggage(ctx, b)
+
+}
+
+// Propagator encodes and decodes baggage within size limits, members over
+// the limits are dropped.
+type Propagator struct {
+	// Propagated keys, every key when nil.
+	Keys []string
+	// Default to DefaultMaxMembers and DefaultMaxBytes.
+	MaxMembers int
+	MaxBytes   int
+}
+
+var Default = &Propagator{}
+
+// Encode encodes the propagated members of b as a W3C baggage header.
+func (p *Propagator) Encode(b Baggage) string {
+
+	maxMembers := p.MaxMembers
+	if maxMembers <= 0 {
+		maxMembers = DefaultMaxMembers
+	}
+
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	members := []string{}
+	size := 0
+
+	for _, key := range p.keys(b) {
+
+		value, ok := b[key]
+		if !ok {
+			continue
+		}
+
+		member := url.QueryEscape(key) + "=" + url.PathEscape(value)
+
+		if len(members) == maxMembers || size+len(member)+1 > maxBytes {
+			break
+		}
+
+		members = append(members, member)
+		size += len(member) + 1
+
+	}
+
+	return strings.Join(members, ",")
+
+}
+
+// Decode parses a W3C baggage header, keeping the propagated keys.
+func (p *Propagator) Decode(header string) (Baggage, error) {
+
+	b := Baggage{}
+
+	if len(header) == 0 {
+		return b, nil
+	}
+
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	if len(header) > maxBytes {
+		return nil, fmt.Errorf("Baggage exceeds %d bytes", maxBytes)
+	}
+
+	for _, member := range strings.Split(header, ",") {
+
+		// Properties (";prop") aren't supported and are dropped.
+		member, _, _ = strings.Cut(member, ";")
+
+		key, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok {
+			return nil, fmt.Errorf("Invalid baggage member: %q", member)
+		}
+
+		key, err := url.QueryUnescape(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid baggage key: %q", key)
+		}
+
+		value, err = url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid baggage value of %s", key)
+		}
+
+		if p.propagated(key) {
+			b[key] = value
+		}
+
+	}
+
+	return b, nil
+
+}
+
+func (p *Propagator) keys(b Baggage) []string {
+
+	if p.Keys != nil {
+		return p.Keys
+	}
+
+	keys := make([]string, 0, len(b))
+	for key := range b {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+
+}
+
+func (p *Propagator) propagated(key string) bool {
+
+	if p.Keys == nil {
+		return true
+	}
+
+	for _, k := range p.Keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// Middleware reads the baggage header of the requests into the context,
+// invalid baggage is dropped.
+func (p *Propagator) Middleware() lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			b, err := p.Decode(req.Header(Header))
+			if err != nil {
+				lambda.LoggerFromContext(ctx).Warn("Dropped invalid baggage", "error", err)
+				return next(ctx, req, res)
+			}
+
+			return next(ContextWithBaggage(ctx, b), req, res)
+
+		}
+
+	}
+
+}
+
+// AppendToOutgoingContext propagates the baggage of ctx as outgoing gRPC
+// metadata.
+func (p *Propagator) AppendToOutgoingContext(ctx context.Context) context.Context {
+
+	header := p.Encode(FromContext(ctx))
+	if len(header) == 0 {
+		return ctx
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(Header, header)
+
+	return metadata.NewOutgoingContext(ctx, md)
+
+}
+
+// ClientInterceptor propagates the baggage on every call, usable with
+// grpc.WithUnaryInterceptor and lambda.ClientConn.
+func (p *Propagator) ClientInterceptor() grpc.UnaryClientInterceptor {
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(p.AppendToOutgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+
+}
+
+// Inject stores the baggage of ctx in the attributes of env.
+func (p *Propagator) Inject(ctx context.Context, env *envelope.Envelope) {
+
+	header := p.Encode(FromContext(ctx))
+	if len(header) == 0 {
+		return
+	}
+
+	if env.Attributes == nil {
+		env.Attributes = make(map[string]string)
+	}
+
+	env.Attributes[Header] = header
+
+}
+
+// Extract returns a context with the baggage stored in env.
+func (p *Propagator) Extract(ctx context.Context, env *envelope.Envelope) (context.Context, error) {
+
+	b, err := p.Decode(env.GetAttributes()[Header])
+	if err != nil {
+		return ctx, err
+	}
+
+	return ContextWithBaggage(ctx, b), nil
+
+}
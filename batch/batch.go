@@ -0,0 +1,5 @@
+// Package batch holds the messages of the batch endpoint, which runs several
+// calls in a single invocation (see lambda.Controller.RegisterBatchHandler).
+package batch
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/batch/v1/batch.proto
@@ -0,0 +1,245 @@
+// Package bench drives a controller with synthetic API Gateway events, to
+// measure the throughput and the allocations of the dispatch path:
+//
+//	result, err := bench.Run(ctx, controller.HandleLambda, bench.Options{
+//		Concurrency: 8,
+//		Requests:    100000,
+//		Event:       bench.UnaryEvent("/acme.users.v1.Users/Get", req, 1024),
+//	})
+package bench
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Handler is the entrypoint driven, e.g. Controller.HandleLambda.
+type Handler func(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
+
+// paddingField holds the padding of the payloads, it's unknown to the
+// messages so it's decoded and skipped like any other field.
+const paddingField = protowire.Number(536870911)
+
+type Options struct {
+	// Concurrent callers. Defaults to GOMAXPROCS.
+	Concurrency int
+	// Total requests sent. Defaults to 10000.
+	Requests int
+	// Returns the event of the i-th request, each call must return a new
+	// event since the controller mutates them.
+	Event func(i int) *events.APIGatewayProxyRequest
+}
+
+type Result struct {
+	Requests int
+	// Invocations returning an error or a status of 500 or more.
+	Errors     int64
+	Duration   time.Duration
+	Throughput float64
+	P50        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	// Per request, for the whole process.
+	AllocsPerOp float64
+	BytesPerOp  float64
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%d requests in %s (%.0f req/s, %d errors) p50=%s p99=%s max=%s %.1f allocs/op %.0f B/op",
+		r.Requests, r.Duration, r.Throughput, r.Errors, r.P50, r.P99, r.Max, r.AllocsPerOp, r.BytesPerOp)
+}
+
+// Run sends the requests and measures them, it stops early when ctx is
+// canceled.
+func Run(ctx context.Context, handler Handler, opts Options) (*Result, error) {
+
+	if opts.Event == nil {
+		return nil, fmt.Errorf("Options.Event is required")
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	if opts.Requests <= 0 {
+		opts.Requests = 10000
+	}
+
+	latencies := make([]time.Duration, opts.Requests)
+
+	var next, errors int64
+	var wg sync.WaitGroup
+
+	before := &runtime.MemStats{}
+	runtime.GC()
+	runtime.ReadMemStats(before)
+
+	start := time.Now()
+
+	for w := 0; w < opts.Concurrency; w++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= opts.Requests {
+					return
+				}
+
+				event := opts.Event(i)
+				callStart := time.Now()
+
+				res, err := handler(ctx, event)
+
+				latencies[i] = time.Since(callStart)
+
+				if err != nil || res == nil || res.StatusCode >= 500 {
+					atomic.AddInt64(&errors, 1)
+				}
+
+			}
+
+		}()
+
+	}
+
+	wg.Wait()
+
+	duration := time.Since(start)
+
+	after := &runtime.MemStats{}
+	runtime.ReadMemStats(after)
+
+	sent := int(next)
+	if sent > opts.Requests {
+		sent = opts.Requests
+	}
+
+	latencies = latencies[:sent]
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+
+	result := &Result{
+		Requests: sent,
+		Errors:   errors,
+		Duration: duration,
+	}
+
+	if sent > 0 {
+		result.Throughput = float64(sent) / duration.Seconds()
+		result.P50 = latencies[sent/2]
+		result.P99 = latencies[sent*99/100]
+		result.Max = latencies[sent-1]
+		result.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / float64(sent)
+		result.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / float64(sent)
+	}
+
+	return result, ctx.Err()
+
+}
+
+// Benchmark drives handler from a Go benchmark, with b.N requests sent in
+// parallel:
+//
+//	func BenchmarkGet(b *testing.B) {
+//		bench.Benchmark(b, controller.HandleLambda, bench.UnaryEvent("/acme.users.v1.Users/Get", req, 0))
+//	}
+func Benchmark(b *testing.B, handler Handler, event func(i int) *events.APIGatewayProxyRequest) {
+
+	var next int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+
+		for pb.Next() {
+
+			res, err := handler(context.Background(), event(int(atomic.AddInt64(&next, 1))))
+			if err != nil {
+				b.Error(err)
+				return
+			}
+
+			if res.StatusCode >= 500 {
+				b.Errorf("Invocation failed with %d: %s", res.StatusCode, res.Body)
+				return
+			}
+
+		}
+
+	})
+
+}
+
+// UnaryEvent returns the events of a unary call of method with msg, padded
+// to at least payloadSize bytes.
+func UnaryEvent(method string, msg proto.Message, payloadSize int) func(i int) *events.APIGatewayProxyRequest {
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(Pad(body, payloadSize))
+
+	return func(i int) *events.APIGatewayProxyRequest {
+		return &events.APIGatewayProxyRequest{
+			Path:            method,
+			HTTPMethod:      "POST",
+			Body:            encoded,
+			IsBase64Encoded: true,
+			Headers: map[string]string{
+				"Content-Type": "application/grpc+proto",
+			},
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID:  fmt.Sprintf("bench-%d", i),
+				HTTPMethod: "POST",
+				Path:       method,
+			},
+		}
+	}
+
+}
+
+// Pad appends an unknown field to the encoded message so it's at least size
+// bytes long.
+func Pad(body []byte, size int) []byte {
+
+	if len(body) >= size {
+		return body
+	}
+
+	padding := size - len(body) - protowire.SizeTag(paddingField)
+
+	// The length prefix shrinks the padding, which may shrink the prefix.
+	for padding > 0 && protowire.SizeBytes(padding) > size-len(body)-protowire.SizeTag(paddingField) {
+		padding--
+	}
+
+	if padding < 0 {
+		padding = 0
+	}
+
+	body = protowire.AppendTag(body, paddingField, protowire.BytesType)
+
+	return protowire.AppendBytes(body, make([]byte, padding))
+
+}
@@ -0,0 +1,28 @@
+// Package blob abstracts the object storage of handlers, with an S3 driver
+// and a local filesystem driver for tests and local runs.
+package blob
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Object struct {
+	Key  string
+	Size int64
+	ETag string
+}
+
+// Store stores objects by key, missing objects fail with NotFound.
+type Store interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Stream reads an object without buffering it, the caller closes it.
+	Stream(ctx context.Context, key string) (io.ReadCloser, error)
+	// Presign returns a url granting method (GET or PUT) on key to clients
+	// without credentials until it expires.
+	Presign(ctx context.Context, method, key string, expires time.Duration) (string, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, key string) error
+}
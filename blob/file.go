@@ -0,0 +1,150 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ Store = &FileStore{}
+
+// FileStore keeps objects as files under Root, presigned urls are file://
+// urls.
+type FileStore struct {
+	Root string
+}
+
+func (f *FileStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, body, 0o644)
+
+}
+
+func (f *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+
+	body, err := f.Stream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+
+}
+
+func (f *FileStore) Stream(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, status.Errorf(codes.NotFound, "Object %s not found", key)
+	}
+
+	return file, err
+
+}
+
+func (f *FileStore) Presign(ctx context.Context, method, key string, expires time.Duration) (string, error) {
+
+	path, err := f.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+
+}
+
+func (f *FileStore) List(ctx context.Context, prefix string) ([]Object, error) {
+
+	objects := []Object{}
+
+	err := filepath.WalkDir(f.Root, func(path string, entry fs.DirEntry, err error) error {
+
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(f.Root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, Object{Key: key, Size: info.Size()})
+
+		return nil
+
+	})
+
+	return objects, err
+
+}
+
+func (f *FileStore) Delete(ctx context.Context, key string) error {
+
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return status.Errorf(codes.NotFound, "Object %s not found", key)
+	}
+
+	return err
+
+}
+
+// path resolves key under Root, rejecting keys escaping it.
+func (f *FileStore) path(key string) (string, error) {
+
+	path := filepath.Join(f.Root, filepath.FromSlash(key))
+
+	rel, err := filepath.Rel(f.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid key %s", key)
+	}
+
+	return path, nil
+
+}
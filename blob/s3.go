@@ -0,0 +1,143 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ Store = &S3Store{}
+
+type S3Store struct {
+	Client *awsapi.Client
+	Bucket string
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body []byte, contentType string) error {
+
+	req, err := http.NewRequest(http.MethodPut, s.Client.S3ObjectUrl(s.Bucket, key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := s.Client.Do(ctx, "s3", req, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return s.checkResponse(req.Method, res, key)
+
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+
+	body, err := s.Stream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+
+}
+
+func (s *S3Store) Stream(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	req, err := http.NewRequest(http.MethodGet, s.Client.S3ObjectUrl(s.Bucket, key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.Client.Do(ctx, "s3", req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkResponse(req.Method, res, key); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	return res.Body, nil
+
+}
+
+func (s *S3Store) Presign(ctx context.Context, method, key string, expires time.Duration) (string, error) {
+	return s.Client.PresignS3(ctx, method, s.Bucket, key, expires)
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+
+	objects, err := s.Client.ListS3Objects(ctx, s.Bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Object, len(objects))
+	for i, object := range objects {
+		list[i] = Object{Key: object.Key, Size: object.Size, ETag: object.ETag}
+	}
+
+	return list, nil
+
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+
+	req, err := http.NewRequest(http.MethodDelete, s.Client.S3ObjectUrl(s.Bucket, key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.Client.Do(ctx, "s3", req, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return s.checkResponse(req.Method, res, key)
+
+}
+
+// checkResponse maps the failures of S3, buckets without list permission
+// answer Forbidden for missing objects, which only reads can tell.
+func (s *S3Store) checkResponse(method string, res *http.Response, key string) error {
+
+	read := method == http.MethodGet || method == http.MethodHead
+
+	switch {
+
+	case res.StatusCode == http.StatusNotFound, res.StatusCode == http.StatusForbidden && read:
+		return status.Errorf(codes.NotFound, "Object %s not found", key)
+
+	case res.StatusCode == http.StatusForbidden:
+		return status.Errorf(codes.PermissionDenied, "Access to object %s denied", key)
+
+	}
+
+	if res.StatusCode >= 300 {
+
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+
+		return &awsapi.Error{
+			StatusCode: res.StatusCode,
+			Code:       http.StatusText(res.StatusCode),
+			Message:    string(bytes.TrimSpace(body)),
+		}
+
+	}
+
+	return nil
+
+}
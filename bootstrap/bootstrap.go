@@ -0,0 +1,322 @@
+// Package bootstrap assembles a controller serving gRPC services with the
+// usual interceptors, configured from the environment and Parameter Store,
+// so main() of a service boils down to one call:
+//
+//	func main() {
+//		bootstrap.Start(newRoot(), bootstrap.Options[*root]{
+//			Services: []bootstrap.Service{
+//				{Desc: ordersv1.Orders_ServiceDesc, Impl: &ordersServer{}},
+//			},
+//			Authorizer: &authz.RoleAuthorizer{Roles: roles},
+//		})
+//	}
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/authz"
+	"github.com/protomesh/protomesh-go/aws/appconfig"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/envelope"
+	"github.com/protomesh/protomesh-go/lifecycle"
+	"google.golang.org/grpc"
+)
+
+// Config is read from the environment (e.g. BASE_PATH for base.path).
+type Config struct {
+	BasePath           app.Config `config:"base.path,str" usage:"Base path of the controller routes"`
+	ErrorPolicy        app.Config `config:"error.policy,str" default:"swallow" usage:"Invocation errors policy: swallow, 5xx or always"`
+	StatusHandler      app.Config `config:"status.handler,bool" usage:"Expose the status report of the controller"`
+	DescriptorsHandler app.Config `config:"descriptors.handler,bool" usage:"Expose the descriptor set of the services"`
+	GraphHandler       app.Config `config:"dependencies.handler,bool" usage:"Expose the dependency graph of the modules"`
+	ParametersPath     app.Config `config:"parameters.path,str" usage:"Parameter Store path of the dynamic configuration"`
+	ParametersTTL      app.Config `config:"parameters.ttl,duration" default:"1m" usage:"Refresh interval of the dynamic configuration"`
+}
+
+// Telemetry holds the OpenTelemetry interceptors of the calls, e.g.
+// otelgrpc.UnaryServerInterceptor() and otelgrpc.StreamServerInterceptor().
+type Telemetry struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// Service is a generated service registration.
+type Service struct {
+	Desc grpc.ServiceDesc
+	Impl interface{}
+	// Attributes of the methods, e.g. lambda.WithMethodTimeout.
+	Options []lambda.ServiceOption
+}
+
+type Options[D app.Dependency] struct {
+	Services []Service
+	// Enables the principal middleware and the authz interceptor when set.
+	Authorizer authz.Authorizer
+	Principal  authz.PrincipalOptions
+	// Registered right after the recovery interceptor, so that the spans
+	// cover the access log and the authorization of the calls.
+	Telemetry Telemetry
+	// Appended after the built-in interceptors.
+	UnaryInterceptors  []grpc.UnaryServerInterceptor
+	StreamInterceptors []grpc.StreamServerInterceptor
+	Middlewares        []lambda.Middleware
+	// Starts the modules of deps (see lifecycle.Manager.Discover) once the
+	// configuration is loaded, invocations wait for their readiness.
+	Lifecycle *lifecycle.Manager
+	// Serves a private REST API (see lambda.HandlePrivateLambda), the
+	// callers being restricted by the policy when it lists VPCs or
+	// endpoints.
+	PrivateAPI *lambda.VPCPolicy
+	// Rejects the new requests of its routes while draining, see
+	// lambda.Drain.
+	Drain *lambda.Drain
+	// Serves the invocations of the Envoy AWS Lambda filter instead of API
+	// Gateway ones, see lambda.EnvoyHandler.
+	Envoy bool
+	// Serves the API Gateway proxy invocations with the Runtime API loop
+	// of the package instead of aws-lambda-go's, see lambda.Runtime.
+	Runtime *lambda.Runtime
+	// Registered by New, runs its flushers once each invocation served by
+	// Start is answered, see lambda.Extension.
+	Extension *lambda.Extension
+	// Runs last, for registrations the options don't cover.
+	Configure func(controller *lambda.Controller[D]) error
+}
+
+// New injects the configuration into deps and assembles the controller:
+// recovery and context error middlewares, recovery, telemetry, access log
+// and trace propagation interceptors, then the authorization ones and the
+// extra interceptors of opts.
+func New[D app.Dependency](deps D, opts Options[D]) (*lambda.Controller[D], error) {
+
+	appOpts := &app.AppOptions{}
+	application := app.NewApp(deps, appOpts)
+
+	cfg := &Config{}
+	appOpts.ApplyConfigs(cfg)
+
+	controller := lambda.NewController[D]()
+	controller.Injector = &app.Injector[D]{}
+	controller.Attach(application, deps)
+
+	controller.Matcher = lambda.MakeUrlPathMatcher(cfg.BasePath.StringVal())
+
+	policy, err := lambda.ParseErrorPolicy(cfg.ErrorPolicy.StringVal())
+	if err != nil {
+		return nil, err
+	}
+
+	controller.ErrorPolicy = policy
+
+	if opts.Extension != nil {
+
+		if opts.Extension.Log == nil {
+			opts.Extension.Log = controller.Log()
+		}
+
+		if err := opts.Extension.Register(context.Background()); err != nil {
+			return nil, err
+		}
+
+	}
+
+	controller.RegisterMiddleware(lambda.RecoveryMiddleware(), lambda.ContextErrorMiddleware())
+
+	if opts.Drain != nil {
+		controller.RegisterMiddleware(opts.Drain.Middleware())
+	}
+
+	if opts.PrivateAPI != nil && len(opts.PrivateAPI.Endpoints)+len(opts.PrivateAPI.Vpcs) > 0 {
+		controller.RegisterMiddleware(lambda.VPCPolicyMiddleware(*opts.PrivateAPI))
+	}
+
+	if cfg.ParametersPath.IsSet() && len(cfg.ParametersPath.StringVal()) > 0 {
+
+		watcher := appconfig.NewWatcher(&appconfig.ParameterStoreSource{
+			Client: awsapi.NewClientFromEnv(),
+			Path:   cfg.ParametersPath.StringVal(),
+		}, cfg.ParametersTTL.DurationVal())
+
+		watcher.Bind(deps)
+
+		if err := watcher.Load(); err != nil {
+			return nil, fmt.Errorf("Failed to load parameters of %s: %w", cfg.ParametersPath.StringVal(), err)
+		}
+
+		controller.RegisterMiddleware(watcher.Middleware())
+
+	}
+
+	if opts.Lifecycle != nil {
+
+		opts.Lifecycle.Discover(deps)
+
+		if err := opts.Lifecycle.Start(context.Background()); err != nil {
+			return nil, err
+		}
+
+		opts.Lifecycle.StopOnSignal(controller.Log())
+
+		controller.RegisterMiddleware(opts.Lifecycle.Middleware())
+		controller.RegisterHealthCheck("lifecycle", opts.Lifecycle.Ready)
+
+	}
+
+	controller.RegisterUnaryInterceptor(lambda.RecoveryInterceptor())
+
+	if opts.Telemetry.Unary != nil {
+		controller.RegisterUnaryInterceptor(opts.Telemetry.Unary)
+	}
+
+	if opts.Telemetry.Stream != nil {
+		controller.RegisterStreamInterceptor(opts.Telemetry.Stream)
+	}
+
+	controller.RegisterUnaryInterceptor(lambda.AccessLogInterceptor(), traceInterceptor)
+
+	if opts.Authorizer != nil {
+		controller.RegisterMiddleware(authz.PrincipalMiddleware(opts.Principal))
+		controller.RegisterUnaryInterceptor(authz.Interceptor(authz.Options{Authorizer: opts.Authorizer}))
+		controller.RegisterStreamInterceptor(authz.StreamInterceptor(authz.Options{Authorizer: opts.Authorizer}))
+	}
+
+	controller.RegisterMiddleware(opts.Middlewares...)
+	controller.RegisterUnaryInterceptor(opts.UnaryInterceptors...)
+	controller.RegisterStreamInterceptor(opts.StreamInterceptors...)
+
+	for _, service := range opts.Services {
+		if err := controller.TryRegisterGRPCService(service.Desc, service.Impl, service.Options...); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.StatusHandler.BoolVal() {
+		if err := controller.TryRegisterStatusHandler(lambda.StatusOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.DescriptorsHandler.BoolVal() {
+		if err := controller.RegisterDescriptorHandler(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Lifecycle != nil && cfg.GraphHandler.BoolVal() {
+		if err := controller.TryRegisterHandler(lifecycle.GraphHandlerKey, opts.Lifecycle.GraphHandler(deps)); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Configure != nil {
+		if err := opts.Configure(controller); err != nil {
+			return nil, err
+		}
+	}
+
+	return controller, nil
+
+}
+
+// Start assembles the controller and serves the invocations, it never
+// returns.
+func Start[D app.Dependency](deps D, opts Options[D]) {
+
+	controller, err := New(deps, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to bootstrap controller:", err)
+		os.Exit(1)
+	}
+
+	handler := lambda.InvocationHandler(controller)
+	if opts.Extension != nil {
+		handler = opts.Extension.Wrap(controller)
+	}
+
+	if opts.Envoy {
+		awslambda.Start(lambda.EnvoyHandler(handler))
+		return
+	}
+
+	if opts.PrivateAPI != nil {
+		awslambda.Start(func(ctx context.Context, privateReq *lambda.PrivateProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+			if opts.Extension != nil {
+				defer opts.Extension.Done(ctx)
+			}
+
+			return controller.HandlePrivateLambda(ctx, privateReq)
+
+		})
+		return
+	}
+
+	if opts.Runtime != nil {
+		opts.Runtime.Start(lambda.ProxyRuntimeHandler(handler))
+		return
+	}
+
+	awslambda.Start(handler.HandleLambda)
+
+}
+
+// Mount is a controller of a bundle, assembled by New with its own
+// options and dependencies.
+type Mount struct {
+	// Base path of the controller routes, stripped before they're matched
+	// so BASE_PATH stays unset.
+	BasePath   string
+	Controller lambda.InvocationHandler
+}
+
+// NewBundle mounts the controllers under their base path, see lambda.Mux.
+// The bundle serves the methods of its controllers in process to the
+// clients using it as lambda.ClientConn.Local.
+func NewBundle(mounts ...Mount) (*lambda.Mux, error) {
+
+	mux := lambda.NewMux()
+
+	for _, m := range mounts {
+		if err := mux.Mount(m.BasePath, m.Controller); err != nil {
+			return nil, err
+		}
+	}
+
+	return mux, nil
+
+}
+
+// StartBundle serves the invocations with the bundle of the controllers,
+// it never returns.
+//
+//	orders, err := bootstrap.New(newOrdersRoot(), bootstrap.Options[*ordersRoot]{...})
+//	billing, err := bootstrap.New(newBillingRoot(), bootstrap.Options[*billingRoot]{...})
+//
+//	bootstrap.StartBundle(
+//		bootstrap.Mount{BasePath: "/orders", Controller: orders},
+//		bootstrap.Mount{BasePath: "/billing", Controller: billing},
+//	)
+func StartBundle(mounts ...Mount) {
+
+	mux, err := NewBundle(mounts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to bootstrap bundle:", err)
+		os.Exit(1)
+	}
+
+	awslambda.Start(mux.HandleLambda)
+
+}
+
+// traceInterceptor propagates the incoming W3C and X-Ray trace context to
+// the calls made by the handlers.
+func traceInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(envelope.TraceFromContext(ctx).AppendToOutgoingContext(ctx), req)
+}
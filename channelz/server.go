@@ -0,0 +1,113 @@
+// Package channelz serves the statistics of the Lambda client connections
+// as a debug service.
+package channelz
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go --go-grpc_out=.. --go-grpc_opt=module=github.com/protomesh/protomesh-go protomesh/channelz/v1/channelz.proto
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ ChannelzServer = &Server{}
+
+// Server lists the registered connections, it exposes targets and errors
+// so its methods require the channelz.admin permission (see
+// authz.Interceptor):
+//
+//	server := channelz.NewServer()
+//	server.Register("orders", ordersConn)
+//	controller.RegisterGRPCService(channelz.Channelz_ServiceDesc, server)
+type Server struct {
+	UnimplementedChannelzServer
+
+	lock  sync.Mutex
+	conns map[string]*lambda.ClientConn
+}
+
+func NewServer() *Server {
+	return &Server{
+		conns: make(map[string]*lambda.ClientConn),
+	}
+}
+
+func (s *Server) Register(name string, conn *lambda.ClientConn) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.conns[name] = conn
+
+}
+
+func (s *Server) ListChannels(ctx context.Context, req *ListChannelsRequest) (*ListChannelsResponse, error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res := &ListChannelsResponse{}
+
+	for name, conn := range s.conns {
+		res.Channels = append(res.Channels, newChannel(name, conn.Stats()))
+	}
+
+	sort.Slice(res.Channels, func(i, j int) bool {
+		return res.Channels[i].Name < res.Channels[j].Name
+	})
+
+	return res, nil
+
+}
+
+func newChannel(name string, stats *lambda.ConnStats) *Channel {
+
+	channel := &Channel{
+		Name:  name,
+		Calls: newCallStats(stats.Calls),
+		Resolver: &ResolverState{
+			LastResolved: timestamp(stats.Resolver.LastResolved),
+			Targets:      stats.Resolver.Targets,
+			LastError:    stats.Resolver.LastError,
+		},
+	}
+
+	for key, calls := range stats.Targets {
+		channel.Targets = append(channel.Targets, &Target{
+			Key:   key,
+			Calls: newCallStats(calls),
+		})
+	}
+
+	sort.Slice(channel.Targets, func(i, j int) bool {
+		return channel.Targets[i].Key < channel.Targets[j].Key
+	})
+
+	return channel
+
+}
+
+func newCallStats(stats lambda.CallStats) *CallStats {
+	return &CallStats{
+		CallsStarted:    stats.CallsStarted,
+		CallsSucceeded:  stats.CallsSucceeded,
+		CallsFailed:     stats.CallsFailed,
+		LastCallStarted: timestamp(stats.LastCallStarted),
+		LastError:       stats.LastError,
+		LastErrorTime:   timestamp(stats.LastErrorTime),
+	}
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+
+}
@@ -0,0 +1,163 @@
+// Package clock provides the time and the random ids of the handlers
+// through the context, set by the controller, so tests swap them for fake
+// implementations:
+//
+//	controller.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	controller.Ids = &clock.SequentialIds{}
+//
+// The dependencies injected into the controller may provide them instead,
+// implementing Provider and IdGeneratorProvider.
+//
+// Expirations, timestamps and refresh intervals read the clock, latency
+// measurements and request signatures keep the wall clock.
+package clock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Clock tells the time to the time-dependent logic of the handlers
+// (expirations, timestamps), see FromContext.
+type Clock interface {
+	Now() time.Time
+}
+
+// IdGenerator fills the random ids and tokens of the handlers (request
+// ids, session ids...), see IdGeneratorFromContext.
+type IdGenerator interface {
+	Read(id []byte)
+}
+
+// Provider is implemented by the dependencies providing the clock of the
+// controller they are injected into.
+type Provider interface {
+	Clock() Clock
+}
+
+// IdGeneratorProvider is implemented by the dependencies providing the id
+// generator of the controller they are injected into.
+type IdGeneratorProvider interface {
+	IdGenerator() IdGenerator
+}
+
+// System is the clock of the host.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// RandomIds reads ids from crypto/rand.
+type RandomIds struct{}
+
+func (RandomIds) Read(id []byte) {
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+}
+
+// Fake is a clock only moving when told, for deterministic tests.
+type Fake struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.now
+
+}
+
+func (f *Fake) Set(now time.Time) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.now = now
+
+}
+
+func (f *Fake) Advance(d time.Duration) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.now = f.now.Add(d)
+
+}
+
+// SequentialIds fills the ids with a counter starting at 1, big endian in
+// the last 8 bytes, so the tests know the ids in advance.
+type SequentialIds struct {
+	lock sync.Mutex
+	next uint64
+}
+
+func (s *SequentialIds) Read(id []byte) {
+
+	s.lock.Lock()
+	s.next++
+	n := s.next
+	s.lock.Unlock()
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, n)
+
+	for i := range id {
+		id[i] = 0
+	}
+
+	if len(id) >= 8 {
+		copy(id[len(id)-8:], counter)
+	} else {
+		copy(id, counter[8-len(id):])
+	}
+
+}
+
+type clockContextKey struct{}
+
+type idGeneratorContextKey struct{}
+
+func ContextWithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+// FromContext returns the clock of the controller, System when it has
+// none.
+func FromContext(ctx context.Context) Clock {
+
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+
+	return System{}
+
+}
+
+func ContextWithIdGenerator(ctx context.Context, ids IdGenerator) context.Context {
+	return context.WithValue(ctx, idGeneratorContextKey{}, ids)
+}
+
+// IdGeneratorFromContext returns the id generator of the controller,
+// RandomIds when it has none.
+func IdGeneratorFromContext(ctx context.Context) IdGenerator {
+
+	if ids, ok := ctx.Value(idGeneratorContextKey{}).(IdGenerator); ok {
+		return ids
+	}
+
+	return RandomIds{}
+
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+func invokeCommand(ctx context.Context, args []string) error {
+
+	t := &target{}
+	headers := headerFlags{}
+
+	flags := flag.NewFlagSet("invoke", flag.ContinueOnError)
+	t.flags(flags)
+	flags.Var(&headers, "H", "Metadata sent with the call as key:value, repeatable")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: protomesh invoke [flags] /package.Service/Method [json|-]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	if err := t.validate(); err != nil {
+		return err
+	}
+
+	method := flags.Arg(0)
+
+	input := []byte("{}")
+
+	switch flags.Arg(1) {
+	case "":
+	case "-":
+
+		stdin, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		input = stdin
+
+	default:
+		input = []byte(flags.Arg(1))
+	}
+
+	// Schemas are resolved from the controller, the CLI needs no generated
+	// code.
+	body, err := t.get(ctx, lambda.DescriptorsHandlerKey, "application/x-protobuf")
+	if err != nil {
+		return err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(body, set); err != nil {
+		return fmt.Errorf("Invalid descriptor set: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return fmt.Errorf("Invalid descriptor set: %w", err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", 1)))
+	if err != nil {
+		return fmt.Errorf("Method %s not served: %w", method, err)
+	}
+
+	methodDesc, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return fmt.Errorf("%s is not a method", method)
+	}
+
+	types := dynamicpb.NewTypes(files)
+
+	in := dynamicpb.NewMessage(methodDesc.Input())
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(input, in); err != nil {
+		return fmt.Errorf("Invalid input: %w", err)
+	}
+
+	for _, header := range headers {
+
+		k, v, ok := strings.Cut(header, ":")
+		if !ok {
+			return fmt.Errorf("Invalid header %s, expected key:value", header)
+		}
+
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.TrimSpace(k), strings.TrimSpace(v))
+
+	}
+
+	out := dynamicpb.NewMessage(methodDesc.Output())
+
+	if err := t.conn().Invoke(ctx, "/"+strings.TrimPrefix(method, "/"), in, out); err != nil {
+		return err
+	}
+
+	output, err := (protojson.MarshalOptions{Resolver: types, Multiline: true}).Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+
+	return nil
+
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+)
+
+func logsCommand(ctx context.Context, args []string) error {
+
+	var function, pattern string
+	var since time.Duration
+	var follow bool
+
+	flags := flag.NewFlagSet("logs", flag.ContinueOnError)
+	flags.StringVar(&function, "function", "", "Name of the function")
+	flags.StringVar(&pattern, "filter", "", "CloudWatch Logs filter pattern (e.g. { $.level = \"ERROR\" })")
+	flags.DurationVar(&since, "since", 10*time.Minute, "How far back to start")
+	flags.BoolVar(&follow, "follow", false, "Keep polling for new events")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(function) == 0 {
+		return fmt.Errorf("-function is required")
+	}
+
+	client := awsapi.NewClientFromEnv()
+
+	start := time.Now().Add(-since)
+	seen := map[string]bool{}
+
+	for {
+
+		next, err := printLogEvents(ctx, client, "/aws/lambda/"+function, pattern, start, seen)
+		if err != nil {
+			return err
+		}
+
+		if !follow {
+			return nil
+		}
+
+		start = next
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(2 * time.Second):
+		}
+
+	}
+
+}
+
+// printLogEvents prints the events from start, skipping the ids already
+// printed, and returns the start of the next poll.
+func printLogEvents(ctx context.Context, client *awsapi.Client, logGroup, pattern string, start time.Time, seen map[string]bool) (time.Time, error) {
+
+	in := map[string]interface{}{
+		"logGroupName": logGroup,
+		"startTime":    start.UnixMilli(),
+	}
+
+	if len(pattern) > 0 {
+		in["filterPattern"] = pattern
+	}
+
+	last := start
+
+	for {
+
+		out := struct {
+			Events []struct {
+				EventId       string `json:"eventId"`
+				LogStreamName string `json:"logStreamName"`
+				Timestamp     int64  `json:"timestamp"`
+				Message       string `json:"message"`
+			} `json:"events"`
+			NextToken string `json:"nextToken"`
+		}{}
+
+		if err := client.CallJSON(ctx, "logs", "1.1", "Logs_20140328.FilterLogEvents", in, &out); err != nil {
+			return start, err
+		}
+
+		for _, event := range out.Events {
+
+			if seen[event.EventId] {
+				continue
+			}
+
+			seen[event.EventId] = true
+
+			t := time.UnixMilli(event.Timestamp)
+			if t.After(last) {
+				last = t
+			}
+
+			fmt.Printf("%s %s", t.Format(time.RFC3339Nano), event.Message)
+
+		}
+
+		if len(out.NextToken) == 0 {
+			// Events of the same millisecond may still arrive.
+			return last, nil
+		}
+
+		in["nextToken"] = out.NextToken
+
+	}
+
+}
@@ -0,0 +1,62 @@
+// Command protomesh operates deployed controllers: it lists their routes,
+// invokes their methods with JSON payloads and tails their logs.
+//
+//	protomesh routes -function orders
+//	protomesh invoke -url https://api.example.com/prod /orders.v1.Orders/GetOrder '{"id": "42"}'
+//	protomesh logs -function orders -since 10m -follow
+//
+// AWS credentials and region are read from the environment.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: protomesh <command> [flags]
+
+Commands:
+  routes   List the routes of a controller (requires its status handler)
+  invoke   Invoke a method with a JSON payload (requires its descriptor handler)
+  logs     Tail the logs of a function
+
+Run protomesh <command> -h for the flags of a command.
+`
+
+func main() {
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	commands := map[string]func(ctx context.Context, args []string) error{
+		"routes": routesCommand,
+		"invoke": invokeCommand,
+		"logs":   logsCommand,
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := command(ctx, os.Args[2:]); err != nil {
+
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+
+	}
+
+}
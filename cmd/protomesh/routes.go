@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+)
+
+func routesCommand(ctx context.Context, args []string) error {
+
+	t := &target{}
+
+	flags := flag.NewFlagSet("routes", flag.ContinueOnError)
+	t.flags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := t.validate(); err != nil {
+		return err
+	}
+
+	body, err := t.get(ctx, lambda.StatusHandlerKey, "application/json")
+	if err != nil {
+		return err
+	}
+
+	st := struct {
+		Routes []lambda.Route `json:"routes"`
+	}{}
+
+	if err := json.Unmarshal(body, &st); err != nil {
+		return fmt.Errorf("Invalid status report: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "KEY\tKIND\tSOURCE")
+
+	for _, route := range st.Routes {
+
+		kind := string(route.Kind)
+		if len(route.Target) > 0 {
+			kind += " -> " + route.Target
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", route.Key, kind, route.Source)
+
+	}
+
+	return w.Flush()
+
+}
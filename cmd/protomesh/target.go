@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc"
+)
+
+// target is the controller called, either through its API Gateway stage or
+// by invoking its function.
+type target struct {
+	url       string
+	iam       bool
+	function  string
+	qualifier string
+
+	client *awsapi.Client
+}
+
+func (t *target) flags(flags *flag.FlagSet) {
+	flags.StringVar(&t.url, "url", "", "Base URL of the API Gateway stage")
+	flags.BoolVar(&t.iam, "iam", false, "Sign the HTTP requests for IAM authorized stages")
+	flags.StringVar(&t.function, "function", "", "Name or ARN of the function, invoked directly")
+	flags.StringVar(&t.qualifier, "qualifier", "", "Version or alias of the function")
+}
+
+func (t *target) validate() error {
+
+	if (len(t.url) == 0) == (len(t.function) == 0) {
+		return fmt.Errorf("Either -url or -function is required")
+	}
+
+	t.client = awsapi.NewClientFromEnv()
+
+	return nil
+
+}
+
+// conn returns the gRPC client of the controller.
+func (t *target) conn() grpc.ClientConnInterface {
+
+	if len(t.url) > 0 {
+
+		conn := &lambda.HTTPClientConn{Url: t.url}
+
+		if t.iam {
+			conn.Client = t.client
+		}
+
+		return conn
+
+	}
+
+	target := &lambda.Target{FunctionName: t.function, Qualifier: t.qualifier}
+
+	return lambda.NewClientConn(t.client, func(ctx context.Context, fullMethod string) ([]*lambda.Target, error) {
+		return []*lambda.Target{target}, nil
+	})
+
+}
+
+// get fetches a handler of the controller which isn't a gRPC method.
+func (t *target) get(ctx context.Context, path, accept string) ([]byte, error) {
+
+	if len(t.url) > 0 {
+		return t.getHTTP(ctx, path, accept)
+	}
+
+	proxyReq := &events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       path,
+		Headers:    map[string]string{"accept": accept},
+	}
+
+	payload, err := json.Marshal(proxyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/2015-03-31/functions/%s/invocations", t.client.Endpoint("lambda"), url.PathEscape(t.function))
+	if len(t.qualifier) > 0 {
+		endpoint += "?Qualifier=" + url.QueryEscape(t.qualifier)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := readResponse(t.client.Do(ctx, "lambda", req, payload))
+	if err != nil {
+		return nil, err
+	}
+
+	proxyRes := &events.APIGatewayProxyResponse{}
+	if err := json.Unmarshal(body, proxyRes); err != nil {
+		return nil, fmt.Errorf("Invalid response of %s: %w", t.function, err)
+	}
+
+	if proxyRes.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s answered %d: %s", path, proxyRes.StatusCode, proxyRes.Body)
+	}
+
+	if proxyRes.IsBase64Encoded {
+		return lambda.DecodeBase64(proxyRes.Body)
+	}
+
+	return []byte(proxyRes.Body), nil
+
+}
+
+func (t *target) getHTTP(ctx context.Context, path, accept string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(t.url, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", accept)
+
+	if t.iam {
+		return readResponse(t.client.Do(ctx, "execute-api", req, nil))
+	}
+
+	return readResponse(http.DefaultClient.Do(req))
+
+}
+
+func readResponse(res *http.Response, err error) ([]byte, error) {
+
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s answered %d: %s", res.Request.URL.Path, res.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return body, nil
+
+}
@@ -0,0 +1,215 @@
+// Package contracttest replays example requests derived from the
+// descriptors against a controller, asserting the invariants of the
+// responses and comparing them with golden files:
+//
+//	func TestContract(t *testing.T) {
+//		contracttest.Run(t, controller.HandleLambda, controller.Routes(), contracttest.Options{
+//			GoldenDir: "testdata/contract",
+//		})
+//	}
+package contracttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// UpdateEnv rewrites the golden files when set to 1, as does the
+// -contract.update flag.
+const UpdateEnv = "PROTOMESH_UPDATE_GOLDEN"
+
+var update = flag.Bool("contract.update", false, "Rewrite the contract golden files")
+
+// Handler is the entrypoint under test, e.g. Controller.HandleLambda.
+type Handler func(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
+
+type Options struct {
+	// Requests by full method, replacing the descriptor-derived examples.
+	Requests map[string]proto.Message
+	// Headers of every request (e.g. authorization).
+	Headers map[string]string
+	// Codes accepted by method besides OK. Defaults to InvalidArgument,
+	// NotFound, FailedPrecondition, PermissionDenied and Unauthenticated.
+	Codes map[string][]codes.Code
+	// Methods skipped, e.g. the ones with side effects on real resources.
+	Skip map[string]bool
+	// Directory of the golden files of the JSON responses, one per method
+	// (e.g. acme.users.v1.Users/Get.json). Disabled when empty.
+	GoldenDir string
+	// Clears the non-deterministic fields (e.g. timestamps) of the responses
+	// before the golden comparison.
+	Normalize func(method string, res proto.Message)
+}
+
+var defaultCodes = []codes.Code{
+	codes.InvalidArgument,
+	codes.NotFound,
+	codes.FailedPrecondition,
+	codes.PermissionDenied,
+	codes.Unauthenticated,
+}
+
+// Run calls every unary route with its example request in a subtest and
+// asserts that:
+//   - the handler answers without server error, with a 2xx status or an
+//     accepted code;
+//   - the 2xx responses decode as the output of the method without unknown
+//     fields;
+//   - their JSON form matches the golden file.
+func Run(t *testing.T, handler Handler, routes []lambda.Route, opts Options) {
+
+	for _, route := range routes {
+
+		if route.Kind != lambda.RouteKindUnary {
+			continue
+		}
+
+		route := route
+		method := "/" + route.Service + "/" + route.Method
+
+		if opts.Skip[method] {
+			continue
+		}
+
+		t.Run(strings.TrimPrefix(route.Key, "/"), func(t *testing.T) {
+			check(t, handler, route, method, opts)
+		})
+
+	}
+
+}
+
+func check(t *testing.T, handler Handler, route lambda.Route, method string, opts Options) {
+
+	desc, ok := lambda.MethodDescriptor(method)
+	if !ok {
+		t.Fatalf("No descriptor registered for %s", method)
+	}
+
+	in, ok := opts.Requests[method]
+	if !ok {
+		in = Example(desc.Input()).Interface()
+	}
+
+	body, err := proto.Marshal(in)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	req := &events.APIGatewayProxyRequest{
+		Path:            route.Key,
+		HTTPMethod:      http.MethodPost,
+		Body:            base64.StdEncoding.EncodeToString(body),
+		IsBase64Encoded: true,
+		Headers: map[string]string{
+			"Content-Type": "application/grpc+proto",
+		},
+	}
+
+	for k, v := range opts.Headers {
+		req.Headers[k] = v
+	}
+
+	res, err := handler(context.Background(), req)
+	if res == nil {
+		t.Fatalf("No response (error %v)", err)
+	}
+
+	code := lambda.CodeFromHTTPStatus(res.StatusCode)
+
+	if res.StatusCode >= 300 {
+
+		accepted := opts.Codes[method]
+		if accepted == nil {
+			accepted = defaultCodes
+		}
+
+		for _, c := range accepted {
+			if c == code {
+				return
+			}
+		}
+
+		t.Fatalf("Unexpected status %d (%s): %s", res.StatusCode, code, res.Body)
+
+	}
+
+	out := lambda.NewMethodOutput(method)
+
+	data := []byte(res.Body)
+	if res.IsBase64Encoded {
+		if data, err = lambda.DecodeBase64(res.Body); err != nil {
+			t.Fatalf("Invalid base64 response: %v", err)
+		}
+	}
+
+	if err := proto.Unmarshal(data, out); err != nil {
+		t.Fatalf("Response doesn't decode as %s: %v", desc.Output().FullName(), err)
+	}
+
+	if unknown := out.ProtoReflect().GetUnknown(); len(unknown) > 0 {
+		t.Errorf("Response has %d bytes of fields unknown to %s", len(unknown), desc.Output().FullName())
+	}
+
+	if len(opts.GoldenDir) > 0 {
+		golden(t, filepath.Join(opts.GoldenDir, filepath.FromSlash(strings.TrimPrefix(method, "/"))+".json"), method, out, opts)
+	}
+
+}
+
+func golden(t *testing.T, path, method string, out proto.Message, opts Options) {
+
+	if opts.Normalize != nil {
+		opts.Normalize(method, out)
+	}
+
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(out)
+	if err != nil {
+		t.Fatalf("Failed to marshal response to JSON: %v", err)
+	}
+
+	// protojson randomizes its whitespace, the golden files are indented.
+	got := &bytes.Buffer{}
+	if err := json.Indent(got, body, "", "  "); err != nil {
+		t.Fatalf("Failed to indent response: %v", err)
+	}
+	got.WriteByte('\n')
+
+	if *update || os.Getenv(UpdateEnv) == "1" {
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Missing golden file (run with -contract.update or %s=1): %v", UpdateEnv, err)
+	}
+
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("Response differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+
+}
@@ -0,0 +1,146 @@
+package contracttest
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Depth of the nested messages populated by Example, recursive messages
+// would be infinite otherwise.
+const exampleDepth = 3
+
+// Example returns a message of desc with every field populated with an
+// example value: scalars are non-zero, repeated and map fields hold one
+// entry and the first member of the oneofs is set.
+func Example(desc protoreflect.MessageDescriptor) protoreflect.Message {
+
+	msg := newMessage(desc)
+
+	populate(msg, exampleDepth)
+
+	return msg
+
+}
+
+func newMessage(desc protoreflect.MessageDescriptor) protoreflect.Message {
+
+	if msgType, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName()); err == nil {
+		return msgType.New()
+	}
+
+	return dynamicpb.NewMessage(desc)
+
+}
+
+func populate(msg protoreflect.Message, depth int) {
+
+	fields := msg.Descriptor().Fields()
+
+	for i := 0; i < fields.Len(); i++ {
+
+		field := fields.Get(i)
+
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && oneof.Fields().Get(0) != field {
+			continue
+		}
+
+		if field.Message() != nil && depth == 0 {
+			continue
+		}
+
+		switch {
+
+		case field.IsMap():
+			value, ok := exampleValue(msg.NewField(field).Map().NewValue(), field.MapValue(), depth)
+			if !ok {
+				continue
+			}
+			key, _ := exampleValue(protoreflect.Value{}, field.MapKey(), depth)
+			msg.Mutable(field).Map().Set(key.MapKey(), value)
+
+		case field.IsList():
+			value, ok := exampleValue(msg.NewField(field).List().NewElement(), field, depth)
+			if !ok {
+				continue
+			}
+			msg.Mutable(field).List().Append(value)
+
+		default:
+			value, ok := exampleValue(msg.NewField(field), field, depth)
+			if !ok {
+				continue
+			}
+			msg.Set(field, value)
+
+		}
+
+	}
+
+}
+
+// exampleValue returns the example of a singular value of field, zero is the
+// new value of message fields.
+func exampleValue(zero protoreflect.Value, field protoreflect.FieldDescriptor, depth int) (protoreflect.Value, bool) {
+
+	switch field.Kind() {
+
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true), true
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(1), true
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(1), true
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(1), true
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(1), true
+
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1.5), true
+
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1.5), true
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString("example-" + string(field.Name())), true
+
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(field.Name())), true
+
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		if values.Len() > 1 {
+			return protoreflect.ValueOfEnum(values.Get(1).Number()), true
+		}
+		return protoreflect.ValueOfEnum(values.Get(0).Number()), true
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if !zero.IsValid() {
+			return zero, false
+		}
+		populateWellKnown(zero.Message(), depth-1)
+		return zero, true
+
+	}
+
+	return protoreflect.Value{}, false
+
+}
+
+// populateWellKnown keeps the well-known types valid (e.g. a Timestamp
+// nanos field of 1 is valid but an Any needs a resolvable type url).
+func populateWellKnown(msg protoreflect.Message, depth int) {
+
+	switch msg.Descriptor().FullName() {
+	case "google.protobuf.Any", "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue", "google.protobuf.FieldMask":
+		return
+	}
+
+	populate(msg, depth)
+
+}
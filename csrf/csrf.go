@@ -0,0 +1,205 @@
+// Package csrf protects the browser-facing routes from cross-site request
+// forgery, with double-submit tokens and origin checks.
+package csrf
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultCookieName = "csrf_token"
+	DefaultHeaderName = "X-CSRF-Token"
+)
+
+// Policy protects a group of routes.
+type Policy struct {
+	// Requires the header to repeat the token cookie, the cookie is issued
+	// to the clients not having it yet.
+	DoubleSubmit bool
+	// Requires the Origin (or Referer) header to be the host of the request
+	// or one of AllowedOrigins.
+	CheckOrigin bool
+	// Allowed origins, e.g. "https://app.example.com".
+	AllowedOrigins []string
+	// Skips the requests without cookies, which don't carry ambient
+	// credentials (e.g. calls between services).
+	SkipWithoutCookies bool
+}
+
+type Protection struct {
+	// Policies by handler key prefix (e.g. "/acme.web.v1."), the longest
+	// matching prefix applies. The routes without policy aren't protected.
+	Groups map[string]*Policy
+	// Defaults to DefaultCookieName.
+	CookieName string
+	// Defaults to DefaultHeaderName.
+	HeaderName string
+	// Issues the token cookie without the Secure attribute, for local
+	// development only.
+	Insecure bool
+}
+
+func NewProtection(groups map[string]*Policy) *Protection {
+	return &Protection{
+		Groups:     groups,
+		CookieName: DefaultCookieName,
+		HeaderName: DefaultHeaderName,
+	}
+}
+
+type tokenContextKey struct{}
+
+// TokenFromContext returns the double-submit token of the request, to be
+// rendered in the pages issuing requests.
+func TokenFromContext(ctx context.Context) string {
+
+	token, _ := ctx.Value(tokenContextKey{}).(string)
+
+	return token
+
+}
+
+// Middleware rejects the unsafe requests of the protected routes failing
+// their policy with PermissionDenied.
+func (p *Protection) Middleware() lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			policy := p.policy(req.HandlerKey)
+			if policy == nil {
+				return next(ctx, req, res)
+			}
+
+			token := ""
+			if cookie, err := req.Cookie(p.CookieName); err == nil {
+				token = cookie.Value
+			}
+
+			if !safeMethod(req.HTTPMethod) && !(policy.SkipWithoutCookies && len(req.Header("Cookie")) == 0) {
+				if err := p.check(policy, req, token); err != nil {
+					lambda.LoggerFromContext(ctx).Warn("Rejected cross-site request", "error", err)
+					return res.WriteError(err)
+				}
+			}
+
+			if policy.DoubleSubmit && len(token) == 0 {
+				token = newToken(ctx)
+				defer res.SetCookie(p.cookie(token))
+			}
+
+			return next(context.WithValue(ctx, tokenContextKey{}, token), req, res)
+
+		}
+
+	}
+
+}
+
+func (p *Protection) policy(key string) *Policy {
+
+	var policy *Policy
+	longest := -1
+
+	for prefix, candidate := range p.Groups {
+		if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+			policy, longest = candidate, len(prefix)
+		}
+	}
+
+	return policy
+
+}
+
+func (p *Protection) check(policy *Policy, req *lambda.Request, token string) error {
+
+	if policy.CheckOrigin {
+
+		origin := req.Header("Origin")
+		if len(origin) == 0 {
+			if referer, err := url.Parse(req.Header("Referer")); err == nil && len(referer.Host) > 0 {
+				origin = referer.Scheme + "://" + referer.Host
+			}
+		}
+
+		if !p.allowedOrigin(policy, req, origin) {
+			return status.Errorf(codes.PermissionDenied, "Origin %q not allowed", origin)
+		}
+
+	}
+
+	if policy.DoubleSubmit {
+
+		header := req.Header(p.HeaderName)
+
+		if len(token) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(header)) != 1 {
+			return status.Errorf(codes.PermissionDenied, "Missing or invalid CSRF token")
+		}
+
+	}
+
+	return nil
+
+}
+
+func (p *Protection) allowedOrigin(policy *Policy, req *lambda.Request, origin string) bool {
+
+	if len(origin) == 0 {
+		return false
+	}
+
+	for _, allowed := range policy.AllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	parsed, err := url.Parse(origin)
+
+	return err == nil && len(parsed.Host) > 0 && strings.EqualFold(parsed.Host, req.Header("Host"))
+
+}
+
+func (p *Protection) cookie(token string) *http.Cookie {
+
+	// Readable by scripts, which repeat it in the header.
+	return &http.Cookie{
+		Name:     p.CookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   !p.Insecure,
+		SameSite: http.SameSiteStrictMode,
+	}
+
+}
+
+func safeMethod(method string) bool {
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+
+}
+
+func newToken(ctx context.Context) string {
+
+	token := make([]byte, 32)
+	clock.IdGeneratorFromContext(ctx).Read(token)
+
+	return base64.RawURLEncoding.EncodeToString(token)
+
+}
@@ -0,0 +1,218 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.23.4
+// source: protomesh/dynamo/v1/dynamo.proto
+
+package dynamo
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Attribute maps a field to a DynamoDB attribute.
+type Attribute struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of the attribute, defaults to the field name.
+	Name         string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PartitionKey bool   `protobuf:"varint,2,opt,name=partition_key,json=partitionKey,proto3" json:"partition_key,omitempty"`
+	SortKey      bool   `protobuf:"varint,3,opt,name=sort_key,json=sortKey,proto3" json:"sort_key,omitempty"`
+	// Integer field incremented on every put, puts of stale versions fail.
+	Version bool `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	// Field not stored.
+	Ignore bool `protobuf:"varint,5,opt,name=ignore,proto3" json:"ignore,omitempty"`
+}
+
+func (x *Attribute) Reset() {
+	*x = Attribute{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protomesh_dynamo_v1_dynamo_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Attribute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attribute) ProtoMessage() {}
+
+func (x *Attribute) ProtoReflect() protoreflect.Message {
+	mi := &file_protomesh_dynamo_v1_dynamo_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attribute.ProtoReflect.Descriptor instead.
+func (*Attribute) Descriptor() ([]byte, []int) {
+	return file_protomesh_dynamo_v1_dynamo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Attribute) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attribute) GetPartitionKey() bool {
+	if x != nil {
+		return x.PartitionKey
+	}
+	return false
+}
+
+func (x *Attribute) GetSortKey() bool {
+	if x != nil {
+		return x.SortKey
+	}
+	return false
+}
+
+func (x *Attribute) GetVersion() bool {
+	if x != nil {
+		return x.Version
+	}
+	return false
+}
+
+func (x *Attribute) GetIgnore() bool {
+	if x != nil {
+		return x.Ignore
+	}
+	return false
+}
+
+var file_protomesh_dynamo_v1_dynamo_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Attribute)(nil),
+		Field:         51004,
+		Name:          "protomesh.dynamo.v1.attribute",
+		Tag:           "bytes,51004,opt,name=attribute",
+		Filename:      "protomesh/dynamo/v1/dynamo.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional protomesh.dynamo.v1.Attribute attribute = 51004;
+	E_Attribute = &file_protomesh_dynamo_v1_dynamo_proto_extTypes[0]
+)
+
+var File_protomesh_dynamo_v1_dynamo_proto protoreflect.FileDescriptor
+
+var file_protomesh_dynamo_v1_dynamo_proto_rawDesc = []byte{
+	0x0a, 0x20, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x64, 0x79, 0x6e, 0x61,
+	0x6d, 0x6f, 0x2f, 0x76, 0x31, 0x2f, 0x64, 0x79, 0x6e, 0x61, 0x6d, 0x6f, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x64, 0x79,
+	0x6e, 0x61, 0x6d, 0x6f, 0x2e, 0x76, 0x31, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x91, 0x01, 0x0a, 0x09, 0x41, 0x74,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x70,
+	0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0c, 0x70, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79,
+	0x12, 0x19, 0x0a, 0x08, 0x73, 0x6f, 0x72, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x73, 0x6f, 0x72, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x3a, 0x5d, 0x0a,
+	0x09, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xbc, 0x8e, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x64, 0x79,
+	0x6e, 0x61, 0x6d, 0x6f, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
+	0x65, 0x52, 0x09, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x42, 0x2a, 0x5a, 0x28,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x6d, 0x65, 0x73, 0x68, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2d, 0x67,
+	0x6f, 0x2f, 0x64, 0x79, 0x6e, 0x61, 0x6d, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protomesh_dynamo_v1_dynamo_proto_rawDescOnce sync.Once
+	file_protomesh_dynamo_v1_dynamo_proto_rawDescData = file_protomesh_dynamo_v1_dynamo_proto_rawDesc
+)
+
+func file_protomesh_dynamo_v1_dynamo_proto_rawDescGZIP() []byte {
+	file_protomesh_dynamo_v1_dynamo_proto_rawDescOnce.Do(func() {
+		file_protomesh_dynamo_v1_dynamo_proto_rawDescData = protoimpl.X.CompressGZIP(file_protomesh_dynamo_v1_dynamo_proto_rawDescData)
+	})
+	return file_protomesh_dynamo_v1_dynamo_proto_rawDescData
+}
+
+var file_protomesh_dynamo_v1_dynamo_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_protomesh_dynamo_v1_dynamo_proto_goTypes = []interface{}{
+	(*Attribute)(nil),                 // 0: protomesh.dynamo.v1.Attribute
+	(*descriptorpb.FieldOptions)(nil), // 1: google.protobuf.FieldOptions
+}
+var file_protomesh_dynamo_v1_dynamo_proto_depIdxs = []int32{
+	1, // 0: protomesh.dynamo.v1.attribute:extendee -> google.protobuf.FieldOptions
+	0, // 1: protomesh.dynamo.v1.attribute:type_name -> protomesh.dynamo.v1.Attribute
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_protomesh_dynamo_v1_dynamo_proto_init() }
+func file_protomesh_dynamo_v1_dynamo_proto_init() {
+	if File_protomesh_dynamo_v1_dynamo_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protomesh_dynamo_v1_dynamo_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Attribute); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protomesh_dynamo_v1_dynamo_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_protomesh_dynamo_v1_dynamo_proto_goTypes,
+		DependencyIndexes: file_protomesh_dynamo_v1_dynamo_proto_depIdxs,
+		MessageInfos:      file_protomesh_dynamo_v1_dynamo_proto_msgTypes,
+		ExtensionInfos:    file_protomesh_dynamo_v1_dynamo_proto_extTypes,
+	}.Build()
+	File_protomesh_dynamo_v1_dynamo_proto = out.File
+	file_protomesh_dynamo_v1_dynamo_proto_rawDesc = nil
+	file_protomesh_dynamo_v1_dynamo_proto_goTypes = nil
+	file_protomesh_dynamo_v1_dynamo_proto_depIdxs = nil
+}
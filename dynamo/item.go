@@ -0,0 +1,66 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Int64 returns the number attribute name, DataLoss when the attribute is
+// missing or isn't a number.
+func (i Item) Int64(name string) (int64, error) {
+
+	av, err := i.attribute(name, events.DataTypeNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := av.Int64()
+	if err != nil {
+		return 0, status.Errorf(codes.DataLoss, "Attribute %s is not an integer: %v", name, err)
+	}
+
+	return n, nil
+
+}
+
+// Binary returns the binary attribute name, DataLoss when the attribute is
+// missing or isn't binary.
+func (i Item) Binary(name string) ([]byte, error) {
+
+	av, err := i.attribute(name, events.DataTypeBinary)
+	if err != nil {
+		return nil, err
+	}
+
+	return av.Binary(), nil
+
+}
+
+// String returns the string attribute name, DataLoss when the attribute is
+// missing or isn't a string.
+func (i Item) String(name string) (string, error) {
+
+	av, err := i.attribute(name, events.DataTypeString)
+	if err != nil {
+		return "", err
+	}
+
+	return av.String(), nil
+
+}
+
+func (i Item) attribute(name string, dataType events.DynamoDBDataType) (events.DynamoDBAttributeValue, error) {
+
+	av, ok := i[name]
+	if !ok {
+		return av, status.Errorf(codes.DataLoss, "Attribute %s is missing", name)
+	}
+
+	if av.DataType() != dataType {
+		return av, status.Errorf(codes.DataLoss, "Attribute %s has an unexpected type", name)
+	}
+
+	return av, nil
+
+}
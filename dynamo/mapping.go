@@ -0,0 +1,395 @@
+// Package dynamo maps proto messages to DynamoDB items, attributes being
+// configured with the protomesh.dynamo.v1.attribute field option, and
+// provides typed tables on top of the mapping.
+package dynamo
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/dynamo/v1/dynamo.proto
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type Item map[string]events.DynamoDBAttributeValue
+
+type field struct {
+	desc protoreflect.FieldDescriptor
+	name string
+}
+
+// schema is the mapping of a message type.
+type schema struct {
+	fields       []*field
+	partitionKey *field
+	sortKey      *field
+	version      *field
+}
+
+var schemas sync.Map
+
+func schemaOf(desc protoreflect.MessageDescriptor) (*schema, error) {
+
+	if s, ok := schemas.Load(desc.FullName()); ok {
+		return s.(*schema), nil
+	}
+
+	s := &schema{}
+
+	fields := desc.Fields()
+
+	for i := 0; i < fields.Len(); i++ {
+
+		fd := fields.Get(i)
+		attr, _ := proto.GetExtension(fd.Options(), E_Attribute).(*Attribute)
+
+		if attr.GetIgnore() {
+			continue
+		}
+
+		f := &field{desc: fd, name: attr.GetName()}
+		if len(f.name) == 0 {
+			f.name = string(fd.Name())
+		}
+
+		s.fields = append(s.fields, f)
+
+		switch {
+
+		case attr.GetPartitionKey():
+			s.partitionKey = f
+
+		case attr.GetSortKey():
+			s.sortKey = f
+
+		case attr.GetVersion():
+			switch fd.Kind() {
+			case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+			default:
+				return nil, fmt.Errorf("Version field %s must be an integer", fd.FullName())
+			}
+			s.version = f
+
+		}
+
+	}
+
+	schemas.Store(desc.FullName(), s)
+
+	return s, nil
+
+}
+
+// Marshal maps msg to an item, unpopulated fields are omitted.
+func Marshal(msg proto.Message) (Item, error) {
+
+	s, err := schemaOf(msg.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.marshal(msg.ProtoReflect())
+
+}
+
+// Unmarshal maps item to msg, attributes without field are ignored.
+func Unmarshal(item Item, msg proto.Message) error {
+
+	s, err := schemaOf(msg.ProtoReflect().Descriptor())
+	if err != nil {
+		return err
+	}
+
+	return s.unmarshal(item, msg.ProtoReflect())
+
+}
+
+func (s *schema) marshal(msg protoreflect.Message) (Item, error) {
+
+	item := make(Item, len(s.fields))
+
+	for _, f := range s.fields {
+
+		if !msg.Has(f.desc) {
+			continue
+		}
+
+		value, err := marshalField(f.desc, msg.Get(f.desc))
+		if err != nil {
+			return nil, err
+		}
+
+		item[f.name] = value
+
+	}
+
+	return item, nil
+
+}
+
+func (s *schema) unmarshal(item Item, msg protoreflect.Message) error {
+
+	for _, f := range s.fields {
+
+		av, ok := item[f.name]
+		if !ok || av.IsNull() {
+			continue
+		}
+
+		if err := unmarshalField(f.desc, av, msg); err != nil {
+			return fmt.Errorf("Invalid attribute %s: %w", f.name, err)
+		}
+
+	}
+
+	return nil
+
+}
+
+func marshalField(fd protoreflect.FieldDescriptor, value protoreflect.Value) (events.DynamoDBAttributeValue, error) {
+
+	switch {
+
+	case fd.IsList():
+
+		list := value.List()
+		values := make([]events.DynamoDBAttributeValue, list.Len())
+
+		for i := range values {
+			av, err := marshalValue(fd, list.Get(i))
+			if err != nil {
+				return av, err
+			}
+			values[i] = av
+		}
+
+		return events.NewListAttribute(values), nil
+
+	case fd.IsMap():
+
+		values := make(map[string]events.DynamoDBAttributeValue)
+
+		var err error
+
+		value.Map().Range(func(key protoreflect.MapKey, v protoreflect.Value) bool {
+			values[key.String()], err = marshalValue(fd.MapValue(), v)
+			return err == nil
+		})
+
+		return events.NewMapAttribute(values), err
+
+	}
+
+	return marshalValue(fd, value)
+
+}
+
+func marshalValue(fd protoreflect.FieldDescriptor, value protoreflect.Value) (events.DynamoDBAttributeValue, error) {
+
+	switch fd.Kind() {
+
+	case protoreflect.StringKind:
+		return events.NewStringAttribute(value.String()), nil
+
+	case protoreflect.BytesKind:
+		return events.NewBinaryAttribute(value.Bytes()), nil
+
+	case protoreflect.BoolKind:
+		return events.NewBooleanAttribute(value.Bool()), nil
+
+	case protoreflect.EnumKind:
+		return events.NewNumberAttribute(strconv.FormatInt(int64(value.Enum()), 10)), nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return events.NewNumberAttribute(strconv.FormatInt(value.Int(), 10)), nil
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return events.NewNumberAttribute(strconv.FormatUint(value.Uint(), 10)), nil
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return events.NewNumberAttribute(strconv.FormatFloat(value.Float(), 'g', -1, 64)), nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+
+		s, err := schemaOf(fd.Message())
+		if err != nil {
+			return events.DynamoDBAttributeValue{}, err
+		}
+
+		item, err := s.marshal(value.Message())
+		if err != nil {
+			return events.DynamoDBAttributeValue{}, err
+		}
+
+		return events.NewMapAttribute(item), nil
+
+	}
+
+	return events.DynamoDBAttributeValue{}, fmt.Errorf("Unsupported field kind %s of %s", fd.Kind(), fd.FullName())
+
+}
+
+func unmarshalField(fd protoreflect.FieldDescriptor, av events.DynamoDBAttributeValue, msg protoreflect.Message) error {
+
+	switch {
+
+	case fd.IsList():
+
+		if av.DataType() != events.DataTypeList {
+			return fmt.Errorf("expected a list")
+		}
+
+		list := msg.Mutable(fd).List()
+
+		for _, item := range av.List() {
+			value, err := unmarshalValue(fd, item, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(value)
+		}
+
+		return nil
+
+	case fd.IsMap():
+
+		if av.DataType() != events.DataTypeMap {
+			return fmt.Errorf("expected a map")
+		}
+
+		m := msg.Mutable(fd).Map()
+
+		for k, item := range av.Map() {
+
+			key, err := unmarshalMapKey(fd.MapKey(), k)
+			if err != nil {
+				return err
+			}
+
+			value, err := unmarshalValue(fd.MapValue(), item, m.NewValue)
+			if err != nil {
+				return err
+			}
+
+			m.Set(key, value)
+
+		}
+
+		return nil
+
+	}
+
+	value, err := unmarshalValue(fd, av, func() protoreflect.Value {
+		return msg.NewField(fd)
+	})
+	if err != nil {
+		return err
+	}
+
+	msg.Set(fd, value)
+
+	return nil
+
+}
+
+func unmarshalValue(fd protoreflect.FieldDescriptor, av events.DynamoDBAttributeValue, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+
+	expected := events.DataTypeNumber
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		expected = events.DataTypeString
+	case protoreflect.BytesKind:
+		expected = events.DataTypeBinary
+	case protoreflect.BoolKind:
+		expected = events.DataTypeBoolean
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		expected = events.DataTypeMap
+	}
+
+	if av.DataType() != expected {
+		return protoreflect.Value{}, fmt.Errorf("unexpected attribute type for %s", fd.Kind())
+	}
+
+	switch fd.Kind() {
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(av.String()), nil
+
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes(av.Binary()), nil
+
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(av.Boolean()), nil
+
+	case protoreflect.EnumKind:
+		n, err := strconv.ParseInt(av.Number(), 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(av.Number(), 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(av.Number(), 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(av.Number(), 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(av.Number(), 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(av.Number(), 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(av.Number(), 64)
+		return protoreflect.ValueOfFloat64(f), err
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+
+		s, err := schemaOf(fd.Message())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+
+		value := newValue()
+
+		return value, s.unmarshal(av.Map(), value.Message())
+
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+
+}
+
+func unmarshalMapKey(fd protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+
+	switch fd.Kind() {
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(key).MapKey(), nil
+
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(key)
+		return protoreflect.ValueOfBool(b).MapKey(), err
+
+	}
+
+	value, err := unmarshalValue(fd, events.NewNumberAttribute(key), nil)
+	if err != nil {
+		return protoreflect.MapKey{}, err
+	}
+
+	return value.MapKey(), nil
+
+}
@@ -0,0 +1,318 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const conditionalCheckFailed = "ConditionalCheckFailedException"
+
+// Table stores messages of type M, its schema must declare a partition key
+// and may declare a sort key and a version.
+type Table[M proto.Message] struct {
+	client *awsapi.Client
+	name   string
+	schema *schema
+	newMsg func() M
+}
+
+func NewTable[M proto.Message](client *awsapi.Client, name string) (*Table[M], error) {
+
+	var zero M
+
+	s, err := schemaOf(zero.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, err
+	}
+
+	if s.partitionKey == nil {
+		return nil, fmt.Errorf("Message %s has no partition key", zero.ProtoReflect().Descriptor().FullName())
+	}
+
+	return &Table[M]{
+		client: client,
+		name:   name,
+		schema: s,
+		newMsg: func() M {
+			return zero.ProtoReflect().New().Interface().(M)
+		},
+	}, nil
+
+}
+
+// Get reads the message with the keys of key.
+func (t *Table[M]) Get(ctx context.Context, key M) (M, error) {
+
+	var zero M
+
+	keyItem, err := t.key(key)
+	if err != nil {
+		return zero, err
+	}
+
+	out := struct {
+		Item Item
+	}{}
+
+	err = t.client.CallDynamoDB(ctx, "GetItem", map[string]interface{}{
+		"TableName":      t.name,
+		"Key":            keyItem,
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return zero, err
+	}
+
+	if len(out.Item) == 0 {
+		return zero, status.Errorf(codes.NotFound, "Item not found in %s", t.name)
+	}
+
+	msg := t.newMsg()
+
+	if err := t.schema.unmarshal(out.Item, msg.ProtoReflect()); err != nil {
+		return zero, status.Errorf(codes.DataLoss, "Corrupted item of %s: %v", t.name, err)
+	}
+
+	return msg, nil
+
+}
+
+// Put writes msg, incrementing its version when the schema has one. Puts
+// of a stale version fail with Aborted.
+func (t *Table[M]) Put(ctx context.Context, msg M) error {
+
+	in, err := t.putInput(msg)
+	if err != nil {
+		return err
+	}
+
+	err = t.client.CallDynamoDB(ctx, "PutItem", in, nil)
+	if err != nil {
+		t.rollbackVersion(msg)
+	}
+
+	if awsapi.IsErrorCode(err, conditionalCheckFailed) {
+		return status.Errorf(codes.Aborted, "Item of %s modified concurrently", t.name)
+	}
+
+	return err
+
+}
+
+// Delete deletes the message with the keys of key.
+func (t *Table[M]) Delete(ctx context.Context, key M) error {
+
+	keyItem, err := t.key(key)
+	if err != nil {
+		return err
+	}
+
+	return t.client.CallDynamoDB(ctx, "DeleteItem", map[string]interface{}{
+		"TableName": t.name,
+		"Key":       keyItem,
+	}, nil)
+
+}
+
+type QueryOptions struct {
+	// Restricts the sort key to a prefix.
+	SortKeyPrefix string
+	// Queries a secondary index, whose keys are the ones of the table.
+	Index      string
+	Limit      int32
+	PageToken  string
+	Descending bool
+}
+
+// Query lists the messages of the partition of key.
+func (t *Table[M]) Query(ctx context.Context, key M, opts QueryOptions) ([]M, string, error) {
+
+	partition, err := marshalField(t.schema.partitionKey.desc, key.ProtoReflect().Get(t.schema.partitionKey.desc))
+	if err != nil {
+		return nil, "", err
+	}
+
+	in := map[string]interface{}{
+		"TableName":                 t.name,
+		"KeyConditionExpression":    "#pk = :pk",
+		"ExpressionAttributeNames":  map[string]string{"#pk": t.schema.partitionKey.name},
+		"ExpressionAttributeValues": Item{":pk": partition},
+		"ScanIndexForward":          !opts.Descending,
+	}
+
+	if len(opts.SortKeyPrefix) > 0 && t.schema.sortKey != nil {
+		in["KeyConditionExpression"] = "#pk = :pk AND begins_with(#sk, :sk)"
+		in["ExpressionAttributeNames"].(map[string]string)["#sk"] = t.schema.sortKey.name
+		in["ExpressionAttributeValues"].(Item)[":sk"] = events.NewStringAttribute(opts.SortKeyPrefix)
+	}
+
+	if len(opts.Index) > 0 {
+		in["IndexName"] = opts.Index
+	}
+
+	if opts.Limit > 0 {
+		in["Limit"] = opts.Limit
+	}
+
+	if len(opts.PageToken) > 0 {
+
+		startKey := Item{}
+
+		body, err := base64.RawURLEncoding.DecodeString(opts.PageToken)
+		if err == nil {
+			err = json.Unmarshal(body, &startKey)
+		}
+		if err != nil {
+			return nil, "", status.Error(codes.InvalidArgument, "Invalid page token")
+		}
+
+		in["ExclusiveStartKey"] = startKey
+
+	}
+
+	out := struct {
+		Items            []Item
+		LastEvaluatedKey Item
+	}{}
+
+	if err := t.client.CallDynamoDB(ctx, "Query", in, &out); err != nil {
+		return nil, "", err
+	}
+
+	msgs := make([]M, 0, len(out.Items))
+
+	for _, item := range out.Items {
+
+		msg := t.newMsg()
+
+		if err := t.schema.unmarshal(item, msg.ProtoReflect()); err != nil {
+			return nil, "", status.Errorf(codes.DataLoss, "Corrupted item of %s: %v", t.name, err)
+		}
+
+		msgs = append(msgs, msg)
+
+	}
+
+	nextToken := ""
+
+	if len(out.LastEvaluatedKey) > 0 {
+
+		body, err := json.Marshal(out.LastEvaluatedKey)
+		if err != nil {
+			return nil, "", err
+		}
+
+		nextToken = base64.RawURLEncoding.EncodeToString(body)
+
+	}
+
+	return msgs, nextToken, nil
+
+}
+
+// key returns the key attributes of msg.
+func (t *Table[M]) key(msg M) (Item, error) {
+
+	item := Item{}
+
+	for _, f := range []*field{t.schema.partitionKey, t.schema.sortKey} {
+
+		if f == nil {
+			continue
+		}
+
+		value, err := marshalField(f.desc, msg.ProtoReflect().Get(f.desc))
+		if err != nil {
+			return nil, err
+		}
+
+		item[f.name] = value
+
+	}
+
+	return item, nil
+
+}
+
+// putInput bumps the version of msg and returns the PutItem input
+// conditioned on the previous version.
+func (t *Table[M]) putInput(msg M) (map[string]interface{}, error) {
+
+	in := map[string]interface{}{
+		"TableName": t.name,
+	}
+
+	if version := t.schema.version; version != nil {
+
+		m := msg.ProtoReflect()
+		current := versionOf(m, version.desc)
+
+		if current == 0 {
+			in["ConditionExpression"] = "attribute_not_exists(#pk)"
+			in["ExpressionAttributeNames"] = map[string]string{"#pk": t.schema.partitionKey.name}
+		} else {
+			in["ConditionExpression"] = "#version = :version"
+			in["ExpressionAttributeNames"] = map[string]string{"#version": version.name}
+			in["ExpressionAttributeValues"] = Item{":version": events.NewNumberAttribute(strconv.FormatInt(current, 10))}
+		}
+
+		setVersion(m, version.desc, current+1)
+
+	}
+
+	item, err := t.schema.marshal(msg.ProtoReflect())
+	if err != nil {
+		t.rollbackVersion(msg)
+		return nil, err
+	}
+
+	in["Item"] = item
+
+	return in, nil
+
+}
+
+func (t *Table[M]) rollbackVersion(msg M) {
+
+	if version := t.schema.version; version != nil {
+		m := msg.ProtoReflect()
+		setVersion(m, version.desc, versionOf(m, version.desc)-1)
+	}
+
+}
+
+func versionOf(m protoreflect.Message, fd protoreflect.FieldDescriptor) int64 {
+
+	switch fd.Kind() {
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return int64(m.Get(fd).Uint())
+	}
+
+	return m.Get(fd).Int()
+
+}
+
+func setVersion(m protoreflect.Message, fd protoreflect.FieldDescriptor, version int64) {
+
+	switch fd.Kind() {
+	case protoreflect.Int32Kind:
+		m.Set(fd, protoreflect.ValueOfInt32(int32(version)))
+	case protoreflect.Int64Kind:
+		m.Set(fd, protoreflect.ValueOfInt64(version))
+	case protoreflect.Uint32Kind:
+		m.Set(fd, protoreflect.ValueOfUint32(uint32(version)))
+	case protoreflect.Uint64Kind:
+		m.Set(fd, protoreflect.ValueOfUint64(uint64(version)))
+	}
+
+}
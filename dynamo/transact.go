@@ -0,0 +1,99 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	transactionCanceled = "TransactionCanceledException"
+
+	maxTransactItems = 100
+)
+
+// TransactOp is a write of a transaction, see TransactWrite.
+type TransactOp struct {
+	item     map[string]interface{}
+	rollback func()
+}
+
+// PutOp puts msg within a transaction, with the version checks of Put.
+func (t *Table[M]) PutOp(msg M) (*TransactOp, error) {
+
+	in, err := t.putInput(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactOp{
+		item: map[string]interface{}{"Put": in},
+		rollback: func() {
+			t.rollbackVersion(msg)
+		},
+	}, nil
+
+}
+
+// DeleteOp deletes the message with the keys of key within a transaction.
+func (t *Table[M]) DeleteOp(key M) (*TransactOp, error) {
+
+	keyItem, err := t.key(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactOp{
+		item: map[string]interface{}{
+			"Delete": map[string]interface{}{
+				"TableName": t.name,
+				"Key":       keyItem,
+			},
+		},
+	}, nil
+
+}
+
+// TransactWrite applies the ops atomically, transactions canceled by a
+// stale version fail with Aborted.
+func TransactWrite(ctx context.Context, client *awsapi.Client, ops ...*TransactOp) error {
+
+	if len(ops) > maxTransactItems {
+		rollback(ops)
+		return status.Errorf(codes.InvalidArgument, "Transactions are limited to %d items", maxTransactItems)
+	}
+
+	items := make([]map[string]interface{}, len(ops))
+	for i, op := range ops {
+		items[i] = op.item
+	}
+
+	err := client.CallDynamoDB(ctx, "TransactWriteItems", map[string]interface{}{
+		"TransactItems": items,
+	}, nil)
+	if err == nil {
+		return nil
+	}
+
+	rollback(ops)
+
+	if awsapi.IsErrorCode(err, transactionCanceled) {
+		return status.Errorf(codes.Aborted, "Transaction canceled: %v", err)
+	}
+
+	return err
+
+}
+
+// rollback reverts the in-memory changes of the ops of a failed transaction.
+func rollback(ops []*TransactOp) {
+
+	for _, op := range ops {
+		if op.rollback != nil {
+			op.rollback()
+		}
+	}
+
+}
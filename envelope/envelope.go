@@ -0,0 +1,119 @@
+package envelope
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/envelope/v1/envelope.proto
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+	AmznTraceIdHeader = "x-amzn-trace-id"
+	RequestIdHeader   = "x-request-id"
+
+	// Attribute holding the id of the request that produced the event.
+	RequestIdAttribute = "request_id"
+)
+
+// New wraps msg in an envelope with a fresh id, the current time (see
+// clock.FromContext) and the trace context found in ctx.
+func New(ctx context.Context, msg proto.Message) (*Envelope, error) {
+
+	payload, err := anypb.New(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	clock.IdGeneratorFromContext(ctx).Read(id)
+
+	env := &Envelope{
+		Id:         hex.EncodeToString(id),
+		Time:       timestamppb.New(clock.FromContext(ctx).Now()),
+		Trace:      TraceFromContext(ctx),
+		Attributes: make(map[string]string),
+		Payload:    payload,
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if requestId := firstValue(md, RequestIdHeader); len(requestId) > 0 {
+			env.Attributes[RequestIdAttribute] = requestId
+		}
+	}
+
+	return env, nil
+
+}
+
+// TraceFromContext extracts the trace context from incoming gRPC metadata,
+// falling back to the X-Ray trace id set by the Lambda runtime.
+func TraceFromContext(ctx context.Context) *TraceContext {
+
+	trace := &TraceContext{}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		trace.Traceparent = firstValue(md, TraceparentHeader)
+		trace.Tracestate = firstValue(md, TracestateHeader)
+		trace.AmznTraceId = firstValue(md, AmznTraceIdHeader)
+	}
+
+	if len(trace.AmznTraceId) == 0 {
+		if traceId, ok := ctx.Value(AmznTraceIdHeader).(string); ok {
+			trace.AmznTraceId = traceId
+		}
+	}
+
+	return trace
+
+}
+
+// AppendToOutgoingContext propagates the envelope trace context as outgoing
+// gRPC metadata, so calls made while handling the event join the same trace.
+func (t *TraceContext) AppendToOutgoingContext(ctx context.Context) context.Context {
+
+	kv := []string{}
+
+	if len(t.GetTraceparent()) > 0 {
+		kv = append(kv, TraceparentHeader, t.Traceparent)
+	}
+
+	if len(t.GetTracestate()) > 0 {
+		kv = append(kv, TracestateHeader, t.Tracestate)
+	}
+
+	if len(t.GetAmznTraceId()) > 0 {
+		kv = append(kv, AmznTraceIdHeader, t.AmznTraceId)
+	}
+
+	if len(kv) == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+
+}
+
+// RequestId returns the id of the request that produced the event.
+func (e *Envelope) RequestId() string {
+	return e.GetAttributes()[RequestIdAttribute]
+}
+
+// TypeUrl returns the type URL of the envelope payload.
+func (e *Envelope) TypeUrl() string {
+	return e.GetPayload().GetTypeUrl()
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if vals := md.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
@@ -0,0 +1,211 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const LegacyAttribute = "protomesh.legacy"
+
+// LegacyResolver returns the message type of a raw JSON payload published
+// before the producer adopted envelopes.
+type LegacyResolver func(raw []byte) (protoreflect.MessageType, error)
+
+// LegacyType resolves every legacy JSON payload to the type of msg.
+func LegacyType(msg proto.Message) LegacyResolver {
+	return func(raw []byte) (protoreflect.MessageType, error) {
+		return msg.ProtoReflect().Type(), nil
+	}
+}
+
+// Validator checks payload types against an external schema registry
+// before they are published or consumed.
+type Validator interface {
+	Validate(ctx context.Context, desc protoreflect.MessageDescriptor) error
+}
+
+// Registry holds the payload types an application is able to decode.
+type Registry struct {
+	types     *protoregistry.Types
+	legacy    LegacyResolver
+	validator Validator
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		types: new(protoregistry.Types),
+	}
+}
+
+func (r *Registry) Register(msgs ...proto.Message) error {
+
+	for _, msg := range msgs {
+		if err := r.types.RegisterMessage(msg.ProtoReflect().Type()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// SetLegacyResolver enables decoding of raw JSON payloads, they are wrapped
+// in an envelope marked with the LegacyAttribute.
+func (r *Registry) SetLegacyResolver(resolver LegacyResolver) {
+	r.legacy = resolver
+}
+
+// SetValidator enables schema validation of decoded payloads, publishers
+// should call Validate before emitting.
+func (r *Registry) SetValidator(validator Validator) {
+	r.validator = validator
+}
+
+// Validate checks the message schema with the configured validator.
+func (r *Registry) Validate(ctx context.Context, msg proto.Message) error {
+
+	if r.validator == nil {
+		return nil
+	}
+
+	return r.validator.Validate(ctx, msg.ProtoReflect().Descriptor())
+
+}
+
+func (r *Registry) Resolver() *protoregistry.Types {
+	return r.types
+}
+
+// Decode unmarshals the envelope payload into a new message of the
+// registered type.
+func (r *Registry) Decode(ctx context.Context, env *Envelope) (proto.Message, error) {
+
+	if env.GetPayload() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Envelope has no payload")
+	}
+
+	msg, err := anypb.UnmarshalNew(env.Payload, proto.UnmarshalOptions{Resolver: r.types})
+	if err == protoregistry.NotFound {
+		return nil, status.Errorf(codes.Unimplemented, "Unknown envelope payload type %s", env.TypeUrl())
+	} else if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to decode envelope payload: %v", err)
+	}
+
+	if err := r.Validate(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+
+}
+
+// Unmarshal reads an envelope in binary or JSON form. JSON data without a
+// payload nor an @type isn't shaped as an envelope, the legacy resolver
+// wraps it. Envelopes with an unregistered payload type fail with
+// Unimplemented.
+func (r *Registry) Unmarshal(ctx context.Context, data []byte) (*Envelope, error) {
+
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+
+		env := &Envelope{}
+
+		if err := proto.Unmarshal(data, env); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Failed to unmarshal envelope: %v", err)
+		}
+
+		return env, nil
+
+	}
+
+	env := &Envelope{}
+
+	err := protojson.UnmarshalOptions{Resolver: r.types}.Unmarshal(trimmed, env)
+	if err == nil && env.Payload != nil {
+		return env, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+
+	if json.Unmarshal(trimmed, &fields) == nil {
+
+		if payload, ok := fields["payload"]; ok {
+			return nil, r.envelopeError(payload, err)
+		}
+
+		// A bare Any instead of an envelope.
+		if _, ok := fields["@type"]; ok {
+			return nil, r.envelopeError(trimmed, err)
+		}
+
+	}
+
+	if r.legacy == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to unmarshal envelope: %v", err)
+	}
+
+	return r.unmarshalLegacy(ctx, trimmed)
+
+}
+
+// envelopeError converts the error of a JSON envelope, Unimplemented when
+// its payload type is not registered.
+func (r *Registry) envelopeError(payload json.RawMessage, err error) error {
+
+	typed := struct {
+		Type string `json:"@type"`
+	}{}
+
+	if json.Unmarshal(payload, &typed) == nil && len(typed.Type) > 0 {
+		if _, findErr := r.types.FindMessageByURL(typed.Type); findErr == protoregistry.NotFound {
+			return status.Errorf(codes.Unimplemented, "Unknown envelope payload type %s", typed.Type)
+		}
+	}
+
+	if err == nil {
+		return status.Error(codes.InvalidArgument, "Envelope has no payload")
+	}
+
+	return status.Errorf(codes.InvalidArgument, "Failed to unmarshal envelope: %v", err)
+
+}
+
+// EncodeJSON renders the envelope using the registered types to expand
+// the payload.
+func (r *Registry) EncodeJSON(env *Envelope) ([]byte, error) {
+	return protojson.MarshalOptions{Resolver: r.types}.Marshal(env)
+}
+
+func (r *Registry) unmarshalLegacy(ctx context.Context, data []byte) (*Envelope, error) {
+
+	msgType, err := r.legacy(data)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to resolve legacy payload type: %v", err)
+	}
+
+	msg := msgType.New().Interface()
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true, Resolver: r.types}).Unmarshal(data, msg); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to unmarshal legacy payload: %v", err)
+	}
+
+	env, err := New(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Attributes[LegacyAttribute] = "json"
+
+	return env, nil
+
+}
@@ -0,0 +1,318 @@
+package eventsource
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/dynamo"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	transactionCanceled = "TransactionCanceledException"
+
+	// Snapshots live in the partition of their aggregate under this
+	// version, the events starting at 1.
+	snapshotVersion = "0"
+)
+
+var (
+	_ Store  = &DynamoStore{}
+	_ Outbox = &DynamoStore{}
+)
+
+type dynamoItem map[string]events.DynamoDBAttributeValue
+
+// DynamoStore keeps each event as an item keyed by the "aggregate_id"
+// string partition key and the "version" number sort key, the snapshot of
+// an aggregate being its item of version 0.
+type DynamoStore struct {
+	// Table keyed like the events one, Append writes the events to it in
+	// the same transaction when set, see Relay.
+	OutboxTable string
+
+	client *awsapi.Client
+	table  string
+}
+
+func NewDynamoStore(client *awsapi.Client, table string) *DynamoStore {
+	return &DynamoStore{
+		client: client,
+		table:  table,
+	}
+}
+
+func (d *DynamoStore) Append(ctx context.Context, aggregateId string, expectedVersion int64, envs []*envelope.Envelope) error {
+
+	if len(envs) == 0 {
+		return nil
+	}
+
+	// Transactions are limited to 100 items, each event takes two with the
+	// outbox.
+	maxEvents := 100
+	if len(d.OutboxTable) > 0 {
+		maxEvents = 50
+	}
+
+	if len(envs) > maxEvents {
+		return status.Errorf(codes.InvalidArgument, "At most %d events are appended at once", maxEvents)
+	}
+
+	items := make([]map[string]interface{}, 0, 2*len(envs))
+
+	for i, env := range envs {
+
+		body, err := proto.Marshal(env)
+		if err != nil {
+			return err
+		}
+
+		item := dynamoItem{
+			"aggregate_id": events.NewStringAttribute(aggregateId),
+			"version":      events.NewNumberAttribute(strconv.FormatInt(expectedVersion+int64(i)+1, 10)),
+			"event":        events.NewBinaryAttribute(body),
+		}
+
+		items = append(items, map[string]interface{}{
+			"Put": map[string]interface{}{
+				"TableName": d.table,
+				"Item":      item,
+				// Another writer appended the same version first.
+				"ConditionExpression":      "attribute_not_exists(#version)",
+				"ExpressionAttributeNames": map[string]string{"#version": "version"},
+			},
+		})
+
+		if len(d.OutboxTable) > 0 {
+			items = append(items, map[string]interface{}{
+				"Put": map[string]interface{}{
+					"TableName": d.OutboxTable,
+					"Item":      item,
+				},
+			})
+		}
+
+	}
+
+	err := d.client.CallDynamoDB(ctx, "TransactWriteItems", map[string]interface{}{"TransactItems": items}, nil)
+
+	if awsapi.IsErrorCode(err, transactionCanceled) {
+		return status.Errorf(codes.Aborted, "Aggregate %s modified concurrently", aggregateId)
+	}
+
+	return err
+
+}
+
+func (d *DynamoStore) Load(ctx context.Context, aggregateId string, afterVersion int64) ([]*Record, error) {
+
+	records := []*Record{}
+
+	in := map[string]interface{}{
+		"TableName":                d.table,
+		"KeyConditionExpression":   "#id = :id AND #version > :version",
+		"ExpressionAttributeNames": map[string]string{"#id": "aggregate_id", "#version": "version"},
+		"ExpressionAttributeValues": dynamoItem{
+			":id":      events.NewStringAttribute(aggregateId),
+			":version": events.NewNumberAttribute(strconv.FormatInt(afterVersion, 10)),
+		},
+		"ConsistentRead": true,
+	}
+
+	for {
+
+		out := struct {
+			Items            []dynamoItem
+			LastEvaluatedKey dynamoItem
+		}{}
+
+		if err := d.client.CallDynamoDB(ctx, "Query", in, &out); err != nil {
+			return nil, err
+		}
+
+		for _, item := range out.Items {
+
+			record, err := DecodeDynamoItem(item)
+			if err != nil {
+				return nil, err
+			}
+
+			if record != nil {
+				records = append(records, record)
+			}
+
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return records, nil
+		}
+
+		in["ExclusiveStartKey"] = out.LastEvaluatedKey
+
+	}
+
+}
+
+// DecodeDynamoItem decodes an event item of DynamoStore, typically the new
+// image of a DynamoDB stream record. Snapshot items return a nil record,
+// items missing the attributes of an event fail with DataLoss.
+func DecodeDynamoItem(item map[string]events.DynamoDBAttributeValue) (*Record, error) {
+
+	aggregateId, err := dynamo.Item(item).String("aggregate_id")
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := dynamo.Item(item).Int64("version")
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted event of aggregate %s: %v", aggregateId, err)
+	}
+
+	if version == 0 {
+		return nil, nil
+	}
+
+	body, err := dynamo.Item(item).Binary("event")
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted event %d of aggregate %s: %v", version, aggregateId, err)
+	}
+
+	env := &envelope.Envelope{}
+	if err := proto.Unmarshal(body, env); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted event %d of aggregate %s: %v", version, aggregateId, err)
+	}
+
+	return &Record{
+		AggregateId: aggregateId,
+		Version:     version,
+		Event:       env,
+	}, nil
+
+}
+
+func (d *DynamoStore) SaveSnapshot(ctx context.Context, snapshot *Snapshot) error {
+
+	// Older snapshots never replace newer ones.
+	err := d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item": dynamoItem{
+			"aggregate_id":     events.NewStringAttribute(snapshot.AggregateId),
+			"version":          events.NewNumberAttribute(snapshotVersion),
+			"snapshot_version": events.NewNumberAttribute(strconv.FormatInt(snapshot.Version, 10)),
+			"state":            events.NewBinaryAttribute(snapshot.State),
+		},
+		"ConditionExpression":       "attribute_not_exists(#version) OR #version < :version",
+		"ExpressionAttributeNames":  map[string]string{"#version": "snapshot_version"},
+		"ExpressionAttributeValues": dynamoItem{":version": events.NewNumberAttribute(strconv.FormatInt(snapshot.Version, 10))},
+	}, nil)
+
+	if awsapi.IsErrorCode(err, "ConditionalCheckFailedException") {
+		return nil
+	}
+
+	return err
+
+}
+
+func (d *DynamoStore) LoadSnapshot(ctx context.Context, aggregateId string) (*Snapshot, error) {
+
+	out := struct {
+		Item dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "GetItem", map[string]interface{}{
+		"TableName": d.table,
+		"Key": dynamoItem{
+			"aggregate_id": events.NewStringAttribute(aggregateId),
+			"version":      events.NewNumberAttribute(snapshotVersion),
+		},
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+
+	version, err := dynamo.Item(out.Item).Int64("snapshot_version")
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted snapshot of aggregate %s: %v", aggregateId, err)
+	}
+
+	state, err := dynamo.Item(out.Item).Binary("state")
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted snapshot of aggregate %s: %v", aggregateId, err)
+	}
+
+	return &Snapshot{
+		AggregateId: aggregateId,
+		Version:     version,
+		State:       state,
+	}, nil
+
+}
+
+// Pending returns at most limit events of the outbox.
+func (d *DynamoStore) Pending(ctx context.Context, limit int) ([]*Record, error) {
+
+	out := struct {
+		Items []dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "Scan", map[string]interface{}{
+		"TableName":      d.OutboxTable,
+		"Limit":          limit,
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(out.Items))
+
+	for _, item := range out.Items {
+
+		record, err := DecodeDynamoItem(item)
+		if err != nil {
+			return nil, err
+		}
+
+		if record != nil {
+			records = append(records, record)
+		}
+
+	}
+
+	return records, nil
+
+}
+
+// Ack removes the published events from the outbox.
+func (d *DynamoStore) Ack(ctx context.Context, records []*Record) error {
+
+	for _, record := range records {
+
+		err := d.client.CallDynamoDB(ctx, "DeleteItem", map[string]interface{}{
+			"TableName": d.OutboxTable,
+			"Key": dynamoItem{
+				"aggregate_id": events.NewStringAttribute(record.AggregateId),
+				"version":      events.NewNumberAttribute(strconv.FormatInt(record.Version, 10)),
+			},
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
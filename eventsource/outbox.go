@@ -0,0 +1,65 @@
+package eventsource
+
+import (
+	"context"
+
+	"github.com/protomesh/protomesh-go/envelope"
+	"github.com/protomesh/protomesh-go/publisher"
+)
+
+// Outbox keeps the appended events until they are published, the stores
+// write to it in the transaction of Append (see DynamoStore.OutboxTable and
+// PostgresStore.Outbox).
+type Outbox interface {
+	// Pending returns at most limit events not published yet.
+	Pending(ctx context.Context, limit int) ([]*Record, error)
+	// Ack removes the published events.
+	Ack(ctx context.Context, records []*Record) error
+}
+
+// Relay publishes the events of an outbox for the projections. Events are
+// removed once published, so they are published at least once even when
+// the relay fails midway. It is a lambda.Flusher, usually run by the
+// extension once the invocations are answered.
+type Relay struct {
+	Outbox    Outbox
+	Publisher *publisher.Publisher
+	// Events published at once, defaults to 100.
+	BatchSize int
+}
+
+// Flush publishes the pending events until the outbox is empty.
+func (r *Relay) Flush(ctx context.Context) error {
+
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	for {
+
+		records, err := r.Outbox.Pending(ctx, batchSize)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		envs := make([]*envelope.Envelope, len(records))
+		for i, record := range records {
+			envs[i] = record.Event
+		}
+
+		if err := r.Publisher.EmitEnvelopes(ctx, envs...); err != nil {
+			return err
+		}
+
+		if err := r.Outbox.Ack(ctx, records); err != nil {
+			return err
+		}
+
+		if len(records) < batchSize {
+			return nil
+		}
+
+	}
+
+}
@@ -0,0 +1,232 @@
+package eventsource
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// PostgresSchema creates the tables of PostgresStore, usually run as a
+// migration.
+const PostgresSchema = `CREATE TABLE IF NOT EXISTS events (
+	aggregate_id TEXT NOT NULL,
+	version BIGINT NOT NULL,
+	type_url TEXT NOT NULL,
+	event BYTEA NOT NULL,
+	recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (aggregate_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS snapshots (
+	aggregate_id TEXT PRIMARY KEY,
+	version BIGINT NOT NULL,
+	state BYTEA NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+	aggregate_id TEXT NOT NULL,
+	version BIGINT NOT NULL,
+	event BYTEA NOT NULL,
+	recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (aggregate_id, version)
+);`
+
+var (
+	_ Store  = &PostgresStore{}
+	_ Outbox = &PostgresStore{}
+)
+
+type PostgresStore struct {
+	// Append writes the events to the outbox table in the same transaction
+	// when set, see Relay.
+	Outbox bool
+
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{
+		db: db,
+	}
+}
+
+func (p *PostgresStore) Append(ctx context.Context, aggregateId string, expectedVersion int64, envs []*envelope.Envelope) error {
+
+	if len(envs) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, env := range envs {
+
+		body, err := proto.Marshal(env)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO events (aggregate_id, version, type_url, event) VALUES ($1, $2, $3, $4)",
+			aggregateId, expectedVersion+int64(i)+1, env.TypeUrl(), body,
+		)
+
+		if isUniqueViolation(err) {
+			return status.Errorf(codes.Aborted, "Aggregate %s modified concurrently", aggregateId)
+		} else if err != nil {
+			return err
+		}
+
+		if !p.Outbox {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO outbox (aggregate_id, version, event) VALUES ($1, $2, $3)",
+			aggregateId, expectedVersion+int64(i)+1, body,
+		)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return tx.Commit()
+
+}
+
+// isUniqueViolation detects the 23505 error of the drivers without
+// depending on any of them.
+func isUniqueViolation(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "23505") || strings.Contains(err.Error(), "duplicate key"))
+}
+
+func (p *PostgresStore) Load(ctx context.Context, aggregateId string, afterVersion int64) ([]*Record, error) {
+
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT version, event FROM events WHERE aggregate_id = $1 AND version > $2 ORDER BY version",
+		aggregateId, afterVersion,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*Record{}
+
+	for rows.Next() {
+
+		record := &Record{AggregateId: aggregateId, Event: &envelope.Envelope{}}
+		body := []byte{}
+
+		if err := rows.Scan(&record.Version, &body); err != nil {
+			return nil, err
+		}
+
+		if err := proto.Unmarshal(body, record.Event); err != nil {
+			return nil, status.Errorf(codes.DataLoss, "Corrupted event %d of aggregate %s: %v", record.Version, aggregateId, err)
+		}
+
+		records = append(records, record)
+
+	}
+
+	return records, rows.Err()
+
+}
+
+// Pending returns at most limit events of the outbox, oldest first.
+func (p *PostgresStore) Pending(ctx context.Context, limit int) ([]*Record, error) {
+
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT aggregate_id, version, event FROM outbox ORDER BY recorded_at, aggregate_id, version LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*Record{}
+
+	for rows.Next() {
+
+		record := &Record{Event: &envelope.Envelope{}}
+		body := []byte{}
+
+		if err := rows.Scan(&record.AggregateId, &record.Version, &body); err != nil {
+			return nil, err
+		}
+
+		if err := proto.Unmarshal(body, record.Event); err != nil {
+			return nil, status.Errorf(codes.DataLoss, "Corrupted event %d of aggregate %s: %v", record.Version, record.AggregateId, err)
+		}
+
+		records = append(records, record)
+
+	}
+
+	return records, rows.Err()
+
+}
+
+// Ack removes the published events from the outbox.
+func (p *PostgresStore) Ack(ctx context.Context, records []*Record) error {
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, record := range records {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM outbox WHERE aggregate_id = $1 AND version = $2", record.AggregateId, record.Version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+
+}
+
+func (p *PostgresStore) SaveSnapshot(ctx context.Context, snapshot *Snapshot) error {
+
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO snapshots (aggregate_id, version, state) VALUES ($1, $2, $3)
+		ON CONFLICT (aggregate_id) DO UPDATE SET version = EXCLUDED.version, state = EXCLUDED.state
+		WHERE snapshots.version < EXCLUDED.version`,
+		snapshot.AggregateId, snapshot.Version, snapshot.State,
+	)
+
+	return err
+
+}
+
+func (p *PostgresStore) LoadSnapshot(ctx context.Context, aggregateId string) (*Snapshot, error) {
+
+	snapshot := &Snapshot{AggregateId: aggregateId}
+
+	err := p.db.QueryRowContext(ctx,
+		"SELECT version, state FROM snapshots WHERE aggregate_id = $1",
+		aggregateId,
+	).Scan(&snapshot.Version, &snapshot.State)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+
+}
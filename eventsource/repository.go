@@ -0,0 +1,141 @@
+package eventsource
+
+import (
+	"context"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/baggage"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/protobuf/proto"
+)
+
+// Repository rebuilds aggregates of state S from their events and appends
+// the events decided by commands. The projections receive the events
+// through the outbox of the store, see Relay.
+type Repository[S proto.Message] struct {
+	Store Store
+	// Decodes the payloads of the stored events.
+	Registry *envelope.Registry
+	// Allocates the empty state of a new aggregate.
+	New func() S
+	// Applies an event to the state, it must be deterministic.
+	Reduce func(state S, event proto.Message) error
+	// Saves a snapshot every SnapshotEvery events, never when 0.
+	SnapshotEvery int64
+}
+
+// Load returns the current state and version of an aggregate, starting
+// from its latest snapshot.
+func (r *Repository[S]) Load(ctx context.Context, aggregateId string) (S, int64, error) {
+
+	state := r.New()
+	version := int64(0)
+
+	snapshot, err := r.Store.LoadSnapshot(ctx, aggregateId)
+	if err != nil {
+		return state, 0, err
+	}
+
+	if snapshot != nil {
+
+		if err := proto.Unmarshal(snapshot.State, state); err != nil {
+			return state, 0, err
+		}
+
+		version = snapshot.Version
+
+	}
+
+	records, err := r.Store.Load(ctx, aggregateId, version)
+	if err != nil {
+		return state, 0, err
+	}
+
+	for _, record := range records {
+
+		if err := r.apply(ctx, state, record.Event); err != nil {
+			return state, 0, err
+		}
+
+		version = record.Version
+
+	}
+
+	return state, version, nil
+
+}
+
+// Execute runs a command against an aggregate, decide returns the events
+// produced from the current state. The command fails with Aborted when the
+// aggregate is modified concurrently, it's safe to retry.
+func (r *Repository[S]) Execute(ctx context.Context, aggregateId string, decide func(state S) ([]proto.Message, error)) (S, error) {
+
+	state, version, err := r.Load(ctx, aggregateId)
+	if err != nil {
+		return state, err
+	}
+
+	events, err := decide(state)
+	if err != nil || len(events) == 0 {
+		return state, err
+	}
+
+	envs := make([]*envelope.Envelope, len(events))
+
+	for i, event := range events {
+
+		if envs[i], err = envelope.New(ctx, event); err != nil {
+			return state, err
+		}
+
+		// The events are published later by the relay of the outbox.
+		baggage.Default.Inject(ctx, envs[i])
+
+		if err := r.Reduce(state, event); err != nil {
+			return state, err
+		}
+
+	}
+
+	if err := r.Store.Append(ctx, aggregateId, version, envs); err != nil {
+		return state, err
+	}
+
+	newVersion := version + int64(len(events))
+
+	if r.SnapshotEvery > 0 && newVersion/r.SnapshotEvery > version/r.SnapshotEvery {
+		// Snapshots are an optimization, Load replays the events anyway.
+		if err := r.snapshot(ctx, aggregateId, newVersion, state); err != nil {
+			lambda.LoggerFromContext(ctx).Warn("Failed to save snapshot", "aggregate_id", aggregateId, "error", err)
+		}
+	}
+
+	return state, nil
+
+}
+
+func (r *Repository[S]) apply(ctx context.Context, state S, env *envelope.Envelope) error {
+
+	event, err := r.Registry.Decode(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	return r.Reduce(state, event)
+
+}
+
+func (r *Repository[S]) snapshot(ctx context.Context, aggregateId string, version int64, state S) error {
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return r.Store.SaveSnapshot(ctx, &Snapshot{
+		AggregateId: aggregateId,
+		Version:     version,
+		State:       body,
+	})
+
+}
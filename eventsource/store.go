@@ -0,0 +1,34 @@
+// Package eventsource persists aggregates as append-only streams of proto
+// events, with optimistic concurrency and snapshots.
+package eventsource
+
+import (
+	"context"
+
+	"github.com/protomesh/protomesh-go/envelope"
+)
+
+// Record is an event of an aggregate stream, versions start at 1.
+type Record struct {
+	AggregateId string
+	Version     int64
+	Event       *envelope.Envelope
+}
+
+// Snapshot is the encoded state of an aggregate at a version.
+type Snapshot struct {
+	AggregateId string
+	Version     int64
+	State       []byte
+}
+
+// Store keeps the event streams. Append fails with Aborted when the stream
+// isn't at expectedVersion anymore.
+type Store interface {
+	Append(ctx context.Context, aggregateId string, expectedVersion int64, events []*envelope.Envelope) error
+	// Load returns the events after version, in order.
+	Load(ctx context.Context, aggregateId string, afterVersion int64) ([]*Record, error)
+	SaveSnapshot(ctx context.Context, snapshot *Snapshot) error
+	// LoadSnapshot returns the latest snapshot, nil when there is none.
+	LoadSnapshot(ctx context.Context, aggregateId string) (*Snapshot, error)
+}
@@ -0,0 +1,205 @@
+// Package eventtest fabricates API Gateway events for unit tests, with the
+// authorizer contexts API Gateway would set, so the authentication and
+// authorization middlewares run without AWS:
+//
+//	req := eventtest.Unary("/acme.users.v1.Users/Get", in,
+//		eventtest.WithCognitoUser("user-1", "admins"),
+//	)
+//	res, err := controller.HandleLambda(ctx, req)
+package eventtest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// Option customizes an event.
+type Option func(req *events.APIGatewayProxyRequest)
+
+// New returns the event of a plain HTTP request.
+func New(httpMethod, path, body string, opts ...Option) *events.APIGatewayProxyRequest {
+
+	req := &events.APIGatewayProxyRequest{
+		HTTPMethod: httpMethod,
+		Path:       path,
+		Body:       body,
+		Headers:    map[string]string{},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:  "123456789012",
+			RequestID:  "eventtest",
+			Stage:      "test",
+			HTTPMethod: httpMethod,
+			Path:       path,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  "127.0.0.1",
+				UserAgent: "eventtest",
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	return req
+
+}
+
+// Unary returns the event of a unary call of the method with msg.
+func Unary(method string, msg proto.Message, opts ...Option) *events.APIGatewayProxyRequest {
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	req := New(http.MethodPost, method, base64.StdEncoding.EncodeToString(body), append([]Option{
+		WithHeader("Content-Type", "application/grpc+proto"),
+	}, opts...)...)
+
+	req.IsBase64Encoded = true
+
+	return req
+
+}
+
+func WithHeader(name, value string) Option {
+
+	return func(req *events.APIGatewayProxyRequest) {
+
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+
+		req.Headers[name] = value
+
+	}
+
+}
+
+// WithCognitoClaims sets the claims of a Cognito user pool authorizer.
+// API Gateway flattens them to strings, lists becoming "[a b]".
+func WithCognitoClaims(claims map[string]interface{}) Option {
+
+	return func(req *events.APIGatewayProxyRequest) {
+
+		flattened := make(map[string]interface{}, len(claims))
+		for k, v := range claims {
+			flattened[k] = flatten(v)
+		}
+
+		authorizer(req)["claims"] = flattened
+
+	}
+
+}
+
+// WithCognitoUser sets the claims of a Cognito user member of groups.
+func WithCognitoUser(sub string, groups ...string) Option {
+
+	claims := map[string]interface{}{
+		"sub":              sub,
+		"cognito:username": sub,
+		"token_use":        "id",
+	}
+
+	if len(groups) > 0 {
+		claims["cognito:groups"] = groups
+	}
+
+	return WithCognitoClaims(claims)
+
+}
+
+// WithAuthorizer sets the context returned by a Lambda authorizer, whose
+// values API Gateway restricts to strings, numbers and booleans.
+func WithAuthorizer(principalId string, values map[string]interface{}) Option {
+
+	return func(req *events.APIGatewayProxyRequest) {
+
+		ctx := authorizer(req)
+
+		ctx["principalId"] = principalId
+
+		for k, v := range values {
+			switch v.(type) {
+			case string, bool, int, int32, int64, float32, float64:
+				ctx[k] = v
+			default:
+				ctx[k] = fmt.Sprint(v)
+			}
+		}
+
+	}
+
+}
+
+// IAMIdentity is the caller of an IAM (SigV4) authorized method.
+type IAMIdentity struct {
+	AccountID string
+	// e.g. "arn:aws:sts::123456789012:assumed-role/role/session".
+	UserArn string
+	// e.g. "AROAEXAMPLE:session".
+	Caller    string
+	User      string
+	AccessKey string
+}
+
+// WithIAM sets the identity of an IAM authorized caller.
+func WithIAM(identity IAMIdentity) Option {
+
+	return func(req *events.APIGatewayProxyRequest) {
+
+		req.RequestContext.Identity.AccountID = identity.AccountID
+		req.RequestContext.Identity.UserArn = identity.UserArn
+		req.RequestContext.Identity.Caller = identity.Caller
+		req.RequestContext.Identity.User = identity.User
+		req.RequestContext.Identity.AccessKey = identity.AccessKey
+
+	}
+
+}
+
+// WithAPIKey sets the API key of the caller.
+func WithAPIKey(key, id string) Option {
+
+	return func(req *events.APIGatewayProxyRequest) {
+		req.RequestContext.Identity.APIKey = key
+		req.RequestContext.Identity.APIKeyID = id
+	}
+
+}
+
+func authorizer(req *events.APIGatewayProxyRequest) map[string]interface{} {
+
+	if req.RequestContext.Authorizer == nil {
+		req.RequestContext.Authorizer = map[string]interface{}{}
+	}
+
+	return req.RequestContext.Authorizer
+
+}
+
+func flatten(v interface{}) string {
+
+	switch value := v.(type) {
+	case string:
+		return value
+	case []string:
+		return "[" + strings.Join(value, " ") + "]"
+	case []interface{}:
+		parts := make([]string, len(value))
+		for i, item := range value {
+			parts[i] = fmt.Sprint(item)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	}
+
+	return fmt.Sprint(v)
+
+}
@@ -0,0 +1,364 @@
+// Package experiment assigns the units of A/B experiments (users, tenants)
+// to variants by deterministic bucketing, exposes the variants to the
+// handlers through the context and emits an exposure event the first time
+// a handler reads one.
+//
+//	controller.RegisterMiddleware(experiment.Middleware(experiment.Options{
+//		Experiments: []*experiment.Experiment{{
+//			Name:     "checkout-flow",
+//			Variants: []experiment.Variant{{Name: "control", Weight: 50}, {Name: "one-page", Weight: 50}},
+//			Flag:     "checkout-experiments",
+//		}},
+//		Unit:    experiment.PrincipalUnit(),
+//		Flags:   experiment.TenantFlags{},
+//		Emitter: publisher,
+//	}))
+//
+//	if variant, _ := experiment.VariantFromContext(ctx, "checkout-flow"); variant == "one-page" {
+//		...
+//	}
+package experiment
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/experiment/v1/experiment.proto
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/authz"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/baggage"
+	"github.com/protomesh/protomesh-go/clock"
+	"github.com/protomesh/protomesh-go/tenancy"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Buckets of the units, the weights of the variants are spread over them.
+const buckets = 10000
+
+// BaggagePrefix prefixes the baggage keys of the variants, so the services
+// called during a request see the variants assigned by the first one.
+const BaggagePrefix = "experiment."
+
+type Experiment struct {
+	Name     string
+	Variants []Variant
+	// Runs only for the requests where the flag is enabled, always when
+	// empty.
+	Flag string
+	// Salt of the bucketing, defaults to the name. Changing it reshuffles
+	// the units.
+	Salt string
+}
+
+// Variant of an experiment, units are assigned to the variants in
+// proportion of their weights.
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// Assign returns the variant of unit, the same unit always gets the same
+// variant while the variants and salt don't change.
+func (e *Experiment) Assign(unit string) string {
+
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	if total <= 0 {
+		return ""
+	}
+
+	salt := e.Salt
+	if len(salt) == 0 {
+		salt = e.Name
+	}
+
+	sum := sha256.Sum256([]byte(salt + ":" + unit))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % buckets)
+
+	threshold := 0
+	for _, v := range e.Variants {
+		threshold += v.Weight * buckets / total
+		if bucket < threshold {
+			return v.Name
+		}
+	}
+
+	// Rounding leaves the last buckets to the last variant.
+	return e.Variants[len(e.Variants)-1].Name
+
+}
+
+func (e *Experiment) has(variant string) bool {
+
+	for _, v := range e.Variants {
+		if len(variant) > 0 && v.Name == variant {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// FlagProvider reports whether a flag is enabled for the request of ctx.
+type FlagProvider interface {
+	Enabled(ctx context.Context, flag string) bool
+}
+
+// TenantFlags reads the flags in the features of the tenant set by the
+// tenancy middleware, flags are disabled for requests without tenant.
+type TenantFlags struct{}
+
+func (TenantFlags) Enabled(ctx context.Context, flag string) bool {
+
+	tenant, ok := tenancy.TenantFromContext(ctx)
+
+	return ok && tenant.Enabled(flag)
+
+}
+
+// Unit returns the bucketed unit of a request, empty when it has none.
+type Unit func(ctx context.Context, req *lambda.Request) string
+
+// PrincipalUnit buckets the authenticated callers, see
+// authz.PrincipalMiddleware.
+func PrincipalUnit() Unit {
+
+	return func(ctx context.Context, req *lambda.Request) string {
+
+		if principal, ok := authz.PrincipalFromContext(ctx); ok {
+			return principal.Id
+		}
+
+		return ""
+
+	}
+
+}
+
+// TenantUnit buckets the tenants, see tenancy.Middleware.
+func TenantUnit() Unit {
+
+	return func(ctx context.Context, req *lambda.Request) string {
+
+		if tenant, ok := tenancy.TenantFromContext(ctx); ok {
+			return tenant.Id
+		}
+
+		return ""
+
+	}
+
+}
+
+func HeaderUnit(header string) Unit {
+	return func(ctx context.Context, req *lambda.Request) string {
+		return req.Header(header)
+	}
+}
+
+// Emitter publishes the exposure events, e.g. a publisher.Publisher whose
+// manifest binds protomesh.experiment.v1.Exposure.
+type Emitter interface {
+	Emit(ctx context.Context, events ...proto.Message) error
+}
+
+// Caller reports whether the caller of a request is trusted, e.g. an
+// internal service.
+type Caller func(ctx context.Context, req *lambda.Request) bool
+
+// RoleCaller trusts the authenticated callers having one of roles, see
+// authz.PrincipalMiddleware.
+func RoleCaller(roles ...string) Caller {
+
+	return func(ctx context.Context, req *lambda.Request) bool {
+
+		principal, ok := authz.PrincipalFromContext(ctx)
+		if !ok {
+			return false
+		}
+
+		for _, role := range principal.Roles {
+			for _, trusted := range roles {
+				if role == trusted {
+					return true
+				}
+			}
+		}
+
+		return false
+
+	}
+
+}
+
+type Options struct {
+	Experiments []*Experiment
+	Unit        Unit
+	// Gates the experiments with a flag, they always run when nil.
+	Flags FlagProvider
+	// Exposures are not emitted when nil.
+	Emitter Emitter
+	// Callers whose baggage variants are kept, the variants are assigned
+	// from the unit of every request when nil.
+	TrustBaggage Caller
+}
+
+// Assignment is the variant of a unit in an experiment.
+type Assignment struct {
+	Experiment string
+	Variant    string
+	Unit       string
+}
+
+type assignments struct {
+	handlerKey string
+	byName     map[string]*Assignment
+
+	lock    sync.Mutex
+	exposed map[string]bool
+	pending []proto.Message
+}
+
+type assignmentsContextKey struct{}
+
+// VariantFromContext returns the variant of the request in experiment,
+// false when the experiment doesn't run for the request. The first read of
+// an experiment during a request records its exposure.
+func VariantFromContext(ctx context.Context, experiment string) (string, bool) {
+
+	a, ok := ctx.Value(assignmentsContextKey{}).(*assignments)
+	if !ok {
+		return "", false
+	}
+
+	assignment, ok := a.byName[experiment]
+	if !ok {
+		return "", false
+	}
+
+	a.expose(ctx, assignment)
+
+	return assignment.Variant, true
+
+}
+
+// AssignmentsFromContext returns the assignments of the request, without
+// recording exposures.
+func AssignmentsFromContext(ctx context.Context) []Assignment {
+
+	a, ok := ctx.Value(assignmentsContextKey{}).(*assignments)
+	if !ok {
+		return nil
+	}
+
+	list := make([]Assignment, 0, len(a.byName))
+	for _, assignment := range a.byName {
+		list = append(list, *assignment)
+	}
+
+	return list
+
+}
+
+func (a *assignments) expose(ctx context.Context, assignment *Assignment) {
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.exposed[assignment.Experiment] {
+		return
+	}
+
+	a.exposed[assignment.Experiment] = true
+
+	a.pending = append(a.pending, &Exposure{
+		Experiment: assignment.Experiment,
+		Variant:    assignment.Variant,
+		Unit:       assignment.Unit,
+		HandlerKey: a.handlerKey,
+		Time:       timestamppb.New(clock.FromContext(ctx).Now()),
+	})
+
+}
+
+// Middleware assigns the unit of each request to the variants of the
+// running experiments, the known variants received in the baggage from the
+// callers trusted by Options.TrustBaggage (assigned by an upstream service)
+// win, so it's registered after the baggage and principal middlewares. The exposures recorded by the handler are emitted
+// once it returns, failures are logged.
+func Middleware(opts Options) lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			a := &assignments{
+				handlerKey: req.HandlerKey,
+				byName:     make(map[string]*Assignment),
+				exposed:    make(map[string]bool),
+			}
+
+			unit := ""
+			if opts.Unit != nil {
+				unit = opts.Unit(ctx, req)
+			}
+
+			// Any caller can send baggage, picking its variants.
+			trusted := opts.TrustBaggage != nil && opts.TrustBaggage(ctx, req)
+
+			for _, exp := range opts.Experiments {
+
+				if len(exp.Flag) > 0 && opts.Flags != nil && !opts.Flags.Enabled(ctx, exp.Flag) {
+					continue
+				}
+
+				key := BaggagePrefix + exp.Name
+
+				if variant := baggage.Get(ctx, key); trusted && exp.has(variant) {
+					// Exposed by the service which assigned it.
+					a.byName[exp.Name] = &Assignment{Experiment: exp.Name, Variant: variant, Unit: unit}
+					a.exposed[exp.Name] = true
+					continue
+				}
+
+				if len(unit) == 0 {
+					continue
+				}
+
+				variant := exp.Assign(unit)
+				if len(variant) == 0 {
+					continue
+				}
+
+				a.byName[exp.Name] = &Assignment{Experiment: exp.Name, Variant: variant, Unit: unit}
+				ctx = baggage.Set(ctx, key, variant)
+
+			}
+
+			err := next(context.WithValue(ctx, assignmentsContextKey{}, a), req, res)
+
+			a.lock.Lock()
+			pending := a.pending
+			a.lock.Unlock()
+
+			if opts.Emitter != nil && len(pending) > 0 {
+				if emitErr := opts.Emitter.Emit(ctx, pending...); emitErr != nil {
+					lambda.LoggerFromContext(ctx).Warn("Failed to emit experiment exposures", "error", emitErr, "exposures", len(pending))
+				}
+			}
+
+			return err
+
+		}
+
+	}
+
+}
@@ -0,0 +1,189 @@
+// Package headers sets the security headers of the responses, with
+// per-route overrides declared by a manifest.
+package headers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+)
+
+// Remove unsets a header of the default policy in an override.
+const Remove = "-"
+
+// Policy lists the response headers, empty values are inherited from the
+// default policy.
+type Policy struct {
+	StrictTransportSecurity string `json:"strictTransportSecurity,omitempty"`
+	ContentTypeOptions      string `json:"contentTypeOptions,omitempty"`
+	ContentSecurityPolicy   string `json:"contentSecurityPolicy,omitempty"`
+	CacheControl            string `json:"cacheControl,omitempty"`
+	FrameOptions            string `json:"frameOptions,omitempty"`
+	ReferrerPolicy          string `json:"referrerPolicy,omitempty"`
+	// Other headers by name.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// DefaultPolicy suits APIs which never render documents.
+var DefaultPolicy = Policy{
+	StrictTransportSecurity: "max-age=63072000; includeSubDomains",
+	ContentTypeOptions:      "nosniff",
+	ContentSecurityPolicy:   "default-src 'none'; frame-ancestors 'none'",
+	CacheControl:            "no-store",
+	FrameOptions:            "DENY",
+	ReferrerPolicy:          "no-referrer",
+}
+
+// Route overrides the default policy for the handler keys matching Match,
+// a trailing "*" matches by prefix.
+type Route struct {
+	Match string `json:"match"`
+	Policy
+}
+
+// Manifest declares the default policy and its overrides, the overrides
+// of every matching route apply in order.
+type Manifest struct {
+	// Defaults to DefaultPolicy.
+	Default *Policy  `json:"default,omitempty"`
+	Routes  []*Route `json:"routes,omitempty"`
+}
+
+// ParseManifest parses a JSON manifest.
+func ParseManifest(data []byte) (*Manifest, error) {
+
+	manifest := &Manifest{}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("Invalid manifest: %w", err)
+	}
+
+	for i, route := range manifest.Routes {
+		if len(route.Match) == 0 {
+			return nil, fmt.Errorf("Route %d of the manifest has no match", i)
+		}
+	}
+
+	return manifest, nil
+
+}
+
+func (p *Policy) values() map[string]string {
+
+	values := map[string]string{
+		"Strict-Transport-Security": p.StrictTransportSecurity,
+		"X-Content-Type-Options":    p.ContentTypeOptions,
+		"Content-Security-Policy":   p.ContentSecurityPolicy,
+		"Cache-Control":             p.CacheControl,
+		"X-Frame-Options":           p.FrameOptions,
+		"Referrer-Policy":           p.ReferrerPolicy,
+	}
+
+	for name, value := range p.Headers {
+		values[name] = value
+	}
+
+	return values
+
+}
+
+// Headers returns the headers of the handler key.
+func (m *Manifest) Headers(key string) map[string]string {
+
+	policy := &DefaultPolicy
+	if m.Default != nil {
+		policy = m.Default
+	}
+
+	headers := map[string]string{}
+
+	for name, value := range policy.values() {
+		if len(value) > 0 && value != Remove {
+			headers[name] = value
+		}
+	}
+
+	for _, route := range m.Routes {
+
+		if !match(route.Match, key) {
+			continue
+		}
+
+		for name, value := range route.values() {
+			switch value {
+			case "":
+			case Remove:
+				delete(headers, name)
+			default:
+				headers[name] = value
+			}
+		}
+
+	}
+
+	return headers
+
+}
+
+// Middleware sets the headers of the matched route on the responses, the
+// headers set by the handlers are kept.
+func (m *Manifest) Middleware() lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			err := next(ctx, req, res)
+
+			for name, value := range m.Headers(req.HandlerKey) {
+
+				if hasHeader(res, name) {
+					continue
+				}
+
+				if res.Headers == nil {
+					res.Headers = make(map[string]string)
+				}
+
+				res.Headers[name] = value
+
+			}
+
+			return err
+
+		}
+
+	}
+
+}
+
+func hasHeader(res *lambda.Response, name string) bool {
+
+	for k := range res.Headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+
+	for k := range res.MultiValueHeaders {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func match(pattern, value string) bool {
+
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+
+	return pattern == value
+
+}
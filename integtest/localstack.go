@@ -0,0 +1,265 @@
+// Package integtest runs integration tests against LocalStack, standing in
+// for DynamoDB, SQS and S3 so the controllers run end-to-end in CI without
+// AWS accounts:
+//
+//	func TestOrders(t *testing.T) {
+//		stack := integtest.Start(t, integtest.Options{})
+//		stack.Setenv(t)
+//		stack.CreateTable(t, "orders-events", "pk", "sk")
+//
+//		controller, err := bootstrap.New(newRoot(), opts)
+//		...
+//		res, err := controller.HandleLambda(ctx, eventtest.Unary("/acme.orders.v1.Orders/Create", in))
+//		...
+//		out, err := projector.HandleDynamoDBStream(ctx, stack.DynamoDBStreamEvent(t, "orders-events"))
+//	}
+//
+// The container is started with the docker CLI, the tests are skipped when
+// docker is missing. An instance already running (e.g. a CI service
+// container) is reused when EndpointEnv is set.
+package integtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+)
+
+// EndpointEnv is the url of a running LocalStack instance, e.g.
+// http://localhost:4566.
+const EndpointEnv = "LOCALSTACK_ENDPOINT"
+
+const (
+	DefaultImage    = "localstack/localstack:3"
+	DefaultServices = "dynamodb,sqs,s3"
+	DefaultRegion   = "us-east-1"
+)
+
+type Options struct {
+	// Defaults to DefaultImage.
+	Image string
+	// Comma separated LocalStack services. Defaults to DefaultServices.
+	Services string
+	// Defaults to DefaultRegion.
+	Region string
+	// Defaults to 2m, the first run pulls the image.
+	StartTimeout time.Duration
+}
+
+// LocalStack is a running instance, stopped with the test.
+type LocalStack struct {
+	// Base url of every service.
+	URL    string
+	Region string
+
+	container string
+}
+
+// Start returns the instance of EndpointEnv or starts a container removed
+// at the end of the test, it fails the test when it doesn't become ready.
+func Start(t testing.TB, opts Options) *LocalStack {
+
+	t.Helper()
+
+	if len(opts.Image) == 0 {
+		opts.Image = DefaultImage
+	}
+
+	if len(opts.Services) == 0 {
+		opts.Services = DefaultServices
+	}
+
+	if len(opts.Region) == 0 {
+		opts.Region = DefaultRegion
+	}
+
+	if opts.StartTimeout == 0 {
+		opts.StartTimeout = 2 * time.Minute
+	}
+
+	stack := &LocalStack{Region: opts.Region}
+
+	if endpoint := os.Getenv(EndpointEnv); len(endpoint) > 0 {
+
+		stack.URL = strings.TrimRight(endpoint, "/")
+
+		if err := stack.waitReady(opts.Services, opts.StartTimeout); err != nil {
+			t.Fatalf("LocalStack at %s not ready: %v", stack.URL, err)
+		}
+
+		return stack
+
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("Docker not available, set " + EndpointEnv + " to use a running LocalStack")
+	}
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+
+	out, err := docker("run", "--detach", "--rm",
+		"--publish", fmt.Sprintf("127.0.0.1:%d:4566", port),
+		"--env", "SERVICES="+opts.Services,
+		"--env", "AWS_DEFAULT_REGION="+opts.Region,
+		opts.Image,
+	)
+	if err != nil {
+		t.Fatalf("Failed to start LocalStack: %v", err)
+	}
+
+	stack.container = strings.TrimSpace(out)
+	stack.URL = fmt.Sprintf("http://127.0.0.1:%d", port)
+
+	t.Cleanup(func() {
+		if _, err := docker("rm", "--force", stack.container); err != nil {
+			t.Logf("Failed to remove LocalStack container %s: %v", stack.container, err)
+		}
+	})
+
+	if err := stack.waitReady(opts.Services, opts.StartTimeout); err != nil {
+
+		if logs, logErr := docker("logs", stack.container); logErr == nil {
+			t.Log(logs)
+		}
+
+		t.Fatalf("LocalStack not ready: %v", err)
+
+	}
+
+	return stack
+
+}
+
+// Client returns a client of the instance.
+func (l *LocalStack) Client() *awsapi.Client {
+	return &awsapi.Client{
+		Region:      l.Region,
+		Credentials: awsapi.StaticCredentials{AccessKeyId: "test", SecretAccessKey: "test"},
+		HttpClient:  http.DefaultClient,
+		Endpoints:   map[string]string{"*": l.URL},
+	}
+}
+
+// Setenv points the clients built from the environment (see
+// awsapi.NewClientFromEnv) to the instance for the duration of the test,
+// so the dependencies injected by the controllers use it.
+func (l *LocalStack) Setenv(t testing.TB) {
+
+	t.Helper()
+
+	type setenv interface {
+		Setenv(key, value string)
+	}
+
+	s, ok := t.(setenv)
+	if !ok {
+		t.Fatalf("%T can't set environment variables", t)
+	}
+
+	s.Setenv("AWS_ENDPOINT_URL", l.URL)
+	s.Setenv("AWS_REGION", l.Region)
+	s.Setenv("AWS_ACCESS_KEY_ID", "test")
+	s.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	s.Setenv("AWS_SESSION_TOKEN", "")
+
+}
+
+func (l *LocalStack) waitReady(services string, timeout time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var lastErr error
+
+	for {
+
+		if lastErr = l.health(ctx, strings.Split(services, ",")); lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return lastErr
+		case <-time.After(500 * time.Millisecond):
+		}
+
+	}
+
+}
+
+// health checks that every service is available or running.
+func (l *LocalStack) health(ctx context.Context, services []string) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.URL+"/_localstack/health", nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	report := struct {
+		Services map[string]string `json:"services"`
+	}{}
+
+	if err := decodeJSON(res, &report); err != nil {
+		return err
+	}
+
+	for _, service := range services {
+
+		service = strings.TrimSpace(service)
+
+		switch report.Services[service] {
+		case "available", "running":
+		default:
+			return fmt.Errorf("Service %s is %q", service, report.Services[service])
+		}
+
+	}
+
+	return nil
+
+}
+
+func docker(args ...string) (string, error) {
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+
+}
+
+func freePort() (int, error) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+
+}
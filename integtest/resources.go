@@ -0,0 +1,282 @@
+package integtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateTable creates a table keyed by the string attributes pk and, when
+// not empty, sk, with a stream of the new and old images (see
+// DynamoDBStreamEvent).
+func (l *LocalStack) CreateTable(t testing.TB, name, pk, sk string) {
+
+	t.Helper()
+
+	type attribute struct {
+		AttributeName string
+		AttributeType string `json:",omitempty"`
+		KeyType       string `json:",omitempty"`
+	}
+
+	attributes := []attribute{{AttributeName: pk, AttributeType: "S"}}
+	schema := []attribute{{AttributeName: pk, KeyType: "HASH"}}
+
+	if len(sk) > 0 {
+		attributes = append(attributes, attribute{AttributeName: sk, AttributeType: "S"})
+		schema = append(schema, attribute{AttributeName: sk, KeyType: "RANGE"})
+	}
+
+	in := map[string]interface{}{
+		"TableName":            name,
+		"AttributeDefinitions": attributes,
+		"KeySchema":            schema,
+		"BillingMode":          "PAY_PER_REQUEST",
+		"StreamSpecification": map[string]interface{}{
+			"StreamEnabled":  true,
+			"StreamViewType": "NEW_AND_OLD_IMAGES",
+		},
+	}
+
+	if err := l.Client().CallJSON(context.Background(), "dynamodb", "1.0", "DynamoDB_20120810.CreateTable", in, nil); err != nil {
+		t.Fatalf("Failed to create table %s: %v", name, err)
+	}
+
+}
+
+// CreateQueue creates a queue and returns its url.
+func (l *LocalStack) CreateQueue(t testing.TB, name string) string {
+
+	t.Helper()
+
+	out := struct {
+		QueueUrl string
+	}{}
+
+	if err := l.Client().CallJSON(context.Background(), "sqs", "1.0", "AmazonSQS.CreateQueue", map[string]string{"QueueName": name}, &out); err != nil {
+		t.Fatalf("Failed to create queue %s: %v", name, err)
+	}
+
+	return out.QueueUrl
+
+}
+
+// CreateBucket creates a bucket.
+func (l *LocalStack) CreateBucket(t testing.TB, name string) {
+
+	t.Helper()
+
+	client := l.Client()
+
+	u := client.S3ObjectUrl(name, "")
+	u.Path = "/" + name
+
+	req, err := http.NewRequest(http.MethodPut, u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.Do(context.Background(), "s3", req, nil)
+	if err != nil {
+		t.Fatalf("Failed to create bucket %s: %v", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("Failed to create bucket %s: status %d: %s", name, res.StatusCode, body)
+	}
+
+}
+
+// ReceiveSQSEvent receives up to max messages of the queue, waiting up to
+// 5s for the first one, and returns them as the event Lambda would deliver.
+// The messages are deleted from the queue.
+func (l *LocalStack) ReceiveSQSEvent(t testing.TB, queueUrl string, max int) *events.SQSEvent {
+
+	t.Helper()
+
+	ctx := context.Background()
+	client := l.Client()
+
+	in := map[string]interface{}{
+		"QueueUrl":                    queueUrl,
+		"MaxNumberOfMessages":         max,
+		"WaitTimeSeconds":             5,
+		"AttributeNames":              []string{"All"},
+		"MessageAttributeNames":       []string{"All"},
+		"MessageSystemAttributeNames": []string{"All"},
+	}
+
+	out := struct {
+		Messages []struct {
+			MessageId              string
+			ReceiptHandle          string
+			Body                   string
+			MD5OfBody              string
+			MD5OfMessageAttributes string
+			Attributes             map[string]string
+			MessageAttributes      map[string]struct {
+				DataType    string
+				StringValue *string
+				BinaryValue []byte
+			}
+		}
+	}{}
+
+	if err := client.CallJSON(ctx, "sqs", "1.0", "AmazonSQS.ReceiveMessage", in, &out); err != nil {
+		t.Fatalf("Failed to receive messages of %s: %v", queueUrl, err)
+	}
+
+	event := &events.SQSEvent{
+		Records: make([]events.SQSMessage, 0, len(out.Messages)),
+	}
+
+	for _, msg := range out.Messages {
+
+		record := events.SQSMessage{
+			MessageId:              msg.MessageId,
+			ReceiptHandle:          msg.ReceiptHandle,
+			Body:                   msg.Body,
+			Md5OfBody:              msg.MD5OfBody,
+			Md5OfMessageAttributes: msg.MD5OfMessageAttributes,
+			Attributes:             msg.Attributes,
+			MessageAttributes:      map[string]events.SQSMessageAttribute{},
+			EventSourceARN:         queueUrl,
+			EventSource:            "aws:sqs",
+			AWSRegion:              l.Region,
+		}
+
+		for name, attr := range msg.MessageAttributes {
+			record.MessageAttributes[name] = events.SQSMessageAttribute{
+				DataType:    attr.DataType,
+				StringValue: attr.StringValue,
+				BinaryValue: attr.BinaryValue,
+			}
+		}
+
+		event.Records = append(event.Records, record)
+
+		del := map[string]string{"QueueUrl": queueUrl, "ReceiptHandle": msg.ReceiptHandle}
+
+		if err := client.CallJSON(ctx, "sqs", "1.0", "AmazonSQS.DeleteMessage", del, nil); err != nil {
+			t.Fatalf("Failed to delete message %s: %v", msg.MessageId, err)
+		}
+
+	}
+
+	return event
+
+}
+
+// DynamoDBStreamEvent reads the stream of the table from its first record
+// and returns the records as the event Lambda would deliver (e.g. to
+// projection.Projector.HandleDynamoDBStream).
+func (l *LocalStack) DynamoDBStreamEvent(t testing.TB, table string) *events.DynamoDBEvent {
+
+	t.Helper()
+
+	ctx := context.Background()
+	client := l.Client()
+
+	described := struct {
+		Table struct {
+			LatestStreamArn string
+		}
+	}{}
+
+	if err := client.CallJSON(ctx, "dynamodb", "1.0", "DynamoDB_20120810.DescribeTable", map[string]string{"TableName": table}, &described); err != nil {
+		t.Fatalf("Failed to describe table %s: %v", table, err)
+	}
+
+	streamArn := described.Table.LatestStreamArn
+	if len(streamArn) == 0 {
+		t.Fatalf("Table %s has no stream", table)
+	}
+
+	stream := struct {
+		StreamDescription struct {
+			Shards []struct {
+				ShardId string
+			}
+		}
+	}{}
+
+	if err := l.callStreams(ctx, "DescribeStream", map[string]string{"StreamArn": streamArn}, &stream); err != nil {
+		t.Fatalf("Failed to describe stream of %s: %v", table, err)
+	}
+
+	event := &events.DynamoDBEvent{
+		Records: []events.DynamoDBEventRecord{},
+	}
+
+	for _, shard := range stream.StreamDescription.Shards {
+
+		iterator := struct {
+			ShardIterator string
+		}{}
+
+		in := map[string]string{
+			"StreamArn":         streamArn,
+			"ShardId":           shard.ShardId,
+			"ShardIteratorType": "TRIM_HORIZON",
+		}
+
+		if err := l.callStreams(ctx, "GetShardIterator", in, &iterator); err != nil {
+			t.Fatalf("Failed to get iterator of shard %s: %v", shard.ShardId, err)
+		}
+
+		for next := iterator.ShardIterator; len(next) > 0; {
+
+			page := struct {
+				Records           []events.DynamoDBEventRecord
+				NextShardIterator string
+			}{}
+
+			if err := l.callStreams(ctx, "GetRecords", map[string]string{"ShardIterator": next}, &page); err != nil {
+				t.Fatalf("Failed to get records of shard %s: %v", shard.ShardId, err)
+			}
+
+			// Open shards always return a next iterator, stop once drained.
+			if len(page.Records) == 0 {
+				break
+			}
+
+			for _, record := range page.Records {
+				record.EventSourceArn = streamArn
+				event.Records = append(event.Records, record)
+			}
+
+			next = page.NextShardIterator
+
+		}
+
+	}
+
+	return event
+
+}
+
+func (l *LocalStack) callStreams(ctx context.Context, op string, in, out interface{}) error {
+	return l.Client().CallJSON(ctx, "dynamodb", "1.0", "DynamoDBStreams_20120810."+op, in, out)
+}
+
+func decodeJSON(res *http.Response, out interface{}) error {
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Status %d: %s", res.StatusCode, body)
+	}
+
+	return json.Unmarshal(body, out)
+
+}
@@ -0,0 +1,225 @@
+package ledger
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+	"github.com/protomesh/protomesh-go/dynamo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	dayLayout = "2006-01-02"
+	// Sort keys order by time, the page tokens are sort keys.
+	sortKeyLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+var _ Store = &DynamoStore{}
+
+type dynamoItem map[string]events.DynamoDBAttributeValue
+
+// DynamoStore keeps invocations in a DynamoDB table partitioned by the "day"
+// string attribute and sorted by the "sk" string attribute, items expire
+// through the "expires_at" TTL attribute. A partition holds the invocations
+// of a whole day, so it suits moderate traffic or a subset of the methods.
+type DynamoStore struct {
+	client    *awsapi.Client
+	table     string
+	retention time.Duration
+}
+
+func NewDynamoStore(client *awsapi.Client, table string, retention time.Duration) *DynamoStore {
+	return &DynamoStore{
+		client:    client,
+		table:     table,
+		retention: retention,
+	}
+}
+
+func (d *DynamoStore) Write(ctx context.Context, inv *Invocation) error {
+
+	body, err := proto.Marshal(inv)
+	if err != nil {
+		return err
+	}
+
+	t := inv.Time.AsTime().UTC()
+
+	item := dynamoItem{
+		"day":        events.NewStringAttribute(t.Format(dayLayout)),
+		"sk":         events.NewStringAttribute(t.Format(sortKeyLayout) + "#" + inv.Id),
+		"method":     events.NewStringAttribute(inv.Method),
+		"code":       events.NewNumberAttribute(strconv.Itoa(int(inv.Code))),
+		"invocation": events.NewBinaryAttribute(body),
+	}
+
+	if len(inv.Caller) > 0 {
+		item["caller"] = events.NewStringAttribute(inv.Caller)
+	}
+
+	if d.retention > 0 {
+		item["expires_at"] = events.NewNumberAttribute(strconv.FormatInt(t.Add(d.retention).Unix(), 10))
+	}
+
+	return d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item":      item,
+	}, nil)
+
+}
+
+// List queries the days from the page token (or today) back to req.Since,
+// which is bounded by the retention.
+func (d *DynamoStore) List(ctx context.Context, req *ListInvocationsRequest) ([]*Invocation, string, error) {
+
+	now := clock.FromContext(ctx).Now().UTC()
+
+	since := now.Add(-24 * time.Hour)
+	if req.Since != nil {
+		since = req.Since.AsTime().UTC()
+	}
+
+	if d.retention > 0 && since.Before(now.Add(-d.retention)) {
+		since = now.Add(-d.retention)
+	}
+
+	cursor := req.PageToken
+	day := now
+
+	if len(cursor) > 0 {
+
+		cursorDay, err := time.Parse(dayLayout, strings.SplitN(cursor, "T", 2)[0])
+		if err != nil {
+			return nil, "", status.Error(codes.InvalidArgument, "Invalid page token")
+		}
+
+		day = cursorDay
+
+	}
+
+	invocations := []*Invocation{}
+	sinceDay := since.Format(dayLayout)
+
+	for ; day.Format(dayLayout) >= sinceDay; day = day.Add(-24 * time.Hour) {
+
+		upper := cursor
+		if len(upper) == 0 {
+			// Sorts after every sort key of the day.
+			upper = day.Format(dayLayout) + "U"
+		}
+
+		items, err := d.query(ctx, req, day.Format(dayLayout), since.Format(sortKeyLayout), upper, int(req.PageSize)-len(invocations))
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, item := range items {
+
+			sortKey, err := dynamo.Item(item).String("sk")
+			if err != nil {
+				return nil, "", err
+			}
+
+			body, err := dynamo.Item(item).Binary("invocation")
+			if err != nil {
+				return nil, "", status.Errorf(codes.DataLoss, "Corrupted invocation %s: %v", sortKey, err)
+			}
+
+			inv := &Invocation{}
+			if err := proto.Unmarshal(body, inv); err != nil {
+				return nil, "", status.Errorf(codes.DataLoss, "Corrupted invocation %s: %v", sortKey, err)
+			}
+
+			invocations = append(invocations, inv)
+
+			if len(invocations) == int(req.PageSize) {
+				return invocations, sortKey, nil
+			}
+
+		}
+
+		cursor = ""
+
+	}
+
+	return invocations, "", nil
+
+}
+
+// query returns up to limit items of a day between the sort keys lower and
+// upper (excluded).
+func (d *DynamoStore) query(ctx context.Context, req *ListInvocationsRequest, day, lower, upper string, limit int) ([]dynamoItem, error) {
+
+	names := map[string]string{"#day": "day", "#sk": "sk"}
+	values := dynamoItem{
+		":day":   events.NewStringAttribute(day),
+		":lower": events.NewStringAttribute(lower),
+		":upper": events.NewStringAttribute(upper),
+	}
+
+	filters := []string{"#sk <> :upper"}
+
+	if len(req.Method) > 0 {
+		names["#method"] = "method"
+		values[":method"] = events.NewStringAttribute(req.Method)
+		filters = append(filters, "#method = :method")
+	}
+
+	if len(req.Caller) > 0 {
+		names["#caller"] = "caller"
+		values[":caller"] = events.NewStringAttribute(req.Caller)
+		filters = append(filters, "#caller = :caller")
+	}
+
+	if req.FailedOnly {
+		names["#code"] = "code"
+		values[":ok"] = events.NewNumberAttribute(strconv.Itoa(int(codes.OK)))
+		filters = append(filters, "#code <> :ok")
+	}
+
+	in := map[string]interface{}{
+		"TableName":                 d.table,
+		"KeyConditionExpression":    "#day = :day AND #sk BETWEEN :lower AND :upper",
+		"FilterExpression":          strings.Join(filters, " AND "),
+		"ExpressionAttributeNames":  names,
+		"ExpressionAttributeValues": values,
+		"ScanIndexForward":          false,
+	}
+
+	items := []dynamoItem{}
+
+	for len(items) < limit {
+
+		out := struct {
+			Items            []dynamoItem
+			LastEvaluatedKey dynamoItem
+		}{}
+
+		if err := d.client.CallDynamoDB(ctx, "Query", in, &out); err != nil {
+			return nil, err
+		}
+
+		items = append(items, out.Items...)
+
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+
+		in["ExclusiveStartKey"] = out.LastEvaluatedKey
+
+	}
+
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+
+}
@@ -0,0 +1,88 @@
+// Package ledger records the method, caller, latency and outcome of every
+// invocation into a queryable store, a lightweight alternative to tracing
+// for inspecting recent traffic.
+package ledger
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go --go-grpc_out=.. --go-grpc_opt=module=github.com/protomesh/protomesh-go protomesh/ledger/v1/ledger.proto
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Store persists and lists invocations.
+type Store interface {
+	Write(ctx context.Context, inv *Invocation) error
+	// List returns the invocations matching req, newest first.
+	List(ctx context.Context, req *ListInvocationsRequest) ([]*Invocation, string, error)
+}
+
+type Options struct {
+	Store Store
+	// Identifies the caller (e.g. the authz principal id).
+	Caller func(ctx context.Context) string
+	// Restricts the ledger to some methods, every method when nil.
+	Methods func(method string) bool
+}
+
+// Interceptor writes an invocation to the store once the call returns,
+// store failures are logged and never fail the call.
+func Interceptor(opts Options) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		if opts.Methods != nil && !opts.Methods(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		start := clock.FromContext(ctx).Now()
+
+		out, err := handler(ctx, req)
+
+		inv, invErr := newInvocation(ctx, &opts, info.FullMethod, err, start)
+		if invErr == nil {
+			invErr = opts.Store.Write(ctx, inv)
+		}
+
+		if invErr != nil {
+			lambda.LoggerFromContext(ctx).Warn("Failed to write invocation to the ledger", "method", info.FullMethod, "error", invErr)
+		}
+
+		return out, err
+
+	}
+
+}
+
+func newInvocation(ctx context.Context, opts *Options, method string, callErr error, start time.Time) (*Invocation, error) {
+
+	id := make([]byte, 16)
+	clock.IdGeneratorFromContext(ctx).Read(id)
+
+	st := status.Convert(callErr)
+
+	inv := &Invocation{
+		Id:        hex.EncodeToString(id),
+		Method:    method,
+		RequestId: lambda.RequestIdFromContext(ctx),
+		Time:      timestamppb.New(start),
+		Latency:   durationpb.New(clock.FromContext(ctx).Now().Sub(start)),
+		Code:      int32(st.Code()),
+		Message:   st.Message(),
+	}
+
+	if opts.Caller != nil {
+		inv.Caller = opts.Caller(ctx)
+	}
+
+	return inv, nil
+
+}
@@ -0,0 +1,48 @@
+package ledger
+
+import (
+	"context"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+var _ LedgerServer = &Server{}
+
+// Server implements protomesh.ledger.v1.Ledger on top of a Store, its
+// methods require the ledger.admin permission (see authz.Interceptor):
+//
+//	controller.RegisterGRPCService(ledger.Ledger_ServiceDesc, ledger.NewServer(store))
+type Server struct {
+	UnimplementedLedgerServer
+
+	store Store
+}
+
+func NewServer(store Store) *Server {
+	return &Server{
+		store: store,
+	}
+}
+
+func (s *Server) ListInvocations(ctx context.Context, req *ListInvocationsRequest) (*ListInvocationsResponse, error) {
+
+	if req.PageSize <= 0 {
+		req.PageSize = DefaultPageSize
+	} else if req.PageSize > MaxPageSize {
+		req.PageSize = MaxPageSize
+	}
+
+	invocations, nextToken, err := s.store.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListInvocationsResponse{
+		Invocations:   invocations,
+		NextPageToken: nextToken,
+	}, nil
+
+}
@@ -0,0 +1,216 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+)
+
+// GraphHandlerKey is the route of the dependency graph handler.
+const GraphHandlerKey = "/_protomesh/dependencies"
+
+// Node is a struct of the dependency tree.
+type Node struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Full keys of the app.Config fields of the struct.
+	ConfigKeys []string `json:"config_keys,omitempty"`
+	// Keys of the config fields without value.
+	UnsetKeys []string `json:"unset_keys,omitempty"`
+	Children  []string `json:"children,omitempty"`
+	// Lifecycle of the node when it's a module.
+	Module        bool     `json:"module"`
+	DependsOn     []string `json:"depends_on,omitempty"`
+	Started       bool     `json:"started"`
+	StartDuration string   `json:"start_duration,omitempty"`
+}
+
+// Graph is the resolved dependency tree, with the lifecycle of its modules.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+}
+
+var configType = reflect.TypeOf((*app.Config)(nil)).Elem()
+
+// Graph walks the dependency tree of deps like Discover does.
+func (m *Manager) Graph(deps app.Dependency) *Graph {
+
+	g := &Graph{}
+
+	root := &Node{Name: "root", Type: reflect.TypeOf(deps).String()}
+	g.Nodes = append(g.Nodes, root)
+
+	m.walk(g, root, reflect.ValueOf(deps), "")
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	started := map[string]bool{}
+	for _, hook := range m.started {
+		started[hook.Name] = true
+	}
+
+	for _, node := range g.Nodes {
+
+		hook, ok := m.hooks[node.Name]
+		if !ok {
+			continue
+		}
+
+		node.Module = true
+		node.DependsOn = hook.DependsOn
+		node.Started = started[node.Name]
+
+		if duration, ok := m.durations[node.Name]; ok {
+			node.StartDuration = duration.String()
+		}
+
+	}
+
+	return g
+
+}
+
+func (m *Manager) walk(g *Graph, node *Node, val reflect.Value, prefix string) {
+
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	el := val.Elem()
+
+	for i := 0; i < el.NumField(); i++ {
+
+		field := el.Type().Field(i)
+		fieldVal := el.Field(i)
+
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get("config"), ",")[0]
+
+		if field.Type == configType {
+
+			if len(prefix) > 0 {
+				key = prefix + "." + key
+			}
+
+			node.ConfigKeys = append(node.ConfigKeys, key)
+
+			if config, ok := fieldVal.Interface().(app.Config); !ok || config == nil || !config.IsSet() {
+				node.UnsetKeys = append(node.UnsetKeys, key)
+			}
+
+			continue
+
+		}
+
+		if fieldVal.Kind() != reflect.Ptr || fieldVal.IsNil() || fieldVal.Elem().Kind() != reflect.Struct {
+			continue
+		}
+
+		name := key
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		if len(prefix) > 0 {
+			name = prefix + "." + name
+		}
+
+		child := &Node{Name: name, Type: fieldVal.Type().String()}
+
+		node.Children = append(node.Children, name)
+		g.Nodes = append(g.Nodes, child)
+
+		m.walk(g, child, fieldVal, name)
+
+	}
+
+}
+
+// DOT renders the graph in the Graphviz format, modules depend on their
+// dependencies through dashed edges.
+func (g *Graph) DOT() string {
+
+	b := &strings.Builder{}
+
+	b.WriteString("digraph dependencies {\n\tnode [shape=box];\n")
+
+	nodes := append([]*Node{}, g.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	for _, node := range nodes {
+
+		label := node.Name + "\\n" + node.Type
+		if len(node.StartDuration) > 0 {
+			label += "\\nstarted in " + node.StartDuration
+		}
+
+		attrs := fmt.Sprintf("label=%q", label)
+		if len(node.UnsetKeys) > 0 {
+			attrs += ", color=orange"
+		}
+		if node.Module && !node.Started {
+			attrs += ", style=dashed"
+		}
+
+		fmt.Fprintf(b, "\t%q [%s];\n", node.Name, attrs)
+
+		for _, child := range node.Children {
+			fmt.Fprintf(b, "\t%q -> %q;\n", node.Name, child)
+		}
+
+		for _, dep := range node.DependsOn {
+			fmt.Fprintf(b, "\t%q -> %q [style=dashed];\n", node.Name, dep)
+		}
+
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+
+}
+
+// GraphHandler serves the graph of deps as JSON, or as DOT when the request
+// accepts text/vnd.graphviz. It discloses the configuration keys so it
+// should be protected like the status handler.
+func (m *Manager) GraphHandler(deps app.Dependency) lambda.Handler {
+
+	return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+		g := m.Graph(deps)
+
+		res.StatusCode = http.StatusOK
+		res.IsBase64Encoded = false
+
+		if strings.Contains(req.Header("Accept"), "text/vnd.graphviz") {
+			res.Headers = map[string]string{"Content-Type": "text/vnd.graphviz"}
+			res.Body = g.DOT()
+			return nil
+		}
+
+		body, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+
+		res.Headers = map[string]string{"Content-Type": "application/json"}
+		res.Body = string(body)
+
+		return nil
+
+	}
+
+}
@@ -0,0 +1,395 @@
+// Package lifecycle starts and stops the modules of the dependency tree in
+// dependency order, so pools, watchers and flushers are initialized
+// deterministically on cold start and flushed on shutdown.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Starter is implemented by modules initialized on cold start.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by modules flushed or closed on shutdown.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// ReadinessGate is implemented by modules which may not be ready right after
+// starting (e.g. waiting for a first configuration fetch).
+type ReadinessGate interface {
+	Ready(ctx context.Context) error
+}
+
+// Dependent is implemented by modules started after other modules, given
+// by name.
+type Dependent interface {
+	DependsOn() []string
+}
+
+// Hook is a module of the lifecycle, any of the functions may be nil.
+type Hook struct {
+	Name      string
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+	Ready     func(ctx context.Context) error
+	// Bounds Start and the readiness wait, defaults to Manager.StartTimeout.
+	Timeout time.Duration
+}
+
+type Manager struct {
+	// Defaults to 10s per module.
+	StartTimeout time.Duration
+	// Bounds the whole shutdown, Lambda gives extensions up to 2s after
+	// SIGTERM. Defaults to 1.5s.
+	StopTimeout time.Duration
+	// Interval between readiness checks while starting. Defaults to 100ms.
+	ReadyInterval time.Duration
+
+	lock      sync.Mutex
+	hooks     map[string]*Hook
+	started   []*Hook
+	durations map[string]time.Duration
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		StartTimeout:  10 * time.Second,
+		StopTimeout:   1500 * time.Millisecond,
+		ReadyInterval: 100 * time.Millisecond,
+		hooks:         make(map[string]*Hook),
+		durations:     make(map[string]time.Duration),
+	}
+}
+
+// Register adds hooks, registering a name twice replaces the hook.
+func (m *Manager) Register(hooks ...Hook) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i := range hooks {
+		m.hooks[hooks[i].Name] = &hooks[i]
+	}
+
+}
+
+// Discover registers the modules of the dependency tree of deps: fields
+// implementing Starter, Stopper or ReadinessGate, found through the same
+// struct pointers app.Injector walks. Modules are named after the config
+// key of their field (or the field name) prefixed by their parents.
+func (m *Manager) Discover(deps app.Dependency) {
+	m.discover(reflect.ValueOf(deps), "")
+}
+
+func (m *Manager) discover(val reflect.Value, prefix string) {
+
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	el := val.Elem()
+
+	for i := 0; i < el.NumField(); i++ {
+
+		field := el.Type().Field(i)
+		fieldVal := el.Field(i)
+
+		if !field.IsExported() || field.Anonymous || fieldVal.Kind() != reflect.Ptr || fieldVal.IsNil() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("config"), ",")[0]
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		if len(prefix) > 0 {
+			name = prefix + "." + name
+		}
+
+		if hook, ok := moduleHook(name, fieldVal.Interface()); ok {
+			m.Register(hook)
+		}
+
+		m.discover(fieldVal, name)
+
+	}
+
+}
+
+func moduleHook(name string, module interface{}) (Hook, bool) {
+
+	hook := Hook{Name: name}
+
+	if starter, ok := module.(Starter); ok {
+		hook.Start = starter.Start
+	}
+
+	if stopper, ok := module.(Stopper); ok {
+		hook.Stop = stopper.Stop
+	}
+
+	if gate, ok := module.(ReadinessGate); ok {
+		hook.Ready = gate.Ready
+	}
+
+	if dependent, ok := module.(Dependent); ok {
+		hook.DependsOn = dependent.DependsOn()
+	}
+
+	return hook, hook.Start != nil || hook.Stop != nil || hook.Ready != nil
+
+}
+
+// Start starts the modules in dependency order, waiting for each one to be
+// ready before starting its dependents. Modules started before a failure
+// are stopped.
+func (m *Manager) Start(ctx context.Context) error {
+
+	order, err := m.order()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range order {
+
+		start := time.Now()
+
+		err := m.start(ctx, hook)
+
+		m.lock.Lock()
+		m.durations[hook.Name] = time.Since(start)
+		m.lock.Unlock()
+
+		if err != nil {
+
+			stopCtx, cancel := context.WithTimeout(context.Background(), m.StopTimeout)
+			defer cancel()
+
+			m.Stop(stopCtx)
+
+			return fmt.Errorf("Failed to start %s: %w", hook.Name, err)
+
+		}
+
+		m.lock.Lock()
+		m.started = append(m.started, hook)
+		m.lock.Unlock()
+
+	}
+
+	return nil
+
+}
+
+func (m *Manager) start(ctx context.Context, hook *Hook) error {
+
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = m.StartTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if hook.Start != nil {
+		if err := hook.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	if hook.Ready == nil {
+		return nil
+	}
+
+	for {
+
+		err := hook.Ready(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Not ready after %s: %w", timeout, err)
+		case <-time.After(m.ReadyInterval):
+		}
+
+	}
+
+}
+
+// Stop stops the started modules in reverse order, every module is stopped
+// even when others fail.
+func (m *Manager) Stop(ctx context.Context) error {
+
+	m.lock.Lock()
+	started := m.started
+	m.started = nil
+	m.lock.Unlock()
+
+	errs := []string{}
+
+	for i := len(started) - 1; i >= 0; i-- {
+
+		hook := started[i]
+
+		if hook.Stop == nil {
+			continue
+		}
+
+		if err := hook.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", hook.Name, err))
+		}
+
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to stop modules: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+
+}
+
+// Ready checks the readiness gates of the started modules, it can be
+// registered as a controller health check.
+func (m *Manager) Ready(ctx context.Context) error {
+
+	m.lock.Lock()
+	started := append([]*Hook{}, m.started...)
+	total := len(m.hooks)
+	m.lock.Unlock()
+
+	if len(started) < total {
+		return status.Errorf(codes.Unavailable, "%d of %d modules started", len(started), total)
+	}
+
+	for _, hook := range started {
+		if hook.Ready != nil {
+			if err := hook.Ready(ctx); err != nil {
+				return status.Errorf(codes.Unavailable, "Module %s not ready: %v", hook.Name, err)
+			}
+		}
+	}
+
+	return nil
+
+}
+
+// Middleware answers Unavailable until every module is started.
+func (m *Manager) Middleware() lambda.Middleware {
+	return func(next lambda.Handler) lambda.Handler {
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			m.lock.Lock()
+			ready := len(m.started) == len(m.hooks)
+			m.lock.Unlock()
+
+			if !ready {
+				return res.WriteError(status.Error(codes.Unavailable, "Modules are starting"))
+			}
+
+			return next(ctx, req, res)
+
+		}
+	}
+}
+
+// StopOnSignal stops the modules on SIGTERM, which Lambda sends before
+// shutting down an execution environment with a registered extension.
+func (m *Manager) StopOnSignal(log app.Logger) {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+
+	go func() {
+
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.StopTimeout)
+		defer cancel()
+
+		if err := m.Stop(ctx); err != nil {
+			log.Error("Failed to stop modules", "error", err)
+		}
+
+		os.Exit(0)
+
+	}()
+
+}
+
+// order sorts the hooks so every hook comes after its dependencies, hooks
+// without dependencies between them are sorted by name.
+func (m *Manager) order() ([]*Hook, error) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	names := make([]string, 0, len(m.hooks))
+	for name := range m.hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	order := []*Hook{}
+	state := map[string]int{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+
+		hook, ok := m.hooks[name]
+		if !ok {
+			return fmt.Errorf("Module %s depends on unknown module %s", path[len(path)-1], name)
+		}
+
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("Dependency cycle: %s -> %s", strings.Join(path, " -> "), name)
+		case 2:
+			return nil
+		}
+
+		state[name] = 1
+
+		for _, dep := range hook.DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		state[name] = 2
+		order = append(order, hook)
+
+		return nil
+
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+
+}
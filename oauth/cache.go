@@ -0,0 +1,210 @@
+package oauth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/status"
+)
+
+// Cache reuses the tokens of Source until they are about to expire, they
+// are refreshed in background during the RefreshBefore window so the calls
+// rarely wait for the token endpoint. Concurrent calls missing the same
+// token wait for a single request of it.
+type Cache struct {
+	Source TokenSource
+	// Caches a token per key (e.g. per subject of a TokenExchange). Defaults
+	// to the subject token for TokenExchange sources, so exchanged tokens
+	// are never shared between callers, a single token otherwise.
+	Key func(ctx context.Context) string
+	// Defaults to 1m in NewCache.
+	RefreshBefore time.Duration
+	// Tokens kept, the least recently used ones are evicted first. Defaults
+	// to 1000.
+	MaxEntries int
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key        string
+	token      *Token
+	refreshing bool
+	// Closed once the request of a missing token completes, err is its
+	// failure.
+	fetched chan struct{}
+	err     error
+}
+
+func NewCache(source TokenSource) *Cache {
+	return &Cache{
+		Source:        source,
+		RefreshBefore: time.Minute,
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+func (c *Cache) Token(ctx context.Context) (*Token, error) {
+
+	key, err := c.key(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+
+	entry := c.entry(key)
+
+	token := entry.token
+	now := clock.FromContext(ctx).Now()
+
+	if token != nil && (token.Expiry.IsZero() || now.Before(token.Expiry)) {
+
+		if !token.Expiry.IsZero() && now.After(token.Expiry.Add(-c.RefreshBefore)) && !entry.refreshing {
+			entry.refreshing = true
+			go c.refresh(ctx, lambda.LoggerFromContext(ctx), entry)
+		}
+
+		c.lock.Unlock()
+
+		return token, nil
+
+	}
+
+	// Another call is requesting the token.
+	if fetched := entry.fetched; fetched != nil {
+
+		c.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-fetched:
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		if entry.err != nil {
+			return nil, entry.err
+		}
+
+		return entry.token, nil
+
+	}
+
+	entry.fetched = make(chan struct{})
+
+	c.lock.Unlock()
+
+	token, err = c.Source.Token(ctx)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err == nil {
+		entry.token = token
+	}
+
+	entry.err = err
+
+	close(entry.fetched)
+	entry.fetched = nil
+
+	return token, err
+
+}
+
+// key returns the cache key of the call.
+func (c *Cache) key(ctx context.Context) (string, error) {
+
+	if c.Key != nil {
+		return c.Key(ctx), nil
+	}
+
+	exchange, ok := c.Source.(*TokenExchange)
+	if !ok {
+		return "", nil
+	}
+
+	subject, err := exchange.SubjectToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(subject))
+
+	return hex.EncodeToString(sum[:]), nil
+
+}
+
+// entry returns the entry of key, created when missing, evicting the least
+// recently used entries beyond MaxEntries. It must be called with the lock
+// held.
+func (c *Cache) entry(key string) *cacheEntry {
+
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.lru = list.New()
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry)
+	}
+
+	entry := &cacheEntry{key: key}
+	c.entries[key] = c.lru.PushFront(entry)
+
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+
+	for c.lru.Len() > maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+
+	return entry
+
+}
+
+// refresh fetches a new token detached from the cancelation of ctx, its
+// values (e.g. the incoming metadata) are kept for the source.
+func (c *Cache) refresh(ctx context.Context, log app.Logger, entry *cacheEntry) {
+
+	token, err := c.Source.Token(detached{ctx})
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry.refreshing = false
+
+	if err != nil {
+		log.Warn("Failed to refresh token", "error", err)
+		return
+	}
+
+	entry.token = token
+
+}
+
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
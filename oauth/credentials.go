@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Interceptor authorizes the outbound calls with the tokens of source, e.g.
+// as the Interceptor of a lambda.ClientConn or lambda.HTTPClientConn. The
+// source should be a Cache.
+func Interceptor(source TokenSource) grpc.UnaryClientInterceptor {
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		token, err := source.Token(ctx)
+		if err != nil {
+			return err
+		}
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		md = md.Copy()
+		md.Set("authorization", token.Header())
+
+		return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+
+	}
+
+}
+
+// Transport authorizes the requests of an http.Client with the tokens of
+// Source.
+type Transport struct {
+	Source TokenSource
+	// Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", token.Header())
+
+	return base.RoundTrip(req)
+
+}
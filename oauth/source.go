@@ -0,0 +1,207 @@
+// Package oauth fetches OAuth2 access tokens (client credentials or token
+// exchange) and authorizes the outbound calls with them.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+type Token struct {
+	AccessToken string
+	// Defaults to Bearer.
+	TokenType string
+	// Zero when the token doesn't expire.
+	Expiry time.Time
+}
+
+// Header returns the Authorization header value of the token.
+func (t *Token) Header() string {
+
+	if len(t.TokenType) == 0 || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer " + t.AccessToken
+	}
+
+	return t.TokenType + " " + t.AccessToken
+
+}
+
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// Endpoint is a token endpoint and the client credentials authenticating to
+// it through HTTP basic authentication.
+type Endpoint struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Defaults to http.DefaultClient.
+	HttpClient *http.Client
+}
+
+// ClientCredentials requests tokens of the client itself.
+type ClientCredentials struct {
+	Endpoint
+	Scopes []string
+	// Requested audience, for the providers requiring it (e.g. Auth0).
+	Audience string
+	// Additional form parameters.
+	Params url.Values
+}
+
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+
+	form := url.Values{"grant_type": {GrantClientCredentials}}
+
+	setParams(form, c.Scopes, c.Audience, c.Params)
+
+	return c.Endpoint.request(ctx, form)
+
+}
+
+// TokenExchange exchanges a subject token (e.g. the token of the caller)
+// for a token of the downstream audience, as in RFC 8693.
+type TokenExchange struct {
+	Endpoint
+	// Returns the token to exchange, e.g. from the incoming metadata.
+	SubjectToken func(ctx context.Context) (string, error)
+	// Defaults to TokenTypeAccessToken.
+	SubjectTokenType   string
+	RequestedTokenType string
+	Scopes             []string
+	Audience           string
+	Params             url.Values
+}
+
+func (e *TokenExchange) Token(ctx context.Context) (*Token, error) {
+
+	subject, err := e.SubjectToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subject) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "No subject token to exchange")
+	}
+
+	subjectType := e.SubjectTokenType
+	if len(subjectType) == 0 {
+		subjectType = TokenTypeAccessToken
+	}
+
+	form := url.Values{
+		"grant_type":         {GrantTokenExchange},
+		"subject_token":      {subject},
+		"subject_token_type": {subjectType},
+	}
+
+	if len(e.RequestedTokenType) > 0 {
+		form.Set("requested_token_type", e.RequestedTokenType)
+	}
+
+	setParams(form, e.Scopes, e.Audience, e.Params)
+
+	return e.Endpoint.request(ctx, form)
+
+}
+
+func setParams(form url.Values, scopes []string, audience string, params url.Values) {
+
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+
+	if len(audience) > 0 {
+		form.Set("audience", audience)
+	}
+
+	for k, v := range params {
+		form[k] = v
+	}
+
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e *Endpoint) request(ctx context.Context, form url.Values) (*Token, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(e.ClientID), url.QueryEscape(e.ClientSecret))
+
+	httpClient := e.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	now := clock.FromContext(ctx).Now()
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to request token: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to read token response: %v", err)
+	}
+
+	out := &tokenResponse{}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Invalid token response (%d): %s", res.StatusCode, body)
+	}
+
+	if res.StatusCode >= 300 || len(out.Error) > 0 {
+
+		code := codes.Unavailable
+		if res.StatusCode >= 400 && res.StatusCode < 500 {
+			code = codes.Unauthenticated
+		}
+
+		return nil, status.Errorf(code, "Token request failed: %s", strings.TrimSpace(fmt.Sprint(out.Error, " ", out.ErrorDescription)))
+
+	}
+
+	if len(out.AccessToken) == 0 {
+		return nil, status.Errorf(codes.Unavailable, "Token response without access token")
+	}
+
+	token := &Token{AccessToken: out.AccessToken, TokenType: out.TokenType}
+
+	if out.ExpiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(out.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+
+}
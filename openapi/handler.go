@@ -0,0 +1,72 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+)
+
+// Register serves the document of the gRPC services of the controller at
+// key (e.g. /openapi.json). The document is generated on the first request,
+// once every service is registered.
+func Register[D lambda.ControllerDependency](c *lambda.Controller[D], key string, opts Options) error {
+
+	var once sync.Once
+	var body []byte
+	var genErr error
+
+	return c.TryRegisterHandler(key, func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+		once.Do(func() {
+
+			doc, err := Generate(Services(c.Routes()), opts)
+			if err != nil {
+				genErr = err
+				return
+			}
+
+			body, genErr = json.Marshal(doc)
+
+		})
+
+		if genErr != nil {
+			return genErr
+		}
+
+		res.StatusCode = http.StatusOK
+		res.Headers = map[string]string{"Content-Type": "application/json"}
+		res.Body = string(body)
+		res.IsBase64Encoded = false
+
+		return nil
+
+	})
+
+}
+
+// Services returns the full names of the gRPC services of the routes.
+func Services(routes []lambda.Route) []string {
+
+	seen := map[string]bool{}
+	services := []string{}
+
+	for _, route := range routes {
+
+		if len(route.Service) == 0 || seen[route.Service] {
+			continue
+		}
+
+		seen[route.Service] = true
+		services = append(services, route.Service)
+
+	}
+
+	sort.Strings(services)
+
+	return services
+
+}
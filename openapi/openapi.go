@@ -0,0 +1,354 @@
+// Package openapi generates an OpenAPI 3 document from the gRPC services
+// registered on a controller, following their google.api.http rules and
+// authz annotations.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/authz"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	Url string `json:"url"`
+}
+
+// PathItem holds the operations of a path by lower case HTTP method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	// Permissions of the authz annotation.
+	Permissions []string `json:"x-permissions,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	// apiKey, http, oauth2 or openIdConnect.
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	// Url of the OpenID Connect discovery document (e.g. of a Cognito user
+	// pool).
+	OpenIdConnectUrl string `json:"openIdConnectUrl,omitempty"`
+}
+
+type Options struct {
+	Title   string
+	Version string
+	Servers []string
+	// Prefix of the paths of the methods without http rule, the base path of
+	// the controller matcher.
+	BasePath string
+	// Required by every method which isn't annotated as public, none when
+	// empty.
+	SecuritySchemes map[string]*SecurityScheme
+}
+
+// Unlike the transcoded routes, methods without http rule are called with
+// their proto3 JSON input on POST /package.Service/Method.
+const jsonContentType = "application/json"
+
+var pathVariable = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// Generate documents the services, given by full name, from the registered
+// descriptors.
+func Generate(services []string, opts Options) (*Document, error) {
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   opts.Title,
+			Version: opts.Version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: opts.SecuritySchemes,
+		},
+	}
+
+	for _, server := range opts.Servers {
+		doc.Servers = append(doc.Servers, Server{Url: server})
+	}
+
+	schemas := &schemaSet{schemas: doc.Components.Schemas}
+
+	sort.Strings(services)
+
+	for _, service := range services {
+
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			return nil, fmt.Errorf("Service %s not registered: %w", service, err)
+		}
+
+		serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", service)
+		}
+
+		methods := serviceDesc.Methods()
+
+		for i := 0; i < methods.Len(); i++ {
+
+			method := methods.Get(i)
+
+			// Streams can't be described by OpenAPI.
+			if method.IsStreamingClient() || method.IsStreamingServer() {
+				continue
+			}
+
+			addMethod(doc, schemas, &opts, method)
+
+		}
+
+	}
+
+	return doc, nil
+
+}
+
+func addMethod(doc *Document, schemas *schemaSet, opts *Options, method protoreflect.MethodDescriptor) {
+
+	service := method.Parent().(protoreflect.ServiceDescriptor)
+
+	rules := []*annotations.HttpRule{}
+
+	if proto.HasExtension(method.Options(), annotations.E_Http) {
+
+		rule := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+
+		rules = append(rules, rule)
+		rules = append(rules, rule.AdditionalBindings...)
+
+	}
+
+	if len(rules) == 0 {
+		rules = append(rules, &annotations.HttpRule{
+			Pattern: &annotations.HttpRule_Post{Post: strings.TrimRight(opts.BasePath, "/") + "/" + string(service.FullName()) + "/" + string(method.Name())},
+			Body:    "*",
+		})
+	}
+
+	code := http.StatusOK
+	if proto.HasExtension(method.Options(), lambda.E_SuccessStatus) {
+		code = int(proto.GetExtension(method.Options(), lambda.E_SuccessStatus).(int32))
+	}
+
+	for i, rule := range rules {
+
+		verb, path := rulePattern(rule)
+		if len(verb) == 0 {
+			continue
+		}
+
+		success := &Response{
+			Description: http.StatusText(code),
+		}
+
+		if code != http.StatusNoContent {
+			success.Content = map[string]*MediaType{jsonContentType: {Schema: schemas.message(method.Output(), rule.ResponseBody)}}
+		}
+
+		op := &Operation{
+			OperationId: string(service.Name()) + "_" + string(method.Name()),
+			Summary:     leadingComment(method),
+			Tags:        []string{string(service.Name())},
+			Responses: map[string]*Response{
+				fmt.Sprintf("%d", code): success,
+				"default": {
+					Description: "Error",
+				},
+			},
+		}
+
+		if i > 0 {
+			op.OperationId += fmt.Sprintf("%d", i+1)
+		}
+
+		addParameters(op, schemas, method.Input(), path, rule, verb)
+
+		addSecurity(op, opts, method)
+
+		item, ok := doc.Paths[pathVariable.ReplaceAllString(path, "{$1}")]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[pathVariable.ReplaceAllString(path, "{$1}")] = item
+		}
+
+		(*item)[strings.ToLower(verb)] = op
+
+	}
+
+}
+
+func rulePattern(rule *annotations.HttpRule) (string, string) {
+
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(pattern.Custom.Kind), pattern.Custom.Path
+	}
+
+	return "", ""
+
+}
+
+// addParameters maps the path variables, the body and the remaining fields
+// (as query parameters) of the input message.
+func addParameters(op *Operation, schemas *schemaSet, input protoreflect.MessageDescriptor, path string, rule *annotations.HttpRule, verb string) {
+
+	bound := map[string]bool{}
+
+	for _, match := range pathVariable.FindAllStringSubmatch(path, -1) {
+
+		bound[match[1]] = true
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   schemas.fieldPath(input, match[1]),
+		})
+
+	}
+
+	switch {
+
+	case rule.Body == "*":
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{jsonContentType: {Schema: schemas.message(input, "")}},
+		}
+		return
+
+	case len(rule.Body) > 0:
+		bound[rule.Body] = true
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{jsonContentType: {Schema: schemas.message(input, rule.Body)}},
+		}
+
+	}
+
+	fields := input.Fields()
+
+	for i := 0; i < fields.Len(); i++ {
+
+		field := fields.Get(i)
+
+		if bound[string(field.Name())] || bound[field.JSONName()] {
+			continue
+		}
+
+		// Only scalars and repeated scalars fit in query parameters.
+		if field.Kind() == protoreflect.MessageKind && !isScalarWrapper(field.Message()) || field.IsMap() {
+			continue
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:   field.JSONName(),
+			In:     "query",
+			Schema: schemas.field(field),
+		})
+
+	}
+
+}
+
+func addSecurity(op *Operation, opts *Options, method protoreflect.MethodDescriptor) {
+
+	if proto.HasExtension(method.Options(), authz.E_Authz) {
+
+		rule := proto.GetExtension(method.Options(), authz.E_Authz).(*authz.Authorization)
+
+		if rule.GetPublic() {
+			return
+		}
+
+		op.Permissions = rule.GetPermissions()
+
+	}
+
+	for name := range opts.SecuritySchemes {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+
+	sort.Slice(op.Security, func(i, j int) bool {
+		return fmt.Sprint(op.Security[i]) < fmt.Sprint(op.Security[j])
+	})
+
+}
+
+func leadingComment(desc protoreflect.Descriptor) string {
+
+	loc := desc.ParentFile().SourceLocations().ByDescriptor(desc)
+
+	return strings.TrimSpace(loc.LeadingComments)
+
+}
@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Schema is the subset of the OpenAPI schema object describing the proto3
+// JSON mapping.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Well-known types with a special JSON mapping.
+var wellKnownSchemas = map[protoreflect.FullName]*Schema{
+	"google.protobuf.Timestamp":   {Type: "string", Format: "date-time"},
+	"google.protobuf.Duration":    {Type: "string", Format: "duration"},
+	"google.protobuf.FieldMask":   {Type: "string"},
+	"google.protobuf.Struct":      {Type: "object"},
+	"google.protobuf.Value":       {},
+	"google.protobuf.ListValue":   {Type: "array", Items: &Schema{}},
+	"google.protobuf.Empty":       {Type: "object"},
+	"google.protobuf.Any":         {Type: "object", Properties: map[string]*Schema{"@type": {Type: "string"}}},
+	"google.protobuf.BoolValue":   {Type: "boolean"},
+	"google.protobuf.StringValue": {Type: "string"},
+	"google.protobuf.BytesValue":  {Type: "string", Format: "byte"},
+	"google.protobuf.Int32Value":  {Type: "integer", Format: "int32"},
+	"google.protobuf.UInt32Value": {Type: "integer", Format: "int64"},
+	"google.protobuf.Int64Value":  {Type: "string", Format: "int64"},
+	"google.protobuf.UInt64Value": {Type: "string", Format: "uint64"},
+	"google.protobuf.FloatValue":  {Type: "number", Format: "float"},
+	"google.protobuf.DoubleValue": {Type: "number", Format: "double"},
+}
+
+func isScalarWrapper(msg protoreflect.MessageDescriptor) bool {
+
+	schema, ok := wellKnownSchemas[msg.FullName()]
+
+	return ok && len(schema.Type) > 0 && schema.Type != "object" && schema.Type != "array"
+
+}
+
+type schemaSet struct {
+	schemas map[string]*Schema
+}
+
+// message returns the schema of msg, or of its field at path when set.
+func (s *schemaSet) message(msg protoreflect.MessageDescriptor, path string) *Schema {
+
+	if len(path) > 0 {
+		return s.fieldPath(msg, path)
+	}
+
+	if schema, ok := wellKnownSchemas[msg.FullName()]; ok {
+		return schema
+	}
+
+	name := string(msg.FullName())
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if _, ok := s.schemas[name]; ok {
+		return ref
+	}
+
+	schema := &Schema{
+		Type:        "object",
+		Description: leadingComment(msg),
+		Properties:  make(map[string]*Schema),
+	}
+
+	// Registered first so recursive messages end on the reference.
+	s.schemas[name] = schema
+
+	fields := msg.Fields()
+
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		schema.Properties[field.JSONName()] = s.field(field)
+	}
+
+	return ref
+
+}
+
+// fieldPath returns the schema of a dotted path of fields, an empty schema
+// when the path doesn't exist.
+func (s *schemaSet) fieldPath(msg protoreflect.MessageDescriptor, path string) *Schema {
+
+	names := strings.Split(path, ".")
+
+	for i, name := range names {
+
+		field := msg.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			field = msg.Fields().ByJSONName(name)
+		}
+
+		if field == nil {
+			return &Schema{}
+		}
+
+		if i == len(names)-1 {
+			return s.field(field)
+		}
+
+		if field.Message() == nil {
+			return &Schema{}
+		}
+
+		msg = field.Message()
+
+	}
+
+	return &Schema{}
+
+}
+
+func (s *schemaSet) field(field protoreflect.FieldDescriptor) *Schema {
+
+	if field.IsMap() {
+		return &Schema{
+			Type:                 "object",
+			Description:          leadingComment(field),
+			AdditionalProperties: s.singular(field.MapValue()),
+		}
+	}
+
+	schema := s.singular(field)
+
+	if field.IsList() {
+		return &Schema{
+			Type:        "array",
+			Description: leadingComment(field),
+			Items:       schema,
+		}
+	}
+
+	if len(schema.Ref) > 0 {
+		return schema
+	}
+
+	described := *schema
+	described.Description = leadingComment(field)
+
+	return &described
+
+}
+
+func (s *schemaSet) singular(field protoreflect.FieldDescriptor) *Schema {
+
+	switch field.Kind() {
+
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+
+	// 64 bits integers are strings in proto3 JSON.
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &Schema{Type: "string", Format: "int64"}
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+
+	case protoreflect.EnumKind:
+
+		values := field.Enum().Values()
+		schema := &Schema{Type: "string"}
+
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+
+		return schema
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.message(field.Message(), "")
+
+	}
+
+	return &Schema{}
+
+}
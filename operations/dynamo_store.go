@@ -0,0 +1,260 @@
+package operations
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+	"github.com/protomesh/protomesh-go/dynamo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	conditionalCheckFailed = "ConditionalCheckFailedException"
+
+	maxUpdateAttempts = 5
+)
+
+var (
+	_ Store = &DynamoStore{}
+)
+
+type dynamoItem map[string]events.DynamoDBAttributeValue
+
+// DynamoStore keeps operations in a DynamoDB table keyed by the "name"
+// string attribute. Items carry the encoded operation, a version used for
+// optimistic locking and, when ttl is set, an "expires_at" TTL attribute.
+type DynamoStore struct {
+	client *awsapi.Client
+	table  string
+	ttl    time.Duration
+}
+
+func NewDynamoStore(client *awsapi.Client, table string, ttl time.Duration) *DynamoStore {
+	return &DynamoStore{
+		client: client,
+		table:  table,
+		ttl:    ttl,
+	}
+}
+
+func (d *DynamoStore) Create(ctx context.Context, op *longrunningpb.Operation) error {
+
+	item, err := d.marshalItem(ctx, op, 1)
+	if err != nil {
+		return err
+	}
+
+	err = d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+		"TableName":                d.table,
+		"Item":                     item,
+		"ConditionExpression":      "attribute_not_exists(#name)",
+		"ExpressionAttributeNames": map[string]string{"#name": "name"},
+	}, nil)
+
+	if awsapi.IsErrorCode(err, conditionalCheckFailed) {
+		return status.Errorf(codes.AlreadyExists, "Operation %s already exists", op.Name)
+	}
+
+	return err
+
+}
+
+func (d *DynamoStore) Get(ctx context.Context, name string) (*longrunningpb.Operation, error) {
+	op, _, err := d.get(ctx, name)
+	return op, err
+}
+
+func (d *DynamoStore) Update(ctx context.Context, name string, fn func(op *longrunningpb.Operation) error) (*longrunningpb.Operation, error) {
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+
+		op, version, err := d.get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fn(op); err != nil {
+			return nil, err
+		}
+
+		item, err := d.marshalItem(ctx, op, version+1)
+		if err != nil {
+			return nil, err
+		}
+
+		err = d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+			"TableName":                 d.table,
+			"Item":                      item,
+			"ConditionExpression":       "#version = :version",
+			"ExpressionAttributeNames":  map[string]string{"#version": "version"},
+			"ExpressionAttributeValues": dynamoItem{":version": events.NewNumberAttribute(strconv.FormatInt(version, 10))},
+		}, nil)
+
+		if awsapi.IsErrorCode(err, conditionalCheckFailed) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return op, nil
+
+	}
+
+	return nil, status.Errorf(codes.Aborted, "Operation %s modified concurrently", name)
+
+}
+
+func (d *DynamoStore) Delete(ctx context.Context, name string) error {
+
+	out := struct {
+		Attributes dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "DeleteItem", map[string]interface{}{
+		"TableName":    d.table,
+		"Key":          dynamoItem{"name": events.NewStringAttribute(name)},
+		"ReturnValues": "ALL_OLD",
+	}, &out)
+	if err != nil {
+		return err
+	}
+
+	if len(out.Attributes) == 0 {
+		return status.Errorf(codes.NotFound, "Operation %s not found", name)
+	}
+
+	return nil
+
+}
+
+func (d *DynamoStore) List(ctx context.Context, prefix string, pageSize int32, pageToken string) ([]*longrunningpb.Operation, string, error) {
+
+	in := map[string]interface{}{
+		"TableName":                 d.table,
+		"FilterExpression":          "begins_with(#name, :prefix)",
+		"ExpressionAttributeNames":  map[string]string{"#name": "name"},
+		"ExpressionAttributeValues": dynamoItem{":prefix": events.NewStringAttribute(prefix)},
+	}
+
+	if pageSize > 0 {
+		in["Limit"] = pageSize
+	}
+
+	if len(pageToken) > 0 {
+
+		startName, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", status.Error(codes.InvalidArgument, "Invalid page token")
+		}
+
+		in["ExclusiveStartKey"] = dynamoItem{"name": events.NewStringAttribute(string(startName))}
+
+	}
+
+	out := struct {
+		Items            []dynamoItem
+		LastEvaluatedKey dynamoItem
+	}{}
+
+	if err := d.client.CallDynamoDB(ctx, "Scan", in, &out); err != nil {
+		return nil, "", err
+	}
+
+	ops := make([]*longrunningpb.Operation, 0, len(out.Items))
+
+	for _, item := range out.Items {
+		op, _, err := d.unmarshalItem(item)
+		if err != nil {
+			return nil, "", err
+		}
+		ops = append(ops, op)
+	}
+
+	nextToken := ""
+	if lastName, ok := out.LastEvaluatedKey["name"]; ok {
+		nextToken = base64.RawURLEncoding.EncodeToString([]byte(lastName.String()))
+	}
+
+	return ops, nextToken, nil
+
+}
+
+func (d *DynamoStore) get(ctx context.Context, name string) (*longrunningpb.Operation, int64, error) {
+
+	out := struct {
+		Item dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "GetItem", map[string]interface{}{
+		"TableName":      d.table,
+		"Key":            dynamoItem{"name": events.NewStringAttribute(name)},
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(out.Item) == 0 {
+		return nil, 0, status.Errorf(codes.NotFound, "Operation %s not found", name)
+	}
+
+	return d.unmarshalItem(out.Item)
+
+}
+
+func (d *DynamoStore) marshalItem(ctx context.Context, op *longrunningpb.Operation, version int64) (dynamoItem, error) {
+
+	body, err := proto.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+
+	item := dynamoItem{
+		"name":      events.NewStringAttribute(op.Name),
+		"operation": events.NewBinaryAttribute(body),
+		"done":      events.NewBooleanAttribute(op.Done),
+		"version":   events.NewNumberAttribute(strconv.FormatInt(version, 10)),
+	}
+
+	if d.ttl > 0 {
+		item["expires_at"] = events.NewNumberAttribute(strconv.FormatInt(clock.FromContext(ctx).Now().Add(d.ttl).Unix(), 10))
+	}
+
+	return item, nil
+
+}
+
+func (d *DynamoStore) unmarshalItem(item dynamoItem) (*longrunningpb.Operation, int64, error) {
+
+	name, err := dynamo.Item(item).String("name")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	body, err := dynamo.Item(item).Binary("operation")
+	if err != nil {
+		return nil, 0, status.Errorf(codes.DataLoss, "Corrupted operation %s: %v", name, err)
+	}
+
+	op := &longrunningpb.Operation{}
+
+	if err := proto.Unmarshal(body, op); err != nil {
+		return nil, 0, status.Errorf(codes.DataLoss, "Corrupted operation %s: %v", name, err)
+	}
+
+	version, err := dynamo.Item(item).Int64("version")
+	if err != nil {
+		return nil, 0, status.Errorf(codes.DataLoss, "Corrupted operation %s version: %v", op.Name, err)
+	}
+
+	return op, version, nil
+
+}
@@ -0,0 +1,133 @@
+package operations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const DefaultNamePrefix = "operations/"
+
+// Manager is used by handlers to start operations and by background workers
+// to report their progress and outcome.
+type Manager struct {
+	store      Store
+	namePrefix string
+}
+
+func NewManager(store Store, namePrefix string) *Manager {
+
+	if len(namePrefix) == 0 {
+		namePrefix = DefaultNamePrefix
+	}
+
+	return &Manager{
+		store:      store,
+		namePrefix: strings.TrimRight(namePrefix, "/") + "/",
+	}
+
+}
+
+// Create stores a new pending operation, handlers return it to the client
+// and hand its name over to a worker.
+func (m *Manager) Create(ctx context.Context, metadata proto.Message) (*longrunningpb.Operation, error) {
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	op := &longrunningpb.Operation{
+		Name: m.namePrefix + hex.EncodeToString(id),
+	}
+
+	if metadata != nil {
+
+		meta, err := anypb.New(metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		op.Metadata = meta
+
+	}
+
+	if err := m.store.Create(ctx, op); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+
+}
+
+func (m *Manager) UpdateMetadata(ctx context.Context, name string, metadata proto.Message) (*longrunningpb.Operation, error) {
+
+	meta, err := anypb.New(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.store.Update(ctx, name, func(op *longrunningpb.Operation) error {
+		if op.Done {
+			return status.Errorf(codes.FailedPrecondition, "Operation %s is already done", name)
+		}
+		op.Metadata = meta
+		return nil
+	})
+
+}
+
+// Complete marks the operation as done with response as its result.
+func (m *Manager) Complete(ctx context.Context, name string, response proto.Message) (*longrunningpb.Operation, error) {
+
+	res, err := anypb.New(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.store.Update(ctx, name, func(op *longrunningpb.Operation) error {
+		if op.Done {
+			return status.Errorf(codes.FailedPrecondition, "Operation %s is already done", name)
+		}
+		op.Done = true
+		op.Result = &longrunningpb.Operation_Response{Response: res}
+		return nil
+	})
+
+}
+
+// Fail marks the operation as done with the status of err as its result.
+func (m *Manager) Fail(ctx context.Context, name string, err error) (*longrunningpb.Operation, error) {
+
+	st, _ := status.FromError(err)
+
+	return m.store.Update(ctx, name, func(op *longrunningpb.Operation) error {
+		if op.Done {
+			return status.Errorf(codes.FailedPrecondition, "Operation %s is already done", name)
+		}
+		op.Done = true
+		op.Result = &longrunningpb.Operation_Error{Error: st.Proto()}
+		return nil
+	})
+
+}
+
+// Cancelled reports whether a client cancelled the operation, workers
+// should poll it between steps and stop early.
+func (m *Manager) Cancelled(ctx context.Context, name string) (bool, error) {
+
+	op, err := m.store.Get(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	return op.GetError().GetCode() == int32(codes.Canceled), nil
+
+}
@@ -0,0 +1,172 @@
+package operations
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	DefaultPollInterval = time.Second
+	DefaultMaxWait      = 25 * time.Second
+
+	// Required by GetOperation, ListOperations and WaitOperation.
+	ReadPermission = "operations.read"
+	// Required by DeleteOperation and CancelOperation.
+	WritePermission = "operations.write"
+)
+
+var (
+	_ longrunningpb.OperationsServer = &Server{}
+)
+
+// Server implements google.longrunning.Operations on top of a Store, it is
+// meant to be registered on the same Controller as the services creating
+// the operations, with the permissions of ServiceOptions:
+//
+//	controller.RegisterGRPCService(longrunningpb.Operations_ServiceDesc, operations.NewServer(store), operations.ServiceOptions()...)
+type Server struct {
+	longrunningpb.UnimplementedOperationsServer
+
+	store Store
+
+	// Interval between store reads while waiting an operation. Defaults to
+	// DefaultPollInterval.
+	PollInterval time.Duration
+	// Upper bound for WaitOperation, kept below the API Gateway timeout.
+	// Defaults to DefaultMaxWait.
+	MaxWait time.Duration
+}
+
+// ServiceOptions requires ReadPermission or WritePermission on the methods
+// of the server (see authz.Interceptor), google.longrunning.Operations
+// declaring no authz option.
+func ServiceOptions() []lambda.ServiceOption {
+
+	read := lambda.AuthPolicy{Permissions: []string{ReadPermission}}
+	write := lambda.AuthPolicy{Permissions: []string{WritePermission}}
+
+	return []lambda.ServiceOption{
+		lambda.WithAuthPolicy("GetOperation", read),
+		lambda.WithAuthPolicy("ListOperations", read),
+		lambda.WithAuthPolicy("WaitOperation", read),
+		lambda.WithAuthPolicy("DeleteOperation", write),
+		lambda.WithAuthPolicy("CancelOperation", write),
+	}
+
+}
+
+func NewServer(store Store) *Server {
+	return &Server{
+		store:        store,
+		PollInterval: DefaultPollInterval,
+		MaxWait:      DefaultMaxWait,
+	}
+}
+
+func (s *Server) GetOperation(ctx context.Context, req *longrunningpb.GetOperationRequest) (*longrunningpb.Operation, error) {
+	return s.store.Get(ctx, req.Name)
+}
+
+func (s *Server) ListOperations(ctx context.Context, req *longrunningpb.ListOperationsRequest) (*longrunningpb.ListOperationsResponse, error) {
+
+	if len(req.Filter) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "Filtering operations is not supported")
+	}
+
+	ops, nextToken, err := s.store.List(ctx, req.Name, req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &longrunningpb.ListOperationsResponse{
+		Operations:    ops,
+		NextPageToken: nextToken,
+	}, nil
+
+}
+
+func (s *Server) DeleteOperation(ctx context.Context, req *longrunningpb.DeleteOperationRequest) (*emptypb.Empty, error) {
+
+	if err := s.store.Delete(ctx, req.Name); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+
+}
+
+// CancelOperation marks the operation as done with a Canceled error,
+// workers notice it through Manager.Cancelled.
+func (s *Server) CancelOperation(ctx context.Context, req *longrunningpb.CancelOperationRequest) (*emptypb.Empty, error) {
+
+	_, err := s.store.Update(ctx, req.Name, func(op *longrunningpb.Operation) error {
+
+		if op.Done {
+			return status.Errorf(codes.FailedPrecondition, "Operation %s is already done", req.Name)
+		}
+
+		op.Done = true
+		op.Result = &longrunningpb.Operation_Error{
+			Error: status.New(codes.Canceled, "Operation cancelled by the client").Proto(),
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+
+}
+
+// WaitOperation polls the store until the operation is done or the wait
+// timeout elapses, returning the latest state in both cases.
+func (s *Server) WaitOperation(ctx context.Context, req *longrunningpb.WaitOperationRequest) (*longrunningpb.Operation, error) {
+
+	wait := s.MaxWait
+	if wait <= 0 {
+		wait = DefaultMaxWait
+	}
+
+	if timeout := req.Timeout.AsDuration(); req.Timeout != nil && timeout < wait {
+		wait = timeout
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	pollInterval := s.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+
+		op, err := s.store.Get(ctx, req.Name)
+		if err != nil || op.Done {
+			return op, err
+		}
+
+		select {
+
+		case <-waitCtx.Done():
+			return op, nil
+
+		case <-ticker.C:
+
+		}
+
+	}
+
+}
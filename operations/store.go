@@ -0,0 +1,20 @@
+package operations
+
+import (
+	"context"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+)
+
+// Store persists operations, implementations must return gRPC status
+// errors (NotFound, AlreadyExists, Aborted) so they reach clients as is.
+type Store interface {
+	Create(ctx context.Context, op *longrunningpb.Operation) error
+	Get(ctx context.Context, name string) (*longrunningpb.Operation, error)
+	// Update applies fn to the latest version of the operation, retrying on
+	// concurrent modifications.
+	Update(ctx context.Context, name string, fn func(op *longrunningpb.Operation) error) (*longrunningpb.Operation, error)
+	Delete(ctx context.Context, name string) error
+	// List returns operations whose name starts with prefix.
+	List(ctx context.Context, prefix string, pageSize int32, pageToken string) ([]*longrunningpb.Operation, string, error)
+}
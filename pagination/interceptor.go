@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type cursorContextKey struct{}
+
+// CursorFromContext returns the cursor decoded by the interceptor from the
+// request page token, it is absent on the first page.
+func CursorFromContext(ctx context.Context) ([]byte, bool) {
+	cursor, ok := ctx.Value(cursorContextKey{}).([]byte)
+	return cursor, ok
+}
+
+// UnaryServerInterceptor validates the AIP-158 pagination fields of list
+// requests: page sizes are defaulted and coerced in place and page tokens
+// are verified before reaching the handler.
+func (p *Paginator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		in, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		msg := in.ProtoReflect()
+		fields := msg.Descriptor().Fields()
+
+		if sizeField := fields.ByName(PageSizeField); sizeField != nil && sizeField.Kind() == protoreflect.Int32Kind {
+
+			size, err := p.PageSize(int32(msg.Get(sizeField).Int()))
+			if err != nil {
+				return nil, err
+			}
+
+			msg.Set(sizeField, protoreflect.ValueOfInt32(size))
+
+		}
+
+		if tokenField := fields.ByName(PageTokenField); tokenField != nil && tokenField.Kind() == protoreflect.StringKind {
+
+			if token := msg.Get(tokenField).String(); len(token) > 0 {
+
+				cursor, err := p.DecodeToken(in, token)
+				if err != nil {
+					return nil, err
+				}
+
+				ctx = context.WithValue(ctx, cursorContextKey{}, cursor)
+
+			}
+
+		}
+
+		return handler(ctx, req)
+
+	}
+
+}
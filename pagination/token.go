@@ -0,0 +1,125 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	PageSizeField      protoreflect.Name = "page_size"
+	PageTokenField     protoreflect.Name = "page_token"
+	NextPageTokenField protoreflect.Name = "next_page_token"
+)
+
+// Paginator issues opaque page tokens signed with a secret and bound to the
+// request that originated them, so a token can't be replayed with a
+// different filter or ordering.
+type Paginator struct {
+	secret          []byte
+	defaultPageSize int32
+	maxPageSize     int32
+}
+
+func NewPaginator(secret []byte, defaultPageSize, maxPageSize int32) *Paginator {
+	return &Paginator{
+		secret:          secret,
+		defaultPageSize: defaultPageSize,
+		maxPageSize:     maxPageSize,
+	}
+}
+
+// PageSize applies the default page size to unset values and coerces values
+// above the maximum, negative values are rejected.
+func (p *Paginator) PageSize(requested int32) (int32, error) {
+
+	if requested < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid %s %d, must not be negative", PageSizeField, requested)
+	}
+
+	if requested == 0 {
+		return p.defaultPageSize, nil
+	}
+
+	if p.maxPageSize > 0 && requested > p.maxPageSize {
+		return p.maxPageSize, nil
+	}
+
+	return requested, nil
+
+}
+
+// EncodeToken signs the cursor for the given list request.
+func (p *Paginator) EncodeToken(req proto.Message, cursor []byte) (string, error) {
+
+	fingerprint, err := requestFingerprint(req)
+	if err != nil {
+		return "", err
+	}
+
+	token := append(append([]byte{}, cursor...), p.sign(cursor, fingerprint)...)
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+
+}
+
+// DecodeToken verifies the token was issued for an equivalent list request
+// and returns its cursor.
+func (p *Paginator) DecodeToken(req proto.Message, token string) ([]byte, error) {
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid %s", PageTokenField)
+	}
+
+	fingerprint, err := requestFingerprint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, sig := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+
+	if !hmac.Equal(sig, p.sign(cursor, fingerprint)) {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid %s, request parameters changed or token was tampered", PageTokenField)
+	}
+
+	return cursor, nil
+
+}
+
+func (p *Paginator) sign(cursor []byte, fingerprint []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(cursor)
+	mac.Write(fingerprint)
+	return mac.Sum(nil)
+}
+
+// requestFingerprint hashes the request without its pagination fields.
+func requestFingerprint(req proto.Message) ([]byte, error) {
+
+	req = proto.Clone(req)
+
+	msg := req.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+
+	for _, name := range []protoreflect.Name{PageSizeField, PageTokenField} {
+		if field := fields.ByName(name); field != nil {
+			msg.Clear(field)
+		}
+	}
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+
+	return sum[:], nil
+
+}
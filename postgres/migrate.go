@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Serializes the migrations of the instances cold starting together.
+const migrationLockId = 7259314006
+
+// Migrate applies the migrations of dir not applied yet, in order. Files
+// are named <version>_<name>.sql (e.g. 0001_create_orders.sql) and usually
+// embedded with go:embed, each one runs in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB, migrations fs.FS, dir string) error {
+
+	files, err := migrationFiles(migrations, dir)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockId); err != nil {
+		return fmt.Errorf("Failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockId)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int64]bool)
+
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+
+		if applied[file.version] {
+			continue
+		}
+
+		script, err := fs.ReadFile(migrations, path.Join(dir, file.name))
+		if err != nil {
+			return err
+		}
+
+		if err := applyMigration(ctx, conn, file, string(script)); err != nil {
+			return fmt.Errorf("Failed to apply migration %s: %w", file.name, err)
+		}
+
+	}
+
+	return nil
+
+}
+
+type migrationFile struct {
+	version int64
+	name    string
+}
+
+func migrationFiles(migrations fs.FS, dir string) ([]migrationFile, error) {
+
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []migrationFile{}
+	versions := make(map[int64]string)
+
+	for _, entry := range entries {
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid migration name %s, expected <version>_<name>.sql", entry.Name())
+		}
+
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("Migrations %s and %s have the same version", other, entry.Name())
+		}
+
+		versions[version] = entry.Name()
+		files = append(files, migrationFile{version: version, name: entry.Name()})
+
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].version < files[j].version
+	})
+
+	return files, nil
+
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, file migrationFile, script string) error {
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", file.version, file.name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+
+}
@@ -0,0 +1,176 @@
+// Package postgres connects Lambda functions to Aurora/RDS Postgres (usually
+// through RDS Proxy) using IAM authentication, and runs the embedded
+// migrations on cold start. The database/sql driver is registered by the
+// application, e.g. by importing github.com/jackc/pgx/v5/stdlib.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+)
+
+type Options struct {
+	// Name of the registered driver, defaults to "pgx".
+	DriverName string
+	// Host of the RDS Proxy or cluster endpoint.
+	Host     string
+	Port     int
+	User     string
+	Database string
+	// Static password, the connections use IAM auth tokens when empty.
+	Password string
+	// Signs the IAM auth tokens, required without password.
+	Client *awsapi.Client
+	// Extra connection parameters, sslmode defaults to require.
+	Params url.Values
+
+	// A function instance serves a request at a time, a couple of
+	// connections are enough. Defaults to 2.
+	MaxOpenConns int
+	// Closes idle connections before the proxy does, defaults to 5 minutes.
+	ConnMaxIdleTime time.Duration
+}
+
+// Open returns a lazily connected database, each new connection is
+// authenticated with a fresh IAM auth token.
+func Open(opts Options) (*sql.DB, error) {
+
+	if len(opts.DriverName) == 0 {
+		opts.DriverName = "pgx"
+	}
+
+	if opts.Port == 0 {
+		opts.Port = 5432
+	}
+
+	if len(opts.Password) == 0 && opts.Client == nil {
+		return nil, fmt.Errorf("Either a password or a client to sign IAM auth tokens is required")
+	}
+
+	// Opening a database doesn't connect, it only resolves the driver.
+	probe, err := sql.Open(opts.DriverName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	drv := probe.Driver()
+	probe.Close()
+
+	db := sql.OpenDB(&connector{opts: opts, driver: drv})
+
+	maxOpenConns := opts.MaxOpenConns
+	if maxOpenConns <= 0 {
+		maxOpenConns = 2
+	}
+
+	maxIdleTime := opts.ConnMaxIdleTime
+	if maxIdleTime <= 0 {
+		maxIdleTime = 5 * time.Minute
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+	db.SetConnMaxIdleTime(maxIdleTime)
+	// IAM auth tokens only matter when connecting, but proxies recycle
+	// connections after a while anyway.
+	db.SetConnMaxLifetime(time.Hour)
+
+	return db, nil
+
+}
+
+type connector struct {
+	opts   Options
+	driver driver.Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+
+	dsn, err := c.dsn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if driverCtx, ok := c.driver.(driver.DriverContext); ok {
+
+		conn, err := driverCtx.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		return conn.Connect(ctx)
+
+	}
+
+	return c.driver.Open(dsn)
+
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *connector) dsn(ctx context.Context) (string, error) {
+
+	password := c.opts.Password
+
+	if len(password) == 0 {
+
+		token, err := AuthToken(ctx, c.opts.Client, c.opts.Host, c.opts.Port, c.opts.User)
+		if err != nil {
+			return "", fmt.Errorf("Failed to generate IAM auth token: %w", err)
+		}
+
+		password = token
+
+	}
+
+	params := url.Values{}
+	for k, v := range c.opts.Params {
+		params[k] = v
+	}
+
+	if len(params.Get("sslmode")) == 0 {
+		params.Set("sslmode", "require")
+	}
+
+	u := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.opts.User, password),
+		Host:     c.opts.Host + ":" + strconv.Itoa(c.opts.Port),
+		Path:     "/" + c.opts.Database,
+		RawQuery: params.Encode(),
+	}
+
+	return u.String(), nil
+
+}
+
+// AuthToken generates an RDS IAM auth token for user, valid 15 minutes.
+func AuthToken(ctx context.Context, client *awsapi.Client, host string, port int, user string) (string, error) {
+
+	creds, err := client.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     host + ":" + strconv.Itoa(port),
+		Path:     "/",
+		RawQuery: url.Values{"Action": {"connect"}, "DBUser": {user}}.Encode(),
+	}
+
+	signed := client.Signer("rds-db").Presign("GET", u, creds, time.Now(), 15*time.Minute)
+
+	// The token is the presigned url without scheme.
+	return signed.Host + signed.Path + "?" + signed.RawQuery, nil
+
+}
@@ -0,0 +1,133 @@
+package projection
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/dynamo"
+)
+
+// CheckpointStore keeps the last version of every aggregate applied by a
+// projection.
+type CheckpointStore interface {
+	// Checkpoint returns 0 when nothing was applied yet.
+	Checkpoint(ctx context.Context, projection, aggregateId string) (int64, error)
+	Save(ctx context.Context, projection, aggregateId string, version int64) error
+	// Reset drops every checkpoint of the projection.
+	Reset(ctx context.Context, projection string) error
+}
+
+var _ CheckpointStore = &DynamoCheckpoints{}
+
+type dynamoItem map[string]events.DynamoDBAttributeValue
+
+// DynamoCheckpoints keeps checkpoints in a DynamoDB table keyed by the
+// "projection" partition key and the "aggregate_id" sort key.
+type DynamoCheckpoints struct {
+	client *awsapi.Client
+	table  string
+}
+
+func NewDynamoCheckpoints(client *awsapi.Client, table string) *DynamoCheckpoints {
+	return &DynamoCheckpoints{
+		client: client,
+		table:  table,
+	}
+}
+
+func (d *DynamoCheckpoints) Checkpoint(ctx context.Context, projection, aggregateId string) (int64, error) {
+
+	out := struct {
+		Item dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "GetItem", map[string]interface{}{
+		"TableName":      d.table,
+		"Key":            d.key(projection, aggregateId),
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(out.Item) == 0 {
+		return 0, nil
+	}
+
+	return dynamo.Item(out.Item).Int64("version")
+
+}
+
+func (d *DynamoCheckpoints) Save(ctx context.Context, projection, aggregateId string, version int64) error {
+
+	item := d.key(projection, aggregateId)
+	item["version"] = events.NewNumberAttribute(strconv.FormatInt(version, 10))
+
+	err := d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item":      item,
+		// Redelivered records never move checkpoints backwards.
+		"ConditionExpression":       "attribute_not_exists(#version) OR #version < :version",
+		"ExpressionAttributeNames":  map[string]string{"#version": "version"},
+		"ExpressionAttributeValues": dynamoItem{":version": item["version"]},
+	}, nil)
+
+	if awsapi.IsErrorCode(err, "ConditionalCheckFailedException") {
+		return nil
+	}
+
+	return err
+
+}
+
+func (d *DynamoCheckpoints) Reset(ctx context.Context, projection string) error {
+
+	in := map[string]interface{}{
+		"TableName":                 d.table,
+		"KeyConditionExpression":    "#projection = :projection",
+		"ExpressionAttributeNames":  map[string]string{"#projection": "projection"},
+		"ExpressionAttributeValues": dynamoItem{":projection": events.NewStringAttribute(projection)},
+		"ProjectionExpression":      "#projection, aggregate_id",
+	}
+
+	for {
+
+		out := struct {
+			Items            []dynamoItem
+			LastEvaluatedKey dynamoItem
+		}{}
+
+		if err := d.client.CallDynamoDB(ctx, "Query", in, &out); err != nil {
+			return err
+		}
+
+		for _, item := range out.Items {
+
+			err := d.client.CallDynamoDB(ctx, "DeleteItem", map[string]interface{}{
+				"TableName": d.table,
+				"Key":       item,
+			}, nil)
+			if err != nil {
+				return err
+			}
+
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+
+		in["ExclusiveStartKey"] = out.LastEvaluatedKey
+
+	}
+
+}
+
+func (d *DynamoCheckpoints) key(projection, aggregateId string) dynamoItem {
+	return dynamoItem{
+		"projection":   events.NewStringAttribute(projection),
+		"aggregate_id": events.NewStringAttribute(aggregateId),
+	}
+}
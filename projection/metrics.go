@@ -0,0 +1,57 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+// LagMetrics writes the lag of the projections in the CloudWatch embedded
+// metric format, Lambda ships the lines to CloudWatch Logs which extracts
+// the ProjectionLag metric (milliseconds) by projection.
+type LagMetrics struct {
+	// Defaults to "Protomesh/Projections".
+	Namespace string
+	// Defaults to os.Stdout.
+	Writer io.Writer
+}
+
+func (m *LagMetrics) report(ctx context.Context, projection string, lag time.Duration, events int) {
+
+	namespace := m.Namespace
+	if len(namespace) == 0 {
+		namespace = "Protomesh/Projections"
+	}
+
+	writer := m.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	line, err := json.Marshal(map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": clock.FromContext(ctx).Now().UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  namespace,
+				"Dimensions": [][]string{{"Projection"}},
+				"Metrics": []map[string]string{
+					{"Name": "ProjectionLag", "Unit": "Milliseconds"},
+					{"Name": "ProjectedEvents", "Unit": "Count"},
+				},
+			}},
+		},
+		"Projection":      projection,
+		"ProjectionLag":   lag.Milliseconds(),
+		"ProjectedEvents": events,
+	})
+	if err != nil {
+		return
+	}
+
+	writer.Write(append(line, '\n'))
+
+}
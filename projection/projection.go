@@ -0,0 +1,84 @@
+// Package projection builds read models from the events of the event store,
+// each projection tracks its own checkpoint per aggregate so records are
+// applied once and in order.
+package projection
+
+import (
+	"context"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+	"github.com/protomesh/protomesh-go/envelope"
+	"github.com/protomesh/protomesh-go/eventsource"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Event is an event of type E passed to the projection handlers.
+type Event[E proto.Message] struct {
+	AggregateId string
+	Version     int64
+	Envelope    *envelope.Envelope
+	Message     E
+}
+
+type handler func(ctx context.Context, record *eventsource.Record, msg proto.Message) error
+
+// Projection is a named set of typed handlers, events without handler only
+// advance the checkpoint.
+type Projection struct {
+	Name string
+	// Drops the read model before a rebuild.
+	Reset func(ctx context.Context) error
+
+	handlers map[protoreflect.FullName]handler
+}
+
+func NewProjection(name string) *Projection {
+	return &Projection{
+		Name:     name,
+		handlers: make(map[protoreflect.FullName]handler),
+	}
+}
+
+// Handle registers the handler of the events of type E.
+func Handle[E proto.Message](p *Projection, fn func(ctx context.Context, event *Event[E]) error) {
+
+	var zero E
+
+	p.handlers[zero.ProtoReflect().Descriptor().FullName()] = func(ctx context.Context, record *eventsource.Record, msg proto.Message) error {
+		return fn(ctx, &Event[E]{
+			AggregateId: record.AggregateId,
+			Version:     record.Version,
+			Envelope:    record.Event,
+			Message:     msg.(E),
+		})
+	}
+
+}
+
+func (p *Projection) apply(ctx context.Context, registry *envelope.Registry, record *eventsource.Record) error {
+
+	msg, err := registry.Decode(ctx, record.Event)
+	if err != nil {
+		return err
+	}
+
+	if fn, ok := p.handlers[msg.ProtoReflect().Descriptor().FullName()]; ok {
+		return fn(ctx, record, msg)
+	}
+
+	return nil
+
+}
+
+// lag is how long after being recorded an event is projected.
+func lag(ctx context.Context, record *eventsource.Record) time.Duration {
+
+	if record.Event.GetTime() == nil {
+		return 0
+	}
+
+	return clock.FromContext(ctx).Now().Sub(record.Event.GetTime().AsTime())
+
+}
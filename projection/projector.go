@@ -0,0 +1,198 @@
+package projection
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/envelope"
+	"github.com/protomesh/protomesh-go/eventsource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamResponse reports the first failed record of the batch when the
+// event source mapping has ReportBatchItemFailures enabled, the stream is
+// retried from it.
+type StreamResponse struct {
+	BatchItemFailures []StreamBatchItemFailure `json:"batchItemFailures"`
+}
+
+// StreamBatchItemFailure identifies a record by its sequence number.
+type StreamBatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+// Projector applies the records of the event store to the registered
+// projections.
+type Projector struct {
+	// Reports the lag of every batch when set.
+	Metrics *LagMetrics
+
+	registry    *envelope.Registry
+	checkpoints CheckpointStore
+	projections map[string]*Projection
+}
+
+func NewProjector(registry *envelope.Registry, checkpoints CheckpointStore) *Projector {
+	return &Projector{
+		registry:    registry,
+		checkpoints: checkpoints,
+		projections: make(map[string]*Projection),
+	}
+}
+
+func (p *Projector) Register(projections ...*Projection) {
+	for _, projection := range projections {
+		p.projections[projection.Name] = projection
+	}
+}
+
+// Project applies the records, in order, to every projection. Records at
+// or below the checkpoint of a projection were already applied and are
+// skipped.
+func (p *Projector) Project(ctx context.Context, records ...*eventsource.Record) error {
+
+	for _, projection := range p.projections {
+
+		applied := 0
+		maxLag := time.Duration(0)
+
+		for _, record := range records {
+
+			ok, err := p.project(ctx, projection, record)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			applied++
+
+			if recordLag := lag(ctx, record); recordLag > maxLag {
+				maxLag = recordLag
+			}
+
+		}
+
+		if p.Metrics != nil && applied > 0 {
+			p.Metrics.report(ctx, projection.Name, maxLag, applied)
+		}
+
+	}
+
+	return nil
+
+}
+
+func (p *Projector) project(ctx context.Context, projection *Projection, record *eventsource.Record) (bool, error) {
+
+	checkpoint, err := p.checkpoints.Checkpoint(ctx, projection.Name, record.AggregateId)
+	if err != nil {
+		return false, err
+	}
+
+	if record.Version <= checkpoint {
+		return false, nil
+	}
+
+	if record.Version > checkpoint+1 {
+		return false, status.Errorf(codes.FailedPrecondition, "Projection %s missed events %d to %d of aggregate %s", projection.Name, checkpoint+1, record.Version-1, record.AggregateId)
+	}
+
+	if err := projection.apply(ctx, p.registry, record); err != nil {
+		return false, err
+	}
+
+	return true, p.checkpoints.Save(ctx, projection.Name, record.AggregateId, record.Version)
+
+}
+
+// HandleDynamoDBStream projects the events appended to the table of an
+// eventsource.DynamoStore. The batch stops at the first failure so the
+// records of an aggregate are never applied out of order.
+func (p *Projector) HandleDynamoDBStream(ctx context.Context, event *events.DynamoDBEvent) (*StreamResponse, error) {
+
+	log := lambda.LoggerFromContext(ctx)
+
+	res := &StreamResponse{
+		BatchItemFailures: []StreamBatchItemFailure{},
+	}
+
+	for _, streamRecord := range event.Records {
+
+		if streamRecord.EventName != string(events.DynamoDBOperationTypeInsert) {
+			continue
+		}
+
+		// Images of keys only streams, snapshots and foreign items have no
+		// event.
+		if _, ok := streamRecord.Change.NewImage["event"]; !ok {
+			continue
+		}
+
+		record, err := eventsource.DecodeDynamoItem(streamRecord.Change.NewImage)
+
+		if err == nil && record != nil {
+			err = p.Project(ctx, record)
+		}
+
+		if err != nil {
+
+			log.Error("Failed to project event", "sequence_number", streamRecord.Change.SequenceNumber, "error", err)
+
+			res.BatchItemFailures = append(res.BatchItemFailures, StreamBatchItemFailure{
+				ItemIdentifier: streamRecord.Change.SequenceNumber,
+			})
+
+			return res, nil
+
+		}
+
+	}
+
+	return res, nil
+
+}
+
+// Rebuild resets a projection and replays the events of the aggregates
+// from store, typically run from an operation while the stream handler
+// of the projection is disabled.
+func (p *Projector) Rebuild(ctx context.Context, name string, store eventsource.Store, aggregateIds []string) error {
+
+	projection, ok := p.projections[name]
+	if !ok {
+		return status.Errorf(codes.NotFound, "Projection %s not registered", name)
+	}
+
+	if projection.Reset != nil {
+		if err := projection.Reset(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := p.checkpoints.Reset(ctx, name); err != nil {
+		return err
+	}
+
+	for _, aggregateId := range aggregateIds {
+
+		records, err := store.Load(ctx, aggregateId, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if _, err := p.project(ctx, projection, record); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	return nil
+
+}
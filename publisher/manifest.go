@@ -0,0 +1,76 @@
+// Package publisher emits typed events, each proto event type being bound
+// by a manifest to the SNS topic, SQS queue or EventBridge bus it's
+// published to.
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type TransportKind string
+
+const (
+	TransportSQS         TransportKind = "sqs"
+	TransportSNS         TransportKind = "sns"
+	TransportEventBridge TransportKind = "eventbridge"
+)
+
+// Binding binds an event type to its destination.
+type Binding struct {
+	// Full name of the proto message, e.g. "acme.orders.v1.OrderPlaced".
+	Type      string        `json:"type"`
+	Transport TransportKind `json:"transport"`
+	// Queue url, topic ARN or event bus name.
+	Target string `json:"target"`
+	// EventBridge detail type, defaults to the type.
+	DetailType string `json:"detailType,omitempty"`
+}
+
+// Manifest declares the events a service publishes.
+type Manifest struct {
+	// Source of the events, e.g. the service name.
+	Source string     `json:"source"`
+	Events []*Binding `json:"events"`
+}
+
+// ParseManifest parses a JSON manifest.
+func ParseManifest(data []byte) (*Manifest, error) {
+
+	manifest := &Manifest{}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("Invalid manifest: %w", err)
+	}
+
+	return manifest, nil
+
+}
+
+func (m *Manifest) validate() error {
+
+	seen := make(map[string]bool)
+
+	for _, binding := range m.Events {
+
+		switch binding.Transport {
+		case TransportSQS, TransportSNS, TransportEventBridge:
+		default:
+			return fmt.Errorf("Unknown transport %q of event %s", binding.Transport, binding.Type)
+		}
+
+		if len(binding.Type) == 0 || len(binding.Target) == 0 {
+			return fmt.Errorf("Event binding requires a type and a target: %+v", binding)
+		}
+
+		if seen[binding.Type] {
+			return fmt.Errorf("Event %s bound twice", binding.Type)
+		}
+
+		seen[binding.Type] = true
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,196 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/baggage"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Batches are limited to 10 entries by every transport.
+const maxBatchSize = 10
+
+type entry struct {
+	id      string
+	binding *Binding
+	body    string
+}
+
+// Publisher wraps events in envelopes carrying the trace, request id and
+// baggage of the context, then publishes them in batches to their bindings.
+type Publisher struct {
+	// Defaults to 3.
+	MaxAttempts int
+	// Defaults to 100ms, doubled on every attempt.
+	Backoff time.Duration
+
+	client   *awsapi.Client
+	source   string
+	bindings map[string]*Binding
+}
+
+func NewPublisher(client *awsapi.Client, manifest *Manifest) (*Publisher, error) {
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Publisher{
+		client:   client,
+		source:   manifest.Source,
+		bindings: make(map[string]*Binding),
+	}
+
+	for _, binding := range manifest.Events {
+		p.bindings[binding.Type] = binding
+	}
+
+	return p, nil
+
+}
+
+// Emit publishes the events, failed entries are retried and the events of
+// types missing from the manifest are rejected before anything is sent.
+func (p *Publisher) Emit(ctx context.Context, events ...proto.Message) error {
+
+	envs := make([]*envelope.Envelope, len(events))
+
+	for i, event := range events {
+
+		env, err := envelope.New(ctx, event)
+		if err != nil {
+			return err
+		}
+
+		baggage.Default.Inject(ctx, env)
+
+		envs[i] = env
+
+	}
+
+	return p.EmitEnvelopes(ctx, envs...)
+
+}
+
+// EmitEnvelopes publishes envelopes built beforehand (e.g. kept in an
+// outbox), their id, time, trace and attributes are kept, the source is set
+// when empty.
+func (p *Publisher) EmitEnvelopes(ctx context.Context, envs ...*envelope.Envelope) error {
+
+	batches := make(map[*Binding][]*entry)
+
+	for i, env := range envs {
+
+		typeUrl := env.TypeUrl()
+		name := typeUrl[strings.LastIndex(typeUrl, "/")+1:]
+
+		binding, ok := p.bindings[name]
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "Event %s not declared in the manifest", name)
+		}
+
+		if len(env.Source) == 0 {
+			env.Source = p.source
+		}
+
+		body, err := protojson.Marshal(env)
+		if err != nil {
+			return err
+		}
+
+		batches[binding] = append(batches[binding], &entry{
+			id:      strconv.Itoa(i),
+			binding: binding,
+			body:    string(body),
+		})
+
+	}
+
+	for binding, entries := range batches {
+		for start := 0; start < len(entries); start += maxBatchSize {
+
+			end := start + maxBatchSize
+			if end > len(entries) {
+				end = len(entries)
+			}
+
+			if err := p.publish(ctx, binding, entries[start:end]); err != nil {
+				return err
+			}
+
+		}
+	}
+
+	return nil
+
+}
+
+func (p *Publisher) publish(ctx context.Context, binding *Binding, entries []*entry) error {
+
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	var err error
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-time.After(backoff << (attempt - 1)):
+			}
+		}
+
+		var failed []*entry
+
+		failed, err = p.send(ctx, binding, entries)
+		if err == nil && len(failed) == 0 {
+			return nil
+		}
+
+		if err == nil {
+			entries = failed
+			err = status.Errorf(codes.Unavailable, "Failed to publish %d %s events", len(failed), binding.Type)
+		}
+
+	}
+
+	return err
+
+}
+
+func (p *Publisher) send(ctx context.Context, binding *Binding, entries []*entry) ([]*entry, error) {
+
+	switch binding.Transport {
+
+	case TransportSQS:
+		return p.sendSQS(ctx, binding, entries)
+
+	case TransportSNS:
+		return p.sendSNS(ctx, binding, entries)
+
+	case TransportEventBridge:
+		return p.sendEventBridge(ctx, binding, entries)
+
+	}
+
+	return nil, fmt.Errorf("Unknown transport %q", binding.Transport)
+
+}
@@ -0,0 +1,153 @@
+package publisher
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sendSQS returns the failed entries for retry, entries rejected because of
+// the sender fail the batch instead.
+func (p *Publisher) sendSQS(ctx context.Context, binding *Binding, entries []*entry) ([]*entry, error) {
+
+	batch := make([]map[string]string, len(entries))
+	for i, e := range entries {
+		batch[i] = map[string]string{"Id": e.id, "MessageBody": e.body}
+	}
+
+	in := map[string]interface{}{
+		"QueueUrl": binding.Target,
+		"Entries":  batch,
+	}
+
+	out := struct {
+		Failed []struct {
+			Id          string
+			Code        string
+			Message     string
+			SenderFault bool
+		}
+	}{}
+
+	if err := p.client.CallJSON(ctx, "sqs", "1.0", "AmazonSQS.SendMessageBatch", in, &out); err != nil {
+		return nil, err
+	}
+
+	failed := []*entry{}
+
+	for _, f := range out.Failed {
+
+		if f.SenderFault {
+			return nil, status.Errorf(codes.InvalidArgument, "SQS rejected %s event: %s %s", binding.Type, f.Code, f.Message)
+		}
+
+		if e := entryById(entries, f.Id); e != nil {
+			failed = append(failed, e)
+		}
+
+	}
+
+	return failed, nil
+
+}
+
+func (p *Publisher) sendSNS(ctx context.Context, binding *Binding, entries []*entry) ([]*entry, error) {
+
+	form := url.Values{
+		"Action":   {"PublishBatch"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {binding.Target},
+	}
+
+	for i, e := range entries {
+		prefix := "PublishBatchRequestEntries.member." + strconv.Itoa(i+1)
+		form.Set(prefix+".Id", e.id)
+		form.Set(prefix+".Message", e.body)
+	}
+
+	out := struct {
+		Failed []struct {
+			Id          string
+			Code        string
+			Message     string
+			SenderFault bool
+		} `xml:"PublishBatchResult>Failed>member"`
+	}{}
+
+	if err := p.client.CallQuery(ctx, "sns", form, &out); err != nil {
+		return nil, err
+	}
+
+	failed := []*entry{}
+
+	for _, f := range out.Failed {
+
+		if f.SenderFault {
+			return nil, status.Errorf(codes.InvalidArgument, "SNS rejected %s event: %s %s", binding.Type, f.Code, f.Message)
+		}
+
+		if e := entryById(entries, f.Id); e != nil {
+			failed = append(failed, e)
+		}
+
+	}
+
+	return failed, nil
+
+}
+
+func (p *Publisher) sendEventBridge(ctx context.Context, binding *Binding, entries []*entry) ([]*entry, error) {
+
+	detailType := binding.DetailType
+	if len(detailType) == 0 {
+		detailType = binding.Type
+	}
+
+	batch := make([]map[string]string, len(entries))
+	for i, e := range entries {
+		batch[i] = map[string]string{
+			"EventBusName": binding.Target,
+			"Source":       p.source,
+			"DetailType":   detailType,
+			"Detail":       e.body,
+		}
+	}
+
+	out := struct {
+		FailedEntryCount int
+		Entries          []struct {
+			ErrorCode string
+		}
+	}{}
+
+	if err := p.client.CallJSON(ctx, "events", "1.1", "AWSEvents.PutEvents", map[string]interface{}{"Entries": batch}, &out); err != nil {
+		return nil, err
+	}
+
+	failed := []*entry{}
+
+	// Results are in the order of the entries.
+	for i, result := range out.Entries {
+		if len(result.ErrorCode) > 0 && i < len(entries) {
+			failed = append(failed, entries[i])
+		}
+	}
+
+	return failed, nil
+
+}
+
+func entryById(entries []*entry, id string) *entry {
+
+	for _, e := range entries {
+		if e.id == id {
+			return e
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,145 @@
+// Package redact masks the sensitive fields of messages, fields are marked
+// with the protomesh.redact.v1.sensitive field option or configured by
+// full name.
+package redact
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/redact/v1/redact.proto
+
+import (
+	"context"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Replaces the value of sensitive string fields, other kinds are cleared.
+const Mask = "[REDACTED]"
+
+// Honours the field options only.
+var Default = &Redactor{}
+
+type Redactor struct {
+	// Full names of the sensitive fields besides the annotated ones (e.g.
+	// "acme.users.v1.User.email"), false unmarks an annotated field.
+	Fields map[string]bool
+
+	sensitive sync.Map
+}
+
+// IsSensitive reports whether field must be redacted.
+func (r *Redactor) IsSensitive(field protoreflect.FieldDescriptor) bool {
+
+	if sensitive, ok := r.sensitive.Load(field.FullName()); ok {
+		return sensitive.(bool)
+	}
+
+	sensitive, ok := r.Fields[string(field.FullName())]
+	if !ok {
+		sensitive = proto.GetExtension(field.Options(), E_Sensitive).(bool)
+	}
+
+	r.sensitive.Store(field.FullName(), sensitive)
+
+	return sensitive
+
+}
+
+// Redact masks the sensitive fields of msg in place, nested messages
+// included.
+func (r *Redactor) Redact(msg proto.Message) {
+	r.redact(msg.ProtoReflect())
+}
+
+// Sanitize redacts msg, it's usable as a replay.Sanitizer.
+func (r *Redactor) Sanitize(method string, msg proto.Message) {
+	r.Redact(msg)
+}
+
+// Redacted returns a redacted copy of msg.
+func (r *Redactor) Redacted(msg proto.Message) proto.Message {
+
+	msg = proto.Clone(msg)
+	r.Redact(msg)
+
+	return msg
+
+}
+
+func (r *Redactor) redact(msg protoreflect.Message) {
+
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+
+		switch {
+
+		case r.IsSensitive(field):
+			if field.Kind() == protoreflect.StringKind && !field.IsList() && !field.IsMap() {
+				msg.Set(field, protoreflect.ValueOfString(Mask))
+			} else {
+				msg.Clear(field)
+			}
+
+		case field.IsMap():
+			if field.MapValue().Message() != nil {
+				value.Map().Range(func(_ protoreflect.MapKey, item protoreflect.Value) bool {
+					r.redact(item.Message())
+					return true
+				})
+			}
+
+		case field.IsList():
+			if field.Message() != nil {
+				list := value.List()
+				for i := 0; i < list.Len(); i++ {
+					r.redact(list.Get(i).Message())
+				}
+			}
+
+		case field.Message() != nil:
+			r.redact(value.Message())
+
+		}
+
+		return true
+
+	})
+
+}
+
+// LogInterceptor logs the redacted request and response of the unary calls
+// at debug level.
+func LogInterceptor(r *Redactor) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		log := lambda.LoggerFromContext(ctx)
+
+		if msg, ok := req.(proto.Message); ok {
+			log.Debug("Received request", "method", info.FullMethod, "request", r.logValue(msg))
+		}
+
+		out, err := handler(ctx, req)
+
+		if msg, ok := out.(proto.Message); ok && err == nil {
+			log.Debug("Sending response", "method", info.FullMethod, "response", r.logValue(msg))
+		}
+
+		return out, err
+
+	}
+
+}
+
+func (r *Redactor) logValue(msg proto.Message) string {
+
+	body, err := protojson.Marshal(r.Redacted(msg))
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(body)
+
+}
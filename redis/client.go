@@ -0,0 +1,270 @@
+// Package redis is a small Redis/ElastiCache client tuned for Lambda: a
+// connection per node reused across invocations, TLS, IAM authentication
+// and cluster redirections. It's the shared backend of the caching, rate
+// limiting and idempotency helpers.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+)
+
+// Nil is returned by the typed helpers for missing keys.
+var Nil = &Error{Message: "redis: nil"}
+
+const maxRedirects = 5
+
+type Options struct {
+	// Address of the node or cluster configuration endpoint, host:port.
+	Addr string
+	TLS  bool
+	// Static password (AUTH token), optional.
+	Username string
+	Password string
+	// Authenticates Username with IAM auth tokens, Password is ignored.
+	IAM *IAMAuth
+	// Defaults to 5 seconds.
+	DialTimeout time.Duration
+}
+
+// Client sends commands to a node or a cluster, following MOVED and ASK
+// redirections. It's safe for concurrent use but serializes the commands
+// of each node, matching the one request at a time of a function instance.
+type Client struct {
+	opts Options
+
+	lock  sync.Mutex
+	conns map[string]*conn
+}
+
+func NewClient(opts Options) *Client {
+	return &Client{
+		opts:  opts,
+		conns: make(map[string]*conn),
+	}
+}
+
+type conn struct {
+	lock    sync.Mutex
+	netConn net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+}
+
+// Do sends a command and returns its reply, see readReply for the types.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+
+	addr := c.opts.Addr
+	asking := false
+
+	for redirect := 0; redirect <= maxRedirects; redirect++ {
+
+		reply, err := c.doNode(ctx, addr, asking, args)
+
+		replyErr, ok := err.(*Error)
+		if !ok {
+			return reply, err
+		}
+
+		// MOVED <slot> <addr> or ASK <slot> <addr>
+		fields := strings.Fields(replyErr.Message)
+		if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+			return nil, err
+		}
+
+		addr = fields[2]
+		asking = fields[0] == "ASK"
+
+	}
+
+	return nil, fmt.Errorf("Too many redirections for %v", args[0])
+
+}
+
+func (c *Client) doNode(ctx context.Context, addr string, asking bool, args []interface{}) (interface{}, error) {
+
+	cn, err := c.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn.lock.Lock()
+	defer cn.lock.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		cn.netConn.SetDeadline(deadline)
+	} else {
+		cn.netConn.SetDeadline(time.Time{})
+	}
+
+	if asking {
+		if _, err := cn.roundTrip([]interface{}{"ASKING"}); err != nil {
+			c.drop(addr, cn)
+			return nil, err
+		}
+	}
+
+	reply, err := cn.roundTrip(args)
+	if _, isReplyErr := err.(*Error); err != nil && !isReplyErr {
+		// The connection state is unknown after I/O errors.
+		c.drop(addr, cn)
+	}
+
+	return reply, err
+
+}
+
+func (cn *conn) roundTrip(args []interface{}) (interface{}, error) {
+
+	if err := writeCommand(cn.writer, args); err != nil {
+		return nil, err
+	}
+
+	return readReply(cn.reader)
+
+}
+
+func (c *Client) conn(ctx context.Context, addr string) (*conn, error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cn, ok := c.conns[addr]; ok {
+		return cn, nil
+	}
+
+	cn, err := c.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c.conns[addr] = cn
+
+	return cn, nil
+
+}
+
+func (c *Client) drop(addr string, cn *conn) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.conns[addr] == cn {
+		delete(c.conns, addr)
+	}
+
+	cn.netConn.Close()
+
+}
+
+func (c *Client) dial(ctx context.Context, addr string) (*conn, error) {
+
+	timeout := c.opts.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var netConn net.Conn
+	var err error
+
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(addr)
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{
+		netConn: netConn,
+		reader:  bufio.NewReader(netConn),
+		writer:  bufio.NewWriter(netConn),
+	}
+
+	if err := c.auth(ctx, cn); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return cn, nil
+
+}
+
+func (c *Client) auth(ctx context.Context, cn *conn) error {
+
+	password := c.opts.Password
+
+	if c.opts.IAM != nil {
+
+		token, err := c.opts.IAM.Token(ctx, c.opts.Username)
+		if err != nil {
+			return fmt.Errorf("Failed to generate IAM auth token: %w", err)
+		}
+
+		password = token
+
+	}
+
+	if len(password) == 0 {
+		return nil
+	}
+
+	args := []interface{}{"AUTH", password}
+	if len(c.opts.Username) > 0 {
+		args = []interface{}{"AUTH", c.opts.Username, password}
+	}
+
+	_, err := cn.roundTrip(args)
+
+	return err
+
+}
+
+// IAMAuth generates ElastiCache IAM auth tokens.
+type IAMAuth struct {
+	Client *awsapi.Client
+	// Replication group or serverless cache name.
+	CacheName  string
+	Serverless bool
+}
+
+// Token returns an auth token for user, valid 15 minutes. Connections stay
+// authenticated after it expires, up to 12 hours.
+func (i *IAMAuth) Token(ctx context.Context, user string) (string, error) {
+
+	creds, err := i.Client.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{"Action": {"connect"}, "User": {user}}
+	if i.Serverless {
+		query.Set("ResourceType", "ServerlessCache")
+	}
+
+	u := &url.URL{
+		Scheme:   "http",
+		Host:     strings.ToLower(i.CacheName),
+		Path:     "/",
+		RawQuery: query.Encode(),
+	}
+
+	signed := i.Client.Signer("elasticache").Presign("GET", u, creds, time.Now(), 15*time.Minute)
+
+	return signed.Host + signed.Path + "?" + signed.RawQuery, nil
+
+}
@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Get returns the value of key, Nil when missing.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return "", err
+	}
+
+	if reply == nil {
+		return "", Nil
+	}
+
+	return reply.(string), nil
+
+}
+
+// Set sets key to value, expiring after ttl when positive.
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+
+	args := []interface{}{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+
+	_, err := c.Do(ctx, args...)
+
+	return err
+
+}
+
+// SetNX sets key to value only when missing, reporting whether it was set.
+func (c *Client) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+
+	args := []interface{}{"SET", key, value, "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return reply != nil, nil
+
+}
+
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return reply.(int64), nil
+
+}
+
+// Increments the counter of the window and sets its expiry on creation.
+const windowScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return n`
+
+// Allow counts a hit of key in the current fixed window, reporting whether
+// the hits stay within limit. Counters are shared by every instance.
+// Windows under a millisecond fail with InvalidArgument.
+func (c *Client) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+
+	if window < time.Millisecond {
+		return false, status.Errorf(codes.InvalidArgument, "Window %s is under a millisecond", window)
+	}
+
+	windowKey := key + ":" + strconv.FormatInt(clock.FromContext(ctx).Now().UnixMilli()/window.Milliseconds(), 10)
+
+	reply, err := c.Do(ctx, "EVAL", windowScript, 1, windowKey, window.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	return reply.(int64) <= limit, nil
+
+}
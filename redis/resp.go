@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Error is an error reply of the server.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// writeCommand encodes args as a RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, args []interface{}) error {
+
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+
+		var s string
+
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		default:
+			s = fmt.Sprint(v)
+		}
+
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+
+	}
+
+	return w.Flush()
+
+}
+
+// readReply decodes a RESP2 reply: simple strings and bulk strings as
+// string, integers as int64, arrays as []interface{}, nil bulk strings and
+// arrays as nil, error replies as *Error.
+func readReply(r *bufio.Reader) (interface{}, error) {
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("Invalid reply line: %q", line)
+	}
+
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+
+	case '+':
+		return payload, nil
+
+	case '-':
+		return nil, &Error{Message: payload}
+
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+
+	case '$':
+
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		return string(buf[:size]), nil
+
+	case '*':
+
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, size)
+
+		for i := range items {
+
+			item, err := readReply(r)
+			if _, isReplyErr := err.(*Error); err != nil && !isReplyErr {
+				return nil, err
+			}
+
+			if err != nil {
+				item = err
+			}
+
+			items[i] = item
+
+		}
+
+		return items, nil
+
+	}
+
+	return nil, fmt.Errorf("Unknown reply type %q", kind)
+
+}
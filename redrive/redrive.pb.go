@@ -0,0 +1,312 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.23.4
+// source: protomesh/redrive/v1/redrive.proto
+
+package redrive
+
+import (
+	_ "github.com/protomesh/protomesh-go/authz"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RedriveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Dead-letter queue read.
+	SourceQueueUrl string `protobuf:"bytes,1,opt,name=source_queue_url,json=sourceQueueUrl,proto3" json:"source_queue_url,omitempty"`
+	// Queue the messages are sent back to.
+	TargetQueueUrl string `protobuf:"bytes,2,opt,name=target_queue_url,json=targetQueueUrl,proto3" json:"target_queue_url,omitempty"`
+	// Messages moved by this call, unbounded when 0.
+	MaxMessages int32 `protobuf:"varint,3,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"`
+	// Rate of the moves, unbounded when 0.
+	MessagesPerSecond float64 `protobuf:"fixed64,4,opt,name=messages_per_second,json=messagesPerSecond,proto3" json:"messages_per_second,omitempty"`
+}
+
+func (x *RedriveRequest) Reset() {
+	*x = RedriveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protomesh_redrive_v1_redrive_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RedriveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedriveRequest) ProtoMessage() {}
+
+func (x *RedriveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protomesh_redrive_v1_redrive_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedriveRequest.ProtoReflect.Descriptor instead.
+func (*RedriveRequest) Descriptor() ([]byte, []int) {
+	return file_protomesh_redrive_v1_redrive_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RedriveRequest) GetSourceQueueUrl() string {
+	if x != nil {
+		return x.SourceQueueUrl
+	}
+	return ""
+}
+
+func (x *RedriveRequest) GetTargetQueueUrl() string {
+	if x != nil {
+		return x.TargetQueueUrl
+	}
+	return ""
+}
+
+func (x *RedriveRequest) GetMaxMessages() int32 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
+func (x *RedriveRequest) GetMessagesPerSecond() float64 {
+	if x != nil {
+		return x.MessagesPerSecond
+	}
+	return 0
+}
+
+type RedriveResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Moved int64 `protobuf:"varint,1,opt,name=moved,proto3" json:"moved,omitempty"`
+	// Messages dropped by the transform.
+	Dropped int64 `protobuf:"varint,2,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	// Messages left in the source queue, they are received again later.
+	Failed int64 `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	// Approximate number of messages left in the source queue.
+	Remaining int64 `protobuf:"varint,4,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	// Whether the source queue was drained.
+	Done bool `protobuf:"varint,5,opt,name=done,proto3" json:"done,omitempty"`
+	// Ids of the failed messages.
+	FailedMessageIds []string `protobuf:"bytes,6,rep,name=failed_message_ids,json=failedMessageIds,proto3" json:"failed_message_ids,omitempty"`
+}
+
+func (x *RedriveResponse) Reset() {
+	*x = RedriveResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protomesh_redrive_v1_redrive_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RedriveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedriveResponse) ProtoMessage() {}
+
+func (x *RedriveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protomesh_redrive_v1_redrive_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedriveResponse.ProtoReflect.Descriptor instead.
+func (*RedriveResponse) Descriptor() ([]byte, []int) {
+	return file_protomesh_redrive_v1_redrive_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RedriveResponse) GetMoved() int64 {
+	if x != nil {
+		return x.Moved
+	}
+	return 0
+}
+
+func (x *RedriveResponse) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *RedriveResponse) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *RedriveResponse) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *RedriveResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *RedriveResponse) GetFailedMessageIds() []string {
+	if x != nil {
+		return x.FailedMessageIds
+	}
+	return nil
+}
+
+var File_protomesh_redrive_v1_redrive_proto protoreflect.FileDescriptor
+
+var file_protomesh_redrive_v1_redrive_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x72, 0x65, 0x64, 0x72,
+	0x69, 0x76, 0x65, 0x2f, 0x76, 0x31, 0x2f, 0x72, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x14, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e,
+	0x72, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2f, 0x76, 0x31, 0x2f, 0x61,
+	0x75, 0x74, 0x68, 0x7a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb7, 0x01, 0x0a, 0x0e, 0x52,
+	0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x28, 0x0a,
+	0x10, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x5f, 0x75, 0x72,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x51,
+	0x75, 0x65, 0x75, 0x65, 0x55, 0x72, 0x6c, 0x12, 0x28, 0x0a, 0x10, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x51, 0x75, 0x65, 0x75, 0x65, 0x55, 0x72,
+	0x6c, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73,
+	0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x11, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x22, 0xb9, 0x01, 0x0a, 0x0f, 0x52, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x76, 0x65,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c,
+	0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64,
+	0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x12,
+	0x0a, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x6f,
+	0x6e, 0x65, 0x12, 0x2c, 0x0a, 0x12, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x5f, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10,
+	0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x73,
+	0x32, 0x76, 0x0a, 0x07, 0x52, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x12, 0x6b, 0x0a, 0x07, 0x52,
+	0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x12, 0x24, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65,
+	0x73, 0x68, 0x2e, 0x72, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65,
+	0x64, 0x72, 0x69, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x72, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x64, 0x72, 0x69, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x13, 0xda, 0xf3, 0x18, 0x0f, 0x0a, 0x0d, 0x72, 0x65, 0x64, 0x72, 0x69,
+	0x76, 0x65, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x42, 0x2b, 0x5a, 0x29, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2d, 0x67, 0x6f, 0x2f, 0x72, 0x65,
+	0x64, 0x72, 0x69, 0x76, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protomesh_redrive_v1_redrive_proto_rawDescOnce sync.Once
+	file_protomesh_redrive_v1_redrive_proto_rawDescData = file_protomesh_redrive_v1_redrive_proto_rawDesc
+)
+
+func file_protomesh_redrive_v1_redrive_proto_rawDescGZIP() []byte {
+	file_protomesh_redrive_v1_redrive_proto_rawDescOnce.Do(func() {
+		file_protomesh_redrive_v1_redrive_proto_rawDescData = protoimpl.X.CompressGZIP(file_protomesh_redrive_v1_redrive_proto_rawDescData)
+	})
+	return file_protomesh_redrive_v1_redrive_proto_rawDescData
+}
+
+var file_protomesh_redrive_v1_redrive_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_protomesh_redrive_v1_redrive_proto_goTypes = []interface{}{
+	(*RedriveRequest)(nil),  // 0: protomesh.redrive.v1.RedriveRequest
+	(*RedriveResponse)(nil), // 1: protomesh.redrive.v1.RedriveResponse
+}
+var file_protomesh_redrive_v1_redrive_proto_depIdxs = []int32{
+	0, // 0: protomesh.redrive.v1.Redrive.Redrive:input_type -> protomesh.redrive.v1.RedriveRequest
+	1, // 1: protomesh.redrive.v1.Redrive.Redrive:output_type -> protomesh.redrive.v1.RedriveResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_protomesh_redrive_v1_redrive_proto_init() }
+func file_protomesh_redrive_v1_redrive_proto_init() {
+	if File_protomesh_redrive_v1_redrive_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protomesh_redrive_v1_redrive_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RedriveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protomesh_redrive_v1_redrive_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RedriveResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protomesh_redrive_v1_redrive_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_protomesh_redrive_v1_redrive_proto_goTypes,
+		DependencyIndexes: file_protomesh_redrive_v1_redrive_proto_depIdxs,
+		MessageInfos:      file_protomesh_redrive_v1_redrive_proto_msgTypes,
+	}.Build()
+	File_protomesh_redrive_v1_redrive_proto = out.File
+	file_protomesh_redrive_v1_redrive_proto_rawDesc = nil
+	file_protomesh_redrive_v1_redrive_proto_goTypes = nil
+	file_protomesh_redrive_v1_redrive_proto_depIdxs = nil
+}
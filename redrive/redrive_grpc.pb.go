@@ -0,0 +1,113 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.23.4
+// source: protomesh/redrive/v1/redrive.proto
+
+package redrive
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Redrive_Redrive_FullMethodName = "/protomesh.redrive.v1.Redrive/Redrive"
+)
+
+// RedriveClient is the client API for Redrive service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RedriveClient interface {
+	// Redrive moves messages until the queue is empty, max_messages is reached
+	// or the invocation runs out of time, callers repeat it until done.
+	Redrive(ctx context.Context, in *RedriveRequest, opts ...grpc.CallOption) (*RedriveResponse, error)
+}
+
+type redriveClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRedriveClient(cc grpc.ClientConnInterface) RedriveClient {
+	return &redriveClient{cc}
+}
+
+func (c *redriveClient) Redrive(ctx context.Context, in *RedriveRequest, opts ...grpc.CallOption) (*RedriveResponse, error) {
+	out := new(RedriveResponse)
+	err := c.cc.Invoke(ctx, Redrive_Redrive_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RedriveServer is the server API for Redrive service.
+// All implementations must embed UnimplementedRedriveServer
+// for forward compatibility
+type RedriveServer interface {
+	// Redrive moves messages until the queue is empty, max_messages is reached
+	// or the invocation runs out of time, callers repeat it until done.
+	Redrive(context.Context, *RedriveRequest) (*RedriveResponse, error)
+	mustEmbedUnimplementedRedriveServer()
+}
+
+// UnimplementedRedriveServer must be embedded to have forward compatible implementations.
+type UnimplementedRedriveServer struct {
+}
+
+func (UnimplementedRedriveServer) Redrive(context.Context, *RedriveRequest) (*RedriveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Redrive not implemented")
+}
+func (UnimplementedRedriveServer) mustEmbedUnimplementedRedriveServer() {}
+
+// UnsafeRedriveServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RedriveServer will
+// result in compilation errors.
+type UnsafeRedriveServer interface {
+	mustEmbedUnimplementedRedriveServer()
+}
+
+func RegisterRedriveServer(s grpc.ServiceRegistrar, srv RedriveServer) {
+	s.RegisterService(&Redrive_ServiceDesc, srv)
+}
+
+func _Redrive_Redrive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedriveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedriveServer).Redrive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Redrive_Redrive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedriveServer).Redrive(ctx, req.(*RedriveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Redrive_ServiceDesc is the grpc.ServiceDesc for Redrive service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Redrive_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protomesh.redrive.v1.Redrive",
+	HandlerType: (*RedriveServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Redrive",
+			Handler:    _Redrive_Redrive_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "protomesh/redrive/v1/redrive.proto",
+}
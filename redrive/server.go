@@ -0,0 +1,408 @@
+// Package redrive moves the messages of SQS dead-letter queues back to
+// their queues, delaying them with an exponential backoff on the number of
+// times they were redriven.
+package redrive
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go --go-grpc_out=.. --go-grpc_opt=module=github.com/protomesh/protomesh-go protomesh/redrive/v1/redrive.proto
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Message attribute counting the redrives of a message.
+const RedriveCountAttribute = "protomesh-redrive-count"
+
+// SQS limit of message attributes, the redrive count isn't added to the
+// messages already at the limit.
+const maxMessageAttributes = 10
+
+// Message is a message received from the dead-letter queue.
+type Message struct {
+	Id         string
+	Body       string
+	Attributes map[string]*MessageAttribute
+	// Times the message was redriven before.
+	Redrives int
+
+	receiptHandle string
+	groupId       string
+}
+
+type MessageAttribute struct {
+	DataType    string
+	StringValue string `json:",omitempty"`
+	BinaryValue []byte `json:",omitempty"`
+}
+
+// Transform rewrites a message before it is sent back, returning nil drops
+// the message.
+type Transform func(ctx context.Context, msg *Message) (*Message, error)
+
+var _ RedriveServer = &Server{}
+
+// Server implements protomesh.redrive.v1.Redrive, its methods require the
+// redrive.admin permission (see authz.Interceptor):
+//
+//	server := redrive.NewServer(client)
+//	server.Queues = func(source, target string) bool { ... }
+//
+//	err := redrive.Register(controller, server)
+type Server struct {
+	UnimplementedRedriveServer
+
+	Transform Transform
+	// Allows the pairs of queues redriven, required.
+	Queues func(source, target string) bool
+	// Delay of the first redrive, doubled on every redrive. Defaults to 1s.
+	BaseDelay time.Duration
+	// Defaults to 15 minutes, the SQS maximum. FIFO queues don't support
+	// delays.
+	MaxDelay time.Duration
+	// Time kept to answer before the deadline. Defaults to 2s.
+	Margin time.Duration
+
+	client *awsapi.Client
+}
+
+func NewServer(client *awsapi.Client) *Server {
+	return &Server{
+		BaseDelay: time.Second,
+		MaxDelay:  15 * time.Minute,
+		Margin:    2 * time.Second,
+		client:    client,
+	}
+}
+
+// Register registers server in the controller, it fails when the server
+// doesn't restrict the queues.
+func Register[D lambda.ControllerDependency](c *lambda.Controller[D], server *Server, opts ...lambda.ServiceOption) error {
+
+	if server.Queues == nil {
+		return status.Errorf(codes.FailedPrecondition, "Queues of the redrive server are required")
+	}
+
+	return c.TryRegisterGRPCService(Redrive_ServiceDesc, server, opts...)
+
+}
+
+func (s *Server) Redrive(ctx context.Context, req *RedriveRequest) (*RedriveResponse, error) {
+
+	if len(req.SourceQueueUrl) == 0 || len(req.TargetQueueUrl) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Source and target queues are required")
+	}
+
+	if s.Queues == nil || !s.Queues(req.SourceQueueUrl, req.TargetQueueUrl) {
+		return nil, status.Errorf(codes.PermissionDenied, "Redrive from %s to %s not allowed", req.SourceQueueUrl, req.TargetQueueUrl)
+	}
+
+	log := lambda.LoggerFromContext(ctx).With("source_queue_url", req.SourceQueueUrl, "target_queue_url", req.TargetQueueUrl)
+
+	res := &RedriveResponse{}
+	start := time.Now()
+
+	for req.MaxMessages <= 0 || res.Moved+res.Dropped < int64(req.MaxMessages) {
+
+		if remaining, ok := lambda.BudgetRemaining(ctx, s.Margin); ok && remaining == 0 {
+			break
+		}
+
+		count := 10
+		if req.MaxMessages > 0 && int64(req.MaxMessages)-res.Moved-res.Dropped < 10 {
+			count = int(int64(req.MaxMessages) - res.Moved - res.Dropped)
+		}
+
+		msgs, err := s.receive(ctx, req.SourceQueueUrl, count)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(msgs) == 0 {
+			res.Done = true
+			break
+		}
+
+		if err := s.move(ctx, req, msgs, res); err != nil {
+			return nil, err
+		}
+
+		log.Info("Redrive progress", "moved", res.Moved, "dropped", res.Dropped, "failed", res.Failed)
+
+		if req.MessagesPerSecond > 0 {
+
+			due := start.Add(time.Duration(float64(res.Moved+res.Dropped) / req.MessagesPerSecond * float64(time.Second)))
+
+			select {
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			case <-time.After(time.Until(due)):
+			}
+
+		}
+
+	}
+
+	remaining, err := s.approximateCount(ctx, req.SourceQueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Remaining = remaining
+
+	return res, nil
+
+}
+
+func (s *Server) move(ctx context.Context, req *RedriveRequest, msgs []*Message, res *RedriveResponse) error {
+
+	sent := []*Message{}
+	done := []*Message{}
+
+	for _, msg := range msgs {
+
+		out := msg
+
+		if s.Transform != nil {
+
+			var err error
+
+			if out, err = s.Transform(ctx, msg); err != nil {
+				lambda.LoggerFromContext(ctx).Warn("Failed to transform message", "message_id", msg.Id, "error", err)
+				res.Failed++
+				res.FailedMessageIds = append(res.FailedMessageIds, msg.Id)
+				continue
+			}
+
+		}
+
+		if out == nil {
+			res.Dropped++
+			done = append(done, msg)
+			continue
+		}
+
+		out.receiptHandle = msg.receiptHandle
+		out.groupId = msg.groupId
+		out.Redrives = msg.Redrives
+
+		sent = append(sent, out)
+
+	}
+
+	if len(sent) > 0 {
+
+		ok, err := s.send(ctx, req.TargetQueueUrl, sent)
+		if err != nil {
+			return err
+		}
+
+		res.Moved += int64(len(ok))
+		res.Failed += int64(len(sent) - len(ok))
+
+		moved := make(map[*Message]bool, len(ok))
+		for _, msg := range ok {
+			moved[msg] = true
+		}
+
+		for _, msg := range sent {
+			if !moved[msg] {
+				res.FailedMessageIds = append(res.FailedMessageIds, msg.Id)
+			}
+		}
+
+		done = append(done, ok...)
+
+	}
+
+	if len(done) > 0 {
+		return s.delete(ctx, req.SourceQueueUrl, done)
+	}
+
+	return nil
+
+}
+
+// delay is the backoff of the redrive of msg.
+func (s *Server) delay(msg *Message) time.Duration {
+
+	delay := s.BaseDelay
+
+	for i := 0; i < msg.Redrives && delay < s.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > s.MaxDelay {
+		delay = s.MaxDelay
+	}
+
+	return delay
+
+}
+
+func (s *Server) receive(ctx context.Context, queueUrl string, count int) ([]*Message, error) {
+
+	out := struct {
+		Messages []struct {
+			MessageId         string
+			ReceiptHandle     string
+			Body              string
+			Attributes        map[string]string
+			MessageAttributes map[string]*MessageAttribute
+		}
+	}{}
+
+	err := s.client.CallSQS(ctx, "ReceiveMessage", map[string]interface{}{
+		"QueueUrl":              queueUrl,
+		"MaxNumberOfMessages":   count,
+		"WaitTimeSeconds":       1,
+		"AttributeNames":        []string{"MessageGroupId"},
+		"MessageAttributeNames": []string{"All"},
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]*Message, len(out.Messages))
+
+	for i, m := range out.Messages {
+
+		msg := &Message{
+			Id:            m.MessageId,
+			Body:          m.Body,
+			Attributes:    m.MessageAttributes,
+			receiptHandle: m.ReceiptHandle,
+			groupId:       m.Attributes["MessageGroupId"],
+		}
+
+		if msg.Attributes == nil {
+			msg.Attributes = make(map[string]*MessageAttribute)
+		}
+
+		if count, ok := msg.Attributes[RedriveCountAttribute]; ok {
+			msg.Redrives, _ = strconv.Atoi(count.StringValue)
+		}
+
+		msgs[i] = msg
+
+	}
+
+	return msgs, nil
+
+}
+
+// send returns the messages sent successfully.
+func (s *Server) send(ctx context.Context, queueUrl string, msgs []*Message) ([]*Message, error) {
+
+	entries := make([]map[string]interface{}, len(msgs))
+
+	for i, msg := range msgs {
+
+		attributes := make(map[string]*MessageAttribute, len(msg.Attributes)+1)
+		for k, v := range msg.Attributes {
+			attributes[k] = v
+		}
+
+		if _, ok := attributes[RedriveCountAttribute]; ok || len(attributes) < maxMessageAttributes {
+			attributes[RedriveCountAttribute] = &MessageAttribute{
+				DataType:    "Number",
+				StringValue: strconv.Itoa(msg.Redrives + 1),
+			}
+		} else {
+			lambda.LoggerFromContext(ctx).Warn("Message at the attributes limit, redrive count not added", "message_id", msg.Id)
+		}
+
+		entry := map[string]interface{}{
+			"Id":                strconv.Itoa(i),
+			"MessageBody":       msg.Body,
+			"MessageAttributes": attributes,
+		}
+
+		if len(msg.groupId) > 0 {
+			entry["MessageGroupId"] = msg.groupId
+			entry["MessageDeduplicationId"] = msg.Id
+		} else {
+			entry["DelaySeconds"] = int(s.delay(msg) / time.Second)
+		}
+
+		entries[i] = entry
+
+	}
+
+	out := struct {
+		Successful []struct {
+			Id string
+		}
+		Failed []struct {
+			Id      string
+			Code    string
+			Message string
+		}
+	}{}
+
+	err := s.client.CallSQS(ctx, "SendMessageBatch", map[string]interface{}{
+		"QueueUrl": queueUrl,
+		"Entries":  entries,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, failure := range out.Failed {
+		if i, err := strconv.Atoi(failure.Id); err == nil && i < len(msgs) {
+			lambda.LoggerFromContext(ctx).Warn("Failed to send message", "message_id", msgs[i].Id, "code", failure.Code, "error", failure.Message)
+		}
+	}
+
+	sent := make([]*Message, 0, len(out.Successful))
+
+	for _, success := range out.Successful {
+		if i, err := strconv.Atoi(success.Id); err == nil && i < len(msgs) {
+			sent = append(sent, msgs[i])
+		}
+	}
+
+	return sent, nil
+
+}
+
+func (s *Server) delete(ctx context.Context, queueUrl string, msgs []*Message) error {
+
+	entries := make([]map[string]string, len(msgs))
+
+	for i, msg := range msgs {
+		entries[i] = map[string]string{
+			"Id":            strconv.Itoa(i),
+			"ReceiptHandle": msg.receiptHandle,
+		}
+	}
+
+	return s.client.CallSQS(ctx, "DeleteMessageBatch", map[string]interface{}{
+		"QueueUrl": queueUrl,
+		"Entries":  entries,
+	}, nil)
+
+}
+
+func (s *Server) approximateCount(ctx context.Context, queueUrl string) (int64, error) {
+
+	out := struct {
+		Attributes map[string]string
+	}{}
+
+	err := s.client.CallSQS(ctx, "GetQueueAttributes", map[string]interface{}{
+		"QueueUrl":       queueUrl,
+		"AttributeNames": []string{"ApproximateNumberOfMessages"},
+	}, &out)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(out.Attributes["ApproximateNumberOfMessages"], 10, 64)
+
+}
@@ -0,0 +1,161 @@
+// Package replay records sanitized production calls and re-executes them
+// against a controller, giving regression coverage from real traffic.
+package replay
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/replay/v1/recording.proto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mathrand "math/rand"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/redact"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Metadata never recorded.
+var DefaultExcludedMetadata = []string{"authorization", "cookie", "x-api-key", "x-amz-security-token"}
+
+// Sink persists recordings.
+type Sink interface {
+	Write(ctx context.Context, rec *Recording) error
+}
+
+// Sanitizer scrubs messages before they are recorded, msg is a copy.
+type Sanitizer func(method string, msg proto.Message)
+
+type RecorderOptions struct {
+	Sink Sink
+	// Share of the calls recorded, from 0 to 1.
+	Ratio float64
+	// Restricts recording to some methods, every method when nil.
+	Methods func(method string) bool
+	// Defaults to DefaultExcludedMetadata.
+	ExcludedMetadata []string
+	// Redacts the sensitive fields, defaults to redact.Default.
+	Redactor   *redact.Redactor
+	Sanitizers []Sanitizer
+}
+
+// RecordInterceptor records a share of the unary calls to the sink, the
+// record is written before the call returns since Lambda freezes the
+// environment between invocations.
+func RecordInterceptor(opts RecorderOptions) grpc.UnaryServerInterceptor {
+
+	if opts.ExcludedMetadata == nil {
+		opts.ExcludedMetadata = DefaultExcludedMetadata
+	}
+
+	if opts.Redactor == nil {
+		opts.Redactor = redact.Default
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		if mathrand.Float64() >= opts.Ratio || (opts.Methods != nil && !opts.Methods(info.FullMethod)) {
+			return handler(ctx, req)
+		}
+
+		// The input may be modified or reused by the handler.
+		in := proto.Clone(req.(proto.Message))
+
+		start := time.Now()
+
+		out, err := handler(ctx, req)
+
+		rec, recErr := newRecording(ctx, &opts, info.FullMethod, in, out, err, start)
+		if recErr == nil {
+			recErr = opts.Sink.Write(ctx, rec)
+		}
+
+		if recErr != nil {
+			lambda.LoggerFromContext(ctx).Warn("Failed to record call", "method", info.FullMethod, "error", recErr)
+		}
+
+		return out, err
+
+	}
+
+}
+
+func newRecording(ctx context.Context, opts *RecorderOptions, method string, in proto.Message, out interface{}, callErr error, start time.Time) (*Recording, error) {
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	st := status.Convert(callErr)
+
+	rec := &Recording{
+		Id:       hex.EncodeToString(id),
+		Method:   method,
+		Time:     timestamppb.New(start),
+		Duration: durationpb.New(time.Since(start)),
+		Metadata: make(map[string]string),
+		Code:     int32(st.Code()),
+		Message:  st.Message(),
+	}
+
+	inMeta, _ := metadata.FromIncomingContext(ctx)
+
+	for k, v := range inMeta {
+		if len(v) > 0 && !isExcluded(k, opts.ExcludedMetadata) {
+			rec.Metadata[k] = v[0]
+		}
+	}
+
+	opts.Redactor.Redact(in)
+
+	for _, sanitize := range opts.Sanitizers {
+		sanitize(method, in)
+	}
+
+	request, err := proto.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.Request = request
+
+	if outMsg, ok := out.(proto.Message); ok && callErr == nil {
+
+		outMsg = opts.Redactor.Redacted(outMsg)
+
+		for _, sanitize := range opts.Sanitizers {
+			sanitize(method, outMsg)
+		}
+
+		response, err := proto.Marshal(outMsg)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Response = response
+
+	}
+
+	return rec, nil
+
+}
+
+func isExcluded(key string, excluded []string) bool {
+
+	for _, k := range excluded {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	return false
+
+}
@@ -0,0 +1,150 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// HandleFunc is the entry point replayed, usually Controller.HandleLambda.
+type HandleFunc func(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
+
+// Result compares a replayed call with its recording.
+type Result struct {
+	Recording *Recording
+	Code      codes.Code
+	Response  []byte
+	// Same status code and equal responses.
+	Match bool
+}
+
+// Replay re-executes a recording against handle.
+func Replay(ctx context.Context, handle HandleFunc, rec *Recording) (*Result, error) {
+
+	req := &events.APIGatewayProxyRequest{
+		HTTPMethod:        http.MethodPost,
+		Path:              rec.Method,
+		Headers:           make(map[string]string, len(rec.Metadata)),
+		MultiValueHeaders: map[string][]string{},
+		Body:              base64.RawStdEncoding.EncodeToString(rec.Request),
+		IsBase64Encoded:   true,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "replay-" + rec.Id,
+		},
+	}
+
+	for k, v := range rec.Metadata {
+		if !isEncodingHeader(k) {
+			req.Headers[k] = v
+		}
+	}
+
+	// The recorded request is re-marshaled raw protobuf, whatever the
+	// encoding of the recorded call.
+	req.Headers["Content-Type"] = "application/x-protobuf"
+
+	res, err := handle(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Recording: rec,
+		Code:      codes.OK,
+	}
+
+	if res.StatusCode >= 300 {
+		result.Code = lambda.CodeFromHTTPStatus(res.StatusCode)
+	} else if res.IsBase64Encoded {
+		body, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(res.Body, "="))
+		if err != nil {
+			return nil, err
+		}
+		result.Response = body
+	} else {
+		result.Response = []byte(res.Body)
+	}
+
+	// Codes sharing an HTTP status can't be told apart.
+	result.Match = res.StatusCode == httpStatus(codes.Code(rec.Code)) && equalResponses(rec.Method, rec.Response, result.Response)
+
+	return result, nil
+
+}
+
+// isEncodingHeader reports whether the header describes the encoding of
+// the recorded bodies (codec and compression), not valid for the replay.
+func isEncodingHeader(name string) bool {
+
+	for _, header := range []string{"Content-Type", "Accept", lambda.GrpcEncodingHeader, lambda.GrpcAcceptEncodingHeader} {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// ReadRecording reads a recording written by S3Sink.
+func ReadRecording(r io.Reader) (*Recording, error) {
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &Recording{}
+
+	if err := proto.Unmarshal(body, rec); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Invalid recording: %v", err)
+	}
+
+	return rec, nil
+
+}
+
+// equalResponses compares the responses as messages when the method is
+// registered, serialization isn't deterministic (e.g. maps).
+func equalResponses(method string, expected, actual []byte) bool {
+
+	if bytes.Equal(expected, actual) {
+		return true
+	}
+
+	expectedMsg, actualMsg := lambda.NewMethodOutput(method), lambda.NewMethodOutput(method)
+	if expectedMsg == nil {
+		return false
+	}
+
+	if proto.Unmarshal(expected, expectedMsg) != nil || proto.Unmarshal(actual, actualMsg) != nil {
+		return false
+	}
+
+	return proto.Equal(expectedMsg, actualMsg)
+
+}
+
+// httpStatus returns the status the controller answers with for code.
+func httpStatus(code codes.Code) int {
+
+	if code == codes.OK {
+		return http.StatusOK
+	}
+
+	if statusCode, ok := lambda.HTTPStatusFromCode(code); ok {
+		return statusCode
+	}
+
+	return http.StatusInternalServerError
+
+}
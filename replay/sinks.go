@@ -0,0 +1,78 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/protobuf/proto"
+)
+
+// S3Sink writes each recording to its own object, partitioned by hour
+// (prefix/2006/01/02/15/id.pb).
+type S3Sink struct {
+	Client *awsapi.Client
+	Bucket string
+	Prefix string
+}
+
+func (s *S3Sink) Write(ctx context.Context, rec *Recording) error {
+
+	body, err := proto.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s/%s/%s.pb", s.Prefix, rec.Time.AsTime().UTC().Format("2006/01/02/15"), rec.Id)
+
+	req, err := http.NewRequest(http.MethodPut, s.Client.S3ObjectUrl(s.Bucket, key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	res, err := s.Client.Do(ctx, "s3", req, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(res.Body)
+		return &awsapi.Error{
+			StatusCode: res.StatusCode,
+			Code:       http.StatusText(res.StatusCode),
+			Message:    string(resBody),
+		}
+	}
+
+	return nil
+
+}
+
+// KinesisSink puts recordings on a Kinesis data stream, partitioned by
+// method.
+type KinesisSink struct {
+	Client     *awsapi.Client
+	StreamName string
+}
+
+func (k *KinesisSink) Write(ctx context.Context, rec *Recording) error {
+
+	body, err := proto.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	in := map[string]interface{}{
+		"StreamName":   k.StreamName,
+		"PartitionKey": rec.Method,
+		"Data":         body,
+	}
+
+	return k.Client.CallJSON(ctx, "kinesis", "1.1", "Kinesis_20131202.PutRecord", in, nil)
+
+}
@@ -0,0 +1,176 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	DefaultBufBaseUrl = "https://buf.build"
+
+	bufReflectPath = "/buf.reflect.v1beta1.FileDescriptorSetService/GetFileDescriptorSet"
+)
+
+var (
+	_ Source = &BufSource{}
+)
+
+// BufSource resolves schemas from a Buf Schema Registry module using the
+// reflection API. The module descriptor set is fetched once and cached.
+type BufSource struct {
+	BaseUrl    string
+	Module     string
+	Version    string
+	Token      string
+	HttpClient *http.Client
+
+	lock  sync.Mutex
+	files *protoregistry.Files
+}
+
+func (b *BufSource) FindMessage(ctx context.Context, name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+
+	files, err := b.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := files.FindDescriptorByName(name)
+	if err == protoregistry.NotFound {
+		return nil, status.Errorf(codes.NotFound, "Schema %s not registered in %s", name, b.Module)
+	} else if err != nil {
+		return nil, err
+	}
+
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Schema %s is not a message", name)
+	}
+
+	return msgDesc, nil
+
+}
+
+func (b *BufSource) load(ctx context.Context) (*protoregistry.Files, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.files != nil {
+		return b.files, nil
+	}
+
+	set, err := b.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid descriptor set for %s: %v", b.Module, err)
+	}
+
+	b.files = files
+
+	return files, nil
+
+}
+
+func (b *BufSource) fetch(ctx context.Context) (*descriptorpb.FileDescriptorSet, error) {
+
+	reqBody := protowire.AppendTag(nil, 1, protowire.BytesType)
+	reqBody = protowire.AppendString(reqBody, b.Module)
+
+	if len(b.Version) > 0 {
+		reqBody = protowire.AppendTag(reqBody, 2, protowire.BytesType)
+		reqBody = protowire.AppendString(reqBody, b.Version)
+	}
+
+	baseUrl := b.BaseUrl
+	if len(baseUrl) == 0 {
+		baseUrl = DefaultBufBaseUrl
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(baseUrl, "/")+bufReflectPath, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/proto")
+	req.Header.Set("Connect-Protocol-Version", "1")
+
+	if len(b.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+
+	httpClient := b.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to reach schema registry: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to read schema registry response: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, status.Errorf(codes.Unavailable, "Schema registry returned %d: %s", res.StatusCode, body)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+
+	for len(body) > 0 {
+
+		num, typ, n := protowire.ConsumeTag(body)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		body = body[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+
+			val, n := protowire.ConsumeBytes(body)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			body = body[n:]
+
+			if err := proto.Unmarshal(val, set); err != nil {
+				return nil, err
+			}
+
+			continue
+
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, body)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		body = body[n:]
+
+	}
+
+	return set, nil
+
+}
@@ -0,0 +1,103 @@
+package schemaregistry
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type Compatibility int
+
+const (
+	// No checks, the registry is only used to resolve schemas.
+	CompatibilityNone Compatibility = iota
+	// Fields sharing a number must agree on kind, cardinality and type.
+	CompatibilityWire
+	// Wire compatibility plus matching field names, required by JSON payloads.
+	CompatibilityJSON
+)
+
+// Drift lists the differences found between a local and a registered schema.
+type Drift []string
+
+func (d Drift) Error() string {
+	return strings.Join(d, "; ")
+}
+
+// CheckCompatibility compares the local message descriptor with the
+// registered one, including nested message fields.
+func CheckCompatibility(local, registered protoreflect.MessageDescriptor, mode Compatibility) Drift {
+
+	if mode == CompatibilityNone {
+		return nil
+	}
+
+	drift := Drift{}
+
+	checkMessage(local, registered, mode, map[protoreflect.FullName]bool{}, &drift)
+
+	if len(drift) == 0 {
+		return nil
+	}
+
+	return drift
+
+}
+
+func checkMessage(local, registered protoreflect.MessageDescriptor, mode Compatibility, visited map[protoreflect.FullName]bool, drift *Drift) {
+
+	if visited[local.FullName()] {
+		return
+	}
+
+	visited[local.FullName()] = true
+
+	if local.FullName() != registered.FullName() {
+		*drift = append(*drift, fmt.Sprintf("%s: registered as %s", local.FullName(), registered.FullName()))
+		return
+	}
+
+	localFields := local.Fields()
+	registeredFields := registered.Fields()
+
+	for i := 0; i < registeredFields.Len(); i++ {
+
+		regField := registeredFields.Get(i)
+
+		field := localFields.ByNumber(regField.Number())
+		if field == nil {
+			continue
+		}
+
+		prefix := fmt.Sprintf("%s field %d", local.FullName(), field.Number())
+
+		if mode == CompatibilityJSON && field.Name() != regField.Name() {
+			*drift = append(*drift, fmt.Sprintf("%s: renamed from %s to %s", prefix, regField.Name(), field.Name()))
+		}
+
+		if field.Kind() != regField.Kind() {
+			*drift = append(*drift, fmt.Sprintf("%s: kind changed from %s to %s", prefix, regField.Kind(), field.Kind()))
+			continue
+		}
+
+		if field.Cardinality() != regField.Cardinality() || field.IsMap() != regField.IsMap() {
+			*drift = append(*drift, fmt.Sprintf("%s: cardinality changed from %s to %s", prefix, regField.Cardinality(), field.Cardinality()))
+			continue
+		}
+
+		switch field.Kind() {
+
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			checkMessage(field.Message(), regField.Message(), mode, visited, drift)
+
+		case protoreflect.EnumKind:
+			if field.Enum().FullName() != regField.Enum().FullName() {
+				*drift = append(*drift, fmt.Sprintf("%s: enum changed from %s to %s", prefix, regField.Enum().FullName(), field.Enum().FullName()))
+			}
+
+		}
+
+	}
+
+}
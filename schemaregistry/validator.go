@@ -0,0 +1,66 @@
+package schemaregistry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	_ envelope.Validator = &Validator{}
+)
+
+// Source resolves the registered version of a message schema.
+type Source interface {
+	FindMessage(ctx context.Context, name protoreflect.FullName) (protoreflect.MessageDescriptor, error)
+}
+
+// Validator checks local message descriptors against a schema registry,
+// results are cached for the lifetime of the container since compiled
+// descriptors can't change.
+type Validator struct {
+	source        Source
+	compatibility Compatibility
+
+	results sync.Map
+}
+
+func NewValidator(source Source, compatibility Compatibility) *Validator {
+	return &Validator{
+		source:        source,
+		compatibility: compatibility,
+	}
+}
+
+func (v *Validator) Validate(ctx context.Context, desc protoreflect.MessageDescriptor) error {
+
+	if res, ok := v.results.Load(desc.FullName()); ok {
+		if res == nil {
+			return nil
+		}
+		return res.(error)
+	}
+
+	registered, err := v.source.FindMessage(ctx, desc.FullName())
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			v.results.Store(desc.FullName(), err)
+		}
+		return err
+	}
+
+	if drift := CheckCompatibility(desc, registered, v.compatibility); drift != nil {
+		err := status.Errorf(codes.FailedPrecondition, "Schema drift for %s: %s", desc.FullName(), drift.Error())
+		v.results.Store(desc.FullName(), err)
+		return err
+	}
+
+	v.results.Store(desc.FullName(), nil)
+
+	return nil
+
+}
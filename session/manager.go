@@ -0,0 +1,216 @@
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const minSecretSize = 32
+
+// Manager loads the session of the requests and saves the modified ones,
+// the cookie only holds the session id signed with Secret.
+type Manager struct {
+	Store Store
+	// Signs the session cookies, at least 32 random bytes.
+	Secret []byte
+	// Defaults to "session".
+	CookieName string
+	// Defaults to "/".
+	CookiePath   string
+	CookieDomain string
+	// Defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// Allows cookies over plain HTTP, for local development only.
+	Insecure bool
+	// Lifetime of the sessions since their last modification. Defaults to
+	// 24h.
+	TTL time.Duration
+}
+
+func NewManager(store Store, secret []byte) *Manager {
+	return &Manager{
+		Store:  store,
+		Secret: secret,
+	}
+}
+
+func (m *Manager) cookieName() string {
+
+	if len(m.CookieName) == 0 {
+		return "session"
+	}
+
+	return m.CookieName
+
+}
+
+func (m *Manager) cookiePath() string {
+
+	if len(m.CookiePath) == 0 {
+		return "/"
+	}
+
+	return m.CookiePath
+
+}
+
+func (m *Manager) sameSite() http.SameSite {
+
+	if m.SameSite == 0 {
+		return http.SameSiteLaxMode
+	}
+
+	return m.SameSite
+
+}
+
+func (m *Manager) ttl() time.Duration {
+
+	if m.TTL <= 0 {
+		return 24 * time.Hour
+	}
+
+	return m.TTL
+
+}
+
+// Middleware sets the session of the request in the context, a new one
+// when the cookie is missing or invalid. New sessions are only saved, and
+// their cookie set, once modified. Requests fail with Internal while the
+// secret is shorter than 32 bytes.
+func (m *Manager) Middleware() lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			log := lambda.LoggerFromContext(ctx)
+
+			if len(m.Secret) < minSecretSize {
+				log.Error("Session secret too short", "size", len(m.Secret), "min", minSecretSize)
+				return res.WriteError(status.Errorf(codes.Internal, "Internal error"))
+			}
+
+			s, err := m.load(ctx, req)
+			if err != nil {
+				log.Warn("Failed to load session", "error", err)
+			}
+
+			if s == nil {
+				s = newSession(ctx, m.ttl())
+			}
+
+			s.ids = clock.IdGeneratorFromContext(ctx)
+
+			err = next(ContextWithSession(ctx, s), req, res)
+
+			if saveErr := m.save(ctx, s, res); saveErr != nil {
+				log.Error("Failed to save session", "error", saveErr)
+			}
+
+			return err
+
+		}
+
+	}
+
+}
+
+func (m *Manager) load(ctx context.Context, req *lambda.Request) (*Session, error) {
+
+	cookie, err := req.Cookie(m.cookieName())
+	if err != nil {
+		return nil, nil
+	}
+
+	id, ok := m.verify(cookie.Value)
+	if !ok {
+		return nil, nil
+	}
+
+	return m.Store.Load(ctx, id)
+
+}
+
+func (m *Manager) save(ctx context.Context, s *Session, res *lambda.Response) error {
+
+	s.lock.Lock()
+	dirty, destroyed, previousId := s.dirty, s.destroyed, s.previousId
+	s.dirty, s.previousId = false, ""
+	s.lock.Unlock()
+
+	if destroyed {
+
+		res.SetCookie(m.cookie("", -1))
+
+		if err := m.Store.Delete(ctx, s.Id); err != nil {
+			return err
+		}
+
+	} else if dirty {
+
+		s.ExpiresAt = clock.FromContext(ctx).Now().Add(m.ttl())
+
+		if err := m.Store.Save(ctx, s); err != nil {
+			return err
+		}
+
+		res.SetCookie(m.cookie(m.sign(s.Id), int(m.ttl()/time.Second)))
+
+	}
+
+	// The rotated session is deleted once the new one is saved, so that a
+	// failed save keeps the user signed in with the previous cookie.
+	if len(previousId) > 0 {
+		return m.Store.Delete(ctx, previousId)
+	}
+
+	return nil
+
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+
+	return &http.Cookie{
+		Name:     m.cookieName(),
+		Value:    value,
+		Path:     m.cookiePath(),
+		Domain:   m.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   !m.Insecure,
+		HttpOnly: true,
+		SameSite: m.sameSite(),
+	}
+
+}
+
+func (m *Manager) sign(id string) string {
+
+	mac := hmac.New(sha256.New, m.Secret)
+	mac.Write([]byte(id))
+
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+}
+
+// verify returns the session id of a signed cookie value.
+func (m *Manager) verify(value string) (string, bool) {
+
+	id, _, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", false
+	}
+
+	return id, hmac.Equal([]byte(m.sign(id)), []byte(value))
+
+}
@@ -0,0 +1,144 @@
+// Package session keeps the state of browser sessions in a store, the
+// sessions being identified by a signed cookie.
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+// Session is the state of a browser session, it's safe for concurrent use.
+type Session struct {
+	Id        string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	lock      sync.Mutex
+	data      map[string]string
+	dirty     bool
+	destroyed bool
+	// Id replaced by Rotate, deleted from the store on save.
+	previousId string
+	// Generates the id of Rotate, set by the Manager.
+	ids clock.IdGenerator
+}
+
+func newSession(ctx context.Context, ttl time.Duration) *Session {
+
+	ids := clock.IdGeneratorFromContext(ctx)
+	now := clock.FromContext(ctx).Now()
+
+	return &Session{
+		Id:        newId(ids),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		data:      make(map[string]string),
+		ids:       ids,
+	}
+
+}
+
+func newId(ids clock.IdGenerator) string {
+
+	if ids == nil {
+		ids = clock.RandomIds{}
+	}
+
+	id := make([]byte, 32)
+	ids.Read(id)
+
+	return base64.RawURLEncoding.EncodeToString(id)
+
+}
+
+func (s *Session) Get(key string) string {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.data[key]
+
+}
+
+func (s *Session) Set(key, value string) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.data[key] = value
+	s.dirty = true
+
+}
+
+func (s *Session) Delete(key string) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.data, key)
+	s.dirty = true
+
+}
+
+// Data returns a copy of the values of the session.
+func (s *Session) Data() map[string]string {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data := make(map[string]string, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+
+	return data
+
+}
+
+// Rotate replaces the id of the session keeping its values, it must be
+// called when the privileges change (e.g. on login) to prevent session
+// fixation.
+func (s *Session) Rotate() {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.previousId) == 0 {
+		s.previousId = s.Id
+	}
+
+	s.Id = newId(s.ids)
+	s.dirty = true
+
+}
+
+// Destroy deletes the session from the store and expires its cookie.
+func (s *Session) Destroy() {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.destroyed = true
+
+}
+
+type sessionContextKey struct{}
+
+// ContextWithSession sets the session of the invocation in ctx.
+func ContextWithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, s)
+}
+
+// FromContext returns the session of the invocation, nil outside of the
+// Manager middleware.
+func FromContext(ctx context.Context) *Session {
+
+	s, _ := ctx.Value(sessionContextKey{}).(*Session)
+
+	return s
+
+}
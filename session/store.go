@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+)
+
+// Store persists the sessions, Load returns nil for unknown or expired
+// sessions.
+type Store interface {
+	Load(ctx context.Context, id string) (*Session, error)
+	Save(ctx context.Context, s *Session) error
+	Delete(ctx context.Context, id string) error
+}
+
+var _ Store = &DynamoStore{}
+
+type dynamoItem map[string]events.DynamoDBAttributeValue
+
+// DynamoStore keeps the sessions in a DynamoDB table keyed by the "id"
+// string attribute, they expire through the "expires_at" TTL attribute.
+type DynamoStore struct {
+	client *awsapi.Client
+	table  string
+}
+
+func NewDynamoStore(client *awsapi.Client, table string) *DynamoStore {
+	return &DynamoStore{
+		client: client,
+		table:  table,
+	}
+}
+
+func (d *DynamoStore) Load(ctx context.Context, id string) (*Session, error) {
+
+	out := struct {
+		Item dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "GetItem", map[string]interface{}{
+		"TableName":      d.table,
+		"Key":            dynamoItem{"id": events.NewStringAttribute(id)},
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	s := &Session{
+		Id:        id,
+		CreatedAt: unixAttribute(out.Item["created_at"]),
+		ExpiresAt: unixAttribute(out.Item["expires_at"]),
+		data:      make(map[string]string),
+	}
+
+	// The TTL deletion lags behind the expiration.
+	if clock.FromContext(ctx).Now().After(s.ExpiresAt) {
+		return nil, nil
+	}
+
+	if data, ok := out.Item["data"]; ok && data.DataType() == events.DataTypeMap {
+		for k, v := range data.Map() {
+			s.data[k] = v.String()
+		}
+	}
+
+	return s, nil
+
+}
+
+func (d *DynamoStore) Save(ctx context.Context, s *Session) error {
+
+	data := make(map[string]events.DynamoDBAttributeValue)
+	for k, v := range s.Data() {
+		data[k] = events.NewStringAttribute(v)
+	}
+
+	return d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item": dynamoItem{
+			"id":         events.NewStringAttribute(s.Id),
+			"data":       events.NewMapAttribute(data),
+			"created_at": events.NewNumberAttribute(strconv.FormatInt(s.CreatedAt.Unix(), 10)),
+			"expires_at": events.NewNumberAttribute(strconv.FormatInt(s.ExpiresAt.Unix(), 10)),
+		},
+	}, nil)
+
+}
+
+func (d *DynamoStore) Delete(ctx context.Context, id string) error {
+
+	return d.client.CallDynamoDB(ctx, "DeleteItem", map[string]interface{}{
+		"TableName": d.table,
+		"Key":       dynamoItem{"id": events.NewStringAttribute(id)},
+	}, nil)
+
+}
+
+func unixAttribute(attr events.DynamoDBAttributeValue) time.Time {
+
+	if attr.DataType() != events.DataTypeNumber {
+		return time.Time{}
+	}
+
+	seconds, err := strconv.ParseInt(attr.Number(), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+
+}
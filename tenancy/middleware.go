@@ -0,0 +1,247 @@
+package tenancy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Options struct {
+	// Tried in order, the first tenant found wins.
+	Extractors []Extractor
+	Store      Store
+	// Config of the tenants unknown to the store, unknown tenants are denied
+	// when nil.
+	Default *Config
+	// Counts the quotas across instances (e.g. a redis.Client), they are
+	// counted per instance when nil.
+	Counter Counter
+	// Tenants whose limits are tracked by the instance, the least recently
+	// seen ones are evicted first (resetting their limits). Defaults to
+	// 10000.
+	MaxTenants int
+}
+
+// Counter counts hits of key in fixed windows shared by every instance.
+type Counter interface {
+	Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error)
+}
+
+// Middleware attaches the tenant to the context and enforces its rate
+// limit and quota. Limits are tracked per function instance, so they bound
+// each instance rather than the whole fleet.
+func Middleware(opts Options) lambda.Middleware {
+
+	limiters := &limiters{max: opts.MaxTenants}
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			tenant, err := resolve(ctx, &opts, req)
+			if err != nil {
+				return res.WriteError(err)
+			}
+
+			// Looked up once resolved, so unknown tenants are never tracked
+			// unless they get the default config.
+			if err := limiters.get(tenant.Id).allow(tenant, clock.FromContext(ctx).Now(), opts.Counter == nil); err != nil {
+				return res.WriteError(err)
+			}
+
+			if err := sharedQuota(ctx, opts.Counter, tenant); err != nil {
+				return res.WriteError(err)
+			}
+
+			ctx = ContextWithTenant(ctx, tenant)
+
+			if req.Header(lambda.TenantHeader) != tenant.Id {
+				ctx = lambda.ContextWithLogger(ctx, lambda.LoggerFromContext(ctx).With("tenant", tenant.Id))
+			}
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
+
+func resolve(ctx context.Context, opts *Options, req *lambda.Request) (*Tenant, error) {
+
+	tenant := &Tenant{}
+
+	for _, extract := range opts.Extractors {
+		if tenant.Id = extract(req); len(tenant.Id) > 0 {
+			break
+		}
+	}
+
+	if len(tenant.Id) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Missing tenant")
+	}
+
+	config, err := opts.Store.Config(ctx, tenant.Id)
+
+	switch {
+
+	case status.Code(err) == codes.NotFound && opts.Default != nil:
+		config = opts.Default
+
+	case status.Code(err) == codes.NotFound:
+		return nil, status.Errorf(codes.PermissionDenied, "Unknown tenant %s", tenant.Id)
+
+	case err != nil:
+		return nil, err
+
+	}
+
+	tenant.Config = config
+
+	return tenant, nil
+
+}
+
+// limiters keeps the limiters of the most recently seen tenants.
+type limiters struct {
+	max int
+
+	lock     sync.Mutex
+	byTenant map[string]*list.Element
+	lru      *list.List
+}
+
+func (l *limiters) get(tenantId string) *limiter {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.byTenant == nil {
+		l.byTenant = make(map[string]*list.Element)
+		l.lru = list.New()
+	}
+
+	if elem, ok := l.byTenant[tenantId]; ok {
+		l.lru.MoveToFront(elem)
+		return elem.Value.(*limiter)
+	}
+
+	bucket := &limiter{tenantId: tenantId}
+	l.byTenant[tenantId] = l.lru.PushFront(bucket)
+
+	max := l.max
+	if max <= 0 {
+		max = 10000
+	}
+
+	for l.lru.Len() > max {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.byTenant, oldest.Value.(*limiter).tenantId)
+	}
+
+	return bucket
+
+}
+
+// limiter is a token bucket for the rate limit and a fixed window for the
+// quota of a tenant.
+type limiter struct {
+	tenantId string
+
+	lock sync.Mutex
+
+	tokens float64
+	last   time.Time
+
+	windowStart time.Time
+	count       int64
+}
+
+func (l *limiter) allow(tenant *Tenant, now time.Time, quota bool) error {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	config := tenant.Config
+
+	if config.RateLimit > 0 {
+
+		burst := float64(config.Burst)
+		if burst < 1 {
+			burst = 1
+		}
+
+		if l.last.IsZero() {
+			l.tokens = burst
+		} else {
+			l.tokens += now.Sub(l.last).Seconds() * config.RateLimit
+			if l.tokens > burst {
+				l.tokens = burst
+			}
+		}
+
+		l.last = now
+
+		if l.tokens < 1 {
+			// Time until the next token.
+			wait := time.Duration((1 - l.tokens) / config.RateLimit * float64(time.Second))
+			return lambda.WithRetryAfter(status.Errorf(codes.ResourceExhausted, "Rate limit of tenant %s exceeded", tenant.Id), wait)
+		}
+
+	}
+
+	if quota && config.Quota > 0 && config.QuotaWindow > 0 {
+
+		if now.Sub(l.windowStart) >= config.QuotaWindow {
+			l.windowStart = now.Truncate(config.QuotaWindow)
+			l.count = 0
+		}
+
+		if l.count >= config.Quota {
+			return lambda.WithRetryAfter(status.Errorf(codes.ResourceExhausted, "Quota of tenant %s exhausted", tenant.Id), l.windowStart.Add(config.QuotaWindow).Sub(now))
+		}
+
+		l.count++
+
+	}
+
+	if config.RateLimit > 0 {
+		l.tokens--
+	}
+
+	return nil
+
+}
+
+// sharedQuota enforces the quota of the tenant with counter, when set.
+func sharedQuota(ctx context.Context, counter Counter, tenant *Tenant) error {
+
+	config := tenant.Config
+
+	if counter == nil || config.Quota <= 0 || config.QuotaWindow <= 0 {
+		return nil
+	}
+
+	allowed, err := counter.Allow(ctx, "protomesh:quota:"+tenant.Id, config.Quota, config.QuotaWindow)
+	if err != nil {
+		// Quotas fail open, the rate limit still bounds the instance.
+		lambda.LoggerFromContext(ctx).Error("Failed to count quota", "tenant", tenant.Id, "error", err)
+		return nil
+	}
+
+	if !allowed {
+		// The shared windows are aligned on their duration too.
+		now := clock.FromContext(ctx).Now()
+		return lambda.WithRetryAfter(status.Errorf(codes.ResourceExhausted, "Quota of tenant %s exhausted", tenant.Id), now.Truncate(config.QuotaWindow).Add(config.QuotaWindow).Sub(now))
+	}
+
+	return nil
+
+}
@@ -0,0 +1,121 @@
+// Package tenancy isolates the tenants of a controller, identifying the
+// tenant of each request and enforcing its configuration.
+package tenancy
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Config of a tenant, zero limits are unlimited.
+type Config struct {
+	// Sustained requests per second and burst.
+	RateLimit float64
+	Burst     int
+	// Requests per window.
+	Quota       int64
+	QuotaWindow time.Duration
+	Features    map[string]bool
+	// Dedicated backends of the tenant, see TargetResolver.
+	Targets []*lambda.Target
+}
+
+type Tenant struct {
+	Id     string
+	Config *Config
+}
+
+// Enabled reports whether feature is enabled for the tenant.
+func (t *Tenant) Enabled(feature string) bool {
+	return t.Config.Features[feature]
+}
+
+type tenantContextKey struct{}
+
+func ContextWithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+func TenantFromContext(ctx context.Context) (*Tenant, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(*Tenant)
+	return tenant, ok
+}
+
+// Extractor returns the tenant of the request, empty when not found.
+type Extractor func(req *lambda.Request) string
+
+func HeaderExtractor(header string) Extractor {
+	return func(req *lambda.Request) string {
+		return req.Header(header)
+	}
+}
+
+// ClaimExtractor reads the tenant from a claim set by the authorizer (e.g.
+// "custom:tenant_id").
+func ClaimExtractor(claim string) Extractor {
+	return func(req *lambda.Request) string {
+		return req.Claim(claim)
+	}
+}
+
+// HostExtractor reads the tenant from the subdomain of domain, e.g. acme
+// for acme.example.com.
+func HostExtractor(domain string) Extractor {
+
+	suffix := "." + strings.TrimPrefix(domain, ".")
+
+	return func(req *lambda.Request) string {
+
+		host := req.Header("Host")
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+
+		return strings.TrimSuffix(host, suffix)
+
+	}
+
+}
+
+// Store provides the configuration of the tenants.
+type Store interface {
+	Config(ctx context.Context, tenantId string) (*Config, error)
+}
+
+type StaticStore map[string]*Config
+
+func (s StaticStore) Config(ctx context.Context, tenantId string) (*Config, error) {
+
+	config, ok := s[tenantId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Tenant %s not found", tenantId)
+	}
+
+	return config, nil
+
+}
+
+// TargetResolver resolves to the dedicated targets of the tenant in the
+// context, falling back to next.
+func TargetResolver(next lambda.TargetResolver) lambda.TargetResolver {
+
+	return func(ctx context.Context, fullMethod string) ([]*lambda.Target, error) {
+
+		if tenant, ok := TenantFromContext(ctx); ok && len(tenant.Config.Targets) > 0 {
+			return tenant.Config.Targets, nil
+		}
+
+		return next(ctx, fullMethod)
+
+	}
+
+}
@@ -0,0 +1,94 @@
+// Package transform mutates requests and responses as declared by a
+// manifest: paths are rewritten, headers injected or stripped, request
+// fields defaulted and response fields moved, without a proxy in front of
+// the function.
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Rule applies to the requests matching its path or method, a trailing "*"
+// matches by prefix and empty matches every request.
+type Rule struct {
+	// Request path, e.g. "/legacy/*".
+	Path string `json:"path,omitempty"`
+	// Full method, e.g. "/acme.users.v1.Users/*".
+	Method string `json:"method,omitempty"`
+
+	// Replaces the Path prefix (without "*") of the request path by
+	// RewritePath before the route is matched.
+	RewritePath string `json:"rewritePath,omitempty"`
+	// Request headers set before the route is matched.
+	SetHeaders map[string]string `json:"setHeaders,omitempty"`
+	// Request headers removed before the route is matched.
+	RemoveHeaders []string `json:"removeHeaders,omitempty"`
+	// Response headers set after the handler, Path is matched against the
+	// rewritten path.
+	SetResponseHeaders map[string]string `json:"setResponseHeaders,omitempty"`
+
+	// Values of the request fields left unset by the caller, by field path
+	// (e.g. "page.size") in the proto3 JSON format.
+	Defaults map[string]json.RawMessage `json:"defaults,omitempty"`
+	// Moves response fields from one path to another of the same type.
+	ResponseFields map[string]string `json:"responseFields,omitempty"`
+}
+
+// Manifest declares the transformation rules, every matching rule applies
+// in order.
+type Manifest struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// ParseManifest parses a JSON manifest.
+func ParseManifest(data []byte) (*Manifest, error) {
+
+	manifest := &Manifest{}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("Invalid manifest: %w", err)
+	}
+
+	return manifest, nil
+
+}
+
+func (m *Manifest) validate() error {
+
+	for i, rule := range m.Rules {
+
+		if len(rule.RewritePath) > 0 && len(rule.Path) == 0 {
+			return fmt.Errorf("Rule %d rewrites the path without matching one", i)
+		}
+
+		if (len(rule.Defaults) > 0 || len(rule.ResponseFields) > 0) && len(rule.Path) > 0 {
+			return fmt.Errorf("Rule %d transforms messages, it must match by method", i)
+		}
+
+		for path := range rule.Defaults {
+			if len(path) == 0 || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+				return fmt.Errorf("Rule %d has an invalid default field path %q", i, path)
+			}
+		}
+
+	}
+
+	return nil
+
+}
+
+func match(pattern, value string) bool {
+
+	if len(pattern) == 0 {
+		return true
+	}
+
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+
+	return pattern == value
+
+}
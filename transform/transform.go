@@ -0,0 +1,268 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type Transformer struct {
+	rules []*Rule
+}
+
+// NewTransformer validates the manifest.
+func NewTransformer(manifest *Manifest) (*Transformer, error) {
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Transformer{rules: manifest.Rules}, nil
+
+}
+
+// Matcher rewrites the path and the headers of the requests before next
+// matches them, it replaces the controller Matcher:
+//
+//	controller.Matcher = transformer.Matcher(lambda.MakeUrlPathMatcher(basePath))
+func (t *Transformer) Matcher(next lambda.Matcher[string]) lambda.Matcher[string] {
+
+	return func(ctx context.Context, req *events.APIGatewayProxyRequest) (string, error) {
+
+		path := req.Path
+
+		for _, rule := range t.rules {
+
+			if len(rule.Method) > 0 || !match(rule.Path, path) {
+				continue
+			}
+
+			if len(rule.RewritePath) > 0 {
+				path = rule.RewritePath + strings.TrimPrefix(path, strings.TrimSuffix(rule.Path, "*"))
+			}
+
+			for _, name := range rule.RemoveHeaders {
+				removeHeader(req, name)
+			}
+
+			for name, value := range rule.SetHeaders {
+				removeHeader(req, name)
+				if req.Headers == nil {
+					req.Headers = make(map[string]string)
+				}
+				req.Headers[name] = value
+			}
+
+		}
+
+		req.Path = path
+
+		return next(ctx, req)
+
+	}
+
+}
+
+// Middleware sets the response headers of the rules.
+func (t *Transformer) Middleware() lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			err := next(ctx, req, res)
+
+			for _, rule := range t.rules {
+
+				if len(rule.SetResponseHeaders) == 0 || !match(rule.Path, req.Path) || !match(rule.Method, req.HandlerKey) {
+					continue
+				}
+
+				if res.Headers == nil {
+					res.Headers = make(map[string]string)
+				}
+
+				for name, value := range rule.SetResponseHeaders {
+					res.Headers[name] = value
+				}
+
+			}
+
+			return err
+
+		}
+
+	}
+
+}
+
+// Interceptor defaults the request fields and moves the response fields of
+// the unary calls.
+func (t *Transformer) Interceptor() grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		rules := make([]*Rule, 0, len(t.rules))
+
+		for _, rule := range t.rules {
+			if len(rule.Path) == 0 && match(rule.Method, info.FullMethod) {
+				rules = append(rules, rule)
+			}
+		}
+
+		if msg, ok := req.(proto.Message); ok {
+			for _, rule := range rules {
+				for path, value := range rule.Defaults {
+					if err := setDefault(msg.ProtoReflect(), path, value); err != nil {
+						return nil, status.Errorf(codes.Internal, "Failed to default %s of %s: %v", path, info.FullMethod, err)
+					}
+				}
+			}
+		}
+
+		out, err := handler(ctx, req)
+		if err != nil {
+			return out, err
+		}
+
+		if msg, ok := out.(proto.Message); ok {
+			for _, rule := range rules {
+				for from, to := range rule.ResponseFields {
+					if err := moveField(msg.ProtoReflect(), from, to); err != nil {
+						return nil, status.Errorf(codes.Internal, "Failed to move %s to %s in %s: %v", from, to, info.FullMethod, err)
+					}
+				}
+			}
+		}
+
+		return out, nil
+
+	}
+
+}
+
+// resolve returns the message holding the last field of path and the field,
+// the intermediate messages are created when create is set. The returned
+// message is nil when an intermediate message is unset.
+func resolve(msg protoreflect.Message, path string, create bool) (protoreflect.Message, protoreflect.FieldDescriptor, error) {
+
+	names := strings.Split(path, ".")
+
+	for i, name := range names {
+
+		field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			field = msg.Descriptor().Fields().ByJSONName(name)
+		}
+
+		if field == nil {
+			return nil, nil, fmt.Errorf("Unknown field %s in %s", name, msg.Descriptor().FullName())
+		}
+
+		if i == len(names)-1 {
+			return msg, field, nil
+		}
+
+		if field.Message() == nil || field.IsList() || field.IsMap() {
+			return nil, nil, fmt.Errorf("Field %s of %s is not a message", name, msg.Descriptor().FullName())
+		}
+
+		if !create && !msg.Has(field) {
+			return nil, field, nil
+		}
+
+		msg = msg.Mutable(field).Message()
+
+	}
+
+	return nil, nil, fmt.Errorf("Empty field path")
+
+}
+
+func setDefault(msg protoreflect.Message, path string, value []byte) error {
+
+	parent, field, err := resolve(msg, path, false)
+	if err != nil {
+		return err
+	}
+
+	if parent != nil && parent.Has(field) {
+		return nil
+	}
+
+	parent, field, err = resolve(msg, path, true)
+	if err != nil {
+		return err
+	}
+
+	holder := parent.New()
+
+	body := fmt.Sprintf("{%q:%s}", field.JSONName(), value)
+
+	if err := protojson.Unmarshal([]byte(body), holder.Interface()); err != nil {
+		return err
+	}
+
+	parent.Set(field, holder.Get(field))
+
+	return nil
+
+}
+
+func moveField(msg protoreflect.Message, from, to string) error {
+
+	src, srcField, err := resolve(msg, from, false)
+	if err != nil {
+		return err
+	}
+
+	if src == nil || !src.Has(srcField) {
+		return nil
+	}
+
+	dst, dstField, err := resolve(msg, to, true)
+	if err != nil {
+		return err
+	}
+
+	if srcField.Kind() != dstField.Kind() || srcField.Cardinality() != dstField.Cardinality() || srcField.IsMap() != dstField.IsMap() {
+		return fmt.Errorf("Fields have different types")
+	}
+
+	if srcField.Message() != nil && srcField.Message().FullName() != dstField.Message().FullName() {
+		return fmt.Errorf("Fields have different message types")
+	}
+
+	value := src.Get(srcField)
+	src.Clear(srcField)
+
+	dst.Set(dstField, value)
+
+	return nil
+
+}
+
+func removeHeader(req *events.APIGatewayProxyRequest, name string) {
+
+	for key := range req.Headers {
+		if strings.EqualFold(key, name) {
+			delete(req.Headers, key)
+		}
+	}
+
+	for key := range req.MultiValueHeaders {
+		if strings.EqualFold(key, name) {
+			delete(req.MultiValueHeaders, key)
+		}
+	}
+
+}
@@ -0,0 +1,223 @@
+// Package validate checks requests and responses against their descriptors:
+// fields marked with the protomesh.validate.v1.required field option must be
+// set and, in strict mode, unknown fields are rejected.
+package validate
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/validate/v1/validate.proto
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Honours the field options only and tolerates unknown fields.
+var Default = &Validator{}
+
+var _ lambda.JSONDecoder = &Validator{}
+
+type Validator struct {
+	// Rejects messages carrying unknown fields, which means the peer was
+	// built against a newer schema. Otherwise they are logged as drift.
+	Strict bool
+	// Overrides Strict by full method name (e.g. "/acme.users.v1.Users/Get").
+	Methods map[string]bool
+	// Full names of the required fields besides the annotated ones, false
+	// unmarks an annotated field.
+	Fields map[string]bool
+
+	required sync.Map
+}
+
+// Violation is a field of a message not matching its descriptor.
+type Violation struct {
+	// Path of the field from the validated message (e.g. "user.emails[0]").
+	Path   string
+	Reason string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Reason
+}
+
+// IsRequired reports whether field must be set.
+func (v *Validator) IsRequired(field protoreflect.FieldDescriptor) bool {
+
+	if required, ok := v.required.Load(field.FullName()); ok {
+		return required.(bool)
+	}
+
+	required, ok := v.Fields[string(field.FullName())]
+	if !ok {
+		required = proto.GetExtension(field.Options(), E_Required).(bool)
+	}
+
+	v.required.Store(field.FullName(), required)
+
+	return required
+
+}
+
+// IsStrict reports whether unknown fields are rejected for method.
+func (v *Validator) IsStrict(method string) bool {
+
+	if strict, ok := v.Methods[method]; ok {
+		return strict
+	}
+
+	return v.Strict
+
+}
+
+// Check returns the missing required fields and the unknown fields of msg,
+// nested messages included.
+func (v *Validator) Check(msg proto.Message) (missing []Violation, unknown []Violation) {
+
+	v.check(msg.ProtoReflect(), "", &missing, &unknown)
+
+	return missing, unknown
+
+}
+
+// DecodeJSON decodes data into m, unknown fields are rejected when method
+// is strict and discarded otherwise. It is the lambda.JSONDecoder of the
+// controllers validating with v:
+//
+//	controller.JSONDecoder = v
+//	controller.RegisterUnaryInterceptor(validate.Interceptor(v))
+func (v *Validator) DecodeJSON(method string, data []byte, m proto.Message) error {
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: !v.IsStrict(method)}).Unmarshal(data, m); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Failed to unmarshal request: %v", err)
+	}
+
+	return nil
+
+}
+
+func (v *Validator) check(msg protoreflect.Message, path string, missing, unknown *[]Violation) {
+
+	if raw := msg.GetUnknown(); len(raw) > 0 {
+		*unknown = append(*unknown, Violation{Path: pathOf(path, "*"), Reason: fmt.Sprintf("%d bytes of unknown fields", len(raw))})
+	}
+
+	fields := msg.Descriptor().Fields()
+
+	for i := 0; i < fields.Len(); i++ {
+
+		field := fields.Get(i)
+		fieldPath := pathOf(path, string(field.Name()))
+
+		if !msg.Has(field) {
+			if v.IsRequired(field) {
+				*missing = append(*missing, Violation{Path: fieldPath, Reason: "required field is not set"})
+			}
+			continue
+		}
+
+		value := msg.Get(field)
+
+		switch {
+
+		case field.IsMap():
+			if field.MapValue().Message() != nil {
+				value.Map().Range(func(key protoreflect.MapKey, item protoreflect.Value) bool {
+					v.check(item.Message(), fmt.Sprintf("%s[%v]", fieldPath, key.Interface()), missing, unknown)
+					return true
+				})
+			}
+
+		case field.IsList():
+			if field.Message() != nil {
+				list := value.List()
+				for j := 0; j < list.Len(); j++ {
+					v.check(list.Get(j).Message(), fmt.Sprintf("%s[%d]", fieldPath, j), missing, unknown)
+				}
+			}
+
+		case field.Message() != nil:
+			v.check(value.Message(), fieldPath, missing, unknown)
+
+		}
+
+	}
+
+}
+
+func pathOf(parent, name string) string {
+
+	if len(parent) == 0 {
+		return name
+	}
+
+	return parent + "." + name
+
+}
+
+func join(violations []Violation) string {
+
+	parts := make([]string, len(violations))
+	for i, violation := range violations {
+		parts[i] = violation.String()
+	}
+
+	return strings.Join(parts, "; ")
+
+}
+
+// Interceptor validates the requests and responses of the unary calls.
+// Requests missing required fields fail with InvalidArgument, and so do
+// requests with unknown fields when the method is strict. Responses are
+// never rejected, their violations are logged as schema drift.
+func Interceptor(v *Validator) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		log := lambda.LoggerFromContext(ctx)
+
+		if msg, ok := req.(proto.Message); ok {
+
+			missing, unknown := v.Check(msg)
+
+			if len(missing) > 0 {
+				return nil, status.Errorf(codes.InvalidArgument, "Invalid request: %s", join(missing))
+			}
+
+			if len(unknown) > 0 {
+
+				if v.IsStrict(info.FullMethod) {
+					return nil, status.Errorf(codes.InvalidArgument, "Request has unknown fields: %s", join(unknown))
+				}
+
+				log.Warn("Request schema drift", "method", info.FullMethod, "unknown", join(unknown))
+
+			}
+
+		}
+
+		out, err := handler(ctx, req)
+
+		if msg, ok := out.(proto.Message); ok && err == nil {
+
+			missing, unknown := v.Check(msg)
+
+			if len(missing) > 0 || len(unknown) > 0 {
+				log.Warn("Response schema drift", "method", info.FullMethod, "missing", join(missing), "unknown", join(unknown))
+			}
+
+		}
+
+		return out, err
+
+	}
+
+}
@@ -0,0 +1,166 @@
+package validate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/go-app"
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/envelope"
+	"google.golang.org/grpc"
+)
+
+const traceMethod = "/protomesh.validate.test.Traces/Echo"
+
+type traceService struct{}
+
+func (traceService) Echo(ctx context.Context, in *envelope.TraceContext) (*envelope.TraceContext, error) {
+	return in, nil
+}
+
+var traceServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protomesh.validate.test.Traces",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Echo",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+
+				in := new(envelope.TraceContext)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: traceMethod}
+
+				return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(traceService).Echo(ctx, req.(*envelope.TraceContext))
+				})
+
+			},
+		},
+	},
+}
+
+type testApp struct{}
+
+func (testApp) Log() app.Logger {
+	return lambda.LoggerFromContext(context.Background())
+}
+
+func TestStrictJSON(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		v      *Validator
+		body   string
+		status int
+	}{
+		{
+			name:   "strict method rejects unknown field",
+			v:      &Validator{Methods: map[string]bool{traceMethod: true}},
+			body:   `{"traceparent":"00-01","unknown":1}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "strict method accepts known fields",
+			v:      &Validator{Strict: true},
+			body:   `{"traceparent":"00-01"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "lenient method discards unknown field",
+			v:      &Validator{Strict: true, Methods: map[string]bool{traceMethod: false}},
+			body:   `{"traceparent":"00-01","unknown":1}`,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			c := lambda.NewController[lambda.ControllerDependency]()
+			c.Injector = &app.Injector[lambda.ControllerDependency]{}
+			c.Attach(testApp{}, struct{}{})
+			c.Matcher = lambda.MakeUrlPathMatcher("")
+			c.JSONDecoder = test.v
+
+			c.RegisterUnaryInterceptor(Interceptor(test.v))
+			c.RegisterGRPCService(traceServiceDesc, traceService{})
+
+			res, _ := c.HandleLambda(context.Background(), &events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       traceMethod,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       test.body,
+			})
+
+			if res.StatusCode != test.status {
+				t.Fatalf("HandleLambda() status = %d, want %d (%s)", res.StatusCode, test.status, res.Body)
+			}
+
+		})
+	}
+
+}
+
+func TestTypedHandler(t *testing.T) {
+
+	v := &Validator{
+		Strict: true,
+		Fields: map[string]bool{"protomesh.envelope.v1.TraceContext.traceparent": true},
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{
+			name:   "unknown field",
+			body:   `{"traceparent":"00-01","unknown":1}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing required field",
+			body:   `{"tracestate":"a=b"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "valid request",
+			body:   `{"traceparent":"00-01"}`,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			c := lambda.NewController[lambda.ControllerDependency]()
+			c.Injector = &app.Injector[lambda.ControllerDependency]{}
+			c.Attach(testApp{}, struct{}{})
+			c.Matcher = lambda.MakeUrlPathMatcher("")
+			c.JSONDecoder = v
+
+			c.RegisterUnaryInterceptor(Interceptor(v))
+			c.RegisterHandler("/hooks/trace", lambda.Unary(func(ctx context.Context, in *envelope.TraceContext) (*envelope.TraceContext, error) {
+				return in, nil
+			}))
+
+			res, _ := c.HandleLambda(context.Background(), &events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       "/hooks/trace",
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       test.body,
+			})
+
+			if res.StatusCode != test.status {
+				t.Fatalf("HandleLambda() status = %d, want %d (%s)", res.StatusCode, test.status, res.Body)
+			}
+
+		})
+	}
+
+}
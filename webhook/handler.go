@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Middleware verifies the requests of the handler keys with their verifier,
+// the other requests are passed through.
+func Middleware(verifiers map[string]Verifier) lambda.Middleware {
+
+	return func(next lambda.Handler) lambda.Handler {
+
+		return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+			verify, ok := verifiers[req.HandlerKey]
+			if !ok {
+				return next(ctx, req, res)
+			}
+
+			body, err := rawBody(req)
+			if err != nil {
+				return res.WriteError(err)
+			}
+
+			if err := verify(ctx, req, body); err != nil {
+				lambda.LoggerFromContext(ctx).Warn("Rejected webhook", "error", err)
+				return res.WriteError(err)
+			}
+
+			return next(ctx, req, res)
+
+		}
+
+	}
+
+}
+
+// Handler verifies the webhook and decodes its JSON payload into M, fields
+// unknown to M are discarded since providers add fields over time. Form
+// encoded payloads (e.g. Slack interactions) are read from the payload
+// field.
+//
+//	controller.RegisterHandler("/webhooks/github", webhook.Handler(webhook.GitHub(secret), onPush))
+func Handler[M proto.Message](verify Verifier, fn func(ctx context.Context, event M) error) lambda.Handler {
+
+	return func(ctx context.Context, req *lambda.Request, res *lambda.Response) error {
+
+		body, err := rawBody(req)
+		if err != nil {
+			return res.WriteError(err)
+		}
+
+		if err := verify(ctx, req, body); err != nil {
+			lambda.LoggerFromContext(ctx).Warn("Rejected webhook", "error", err)
+			return res.WriteError(err)
+		}
+
+		if strings.HasPrefix(req.Header("Content-Type"), "application/x-www-form-urlencoded") {
+
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				return res.WriteError(status.Errorf(codes.InvalidArgument, "Invalid form payload: %v", err))
+			}
+
+			body = []byte(form.Get("payload"))
+
+		}
+
+		var zero M
+		event := zero.ProtoReflect().New().Interface().(M)
+
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, event); err != nil {
+			return res.WriteError(status.Errorf(codes.InvalidArgument, "Invalid webhook payload: %v", err))
+		}
+
+		if err := fn(ctx, event); err != nil {
+			return res.WriteError(err)
+		}
+
+		res.StatusCode = http.StatusOK
+		res.Body = ""
+		res.IsBase64Encoded = false
+
+		return nil
+
+	}
+
+}
+
+func rawBody(req *lambda.Request) ([]byte, error) {
+
+	if !req.IsBase64Encoded {
+		return []byte(req.Body), nil
+	}
+
+	body, err := lambda.DecodeBase64(req.Body)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid base64 body: %v", err)
+	}
+
+	return body, nil
+
+}
@@ -0,0 +1,147 @@
+// Package webhook verifies the signatures of third-party webhooks before
+// their payloads reach the handlers.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/protomesh/protomesh-go/aws/lambda"
+	"github.com/protomesh/protomesh-go/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultTolerance is the maximum age of the timestamped signatures, to
+// prevent replays.
+const DefaultTolerance = 5 * time.Minute
+
+// Verifier checks the signature of the raw body of req, failing with
+// Unauthenticated.
+type Verifier func(ctx context.Context, req *lambda.Request, body []byte) error
+
+// GitHub verifies the X-Hub-Signature-256 header.
+func GitHub(secret string) Verifier {
+
+	return func(ctx context.Context, req *lambda.Request, body []byte) error {
+
+		signature, ok := strings.CutPrefix(req.Header("X-Hub-Signature-256"), "sha256=")
+		if !ok {
+			return status.Errorf(codes.Unauthenticated, "Missing GitHub signature")
+		}
+
+		return checkSignature(secret, signature, body)
+
+	}
+
+}
+
+// Stripe verifies the Stripe-Signature header, signatures older than
+// tolerance are rejected. Tolerance defaults to DefaultTolerance.
+func Stripe(secret string, tolerance time.Duration) Verifier {
+
+	return func(ctx context.Context, req *lambda.Request, body []byte) error {
+
+		timestamp := ""
+		signatures := []string{}
+
+		for _, part := range strings.Split(req.Header("Stripe-Signature"), ",") {
+
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signatures = append(signatures, value)
+			}
+
+		}
+
+		if len(timestamp) == 0 || len(signatures) == 0 {
+			return status.Errorf(codes.Unauthenticated, "Missing Stripe signature")
+		}
+
+		if err := checkTimestamp(ctx, timestamp, tolerance); err != nil {
+			return err
+		}
+
+		signed := append([]byte(timestamp+"."), body...)
+
+		for _, signature := range signatures {
+			if checkSignature(secret, signature, signed) == nil {
+				return nil
+			}
+		}
+
+		return status.Errorf(codes.Unauthenticated, "Invalid signature")
+
+	}
+
+}
+
+// Slack verifies the X-Slack-Signature header, signatures older than
+// tolerance are rejected. Tolerance defaults to DefaultTolerance.
+func Slack(secret string, tolerance time.Duration) Verifier {
+
+	return func(ctx context.Context, req *lambda.Request, body []byte) error {
+
+		timestamp := req.Header("X-Slack-Request-Timestamp")
+
+		signature, ok := strings.CutPrefix(req.Header("X-Slack-Signature"), "v0=")
+		if !ok || len(timestamp) == 0 {
+			return status.Errorf(codes.Unauthenticated, "Missing Slack signature")
+		}
+
+		if err := checkTimestamp(ctx, timestamp, tolerance); err != nil {
+			return err
+		}
+
+		return checkSignature(secret, signature, append([]byte("v0:"+timestamp+":"), body...))
+
+	}
+
+}
+
+func checkSignature(secret, signature string, signed []byte) error {
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "Invalid signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(signed)
+
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return status.Errorf(codes.Unauthenticated, "Invalid signature")
+	}
+
+	return nil
+
+}
+
+func checkTimestamp(ctx context.Context, timestamp string, tolerance time.Duration) error {
+
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "Invalid signature timestamp")
+	}
+
+	age := clock.FromContext(ctx).Now().Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return status.Errorf(codes.Unauthenticated, "Signature timestamp out of tolerance")
+	}
+
+	return nil
+
+}
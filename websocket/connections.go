@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Connections sends frames to clients through the API Gateway management
+// API of the WebSocket API.
+type Connections struct {
+	Client *awsapi.Client
+	// Management API url, https://{domain}/{stage} for the API Gateway
+	// domain (see the request context of the invocation).
+	Endpoint string
+}
+
+func (c *Connections) Send(ctx context.Context, connectionId string, frame *Frame) error {
+
+	body, err := protojson.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/@connections/%s", c.Endpoint, url.PathEscape(connectionId)), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.Client.Do(ctx, "execute-api", req, body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "Failed to post to connection %s: %v", connectionId, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusGone {
+		return status.Errorf(codes.Canceled, "Connection %s is gone", connectionId)
+	}
+
+	if res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(res.Body)
+		return status.Errorf(codes.Unavailable, "Post to connection %s returned %d: %s", connectionId, res.StatusCode, resBody)
+	}
+
+	return nil
+
+}
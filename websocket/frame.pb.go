@@ -0,0 +1,309 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.23.4
+// source: protomesh/websocket/v1/frame.proto
+
+package websocket
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Frame_Kind int32
+
+const (
+	Frame_KIND_UNSPECIFIED Frame_Kind = 0
+	// Client to server, starts a stream calling method with the metadata.
+	Frame_OPEN Frame_Kind = 1
+	// Carries a serialized message in payload, in both directions.
+	Frame_MESSAGE Frame_Kind = 2
+	// Client to server, no more messages will be sent.
+	Frame_HALF_CLOSE Frame_Kind = 3
+	// Client to server, aborts the stream.
+	Frame_CANCEL Frame_Kind = 4
+	// Server to client, response headers in metadata.
+	Frame_HEADERS Frame_Kind = 5
+	// Server to client, ends the stream with code, message and the
+	// trailers in metadata.
+	Frame_TRAILERS Frame_Kind = 6
+)
+
+// Enum value maps for Frame_Kind.
+var (
+	Frame_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "OPEN",
+		2: "MESSAGE",
+		3: "HALF_CLOSE",
+		4: "CANCEL",
+		5: "HEADERS",
+		6: "TRAILERS",
+	}
+	Frame_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"OPEN":             1,
+		"MESSAGE":          2,
+		"HALF_CLOSE":       3,
+		"CANCEL":           4,
+		"HEADERS":          5,
+		"TRAILERS":         6,
+	}
+)
+
+func (x Frame_Kind) Enum() *Frame_Kind {
+	p := new(Frame_Kind)
+	*p = x
+	return p
+}
+
+func (x Frame_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Frame_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_protomesh_websocket_v1_frame_proto_enumTypes[0].Descriptor()
+}
+
+func (Frame_Kind) Type() protoreflect.EnumType {
+	return &file_protomesh_websocket_v1_frame_proto_enumTypes[0]
+}
+
+func (x Frame_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Frame_Kind.Descriptor instead.
+func (Frame_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_protomesh_websocket_v1_frame_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// Frame multiplexes gRPC streams over a WebSocket connection, each
+// WebSocket message carries a single frame encoded in protojson.
+type Frame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Chosen by the client, unique within the connection.
+	StreamId string `protobuf:"bytes,1,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	// Position of the frame in its direction of the stream, starting at 0
+	// with the OPEN frame. Frames may be delivered out of order.
+	Sequence uint64     `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Kind     Frame_Kind `protobuf:"varint,3,opt,name=kind,proto3,enum=protomesh.websocket.v1.Frame_Kind" json:"kind,omitempty"`
+	// Full method name for OPEN frames (e.g. /package.Service/Method).
+	Method   string            `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`
+	Metadata map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Payload  []byte            `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+	// gRPC status code of TRAILERS frames.
+	Code    int32  `protobuf:"varint,7,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,8,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protomesh_websocket_v1_frame_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_protomesh_websocket_v1_frame_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_protomesh_websocket_v1_frame_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Frame) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *Frame) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Frame) GetKind() Frame_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Frame_KIND_UNSPECIFIED
+}
+
+func (x *Frame) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Frame) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Frame) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Frame) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Frame) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_protomesh_websocket_v1_frame_proto protoreflect.FileDescriptor
+
+var file_protomesh_websocket_v1_frame_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2f, 0x77, 0x65, 0x62, 0x73,
+	0x6f, 0x63, 0x6b, 0x65, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x16, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e,
+	0x77, 0x65, 0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x22, 0xca, 0x03, 0x0a,
+	0x05, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12,
+	0x36, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x77, 0x65, 0x62, 0x73, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x2e, 0x4b, 0x69, 0x6e,
+	0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12,
+	0x47, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x2b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x77, 0x65,
+	0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x61, 0x6d, 0x65,
+	0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08,
+	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x1a, 0x3b, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x6a, 0x0a,
+	0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x10, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x4f,
+	0x50, 0x45, 0x4e, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45,
+	0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x48, 0x41, 0x4c, 0x46, 0x5f, 0x43, 0x4c, 0x4f, 0x53, 0x45,
+	0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x10, 0x04, 0x12, 0x0b,
+	0x0a, 0x07, 0x48, 0x45, 0x41, 0x44, 0x45, 0x52, 0x53, 0x10, 0x05, 0x12, 0x0c, 0x0a, 0x08, 0x54,
+	0x52, 0x41, 0x49, 0x4c, 0x45, 0x52, 0x53, 0x10, 0x06, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73,
+	0x68, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x6d, 0x65, 0x73, 0x68, 0x2d, 0x67, 0x6f, 0x2f, 0x77,
+	0x65, 0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protomesh_websocket_v1_frame_proto_rawDescOnce sync.Once
+	file_protomesh_websocket_v1_frame_proto_rawDescData = file_protomesh_websocket_v1_frame_proto_rawDesc
+)
+
+func file_protomesh_websocket_v1_frame_proto_rawDescGZIP() []byte {
+	file_protomesh_websocket_v1_frame_proto_rawDescOnce.Do(func() {
+		file_protomesh_websocket_v1_frame_proto_rawDescData = protoimpl.X.CompressGZIP(file_protomesh_websocket_v1_frame_proto_rawDescData)
+	})
+	return file_protomesh_websocket_v1_frame_proto_rawDescData
+}
+
+var file_protomesh_websocket_v1_frame_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_protomesh_websocket_v1_frame_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_protomesh_websocket_v1_frame_proto_goTypes = []interface{}{
+	(Frame_Kind)(0), // 0: protomesh.websocket.v1.Frame.Kind
+	(*Frame)(nil),   // 1: protomesh.websocket.v1.Frame
+	nil,             // 2: protomesh.websocket.v1.Frame.MetadataEntry
+}
+var file_protomesh_websocket_v1_frame_proto_depIdxs = []int32{
+	0, // 0: protomesh.websocket.v1.Frame.kind:type_name -> protomesh.websocket.v1.Frame.Kind
+	2, // 1: protomesh.websocket.v1.Frame.metadata:type_name -> protomesh.websocket.v1.Frame.MetadataEntry
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_protomesh_websocket_v1_frame_proto_init() }
+func file_protomesh_websocket_v1_frame_proto_init() {
+	if File_protomesh_websocket_v1_frame_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protomesh_websocket_v1_frame_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Frame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protomesh_websocket_v1_frame_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_protomesh_websocket_v1_frame_proto_goTypes,
+		DependencyIndexes: file_protomesh_websocket_v1_frame_proto_depIdxs,
+		EnumInfos:         file_protomesh_websocket_v1_frame_proto_enumTypes,
+		MessageInfos:      file_protomesh_websocket_v1_frame_proto_msgTypes,
+	}.Build()
+	File_protomesh_websocket_v1_frame_proto = out.File
+	file_protomesh_websocket_v1_frame_proto_rawDesc = nil
+	file_protomesh_websocket_v1_frame_proto_goTypes = nil
+	file_protomesh_websocket_v1_frame_proto_depIdxs = nil
+}
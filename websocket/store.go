@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/protomesh/protomesh-go/aws/awsapi"
+	"github.com/protomesh/protomesh-go/clock"
+	"github.com/protomesh/protomesh-go/dynamo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// FrameStore buffers the client frames of a stream, they are delivered to
+// any instance of the function while the stream runs in the invocation that
+// received the OPEN frame.
+type FrameStore interface {
+	Put(ctx context.Context, connectionId string, frame *Frame) error
+	// Get returns the frame at sequence, nil if it wasn't received yet.
+	Get(ctx context.Context, connectionId, streamId string, sequence uint64) (*Frame, error)
+}
+
+var (
+	_ FrameStore = &DynamoFrameStore{}
+)
+
+type dynamoItem map[string]events.DynamoDBAttributeValue
+
+// DynamoFrameStore keeps frames in a DynamoDB table keyed by the "stream"
+// string attribute (connection and stream ids) and the "sequence" number
+// attribute, with an "expires_at" TTL attribute when ttl is set.
+type DynamoFrameStore struct {
+	client *awsapi.Client
+	table  string
+	ttl    time.Duration
+}
+
+func NewDynamoFrameStore(client *awsapi.Client, table string, ttl time.Duration) *DynamoFrameStore {
+	return &DynamoFrameStore{
+		client: client,
+		table:  table,
+		ttl:    ttl,
+	}
+}
+
+func (d *DynamoFrameStore) Put(ctx context.Context, connectionId string, frame *Frame) error {
+
+	body, err := proto.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	item := dynamoItem{
+		"stream":   events.NewStringAttribute(streamKey(connectionId, frame.StreamId)),
+		"sequence": events.NewNumberAttribute(strconv.FormatUint(frame.Sequence, 10)),
+		"frame":    events.NewBinaryAttribute(body),
+	}
+
+	if d.ttl > 0 {
+		item["expires_at"] = events.NewNumberAttribute(strconv.FormatInt(clock.FromContext(ctx).Now().Add(d.ttl).Unix(), 10))
+	}
+
+	return d.client.CallDynamoDB(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item":      item,
+	}, nil)
+
+}
+
+func (d *DynamoFrameStore) Get(ctx context.Context, connectionId, streamId string, sequence uint64) (*Frame, error) {
+
+	out := struct {
+		Item dynamoItem
+	}{}
+
+	err := d.client.CallDynamoDB(ctx, "GetItem", map[string]interface{}{
+		"TableName": d.table,
+		"Key": dynamoItem{
+			"stream":   events.NewStringAttribute(streamKey(connectionId, streamId)),
+			"sequence": events.NewNumberAttribute(strconv.FormatUint(sequence, 10)),
+		},
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+
+	body, err := dynamo.Item(out.Item).Binary("frame")
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted frame %d of stream %s: %v", sequence, streamId, err)
+	}
+
+	frame := &Frame{}
+
+	if err := proto.Unmarshal(body, frame); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Corrupted frame %d of stream %s: %v", sequence, streamId, err)
+	}
+
+	return frame, nil
+
+}
+
+func streamKey(connectionId, streamId string) string {
+	return connectionId + "/" + streamId
+}
@@ -0,0 +1,5 @@
+// Package websocket holds the frames multiplexing gRPC streams over API
+// Gateway WebSocket connections (see lambda.Controller.HandleWebSocket).
+package websocket
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/protomesh/protomesh-go protomesh/websocket/v1/frame.proto